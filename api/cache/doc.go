@@ -0,0 +1,54 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cache contains an informer, which keeps a local replica of the
+// objects on the service registry and notifies you when they change.
+//
+// The informer lists all namespaces - or only the ones you choose - along
+// with their services and endpoints when it is started, and then lists them
+// again periodically to keep the replica up to date. Its lister functions,
+// e.g. GetEndpoint or ListServices, never perform calls to the service
+// registry and thus return immediately: this makes it suitable for
+// applications that need to perform many lookups, e.g. sidecars.
+//
+// Example:
+// 	inf, err := cache.NewInformer(sr,
+// 		informer.WithNamespaces("sales"),
+// 		informer.WithResyncPeriod(30*time.Second))
+// 	if err != nil {
+// 		return err
+// 	}
+//
+// 	inf.AddIndexer("address", cache.IndexByAddress)
+// 	inf.AddEventHandler(cache.EventHandlerFuncs{
+// 		AddFunc: func(obj interface{}) {
+// 			if endp, ok := obj.(*coretypes.Endpoint); ok {
+// 				fmt.Println("new endpoint", endp.Name)
+// 			}
+// 		},
+// 	})
+//
+// 	if err := inf.Start(ctx); err != nil {
+// 		return err
+// 	}
+//
+// 	endpoints, err := inf.ByIndex("address", "10.10.10.10")
+//
+// Objects returned by the informer are shared with the replica and with other
+// callers, so you must not modify them: use their Clone function if you need
+// to do so.
+package cache
@@ -0,0 +1,141 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"sort"
+
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+)
+
+// EventHandler is notified by an informer every time an object is added to,
+// updated on or deleted from its replica.
+//
+// Objects are one of *coretypes.Namespace, *coretypes.Service or
+// *coretypes.Endpoint. Handlers are called one at a time and in order, so
+// they should return quickly.
+type EventHandler interface {
+	OnAdd(obj interface{})
+	OnUpdate(oldObj, newObj interface{})
+	OnDelete(obj interface{})
+}
+
+// EventHandlerFuncs is an EventHandler that calls the functions it contains,
+// if they are not nil.
+type EventHandlerFuncs struct {
+	AddFunc    func(obj interface{})
+	UpdateFunc func(oldObj, newObj interface{})
+	DeleteFunc func(obj interface{})
+}
+
+// OnAdd calls AddFunc if it is not nil.
+func (e EventHandlerFuncs) OnAdd(obj interface{}) {
+	if e.AddFunc != nil {
+		e.AddFunc(obj)
+	}
+}
+
+// OnUpdate calls UpdateFunc if it is not nil.
+func (e EventHandlerFuncs) OnUpdate(oldObj, newObj interface{}) {
+	if e.UpdateFunc != nil {
+		e.UpdateFunc(oldObj, newObj)
+	}
+}
+
+// OnDelete calls DeleteFunc if it is not nil.
+func (e EventHandlerFuncs) OnDelete(obj interface{}) {
+	if e.DeleteFunc != nil {
+		e.DeleteFunc(obj)
+	}
+}
+
+type eventType int
+
+const (
+	eventAdd eventType = iota
+	eventUpdate
+	eventDelete
+)
+
+type event struct {
+	eventType eventType
+	oldObj    interface{}
+	newObj    interface{}
+}
+
+func (e *event) notify(handler EventHandler) {
+	switch e.eventType {
+	case eventAdd:
+		handler.OnAdd(e.newObj)
+	case eventUpdate:
+		handler.OnUpdate(e.oldObj, e.newObj)
+	case eventDelete:
+		handler.OnDelete(e.oldObj)
+	}
+}
+
+// diff returns the events needed to go from the old objects to the new ones.
+// Objects are keyed by their path, so that additions and updates are sorted
+// with parents before their children and deletions the other way around.
+func diff(oldObjs, newObjs map[string]interface{}) []event {
+	events := []event{}
+	for _, key := range sortedKeys(newObjs) {
+		oldObj, exists := oldObjs[key]
+		switch {
+		case !exists:
+			events = append(events, event{eventType: eventAdd, newObj: newObjs[key]})
+		case !deepEqual(oldObj, newObjs[key]):
+			events = append(events, event{eventType: eventUpdate, oldObj: oldObj, newObj: newObjs[key]})
+		}
+	}
+
+	deleted := sortedKeys(oldObjs)
+	for i := len(deleted) - 1; i >= 0; i-- {
+		if _, exists := newObjs[deleted[i]]; !exists {
+			events = append(events, event{eventType: eventDelete, oldObj: oldObjs[deleted[i]]})
+		}
+	}
+
+	return events
+}
+
+func deepEqual(oldObj, newObj interface{}) bool {
+	switch o := oldObj.(type) {
+	case *coretypes.Namespace:
+		n, ok := newObj.(*coretypes.Namespace)
+		return ok && o.DeepEqualTo(n) && o.Revision == n.Revision
+	case *coretypes.Service:
+		n, ok := newObj.(*coretypes.Service)
+		return ok && o.DeepEqualTo(n) && o.Revision == n.Revision
+	case *coretypes.Endpoint:
+		n, ok := newObj.(*coretypes.Endpoint)
+		return ok && o.DeepEqualTo(n) && o.Revision == n.Revision
+	}
+
+	return false
+}
+
+func sortedKeys(objs map[string]interface{}) []string {
+	keys := make([]string, 0, len(objs))
+	for key := range objs {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"sort"
+
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+)
+
+// IndexFunc returns the values that the endpoint provided as argument is
+// indexed with. An endpoint can be indexed with any number of values,
+// including none.
+type IndexFunc func(endp *coretypes.Endpoint) []string
+
+// IndexByAddress indexes endpoints by their address.
+//
+// Example:
+// 	inf.AddIndexer("address", cache.IndexByAddress)
+// 	endpoints, err := inf.ByIndex("address", "10.10.10.10")
+func IndexByAddress(endp *coretypes.Endpoint) []string {
+	if endp.Address == "" {
+		return []string{}
+	}
+
+	return []string{endp.Address}
+}
+
+// IndexByMetadata returns an IndexFunc that indexes endpoints by the value
+// they have for the provided metadata key. Endpoints that do not have such
+// key are not indexed.
+//
+// Example:
+// 	inf.AddIndexer("version", cache.IndexByMetadata("version"))
+// 	endpoints, err := inf.ByIndex("version", "v1.2.1")
+func IndexByMetadata(key string) IndexFunc {
+	return func(endp *coretypes.Endpoint) []string {
+		value, exists := endp.Metadata[key]
+		if !exists {
+			return []string{}
+		}
+
+		return []string{value}
+	}
+}
+
+// index contains the endpoints indexed by a single IndexFunc, keyed by the
+// values it returned.
+type index map[string][]*coretypes.Endpoint
+
+func buildIndex(indexFunc IndexFunc, endpoints map[string]map[string]*coretypes.Endpoint) index {
+	idx := index{}
+	for _, servEndpoints := range endpoints {
+		for _, endp := range servEndpoints {
+			for _, value := range indexFunc(endp) {
+				idx[value] = append(idx[value], endp)
+			}
+		}
+	}
+
+	for _, indexed := range idx {
+		sort.Slice(indexed, func(i, j int) bool {
+			return endpointKey(indexed[i]) < endpointKey(indexed[j])
+		})
+	}
+
+	return idx
+}
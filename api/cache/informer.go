@@ -0,0 +1,499 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/CloudNativeSDWAN/serego/api/core"
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/options/get"
+	"github.com/CloudNativeSDWAN/serego/api/options/informer"
+)
+
+// Informer keeps a local replica of namespaces, services and endpoints of a
+// service registry, notifying its event handlers every time they change.
+//
+// It must be created with NewInformer and started with Start.
+type Informer struct {
+	sr   *core.ServiceRegistry
+	opts *informer.Options
+
+	// lock protects the replica, the indexers and the state of the informer.
+	lock     sync.RWMutex
+	replica  *snapshot
+	indexers map[string]IndexFunc
+	indices  map[string]index
+	started  bool
+	lastErr  error
+
+	// dispatchLock makes sure that resyncs are performed and handlers are
+	// notified one at a time and in order, also when they are added while
+	// the informer is running.
+	dispatchLock sync.Mutex
+	handlers     []EventHandler
+}
+
+// snapshot contains all objects replicated by an informer.
+type snapshot struct {
+	// namespaces are keyed by their name.
+	namespaces map[string]*coretypes.Namespace
+	// services are keyed by their namespace and then by their name.
+	services map[string]map[string]*coretypes.Service
+	// endpoints are keyed by their namespace and service, joined as a path,
+	// and then by their name.
+	endpoints map[string]map[string]*coretypes.Endpoint
+}
+
+// NewInformer returns an informer that replicates objects from the provided
+// service registry. Nothing is replicated until Start is called.
+//
+// Example:
+// 	inf, err := cache.NewInformer(sr, informer.WithNamespaces("sales"))
+// 	if err != nil {
+// 		return err
+// 	}
+func NewInformer(sr *core.ServiceRegistry, opts ...informer.Option) (*Informer, error) {
+	if sr == nil {
+		return nil, srerr.NoServiceRegistryProvided
+	}
+
+	infOpts := &informer.Options{ResyncPeriod: informer.DefaultResyncPeriod}
+	for _, opt := range opts {
+		if err := opt(infOpts); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Informer{
+		sr:       sr,
+		opts:     infOpts,
+		replica:  newSnapshot(),
+		indexers: map[string]IndexFunc{},
+		indices:  map[string]index{},
+	}, nil
+}
+
+// AddIndexer adds an index of endpoints with the provided name, which you
+// can later query with ByIndex. It can be called before or after starting
+// the informer.
+//
+// Example:
+// 	if err := inf.AddIndexer("address", cache.IndexByAddress); err != nil {
+// 		return err
+// 	}
+func (i *Informer) AddIndexer(name string, indexFunc IndexFunc) error {
+	if name == "" {
+		return srerr.EmptyIndexerName
+	}
+
+	if indexFunc == nil {
+		return srerr.NoIndexFuncProvided
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if _, exists := i.indexers[name]; exists {
+		return srerr.IndexerAlreadyExists
+	}
+
+	i.indexers[name] = indexFunc
+	i.indices[name] = buildIndex(indexFunc, i.replica.endpoints)
+	return nil
+}
+
+// AddEventHandler adds a handler that will be notified every time an object
+// is added, updated or deleted.
+//
+// If the informer has already replicated some objects, the handler is
+// notified of their addition before any other change.
+func (i *Informer) AddEventHandler(handler EventHandler) {
+	if handler == nil {
+		return
+	}
+
+	i.dispatchLock.Lock()
+	defer i.dispatchLock.Unlock()
+
+	i.lock.RLock()
+	events := diff(map[string]interface{}{}, i.replica.objects())
+	i.lock.RUnlock()
+
+	for _, ev := range events {
+		ev.notify(handler)
+	}
+
+	i.handlers = append(i.handlers, handler)
+}
+
+// Start replicates all objects from the service registry and returns once
+// this is done, or with an error if this failed. After that, objects are
+// replicated again periodically in background according to the resync
+// period, until the provided context is canceled.
+//
+// Errors that occur while replicating objects in background do not change
+// the replica, and the last one can be retrieved with LastSyncError.
+//
+// Example:
+// 	ctx, cancel := context.WithCancel(context.Background())
+// 	defer cancel()
+//
+// 	if err := inf.Start(ctx); err != nil {
+// 		return err
+// 	}
+func (i *Informer) Start(ctx context.Context) error {
+	i.lock.Lock()
+	if i.started {
+		i.lock.Unlock()
+		return srerr.InformerAlreadyStarted
+	}
+
+	i.started = true
+	i.lock.Unlock()
+
+	if err := i.Resync(ctx); err != nil {
+		i.lock.Lock()
+		i.started = false
+		i.lock.Unlock()
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(i.opts.ResyncPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				i.Resync(ctx)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Resync replicates all objects from the service registry immediately,
+// notifying the handlers of any change. You don't need to call this, as it
+// is done periodically by the informer, unless you want to see changes
+// before the next resync.
+//
+// If any object is stale, e.g. because the service registry can't be reached
+// and it was taken from the cache, the replica is left as it is and
+// StaleObject is returned, as well as by LastSyncError.
+func (i *Informer) Resync(ctx context.Context) error {
+	// Hold dispatchLock for the whole resync, so that concurrent resyncs do
+	// not replace a replica with an older one.
+	i.dispatchLock.Lock()
+	defer i.dispatchLock.Unlock()
+
+	replica, err := i.list(ctx)
+
+	i.lock.Lock()
+	i.lastErr = err
+	if err != nil {
+		i.lock.Unlock()
+		return err
+	}
+
+	events := diff(i.replica.objects(), replica.objects())
+	i.replica = replica
+	for name, indexFunc := range i.indexers {
+		i.indices[name] = buildIndex(indexFunc, replica.endpoints)
+	}
+	i.lock.Unlock()
+
+	for _, ev := range events {
+		for _, handler := range i.handlers {
+			ev.notify(handler)
+		}
+	}
+
+	return nil
+}
+
+// LastSyncError returns the error that occurred the last time objects were
+// replicated, or nil if that was successful.
+func (i *Informer) LastSyncError() error {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	return i.lastErr
+}
+
+// list retrieves all objects to replicate from the service registry.
+func (i *Informer) list(ctx context.Context) (*snapshot, error) {
+	replica := newSnapshot()
+
+	nsOps := []*core.NamespaceOperation{}
+	if len(i.opts.Namespaces) == 0 {
+		nsIt := i.sr.Namespace(core.Any).List()
+		for {
+			ns, nsOp, err := nsIt.Next(ctx)
+			if err != nil {
+				if srerr.IsIteratorDone(err) {
+					break
+				}
+
+				return nil, fmt.Errorf("could not list namespaces: %w", err)
+			}
+
+			if ns.Stale {
+				return nil, fmt.Errorf("could not list namespaces: %w", srerr.StaleObject)
+			}
+
+			replica.namespaces[ns.Name] = ns
+			nsOps = append(nsOps, nsOp)
+		}
+	} else {
+		for _, name := range i.opts.Namespaces {
+			nsOp := i.sr.Namespace(name)
+			ns, err := nsOp.Get(ctx, get.WithForceRefresh())
+			if err != nil {
+				if srerr.IsNotFound(err) {
+					continue
+				}
+
+				return nil, fmt.Errorf("could not get namespace %s: %w", name, err)
+			}
+
+			if ns.Stale {
+				return nil, fmt.Errorf("could not get namespace %s: %w", name, srerr.StaleObject)
+			}
+
+			replica.namespaces[ns.Name] = ns
+			nsOps = append(nsOps, nsOp)
+		}
+	}
+
+	for _, nsOp := range nsOps {
+		servIt := nsOp.Service(core.Any).List()
+		for {
+			serv, servOp, err := servIt.Next(ctx)
+			if err != nil {
+				if srerr.IsIteratorDone(err) {
+					break
+				}
+
+				return nil, fmt.Errorf("could not list services: %w", err)
+			}
+
+			if serv.Stale {
+				return nil, fmt.Errorf("could not list services: %w", srerr.StaleObject)
+			}
+
+			if replica.services[serv.Namespace] == nil {
+				replica.services[serv.Namespace] = map[string]*coretypes.Service{}
+			}
+			replica.services[serv.Namespace][serv.Name] = serv
+
+			endpIt := servOp.Endpoint(core.Any).List()
+			for {
+				endp, _, err := endpIt.Next(ctx)
+				if err != nil {
+					if srerr.IsIteratorDone(err) {
+						break
+					}
+
+					return nil, fmt.Errorf("could not list endpoints: %w", err)
+				}
+
+				if endp.Stale {
+					return nil, fmt.Errorf("could not list endpoints: %w", srerr.StaleObject)
+				}
+
+				servKey := path.Join(endp.Namespace, endp.Service)
+				if replica.endpoints[servKey] == nil {
+					replica.endpoints[servKey] = map[string]*coretypes.Endpoint{}
+				}
+				replica.endpoints[servKey][endp.Name] = endp
+			}
+		}
+	}
+
+	return replica, nil
+}
+
+// ListNamespaces returns all the replicated namespaces, sorted by name.
+func (i *Informer) ListNamespaces() []*coretypes.Namespace {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	namespaces := make([]*coretypes.Namespace, 0, len(i.replica.namespaces))
+	for _, ns := range i.replica.namespaces {
+		namespaces = append(namespaces, ns)
+	}
+
+	sort.Slice(namespaces, func(a, b int) bool {
+		return namespaces[a].Name < namespaces[b].Name
+	})
+	return namespaces
+}
+
+// GetNamespace returns the replicated namespace with the provided name, or
+// NamespaceNotFound if it does not exist.
+func (i *Informer) GetNamespace(name string) (*coretypes.Namespace, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	ns, exists := i.replica.namespaces[name]
+	if !exists {
+		return nil, srerr.NamespaceNotFound
+	}
+
+	return ns, nil
+}
+
+// ListServices returns all the replicated services of the provided
+// namespace, sorted by name.
+func (i *Informer) ListServices(namespace string) []*coretypes.Service {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	services := make([]*coretypes.Service, 0, len(i.replica.services[namespace]))
+	for _, serv := range i.replica.services[namespace] {
+		services = append(services, serv)
+	}
+
+	sort.Slice(services, func(a, b int) bool {
+		return services[a].Name < services[b].Name
+	})
+	return services
+}
+
+// GetService returns the replicated service with the provided name, or
+// ServiceNotFound if it does not exist.
+func (i *Informer) GetService(namespace, name string) (*coretypes.Service, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	serv, exists := i.replica.services[namespace][name]
+	if !exists {
+		return nil, srerr.ServiceNotFound
+	}
+
+	return serv, nil
+}
+
+// ListEndpoints returns all the replicated endpoints of the provided
+// service, sorted by name.
+func (i *Informer) ListEndpoints(namespace, service string) []*coretypes.Endpoint {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	servEndpoints := i.replica.endpoints[path.Join(namespace, service)]
+	endpoints := make([]*coretypes.Endpoint, 0, len(servEndpoints))
+	for _, endp := range servEndpoints {
+		endpoints = append(endpoints, endp)
+	}
+
+	sort.Slice(endpoints, func(a, b int) bool {
+		return endpoints[a].Name < endpoints[b].Name
+	})
+	return endpoints
+}
+
+// GetEndpoint returns the replicated endpoint with the provided name, or
+// EndpointNotFound if it does not exist.
+//
+// Example:
+// 	endp, err := inf.GetEndpoint("sales", "payroll", "payroll-1")
+// 	if err != nil {
+// 		return err
+// 	}
+//
+// 	fmt.Printf("payroll-1 is at %s:%d\n", endp.Address, endp.Port)
+func (i *Informer) GetEndpoint(namespace, service, name string) (*coretypes.Endpoint, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	endp, exists := i.replica.endpoints[path.Join(namespace, service)][name]
+	if !exists {
+		return nil, srerr.EndpointNotFound
+	}
+
+	return endp, nil
+}
+
+// ByIndex returns the replicated endpoints that the indexer with the
+// provided name indexed with the provided value, or IndexerNotFound if no
+// such indexer was added.
+//
+// Example:
+// 	endpoints, err := inf.ByIndex("address", "10.10.10.10")
+// 	if err != nil {
+// 		return err
+// 	}
+//
+// 	for _, endp := range endpoints {
+// 		fmt.Println(endp.Name, "is on 10.10.10.10")
+// 	}
+func (i *Informer) ByIndex(indexName, value string) ([]*coretypes.Endpoint, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	idx, exists := i.indices[indexName]
+	if !exists {
+		return nil, srerr.IndexerNotFound
+	}
+
+	return append([]*coretypes.Endpoint{}, idx[value]...), nil
+}
+
+func newSnapshot() *snapshot {
+	return &snapshot{
+		namespaces: map[string]*coretypes.Namespace{},
+		services:   map[string]map[string]*coretypes.Service{},
+		endpoints:  map[string]map[string]*coretypes.Endpoint{},
+	}
+}
+
+// objects returns all objects of the snapshot keyed by their path.
+func (s *snapshot) objects() map[string]interface{} {
+	objs := map[string]interface{}{}
+	for name, ns := range s.namespaces {
+		objs[name] = ns
+	}
+
+	for nsName, services := range s.services {
+		for name, serv := range services {
+			objs[path.Join(nsName, name)] = serv
+		}
+	}
+
+	for _, endpoints := range s.endpoints {
+		for _, endp := range endpoints {
+			objs[endpointKey(endp)] = endp
+		}
+	}
+
+	return objs
+}
+
+func endpointKey(endp *coretypes.Endpoint) string {
+	return path.Join(endp.Namespace, endp.Service, endp.Name)
+}
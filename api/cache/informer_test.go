@@ -0,0 +1,406 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/CloudNativeSDWAN/serego/api/cache"
+	"github.com/CloudNativeSDWAN/serego/api/core"
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/fake"
+	"github.com/CloudNativeSDWAN/serego/api/options/get"
+	"github.com/CloudNativeSDWAN/serego/api/options/informer"
+	"github.com/CloudNativeSDWAN/serego/api/options/list"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// registry is a fake service registry with namespaces, services and
+// endpoints stored in memory.
+type registry struct {
+	lock      sync.Mutex
+	endpoints map[string]map[string][]*coretypes.Endpoint
+	listErr   error
+	// stale makes Get return stale namespaces.
+	stale bool
+}
+
+func (r *registry) namespaceNames() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	names := []string{}
+	for name := range r.endpoints {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (r *registry) serviceNames(nsName string) []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	names := []string{}
+	for name := range r.endpoints[nsName] {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (r *registry) namespace(name string) *fake.NamespaceOperation {
+	return &fake.NamespaceOperation{
+		Name_: name,
+		Get_: func(_ context.Context, _ *get.Options) (*coretypes.Namespace, error) {
+			r.lock.Lock()
+			defer r.lock.Unlock()
+
+			if _, exists := r.endpoints[name]; !exists {
+				return nil, srerr.NamespaceNotFound
+			}
+
+			return &coretypes.Namespace{Name: name, Metadata: map[string]string{}, Stale: r.stale}, nil
+		},
+		List_: func(_ *list.Options) ops.NamespaceLister {
+			names := r.namespaceNames()
+			return &fake.FakeNamespaceIterator{
+				Next_: func(_ context.Context) (*coretypes.Namespace, ops.NamespaceOperation, error) {
+					if r.listErr != nil {
+						return nil, nil, r.listErr
+					}
+
+					if len(names) == 0 {
+						return nil, nil, srerr.IteratorDone
+					}
+
+					nsName := names[0]
+					names = names[1:]
+					return &coretypes.Namespace{Name: nsName, Metadata: map[string]string{}}, r.namespace(nsName), nil
+				},
+			}
+		},
+		Service_: func(servName string) ops.ServiceOperation {
+			return r.service(name, servName)
+		},
+	}
+}
+
+func (r *registry) service(nsName, name string) *fake.ServiceOperation {
+	return &fake.ServiceOperation{
+		Name_: name,
+		List_: func(_ *list.Options) ops.ServiceLister {
+			names := r.serviceNames(nsName)
+			return &fake.FakeServiceIterator{
+				Next_: func(_ context.Context) (*coretypes.Service, ops.ServiceOperation, error) {
+					if len(names) == 0 {
+						return nil, nil, srerr.IteratorDone
+					}
+
+					servName := names[0]
+					names = names[1:]
+					return &coretypes.Service{Name: servName, Namespace: nsName, Metadata: map[string]string{}}, r.service(nsName, servName), nil
+				},
+			}
+		},
+		Endpoint_: func(string) ops.EndpointOperation {
+			return &fake.EndpointOperation{
+				List_: func(_ *list.Options) ops.EndpointLister {
+					r.lock.Lock()
+					endpoints := append([]*coretypes.Endpoint{}, r.endpoints[nsName][name]...)
+					r.lock.Unlock()
+
+					return &fake.FakeEndpointIterator{
+						Next_: func(_ context.Context) (*coretypes.Endpoint, ops.EndpointOperation, error) {
+							if len(endpoints) == 0 {
+								return nil, nil, srerr.IteratorDone
+							}
+
+							endp := endpoints[0]
+							endpoints = endpoints[1:]
+							return endp, &fake.EndpointOperation{Name_: endp.Name}, nil
+						},
+					}
+				},
+			}
+		},
+	}
+}
+
+func newEndpoint(nsName, servName, name, address string) *coretypes.Endpoint {
+	return &coretypes.Endpoint{
+		Name:      name,
+		Namespace: nsName,
+		Service:   servName,
+		Address:   address,
+		Port:      80,
+		Metadata:  map[string]string{"version": "v1"},
+	}
+}
+
+var _ = Describe("Informer", func() {
+	var (
+		sr     *core.ServiceRegistry
+		reg    *registry
+		ctx    context.Context
+		cancel context.CancelFunc
+		events []string
+	)
+
+	recorder := cache.EventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			events = append(events, fmt.Sprintf("add %s", objectName(obj)))
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			events = append(events, fmt.Sprintf("update %s", objectName(newObj)))
+		},
+		DeleteFunc: func(obj interface{}) {
+			events = append(events, fmt.Sprintf("delete %s", objectName(obj)))
+		},
+	}
+
+	BeforeEach(func() {
+		events = []string{}
+		ctx, cancel = context.WithCancel(context.Background())
+		// Keep the registry in a local variable, as informers of previous
+		// tests may still be resyncing in background.
+		r := &registry{
+			endpoints: map[string]map[string][]*coretypes.Endpoint{
+				"ns-1": {
+					"serv-1": {
+						newEndpoint("ns-1", "serv-1", "endp-1", "10.10.10.10"),
+						newEndpoint("ns-1", "serv-1", "endp-2", "10.10.10.11"),
+					},
+				},
+				"ns-2": {
+					"serv-2": {
+						newEndpoint("ns-2", "serv-2", "endp-3", "10.10.10.10"),
+					},
+				},
+			},
+		}
+		reg = r
+		wrp, _ := fake.NewFakeWrapper()
+		wrp.Namespace_ = func(name string) ops.NamespaceOperation {
+			return r.namespace(name)
+		}
+		sr, _ = core.NewServiceRegistryFromWrapper(wrp)
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("replicates all objects", func() {
+		inf, err := cache.NewInformer(sr)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inf.ListNamespaces()).To(BeEmpty())
+		Expect(inf.Start(ctx)).To(Succeed())
+
+		Expect(inf.ListNamespaces()).To(HaveLen(2))
+		Expect(inf.ListNamespaces()[1].Name).To(Equal("ns-2"))
+		Expect(inf.ListServices("ns-1")).To(HaveLen(1))
+		Expect(inf.ListServices("ns-3")).To(BeEmpty())
+		Expect(inf.ListEndpoints("ns-1", "serv-1")).To(Equal(reg.endpoints["ns-1"]["serv-1"]))
+
+		ns, err := inf.GetNamespace("ns-2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ns.Name).To(Equal("ns-2"))
+		serv, err := inf.GetService("ns-2", "serv-2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(serv.Name).To(Equal("serv-2"))
+		endp, err := inf.GetEndpoint("ns-2", "serv-2", "endp-3")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(endp).To(Equal(reg.endpoints["ns-2"]["serv-2"][0]))
+
+		_, err = inf.GetNamespace("ns-3")
+		Expect(err).To(MatchError(srerr.NamespaceNotFound))
+		_, err = inf.GetService("ns-1", "serv-2")
+		Expect(err).To(MatchError(srerr.ServiceNotFound))
+		_, err = inf.GetEndpoint("ns-1", "serv-1", "endp-3")
+		Expect(err).To(MatchError(srerr.EndpointNotFound))
+	})
+
+	It("replicates only the provided namespaces", func() {
+		inf, _ := cache.NewInformer(sr, informer.WithNamespaces("ns-2", "ns-3"))
+		Expect(inf.Start(ctx)).To(Succeed())
+		Expect(inf.ListNamespaces()).To(HaveLen(1))
+		Expect(inf.ListNamespaces()[0].Name).To(Equal("ns-2"))
+		Expect(inf.ListEndpoints("ns-1", "serv-1")).To(BeEmpty())
+	})
+
+	It("notifies handlers of all changes", func() {
+		inf, _ := cache.NewInformer(sr, informer.WithNamespaces("ns-1"))
+		inf.AddEventHandler(recorder)
+		Expect(inf.Start(ctx)).To(Succeed())
+		Expect(events).To(Equal([]string{
+			"add ns-1", "add ns-1/serv-1", "add ns-1/serv-1/endp-1", "add ns-1/serv-1/endp-2",
+		}))
+
+		events = []string{}
+		reg.lock.Lock()
+		updated := newEndpoint("ns-1", "serv-1", "endp-1", "10.10.10.12")
+		reg.endpoints["ns-1"] = map[string][]*coretypes.Endpoint{
+			"serv-1": {updated},
+			"serv-3": {},
+		}
+		reg.lock.Unlock()
+
+		Expect(inf.Resync(ctx)).To(Succeed())
+		Expect(events).To(Equal([]string{
+			"update ns-1/serv-1/endp-1", "add ns-1/serv-3", "delete ns-1/serv-1/endp-2",
+		}))
+
+		By("notifying new handlers of existing objects", func() {
+			events = []string{}
+			inf.AddEventHandler(recorder)
+			Expect(events).To(Equal([]string{
+				"add ns-1", "add ns-1/serv-1", "add ns-1/serv-1/endp-1", "add ns-1/serv-3",
+			}))
+		})
+
+		By("deleting children before their parents", func() {
+			events = []string{}
+			reg.lock.Lock()
+			delete(reg.endpoints, "ns-1")
+			reg.lock.Unlock()
+
+			Expect(inf.Resync(ctx)).To(Succeed())
+			Expect(events).To(Equal([]string{
+				"delete ns-1/serv-3", "delete ns-1/serv-3",
+				"delete ns-1/serv-1/endp-1", "delete ns-1/serv-1/endp-1",
+				"delete ns-1/serv-1", "delete ns-1/serv-1",
+				"delete ns-1", "delete ns-1",
+			}))
+		})
+	})
+
+	It("resyncs periodically", func() {
+		inf, _ := cache.NewInformer(sr, informer.WithResyncPeriod(10*time.Millisecond))
+		Expect(inf.Start(ctx)).To(Succeed())
+
+		reg.lock.Lock()
+		reg.endpoints["ns-3"] = map[string][]*coretypes.Endpoint{}
+		reg.lock.Unlock()
+
+		Eventually(inf.ListNamespaces).Should(HaveLen(3))
+	})
+
+	Describe("Indexing endpoints", func() {
+		It("returns the endpoints with the same value", func() {
+			inf, _ := cache.NewInformer(sr)
+			Expect(inf.AddIndexer("address", cache.IndexByAddress)).To(Succeed())
+			Expect(inf.Start(ctx)).To(Succeed())
+			Expect(inf.AddIndexer("version", cache.IndexByMetadata("version"))).To(Succeed())
+
+			endpoints, err := inf.ByIndex("address", "10.10.10.10")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(endpoints).To(Equal([]*coretypes.Endpoint{
+				reg.endpoints["ns-1"]["serv-1"][0],
+				reg.endpoints["ns-2"]["serv-2"][0],
+			}))
+			Expect(inf.ByIndex("version", "v1")).To(HaveLen(3))
+			Expect(inf.ByIndex("version", "v2")).To(BeEmpty())
+
+			reg.lock.Lock()
+			delete(reg.endpoints, "ns-2")
+			reg.lock.Unlock()
+			Expect(inf.Resync(ctx)).To(Succeed())
+			Expect(inf.ByIndex("address", "10.10.10.10")).To(HaveLen(1))
+		})
+
+		Context("in case of user errors", func() {
+			It("returns an error", func() {
+				inf, _ := cache.NewInformer(sr)
+				Expect(inf.AddIndexer("", cache.IndexByAddress)).To(MatchError(srerr.EmptyIndexerName))
+				Expect(inf.AddIndexer("address", nil)).To(MatchError(srerr.NoIndexFuncProvided))
+				Expect(inf.AddIndexer("address", cache.IndexByAddress)).To(Succeed())
+				Expect(inf.AddIndexer("address", cache.IndexByAddress)).To(MatchError(srerr.IndexerAlreadyExists))
+
+				_, err := inf.ByIndex("version", "v1")
+				Expect(err).To(MatchError(srerr.IndexerNotFound))
+			})
+		})
+	})
+
+	Context("in case of errors", func() {
+		It("returns an error", func() {
+			By("checking the options", func() {
+				_, err := cache.NewInformer(nil)
+				Expect(err).To(MatchError(srerr.NoServiceRegistryProvided))
+				_, err = cache.NewInformer(sr, informer.WithResyncPeriod(0))
+				Expect(err).To(MatchError(srerr.InvalidResyncPeriod))
+			})
+
+			By("listing objects", func() {
+				expErr := fmt.Errorf("whatever")
+				reg.listErr = expErr
+
+				inf, _ := cache.NewInformer(sr)
+				Expect(inf.Start(ctx)).To(MatchError(expErr))
+				Expect(inf.LastSyncError()).To(MatchError(expErr))
+
+				reg.listErr = nil
+				Expect(inf.Start(ctx)).To(Succeed())
+				Expect(inf.LastSyncError()).NotTo(HaveOccurred())
+				Expect(inf.Start(ctx)).To(MatchError(srerr.InformerAlreadyStarted))
+			})
+
+			By("getting stale objects", func() {
+				reg.lock.Lock()
+				reg.stale = true
+				reg.lock.Unlock()
+				inf, _ := cache.NewInformer(sr, informer.WithNamespaces("ns-1"))
+				Expect(inf.Start(ctx)).To(MatchError(srerr.StaleObject))
+				Expect(inf.LastSyncError()).To(MatchError(srerr.StaleObject))
+
+				reg.lock.Lock()
+				reg.stale = false
+				stale := *reg.endpoints["ns-2"]["serv-2"][0]
+				stale.Stale = true
+				reg.endpoints["ns-2"]["serv-2"][0] = &stale
+				reg.lock.Unlock()
+				inf, _ = cache.NewInformer(sr)
+				Expect(inf.Start(ctx)).To(MatchError(srerr.StaleObject))
+				Expect(inf.ListNamespaces()).To(BeEmpty())
+			})
+		})
+	})
+})
+
+func objectName(obj interface{}) string {
+	switch o := obj.(type) {
+	case *coretypes.Namespace:
+		return o.Name
+	case *coretypes.Service:
+		return o.Namespace + "/" + o.Name
+	case *coretypes.Endpoint:
+		return o.Namespace + "/" + o.Service + "/" + o.Name
+	}
+
+	return ""
+}
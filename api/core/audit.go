@@ -0,0 +1,145 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/CloudNativeSDWAN/serego/api/core/types"
+	"github.com/CloudNativeSDWAN/serego/api/options/register"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+)
+
+type principalKey struct{}
+
+type auditEntryKey struct{}
+
+// WithPrincipal returns a copy of the context with the provided principal,
+// e.g. the name of the user or application on whose behalf operations are
+// performed, which is recorded on the audit entries of operations performed
+// with it. Read wrapper.WithAuditSink to learn more.
+//
+// Example:
+// 	ctx := core.WithPrincipal(context.Background(), "alice")
+// 	err := sr.Namespace("sales").Register(ctx)
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal set on the context with
+// WithPrincipal, or an empty string if none is set.
+func PrincipalFromContext(ctx context.Context) string {
+	principal, _ := ctx.Value(principalKey{}).(string)
+	return principal
+}
+
+// startAudit returns a copy of the context with a new audit entry for the
+// operation on the object with the provided path, which is filled as the
+// operation goes on and must be recorded with audit once it is done. No entry
+// is created if no audit sink was provided.
+func (s *ServiceRegistry) startAudit(ctx context.Context, action wrapper.AuditAction, object wrapper.Object, path string) (context.Context, *wrapper.AuditEntry) {
+	if s == nil || s.auditSink == nil {
+		return ctx, nil
+	}
+
+	entry := &wrapper.AuditEntry{
+		Principal: PrincipalFromContext(ctx),
+		Action:    action,
+		Object:    object,
+		Path:      path,
+	}
+
+	return context.WithValue(ctx, auditEntryKey{}, entry), entry
+}
+
+// audit records the entry on the audit sink along with the error returned by
+// the operation, if any. Errors from the sink are only logged, as the
+// operation was already performed anyways.
+func (s *ServiceRegistry) audit(entry *wrapper.AuditEntry, err error) {
+	if entry == nil {
+		return
+	}
+
+	entry.Time = time.Now()
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	if recErr := s.auditSink.Record(entry); recErr != nil {
+		s.log().Error(recErr, "could not record audit entry", "path", entry.Path)
+	}
+}
+
+// setAuditRegister sets on the audit entry in the context, if any, the
+// register mode and how the object is before and after the operation.
+func setAuditRegister(ctx context.Context, mode register.RegisterMode, before, after interface{}) {
+	if entry, ok := ctx.Value(auditEntryKey{}).(*wrapper.AuditEntry); ok {
+		entry.Mode = registerModeName(mode)
+		entry.Before, entry.After = auditObject(before), auditObject(after)
+	}
+}
+
+// setAuditBefore sets on the audit entry in the context, if any, how the
+// object is before the operation.
+func setAuditBefore(ctx context.Context, before interface{}) {
+	if entry, ok := ctx.Value(auditEntryKey{}).(*wrapper.AuditEntry); ok {
+		entry.Before = auditObject(before)
+	}
+}
+
+// setAuditPath sets the path of the object on the audit entry in the context,
+// if any, e.g. after its name was generated.
+func setAuditPath(ctx context.Context, path string) {
+	if entry, ok := ctx.Value(auditEntryKey{}).(*wrapper.AuditEntry); ok {
+		entry.Path = path
+	}
+}
+
+// auditObject makes sure objects that don't exist are recorded as nil rather
+// than as nil pointers.
+func auditObject(object interface{}) interface{} {
+	switch o := object.(type) {
+	case *types.Namespace:
+		if o == nil {
+			return nil
+		}
+	case *types.Service:
+		if o == nil {
+			return nil
+		}
+	case *types.Endpoint:
+		if o == nil {
+			return nil
+		}
+	}
+
+	return object
+}
+
+func (n *NamespaceOperation) auditTarget() (wrapper.Object, string) {
+	return wrapper.NamespaceObject, n.pathName
+}
+
+func (s *ServiceOperation) auditTarget() (wrapper.Object, string) {
+	return wrapper.ServiceObject, s.pathName
+}
+
+func (e *EndpointOperation) auditTarget() (wrapper.Object, string) {
+	return wrapper.EndpointObject, e.pathName
+}
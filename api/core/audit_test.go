@@ -0,0 +1,138 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/CloudNativeSDWAN/serego/api/core"
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/fake"
+	"github.com/CloudNativeSDWAN/serego/api/options/get"
+	"github.com/CloudNativeSDWAN/serego/api/options/register"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Audit", func() {
+	var (
+		sr        *core.ServiceRegistry
+		sink      *wrapper.MemoryAuditSink
+		current   *coretypes.Namespace
+		createErr error
+		ctx       = core.WithPrincipal(context.TODO(), "alice")
+	)
+
+	BeforeEach(func() {
+		current, createErr = nil, nil
+		nsop := &fake.NamespaceOperation{
+			Get_: func(_ context.Context, _ *get.Options) (*coretypes.Namespace, error) {
+				if current == nil {
+					return nil, srerr.NamespaceNotFound
+				}
+
+				return current, nil
+			},
+			Create_: func(_ context.Context, metadata map[string]string) (*coretypes.Namespace, error) {
+				if createErr != nil {
+					return nil, createErr
+				}
+
+				current = &coretypes.Namespace{Name: "ns", Metadata: metadata}
+				return current, nil
+			},
+			Update_: func(_ context.Context, metadata map[string]string, _ string) (*coretypes.Namespace, error) {
+				current = &coretypes.Namespace{Name: "ns", Metadata: metadata}
+				return current, nil
+			},
+			Delete_: func(_ context.Context) error {
+				current = nil
+				return nil
+			},
+		}
+		wrp, _ := fake.NewFakeWrapper()
+		wrp.Namespace_ = func(string) ops.NamespaceOperation {
+			return nsop
+		}
+
+		sink = wrapper.NewMemoryAuditSink()
+		sr, _ = core.NewServiceRegistryFromWrapper(wrp, wrapper.WithAuditSink(sink))
+	})
+
+	It("records register and deregister operations", func() {
+		Expect(sr.Namespace("ns").Register(ctx, register.WithKV("team", "a"))).To(Succeed())
+		Expect(sr.Namespace("ns").Register(ctx, register.WithKV("env", "prod"))).To(Succeed())
+		Expect(sr.Namespace("ns").Deregister(ctx)).To(Succeed())
+
+		entries := sink.Entries()
+		Expect(entries).To(HaveLen(3))
+		for _, entry := range entries {
+			Expect(entry.Principal).To(Equal("alice"))
+			Expect(entry.Object).To(Equal(wrapper.NamespaceObject))
+			Expect(entry.Path).To(Equal("namespaces/ns"))
+			Expect(entry.Time).NotTo(BeZero())
+			Expect(entry.Error).To(BeEmpty())
+		}
+
+		Expect(entries[0].Action).To(Equal(wrapper.RegisterAction))
+		Expect(entries[0].Mode).To(Equal("create"))
+		Expect(entries[0].Before).To(BeNil())
+		Expect(entries[0].After).To(Equal(&coretypes.Namespace{Name: "ns", Metadata: map[string]string{"team": "a"}}))
+
+		Expect(entries[1].Action).To(Equal(wrapper.RegisterAction))
+		Expect(entries[1].Mode).To(Equal("update"))
+		Expect(entries[1].Before).To(Equal(&coretypes.Namespace{Name: "ns", Metadata: map[string]string{"team": "a"}}))
+		Expect(entries[1].After).To(Equal(&coretypes.Namespace{Name: "ns", Metadata: map[string]string{"team": "a", "env": "prod"}}))
+
+		Expect(entries[2].Action).To(Equal(wrapper.DeregisterAction))
+		Expect(entries[2].Mode).To(BeEmpty())
+		Expect(entries[2].After).To(BeNil())
+	})
+
+	It("records failed operations", func() {
+		createErr = errors.New("permission denied")
+		err := sr.Namespace("ns").Register(ctx)
+		Expect(err).To(MatchError(createErr))
+
+		entries := sink.Entries()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Mode).To(Equal("create"))
+		Expect(entries[0].Error).To(HaveSuffix("permission denied"))
+	})
+
+	It("records operations performed in a transaction", func() {
+		current = &coretypes.Namespace{Name: "ns", Metadata: map[string]string{"team": "a"}}
+		err := sr.Txn(ctx).Deregister(sr.Namespace("ns")).Commit()
+		Expect(err).NotTo(HaveOccurred())
+
+		entries := sink.Entries()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Action).To(Equal(wrapper.DeregisterAction))
+		Expect(entries[0].Principal).To(Equal("alice"))
+		Expect(entries[0].Before).To(Equal(&coretypes.Namespace{Name: "ns", Metadata: map[string]string{"team": "a"}}))
+	})
+
+	It("returns the principal from the context", func() {
+		Expect(core.PrincipalFromContext(ctx)).To(Equal("alice"))
+		Expect(core.PrincipalFromContext(context.TODO())).To(BeEmpty())
+	})
+})
@@ -0,0 +1,365 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/options/bulk"
+	"github.com/CloudNativeSDWAN/serego/api/options/deregister"
+	"github.com/CloudNativeSDWAN/serego/api/options/register"
+)
+
+// RegisterRequest contains the name of an object to register in a bulk
+// operation, along with the options to register it with.
+type RegisterRequest struct {
+	Name    string
+	Options []register.Option
+}
+
+// DeregisterRequest contains the name of an object to deregister in a bulk
+// operation, along with the options to deregister it with.
+type DeregisterRequest struct {
+	Name    string
+	Options []deregister.Option
+}
+
+// BulkResult is the outcome of a bulk operation for a single object.
+type BulkResult struct {
+	// Name of the object. For endpoints registered with the GenerateName
+	// option this is the name that was generated.
+	Name string
+	// Err is the error returned while registering or deregistering the
+	// object, or nil if it was successful.
+	Err error
+}
+
+// BulkReport contains the outcome of a bulk operation for each object, in the
+// same order as they were requested.
+type BulkReport struct {
+	Results []BulkResult
+}
+
+// Failed returns the results of the objects that could not be registered or
+// deregistered.
+func (r *BulkReport) Failed() []BulkResult {
+	failed := []BulkResult{}
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
+
+// Err returns nil if all objects were successfully registered or
+// deregistered, or an error wrapping the first one that failed otherwise.
+func (r *BulkReport) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%d out of %d operations failed, first error on %s: %w", len(failed), len(r.Results), failed[0].Name, failed[0].Err)
+}
+
+// RegisterNamespaces registers all the provided namespaces concurrently,
+// as if Register was called on each one of them.
+//
+// An error is returned only if the bulk options are not valid: errors on
+// single namespaces are reported in the returned BulkReport.
+func (s *ServiceRegistry) RegisterNamespaces(ctx context.Context, namespaces []RegisterRequest, opts ...bulk.Option) (*BulkReport, error) {
+	bulkOpts, err := parseBulkOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return runBulk(ctx, bulkOpts, registerNames(namespaces), func(ctx context.Context, i int) BulkResult {
+		return BulkResult{
+			Name: namespaces[i].Name,
+			Err:  s.Namespace(namespaces[i].Name).Register(ctx, namespaces[i].Options...),
+		}
+	}), nil
+}
+
+// DeregisterNamespaces deregisters all the provided namespaces concurrently,
+// as if Deregister was called on each one of them.
+//
+// An error is returned only if the bulk options are not valid: errors on
+// single namespaces are reported in the returned BulkReport.
+func (s *ServiceRegistry) DeregisterNamespaces(ctx context.Context, namespaces []DeregisterRequest, opts ...bulk.Option) (*BulkReport, error) {
+	bulkOpts, err := parseBulkOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return runBulk(ctx, bulkOpts, deregisterNames(namespaces), func(ctx context.Context, i int) BulkResult {
+		return BulkResult{
+			Name: namespaces[i].Name,
+			Err:  s.Namespace(namespaces[i].Name).Deregister(ctx, namespaces[i].Options...),
+		}
+	}), nil
+}
+
+// RegisterServices registers all the provided services concurrently on this
+// namespace, as if Register was called on each one of them.
+//
+// The namespace is retrieved only once and shared with all services, so that
+// it is not retrieved again for each one of them: if this fails, or if it is
+// stale because the service registry can't be reached, an error is returned
+// and no service is registered. An error is also returned if the bulk
+// options are not valid, while errors on single services are reported in the
+// returned BulkReport.
+func (n *NamespaceOperation) RegisterServices(ctx context.Context, services []RegisterRequest, opts ...bulk.Option) (*BulkReport, error) {
+	bulkOpts, err := parseBulkOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ns, err := n.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get namespace before registering its services: %w", err)
+	}
+	if ns.Stale {
+		return nil, fmt.Errorf("could not get namespace before registering its services: %w", srerr.StaleObject)
+	}
+	ctx = ops.WithParent(ctx, ns)
+
+	return runBulk(ctx, bulkOpts, registerNames(services), func(ctx context.Context, i int) BulkResult {
+		return BulkResult{
+			Name: services[i].Name,
+			Err:  n.Service(services[i].Name).Register(ctx, services[i].Options...),
+		}
+	}), nil
+}
+
+// DeregisterServices deregisters all the provided services concurrently from
+// this namespace, as if Deregister was called on each one of them.
+//
+// The namespace is retrieved only once and shared with all services: if it
+// does not exist, the services are considered as already deregistered, while
+// any other error, or a stale namespace, is returned and no service is
+// deregistered. An error is also returned if the bulk options are not valid,
+// while errors on single services are reported in the returned BulkReport.
+func (n *NamespaceOperation) DeregisterServices(ctx context.Context, services []DeregisterRequest, opts ...bulk.Option) (*BulkReport, error) {
+	bulkOpts, err := parseBulkOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ns, err := n.Get(ctx)
+	if err != nil {
+		if !srerr.IsNotFound(err) {
+			return nil, fmt.Errorf("could not get namespace before deregistering its services: %w", err)
+		}
+
+		return parentNotFoundReport(services, err), nil
+	}
+	if ns.Stale {
+		return nil, fmt.Errorf("could not get namespace before deregistering its services: %w", srerr.StaleObject)
+	}
+	ctx = ops.WithParent(ctx, ns)
+
+	return runBulk(ctx, bulkOpts, deregisterNames(services), func(ctx context.Context, i int) BulkResult {
+		return BulkResult{
+			Name: services[i].Name,
+			Err:  n.Service(services[i].Name).Deregister(ctx, services[i].Options...),
+		}
+	}), nil
+}
+
+// RegisterEndpoints registers all the provided endpoints concurrently on this
+// service, as if Register was called on each one of them.
+//
+// The service is retrieved only once and shared with all endpoints, so that
+// it is not retrieved again for each one of them: if this fails, or if it is
+// stale because the service registry can't be reached, an error is returned
+// and no endpoint is registered. An error is also returned if the bulk
+// options are not valid, while errors on single endpoints are reported in
+// the returned BulkReport.
+//
+// Example:
+// 	report, err := servOp.RegisterEndpoints(ctx, []core.RegisterRequest{
+// 		{Name: "payroll-1", Options: []register.Option{
+// 			register.WithAddress("10.10.10.10"), register.WithPort(8080)}},
+// 		{Name: "payroll-2", Options: []register.Option{
+// 			register.WithAddress("10.10.10.11"), register.WithPort(8080)}},
+// 	}, bulk.WithConcurrency(20))
+// 	if err != nil {
+// 		return err
+// 	}
+// 	for _, failed := range report.Failed() {
+// 		fmt.Println("could not register", failed.Name, ":", failed.Err)
+// 	}
+func (s *ServiceOperation) RegisterEndpoints(ctx context.Context, endpoints []RegisterRequest, opts ...bulk.Option) (*BulkReport, error) {
+	bulkOpts, err := parseBulkOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	serv, err := s.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get service before registering its endpoints: %w", err)
+	}
+	if serv.Stale {
+		return nil, fmt.Errorf("could not get service before registering its endpoints: %w", srerr.StaleObject)
+	}
+	ctx = ops.WithParent(ctx, serv)
+
+	return runBulk(ctx, bulkOpts, registerNames(endpoints), func(ctx context.Context, i int) BulkResult {
+		endpOp := s.Endpoint(endpoints[i].Name)
+		err := endpOp.Register(ctx, endpoints[i].Options...)
+
+		// Use the operation's name, as it may have been generated.
+		return BulkResult{Name: endpOp.name, Err: err}
+	}), nil
+}
+
+// DeregisterEndpoints deregisters all the provided endpoints concurrently
+// from this service, as if Deregister was called on each one of them.
+//
+// The service is retrieved only once and shared with all endpoints: if it
+// does not exist, the endpoints are considered as already deregistered,
+// while any other error, or a stale service, is returned and no endpoint is
+// deregistered. An error is also returned if the bulk options are not valid,
+// while errors on single endpoints are reported in the returned BulkReport.
+func (s *ServiceOperation) DeregisterEndpoints(ctx context.Context, endpoints []DeregisterRequest, opts ...bulk.Option) (*BulkReport, error) {
+	bulkOpts, err := parseBulkOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	serv, err := s.Get(ctx)
+	if err != nil {
+		if !srerr.IsNotFound(err) {
+			return nil, fmt.Errorf("could not get service before deregistering its endpoints: %w", err)
+		}
+
+		return parentNotFoundReport(endpoints, err), nil
+	}
+	if serv.Stale {
+		return nil, fmt.Errorf("could not get service before deregistering its endpoints: %w", srerr.StaleObject)
+	}
+	ctx = ops.WithParent(ctx, serv)
+
+	return runBulk(ctx, bulkOpts, deregisterNames(endpoints), func(ctx context.Context, i int) BulkResult {
+		return BulkResult{
+			Name: endpoints[i].Name,
+			Err:  s.Endpoint(endpoints[i].Name).Deregister(ctx, endpoints[i].Options...),
+		}
+	}), nil
+}
+
+func parseBulkOptions(opts []bulk.Option) (*bulk.Options, error) {
+	bulkOpts := &bulk.Options{Concurrency: bulk.DefaultConcurrency}
+	for _, opt := range opts {
+		if err := opt(bulkOpts); err != nil {
+			return nil, err
+		}
+	}
+
+	return bulkOpts, nil
+}
+
+// runBulk calls do for each one of the named objects, running at most as many
+// of them at the same time as the concurrency in the options. Objects that
+// could not be started because the context was canceled will have the
+// context's error as result.
+func runBulk(ctx context.Context, bulkOpts *bulk.Options, names []string, do func(context.Context, int) BulkResult) *BulkReport {
+	var (
+		report    = &BulkReport{Results: make([]BulkResult, len(names))}
+		semaphore = make(chan struct{}, bulkOpts.Concurrency)
+		wg        sync.WaitGroup
+	)
+
+	for i := range names {
+		// Check the context first, as select picks a random case when both
+		// are ready.
+		if ctx.Err() != nil {
+			report.Results[i] = BulkResult{Name: names[i], Err: ctx.Err()}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			report.Results[i] = BulkResult{Name: names[i], Err: ctx.Err()}
+			continue
+		case semaphore <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			report.Results[i] = do(ctx, i)
+		}(i)
+	}
+
+	wg.Wait()
+	return report
+}
+
+// parentNotFoundReport reports all objects as successfully deregistered,
+// unless they were requested to fail if they don't exist.
+func parentNotFoundReport(requests []DeregisterRequest, parentErr error) *BulkReport {
+	report := &BulkReport{Results: make([]BulkResult, len(requests))}
+
+	for i, req := range requests {
+		report.Results[i].Name = req.Name
+
+		derOpts := &deregister.Options{}
+		for _, opt := range req.Options {
+			if err := opt(derOpts); err != nil {
+				report.Results[i].Err = err
+				break
+			}
+		}
+
+		if report.Results[i].Err == nil && derOpts.FailNotExists {
+			report.Results[i].Err = parentErr
+		}
+	}
+
+	return report
+}
+
+func registerNames(requests []RegisterRequest) []string {
+	names := make([]string, len(requests))
+	for i, req := range requests {
+		names[i] = req.Name
+	}
+
+	return names
+}
+
+func deregisterNames(requests []DeregisterRequest) []string {
+	names := make([]string, len(requests))
+	for i, req := range requests {
+		names[i] = req.Name
+	}
+
+	return names
+}
@@ -0,0 +1,289 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/CloudNativeSDWAN/serego/api/core"
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/fake"
+	"github.com/CloudNativeSDWAN/serego/api/options/bulk"
+	"github.com/CloudNativeSDWAN/serego/api/options/deregister"
+	"github.com/CloudNativeSDWAN/serego/api/options/get"
+	"github.com/CloudNativeSDWAN/serego/api/options/register"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bulk operations", func() {
+	const (
+		nsName   = "ns"
+		servName = "serv"
+	)
+
+	var (
+		sr         *core.ServiceRegistry
+		nsop       *fake.NamespaceOperation
+		servop     *fake.ServiceOperation
+		ctx        = context.TODO()
+		lock       sync.Mutex
+		inFlight   int
+		maxFlight  int
+		registered []string
+		failOn     string
+		expErr     = fmt.Errorf("whatever")
+	)
+
+	newEndpoint := func(name string) *fake.EndpointOperation {
+		return &fake.EndpointOperation{
+			Name_: name,
+			Get_: func(_ context.Context, _ *get.Options) (*coretypes.Endpoint, error) {
+				return nil, srerr.EndpointNotFound
+			},
+			Create_: func(_ context.Context, _ string, _ int32, _ map[string]string) (*coretypes.Endpoint, error) {
+				lock.Lock()
+				inFlight++
+				if inFlight > maxFlight {
+					maxFlight = inFlight
+				}
+				lock.Unlock()
+
+				time.Sleep(time.Millisecond)
+
+				lock.Lock()
+				defer lock.Unlock()
+				inFlight--
+				if name == failOn {
+					return nil, expErr
+				}
+
+				registered = append(registered, name)
+				return nil, nil
+			},
+			Delete_: func(_ context.Context) error {
+				if name == failOn {
+					return expErr
+				}
+
+				return nil
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		inFlight, maxFlight, failOn = 0, 0, ""
+		registered = []string{}
+		nsop = &fake.NamespaceOperation{
+			Get_: func(_ context.Context, _ *get.Options) (*coretypes.Namespace, error) {
+				return &coretypes.Namespace{Name: nsName}, nil
+			},
+		}
+		servop = &fake.ServiceOperation{
+			Get_: func(_ context.Context, _ *get.Options) (*coretypes.Service, error) {
+				return &coretypes.Service{Name: servName, Namespace: nsName}, nil
+			},
+			Endpoint_: func(name string) ops.EndpointOperation {
+				return newEndpoint(name)
+			},
+		}
+		nsop.Service_ = func(string) ops.ServiceOperation {
+			return servop
+		}
+		wrp, _ := fake.NewFakeWrapper()
+		wrp.Namespace_ = func(string) ops.NamespaceOperation {
+			return nsop
+		}
+		sr, _ = core.NewServiceRegistryFromWrapper(wrp)
+	})
+
+	Describe("Registering endpoints", func() {
+		It("registers all of them with bounded concurrency", func() {
+			endpoints := []core.RegisterRequest{}
+			for i := 0; i < 20; i++ {
+				endpoints = append(endpoints, core.RegisterRequest{
+					Name: fmt.Sprintf("endp-%d", i),
+					Options: []register.Option{
+						register.WithAddress("10.10.10.10"),
+						register.WithPort(int32(8000 + i)),
+					},
+				})
+			}
+			failOn = "endp-5"
+
+			report, err := sr.Namespace(nsName).Service(servName).
+				RegisterEndpoints(ctx, endpoints, bulk.WithConcurrency(3))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Results).To(HaveLen(20))
+			Expect(registered).To(HaveLen(19))
+			Expect(maxFlight).To(BeNumerically("<=", 3))
+			Expect(report.Results[0]).To(Equal(core.BulkResult{Name: "endp-0"}))
+			Expect(report.Failed()).To(HaveLen(1))
+			Expect(report.Failed()[0].Name).To(Equal("endp-5"))
+			Expect(report.Failed()[0].Err).To(MatchError(expErr))
+			Expect(report.Err()).To(MatchError(expErr))
+		})
+
+		It("shares the service with all endpoints", func() {
+			parents := make(chan *coretypes.Service, 2)
+			servop.Endpoint_ = func(name string) ops.EndpointOperation {
+				endp := newEndpoint(name)
+				endp.Create_ = func(ctx context.Context, _ string, _ int32, _ map[string]string) (*coretypes.Endpoint, error) {
+					parents <- ops.ParentService(ctx, nsName, servName)
+					return nil, nil
+				}
+				return endp
+			}
+
+			report, err := sr.Namespace(nsName).Service(servName).
+				RegisterEndpoints(ctx, []core.RegisterRequest{{Name: "endp-1"}, {Name: "endp-2"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Err()).NotTo(HaveOccurred())
+			Expect(<-parents).To(Equal(&coretypes.Service{Name: servName, Namespace: nsName}))
+			Expect(<-parents).To(Equal(&coretypes.Service{Name: servName, Namespace: nsName}))
+		})
+
+		It("reports generated names", func() {
+			report, err := sr.Namespace(nsName).Service(servName).
+				RegisterEndpoints(ctx, []core.RegisterRequest{
+					{Options: []register.Option{register.WithGenerateName()}},
+				})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Err()).NotTo(HaveOccurred())
+			Expect(report.Results[0].Name).To(HavePrefix(servName + "-"))
+		})
+
+		Context("in case of errors", func() {
+			It("returns an error and does nothing", func() {
+				By("checking the options", func() {
+					_, err := sr.Namespace(nsName).Service(servName).
+						RegisterEndpoints(ctx, []core.RegisterRequest{{Name: "endp"}},
+							bulk.WithConcurrency(0))
+					Expect(err).To(MatchError(srerr.InvalidConcurrency))
+				})
+
+				By("getting the parent service only once", func() {
+					times := 0
+					servop.Get_ = func(_ context.Context, _ *get.Options) (*coretypes.Service, error) {
+						times++
+						return nil, srerr.ServiceNotFound
+					}
+					_, err := sr.Namespace(nsName).Service(servName).
+						RegisterEndpoints(ctx, []core.RegisterRequest{{Name: "endp-1"}, {Name: "endp-2"}})
+					Expect(err).To(MatchError(srerr.ServiceNotFound))
+					Expect(times).To(Equal(1))
+				})
+
+				By("refusing stale services", func() {
+					stale := &coretypes.Service{Name: servName, Namespace: nsName, Stale: true}
+					servop.Get_ = func(_ context.Context, _ *get.Options) (*coretypes.Service, error) {
+						return stale, nil
+					}
+					_, err := sr.Namespace(nsName).Service(servName).
+						RegisterEndpoints(ctx, []core.RegisterRequest{{Name: "endp-1"}})
+					Expect(err).To(MatchError(srerr.StaleObject))
+					_, err = sr.Namespace(nsName).Service(servName).
+						DeregisterEndpoints(ctx, []core.DeregisterRequest{{Name: "endp-1"}})
+					Expect(err).To(MatchError(srerr.StaleObject))
+					Expect(ops.ParentService(ops.WithParent(ctx, stale), nsName, servName)).To(BeNil())
+				})
+
+				Expect(registered).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Deregistering endpoints", func() {
+		It("reports errors on each one of them", func() {
+			failOn = "endp-2"
+			report, err := sr.Namespace(nsName).Service(servName).
+				DeregisterEndpoints(ctx, []core.DeregisterRequest{
+					{Name: "endp-1"}, {Name: "endp-2"}, {Name: "endp-3"},
+				})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Results).To(HaveLen(3))
+			Expect(report.Results[0]).To(Equal(core.BulkResult{Name: "endp-1"}))
+			Expect(report.Results[1].Name).To(Equal("endp-2"))
+			Expect(report.Results[1].Err).To(MatchError(expErr))
+			Expect(report.Results[2]).To(Equal(core.BulkResult{Name: "endp-3"}))
+		})
+
+		Context("when the service does not exist", func() {
+			It("considers them as deregistered", func() {
+				servop.Get_ = func(_ context.Context, _ *get.Options) (*coretypes.Service, error) {
+					return nil, srerr.ServiceNotFound
+				}
+				servop.Endpoint_ = func(string) ops.EndpointOperation {
+					Fail("should not deregister endpoints")
+					return nil
+				}
+
+				report, err := sr.Namespace(nsName).Service(servName).
+					DeregisterEndpoints(ctx, []core.DeregisterRequest{
+						{Name: "endp-1"},
+						{Name: "endp-2", Options: []deregister.Option{deregister.WithFailIfNotExists()}},
+					})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report.Results).To(HaveLen(2))
+				Expect(report.Results[0]).To(Equal(core.BulkResult{Name: "endp-1"}))
+				Expect(report.Results[1].Name).To(Equal("endp-2"))
+				Expect(report.Results[1].Err).To(MatchError(srerr.ServiceNotFound))
+			})
+		})
+	})
+
+	Describe("Registering namespaces and services", func() {
+		It("registers all of them", func() {
+			nsop.Get_ = func(_ context.Context, _ *get.Options) (*coretypes.Namespace, error) {
+				return nil, srerr.NamespaceNotFound
+			}
+			nsop.Create_ = func(_ context.Context, _ map[string]string) (*coretypes.Namespace, error) {
+				return nil, nil
+			}
+
+			report, err := sr.RegisterNamespaces(ctx, []core.RegisterRequest{
+				{Name: "ns-1"}, {Name: ""},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Results).To(Equal([]core.BulkResult{
+				{Name: "ns-1"}, {Name: "", Err: srerr.EmptyNamespaceName},
+			}))
+
+			_, err = sr.Namespace(nsName).RegisterServices(ctx, []core.RegisterRequest{{Name: servName}})
+			Expect(err).To(MatchError(srerr.NamespaceNotFound))
+		})
+	})
+
+	Context("with a canceled context", func() {
+		It("does not start any operation", func() {
+			canceledCtx, cancel := context.WithCancel(ctx)
+			cancel()
+
+			report, err := sr.DeregisterNamespaces(canceledCtx, []core.DeregisterRequest{{Name: "ns-1"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Results).To(Equal([]core.BulkResult{
+				{Name: "ns-1", Err: context.Canceled},
+			}))
+		})
+	})
+})
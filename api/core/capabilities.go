@@ -0,0 +1,152 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"fmt"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+)
+
+// MetadataLimits are the limits of the service registry on the metadata of
+// an object. Zero values mean that there is no limit.
+type MetadataLimits struct {
+	// MaxEntries is the maximum number of key-value pairs.
+	MaxEntries int
+	// MaxKeyLength is the maximum length of each key.
+	MaxKeyLength int
+	// MaxValueLength is the maximum length of each value.
+	MaxValueLength int
+	// MaxTotalLength is the maximum length of all keys and values together.
+	MaxTotalLength int
+}
+
+// Capabilities describes what the service registry supports.
+//
+// Service registries provided through NewServiceRegistryFromWrapper are
+// assumed to support metadata with no limits, and transactions only if they
+// implement them.
+type Capabilities struct {
+	// NamespaceMetadata is true if namespaces can have metadata.
+	NamespaceMetadata bool
+	// NamespaceMetadataLimits, ServiceMetadataLimits and
+	// EndpointMetadataLimits are the limits on the metadata of each object,
+	// which are checked by Register before calling the service registry.
+	NamespaceMetadataLimits MetadataLimits
+	ServiceMetadataLimits   MetadataLimits
+	EndpointMetadataLimits  MetadataLimits
+	// Watch is true if the service registry can notify changes to objects,
+	// e.g. for wrapper.WithCacheInvalidation.
+	Watch bool
+	// Transactions is true if transactions are performed atomically rather
+	// than one operation after the other. Read Txn to learn more.
+	Transactions bool
+	// ServerSideFilters is true if list filters are applied by the service
+	// registry, rather than by the API after retrieving all objects.
+	ServerSideFilters bool
+	// IPv6 is true if endpoints can have IPv6 addresses.
+	IPv6 bool
+	// RecursiveDelete is true if deregistering a namespace also removes all
+	// its services and endpoints, rather than failing if it is not empty.
+	RecursiveDelete bool
+}
+
+var backendCapabilities = map[string]Capabilities{
+	metrics.Etcd: {
+		NamespaceMetadata: true,
+		Watch:             true,
+		Transactions:      true,
+		IPv6:              true,
+		RecursiveDelete:   true,
+	},
+	// Metadata of namespaces and services are tags, while metadata of
+	// endpoints are custom attributes, two of which are used for the address
+	// and port.
+	metrics.CloudMap: {
+		NamespaceMetadata:       true,
+		NamespaceMetadataLimits: MetadataLimits{MaxEntries: 50, MaxKeyLength: 128, MaxValueLength: 256},
+		ServiceMetadataLimits:   MetadataLimits{MaxEntries: 50, MaxKeyLength: 128, MaxValueLength: 256},
+		EndpointMetadataLimits:  MetadataLimits{MaxEntries: 28, MaxKeyLength: 255, MaxValueLength: 1024},
+		IPv6:                    true,
+	},
+	// Metadata of namespaces are labels, while metadata of services and
+	// endpoints are annotations.
+	metrics.ServiceDirectory: {
+		NamespaceMetadata:       true,
+		NamespaceMetadataLimits: MetadataLimits{MaxEntries: 64, MaxKeyLength: 63, MaxValueLength: 63},
+		ServiceMetadataLimits:   MetadataLimits{MaxTotalLength: 2000},
+		EndpointMetadataLimits:  MetadataLimits{MaxTotalLength: 512},
+		ServerSideFilters:       true,
+		IPv6:                    true,
+		RecursiveDelete:         true,
+	},
+}
+
+// capabilitiesOf returns the capabilities of the provided backend.
+func capabilitiesOf(backend string, w ops.ServiceRegistryWrapper) Capabilities {
+	if capabilities, exists := backendCapabilities[backend]; exists {
+		return capabilities
+	}
+
+	_, transactions := w.(ops.Transactioner)
+	return Capabilities{
+		NamespaceMetadata: true,
+		Transactions:      transactions,
+	}
+}
+
+// Capabilities returns what the service registry supports.
+func (s *ServiceRegistry) Capabilities() Capabilities {
+	if s == nil {
+		return Capabilities{}
+	}
+
+	return s.capabilities
+}
+
+// check returns an error if the metadata exceed the limits.
+func (l MetadataLimits) check(metadata map[string]string) error {
+	if l.MaxEntries > 0 && len(metadata) > l.MaxEntries {
+		return fmt.Errorf("%w: %d key-value pairs provided, at most %d are allowed",
+			srerr.TooManyMetadata, len(metadata), l.MaxEntries)
+	}
+
+	total := 0
+	for k, v := range metadata {
+		if l.MaxKeyLength > 0 && len(k) > l.MaxKeyLength {
+			return fmt.Errorf("%w: key %q is longer than %d characters",
+				srerr.MetadataKeyTooLong, k, l.MaxKeyLength)
+		}
+
+		if l.MaxValueLength > 0 && len(v) > l.MaxValueLength {
+			return fmt.Errorf("%w: value of key %q is longer than %d characters",
+				srerr.MetadataValueTooLong, k, l.MaxValueLength)
+		}
+
+		total += len(k) + len(v)
+	}
+
+	if l.MaxTotalLength > 0 && total > l.MaxTotalLength {
+		return fmt.Errorf("%w: keys and values are %d characters long, at most %d are allowed",
+			srerr.MetadataTooLong, total, l.MaxTotalLength)
+	}
+
+	return nil
+}
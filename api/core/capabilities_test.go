@@ -0,0 +1,88 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/CloudNativeSDWAN/serego/api/core"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/fake"
+	"github.com/CloudNativeSDWAN/serego/api/options/register"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Capabilities", func() {
+	ctx := context.TODO()
+
+	It("describes service registries provided as wrappers", func() {
+		wrp, _ := fake.NewFakeWrapper()
+		sr, _ := core.NewServiceRegistryFromWrapper(wrp)
+		Expect(sr.Capabilities()).To(Equal(core.Capabilities{NamespaceMetadata: true}))
+
+		txnWrp, _ := fake.NewFakeTransactionalWrapper()
+		sr, _ = core.NewServiceRegistryFromWrapper(txnWrp)
+		Expect(sr.Capabilities()).To(Equal(core.Capabilities{NamespaceMetadata: true, Transactions: true}))
+
+		Expect((&core.ServiceRegistry{}).Capabilities()).To(Equal(core.Capabilities{}))
+	})
+
+	Context("with a service registry with limits", func() {
+		var sr *core.ServiceRegistry
+
+		BeforeEach(func() {
+			// Limits are checked before any call is performed, so the
+			// client doesn't need to be configured.
+			var err error
+			sr, err = core.NewServiceRegistryFromCloudMap(servicediscovery.NewFromConfig(aws.Config{}))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("describes them", func() {
+			capabilities := sr.Capabilities()
+			Expect(capabilities.NamespaceMetadata).To(BeTrue())
+			Expect(capabilities.ServiceMetadataLimits).To(Equal(core.MetadataLimits{
+				MaxEntries: 50, MaxKeyLength: 128, MaxValueLength: 256,
+			}))
+			Expect(capabilities.Transactions).To(BeFalse())
+			Expect(capabilities.RecursiveDelete).To(BeFalse())
+		})
+
+		It("does not register metadata that exceed them", func() {
+			metadata := map[string]string{}
+			for i := 0; i < 51; i++ {
+				metadata[fmt.Sprintf("key-%d", i)] = "value"
+			}
+			err := sr.Namespace("ns").Register(ctx, register.WithMetadata(metadata))
+			Expect(err).To(MatchError(srerr.TooManyMetadata))
+			Expect(srerr.IsInvalidArgument(err)).To(BeTrue())
+
+			err = sr.Namespace("ns").Service("serv").Register(ctx, register.WithKV(strings.Repeat("k", 129), "value"))
+			Expect(err).To(MatchError(srerr.MetadataKeyTooLong))
+
+			err = sr.Namespace("ns").Service("serv").Endpoint("endp").
+				Register(ctx, register.WithKV("key", strings.Repeat("v", 1025)))
+			Expect(err).To(MatchError(srerr.MetadataValueTooLong))
+		})
+	})
+})
@@ -88,6 +88,23 @@
 // Get retrieves the object by checking the internal cache and, if
 // not found there, by performing a call to the service registry.
 //
+// Concurrent Get operations on the same object share the same call to the
+// service registry, and so do List operations fetching the same page of
+// results, unless the ForceRefresh option is provided.
+//
+// Objects that are not found are remembered for a short time as well, so
+// that getting them again returns the same error immediately, unless they are
+// created through the API in the meantime. You can change this time with the
+// WithNegativeCacheExpirationTime wrapper option.
+//
+// Objects returned by List operations are stored on cache as well. To load a
+// whole namespace with all of its services and endpoints on cache at once,
+// e.g. at startup, you can use the Prefetch function.
+//
+// If other applications modify the service registry as well, objects on
+// cache may be outdated until they expire: on etcd, you can avoid this with
+// the WithCacheInvalidation wrapper option.
+//
 // Check out the the examples provided in each Get function and read their
 // description to learn more about their behavior and options they accept.
 //
@@ -109,6 +126,10 @@
 // ReplaceMetadata to its options, which will still add the pair but destroy
 // all the existing ones.
 //
+// Some service registries limit the number and length of metadata, which
+// Register checks before calling them: Capabilities tells you which limits
+// apply and what else the service registry supports, e.g. transactions.
+//
 // Check out the the examples provided in each Register function and read their
 // description to learn more about their behavior and options they accept.
 //
@@ -141,6 +162,14 @@
 // name, metadata, address or ports, and for a full list of options and
 // filters check out each List function to see what they accept.
 //
+// Metadata filters can also be written as Kubernetes label selectors with
+// list.WithSelector, e.g. "env in (prod,staging),!deprecated".
+//
+// To combine conditions on all fields of objects, e.g. name, address, port
+// and metadata, you can provide an expression with list.WithExpression, e.g.
+// `name ~ "^api-" && port >= 8000`: expressions are always evaluated by the
+// API.
+//
 // You can then iterate through the objects with the Next function of each
 // iterator, checking if there are no more elements with the provided errors
 // package (look at the examples).
@@ -153,6 +182,15 @@
 // done to allow you to perform an operation on the object immediately, without
 // instantiating one for it or repeating yourself.
 //
+// Objects are returned in the order the service registry returns them, unless
+// you provide list.WithOrderBy: in that case they are sorted by the service
+// registry if it can, or by the API otherwise, which retrieves all objects
+// before returning the first one.
+//
+// To resume listing at a later time, e.g. to serve the next page of results
+// of a REST API, call NextPageToken on the iterator and provide the token
+// to the next List call with list.WithPageToken.
+//
 // Next
 //
 // Next functions can be called on the results of a List function and they
@@ -163,6 +201,91 @@
 // no filters are passed, and it automatically tries the next element if it
 // does not.
 //
+// Transactions
+//
+// If you need to register or deregister multiple objects together, e.g. a
+// service and all of its endpoints, you can group those operations in a
+// transaction through the Txn function and apply them with Commit.
+//
+// On etcd the transaction is atomic, while on the other service registries
+// operations are applied one by one and the ones already applied are reverted
+// in case of errors. Read the Txn documentation to learn more.
+//
+// Bulk operations
+//
+// If you need to register or deregister many objects at once, e.g. hundreds
+// of endpoints, you can use the bulk functions, like RegisterEndpoints, which
+// work on multiple objects concurrently. Differently from transactions, they
+// do not stop at the first error but return a report with the outcome for
+// each object instead.
+//
+// Retries
+//
+// Operations may fail because of temporary errors, e.g. when the service
+// registry is unavailable or throttling requests: you can check this with
+// the IsTransient function of the errors package. If you provide a retry
+// policy with the WithRetryPolicy wrapper option, such operations are retried
+// automatically with exponential backoff.
+//
+// Rate limits
+//
+// To avoid exhausting the API quotas of your account, you can limit the rate
+// of calls performed to the service registry with the WithRateLimit wrapper
+// option, or with WithReadRateLimit and WithWriteRateLimit to have different
+// limits for calls that read and modify the service registry.
+//
+// Stale objects and circuit breaker
+//
+// If the service registry is temporarily unavailable, Get operations can
+// return objects from the cache even if they are expired, as long as they
+// expired no longer than the grace period provided with the
+// WithStaleWhileError wrapper option: such objects have their Stale field set
+// to true. With the WithCircuitBreaker wrapper option calls are not even
+// performed after too many consecutive failures and the CircuitOpen error is
+// returned instead, until the service registry is considered healthy again.
+//
+// To return objects even when the application restarts while the service
+// registry can't be reached, you can save them on disk with a FileCache and
+// the WithPersistentCache wrapper option.
+//
+// Metrics, tracing and logging
+//
+// You can export Prometheus metrics about the operations performed on the
+// service registry, e.g. how many of them failed and how long they took, and
+// about the cache by providing a registerer to the WithMetrics wrapper option.
+//
+// Similarly, you can trace operations with OpenTelemetry by providing a tracer
+// provider to the WithTracerProvider wrapper option: each operation, e.g.
+// Register, has its own span, with child spans for the operations it performs
+// and the calls to the service registry, e.g. to get the object first.
+//
+// To know what the API is doing, e.g. which calls it performs and whether it
+// found objects on cache, you can provide a logr logger with the WithLogger
+// wrapper option.
+//
+// Interceptors
+//
+// If you need to run your own code around each call to the service registry,
+// e.g. to audit or forbid some of them, you can provide interceptors with the
+// WithInterceptors wrapper option: they know which operation is being
+// performed, on which object and with which options, and they can see or
+// change its result and error, or even prevent the call from being performed.
+//
+// Audit
+//
+// To know who registered or deregistered what and when, you can record all
+// Register and Deregister operations, including the failed ones, on an audit
+// sink provided with the WithAuditSink wrapper option, e.g. a file with one
+// JSON object per line. Set the principal performing the operations on their
+// context with WithPrincipal.
+//
+// Local replica
+//
+// If you need to look up objects very often, e.g. in a sidecar, you can keep
+// a local replica of them with an informer from the cache package
+// (pkg.go.dev/github.com/CloudNativeSDWAN/serego/api/cache), which also
+// notifies you when objects are added, updated or deleted.
+//
 // Quickstart example
 //
 // Take a look at this example:
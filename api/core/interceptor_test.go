@@ -0,0 +1,128 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core_test
+
+import (
+	"context"
+	"errors"
+
+	"github.com/CloudNativeSDWAN/serego/api/core"
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/fake"
+	"github.com/CloudNativeSDWAN/serego/api/options/get"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("Interceptors", func() {
+	var (
+		wrp      *fake.FakeWrapper
+		attempts int
+		deleted  bool
+		calls    []wrapper.Call
+		errs     []error
+		record   wrapper.Interceptor
+	)
+
+	BeforeEach(func() {
+		attempts, deleted = 0, false
+		calls, errs = []wrapper.Call{}, []error{}
+		record = func(ctx context.Context, call *wrapper.Call, next wrapper.Handler) error {
+			err := next(ctx)
+			calls, errs = append(calls, *call), append(errs, err)
+			return err
+		}
+
+		nsop := &fake.NamespaceOperation{
+			Get_: func(_ context.Context, _ *get.Options) (*coretypes.Namespace, error) {
+				attempts++
+				if attempts < 2 {
+					return nil, status.Error(codes.Unavailable, "unavailable")
+				}
+
+				return &coretypes.Namespace{Name: "ns"}, nil
+			},
+			Delete_: func(_ context.Context) error {
+				deleted = true
+				return nil
+			},
+		}
+		wrp, _ = fake.NewFakeWrapper()
+		wrp.Namespace_ = func(string) ops.NamespaceOperation {
+			return nsop
+		}
+	})
+
+	It("sees each operation once, with its result", func() {
+		sr, err := core.NewServiceRegistryFromWrapper(wrp,
+			wrapper.WithInterceptors(record),
+			wrapper.WithRetryPolicy(wrapper.RetryPolicy{MaxAttempts: 2}),
+			wrapper.WithNoCache())
+		Expect(err).NotTo(HaveOccurred())
+
+		ns, err := sr.Namespace("ns").Get(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(attempts).To(Equal(2))
+		Expect(calls).To(HaveLen(1))
+		Expect(calls[0].Operation).To(Equal(wrapper.GetOperation))
+		Expect(calls[0].Object).To(Equal(wrapper.NamespaceObject))
+		Expect(calls[0].Path).To(Equal("namespaces/ns"))
+		Expect(calls[0].Options).To(BeAssignableToTypeOf(&get.Options{}))
+		Expect(calls[0].Result).To(Equal(ns))
+		Expect(errs).To(Equal([]error{nil}))
+	})
+
+	It("sees the errors returned", func() {
+		sr, _ := core.NewServiceRegistryFromWrapper(wrp,
+			wrapper.WithInterceptors(record),
+			wrapper.WithNoCache())
+
+		_, err := sr.Namespace("ns").Get(context.TODO())
+		Expect(srerr.IsTransient(err)).To(BeTrue())
+		Expect(calls).To(HaveLen(1))
+		Expect(calls[0].Result).To(BeNil())
+		Expect(errs).To(HaveLen(1))
+		Expect(srerr.IsTransient(errs[0])).To(BeTrue())
+	})
+
+	It("can prevent calls from being performed", func() {
+		denied := errors.New("deletions are not allowed")
+		denyDeletes := func(ctx context.Context, call *wrapper.Call, next wrapper.Handler) error {
+			if call.Operation == wrapper.DeleteOperation {
+				return denied
+			}
+
+			return next(ctx)
+		}
+		sr, _ := core.NewServiceRegistryFromWrapper(wrp,
+			wrapper.WithInterceptors(record, denyDeletes),
+			wrapper.WithNoCache())
+
+		err := sr.Namespace("ns").Deregister(context.TODO())
+		Expect(err).To(MatchError(denied))
+		Expect(deleted).To(BeFalse())
+		Expect(calls).To(HaveLen(1))
+		Expect(calls[0].Operation).To(Equal(wrapper.DeleteOperation))
+		Expect(errs).To(Equal([]error{denied}))
+	})
+})
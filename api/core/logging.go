@@ -0,0 +1,43 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+// Keys and values of the objects' names, to be logged along with messages
+// about them.
+
+func (n *NamespaceOperation) keysAndValues() []interface{} {
+	return []interface{}{"namespace", n.name}
+}
+
+func (s *ServiceOperation) keysAndValues() []interface{} {
+	keysAndValues := []interface{}{}
+	if s.parent != nil {
+		keysAndValues = s.parent.keysAndValues()
+	}
+
+	return append(keysAndValues, "service", s.name)
+}
+
+func (e *EndpointOperation) keysAndValues() []interface{} {
+	keysAndValues := []interface{}{}
+	if e.parent != nil {
+		keysAndValues = e.parent.keysAndValues()
+	}
+
+	return append(keysAndValues, "endpoint", e.name)
+}
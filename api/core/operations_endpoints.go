@@ -23,11 +23,15 @@ import (
 
 	"github.com/CloudNativeSDWAN/serego/api/core/types"
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/logging"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
 	"github.com/CloudNativeSDWAN/serego/api/options/deregister"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
 	"github.com/CloudNativeSDWAN/serego/api/options/register"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // EndpointOperation contains data and code that will be used to perform
@@ -61,7 +65,10 @@ func (s *ServiceOperation) Endpoint(name string) *EndpointOperation {
 //
 // Unless cache is disabled, it will first check its cache and later the
 // service registry if not found there.
-func (e *EndpointOperation) Get(ctx context.Context, opts ...get.Option) (*types.Endpoint, error) {
+func (e *EndpointOperation) Get(ctx context.Context, opts ...get.Option) (ep *types.Endpoint, err error) {
+	ctx, span := e.root.startSpan(ctx, "Endpoint.Get", e.attributes()...)
+	defer func() { tracing.End(span, err) }()
+
 	if err := e.checkNames(); err != nil {
 		return nil, err
 	}
@@ -73,12 +80,7 @@ func (e *EndpointOperation) Get(ctx context.Context, opts ...get.Option) (*types
 		}
 	}
 
-	ep, err := e.op.Get(ctx, getOptions)
-	if err != nil {
-		return nil, err
-	}
-
-	return ep, nil
+	return e.op.Get(ctx, getOptions)
 }
 
 // Register will insert - or update, if already exists - the endpoint with the
@@ -88,14 +90,11 @@ func (e *EndpointOperation) Get(ctx context.Context, opts ...get.Option) (*types
 // as long as you also have the GenerateName option enabled, in which case
 // a name will be generated for this endpoint starting from its parent service
 // name.
-func (e *EndpointOperation) Register(ctx context.Context, opts ...register.Option) error {
-	if e.root == nil {
-		return srerr.UninitializedOperation
-	}
-
-	if err := e.parent.checkNames(); err != nil {
-		return err
-	}
+func (e *EndpointOperation) Register(ctx context.Context, opts ...register.Option) (err error) {
+	ctx, span := e.root.startSpan(ctx, "Endpoint.Register", e.attributes()...)
+	defer func() { tracing.End(span, err) }()
+	ctx, entry := e.root.startAudit(ctx, wrapper.RegisterAction, wrapper.EndpointObject, e.pathName)
+	defer func() { e.root.audit(entry, err) }()
 
 	regOpts := &register.Options{}
 	for _, opt := range opts {
@@ -104,24 +103,64 @@ func (e *EndpointOperation) Register(ctx context.Context, opts ...register.Optio
 		}
 	}
 
+	step, err := e.planRegister(ctx, regOpts)
+	if err != nil || step == nil {
+		return err
+	}
+
+	return step.apply(ctx)
+}
+
+func (e *EndpointOperation) planRegister(ctx context.Context, regOpts *register.Options) (*txnStep, error) {
+	if e.root == nil {
+		return nil, srerr.UninitializedOperation
+	}
+
+	if err := e.parent.checkNames(); err != nil {
+		return nil, err
+	}
+
 	if e.name == "" {
 		if !regOpts.GenerateName ||
 			(regOpts.GenerateName && regOpts.RegisterMode == register.UpdateMode) {
-			return srerr.EmptyEndpointName
+			return nil, srerr.EmptyEndpointName
 		}
 
 		name := generateRandomName(e.parent.name)
 		e.pathName = path.Join(e.pathName, name)
 		e.name = name
 		e.op = e.parent.op.Endpoint(name)
+		trace.SpanFromContext(ctx).SetAttributes(tracing.EndpointKey.String(name))
+		setAuditPath(ctx, e.pathName)
 	}
 
-	ep, err := e.Get(ctx)
+	// Check the metadata provided before calling the service registry, and
+	// later the ones that will be actually registered.
+	limits := e.root.Capabilities().EndpointMetadataLimits
+	if err := limits.check(regOpts.Metadata); err != nil {
+		return nil, err
+	}
+
+	getOpts := []get.Option{}
+	if regOpts.ExpectedRevision != "" {
+		// Cache may be outdated and we need the actual revision.
+		getOpts = append(getOpts, get.WithForceRefresh())
+	}
+
+	ep, err := e.Get(ctx, getOpts...)
 	registerMode, newMetadata, err := prepareRegisterOperation(regOpts, ep, err)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	if err := limits.check(newMetadata); err != nil {
+		return nil, err
+	}
+
+	setRegisterMode(ctx, registerMode)
+	e.root.log().V(logging.Debug).Info("registering endpoint",
+		append(e.keysAndValues(), "mode", registerModeName(registerMode))...)
+
 	// Reset some values.
 	if regOpts.Address == nil {
 		regOpts.Address = func() *string {
@@ -145,25 +184,56 @@ func (e *EndpointOperation) Register(ctx context.Context, opts ...register.Optio
 		}()
 	}
 
-	if registerMode == register.CreateMode {
-		_, err = e.op.Create(ctx, *regOpts.Address, *regOpts.Port, newMetadata)
-	} else {
-		epToUpdate := &types.Endpoint{
-			Name:      e.name,
-			Service:   e.parent.name,
+	address, port := *regOpts.Address, *regOpts.Port
+	epToUpdate := &types.Endpoint{
+		Name:      e.name,
+		Service:   e.parent.name,
+		Namespace: e.parent.parent.name,
+		Address:   address,
+		Port:      port,
+		Metadata:  newMetadata,
+	}
+	setAuditRegister(ctx, registerMode, ep, epToUpdate)
+
+	step := &txnStep{
+		step: ops.TxnStep{
 			Namespace: e.parent.parent.name,
-			Address:   *regOpts.Address,
-			Port:      *regOpts.Port,
+			Service:   e.parent.name,
+			Endpoint:  e.name,
+			Address:   address,
+			Port:      port,
 			Metadata:  newMetadata,
+		},
+		pathName: e.pathName,
+	}
+
+	if registerMode == register.CreateMode {
+		step.step.Action = ops.TxnCreate
+		step.apply = func(ctx context.Context) error {
+			_, err := e.op.Create(ctx, address, port, newMetadata)
+			return err
 		}
-		if ep.DeepEqualTo(epToUpdate) {
-			return nil
-		}
+		step.revert = e.op.Delete
+		return step, nil
+	}
 
-		_, err = e.op.Update(ctx, *regOpts.Address, *regOpts.Port, newMetadata)
+	if ep.DeepEqualTo(epToUpdate) {
+		e.root.log().V(logging.Debug).Info("endpoint did not change, not updating it", e.keysAndValues()...)
+		return nil, nil
 	}
 
-	return err
+	step.step.Action = ops.TxnUpdate
+	step.step.Revision = ep.Revision
+	step.apply = func(ctx context.Context) error {
+		_, err := e.op.Update(ctx, address, port, newMetadata, regOpts.ExpectedRevision)
+		return err
+	}
+	step.revert = func(ctx context.Context) error {
+		_, err := e.op.Update(ctx, ep.Address, ep.Port, ep.Metadata, "")
+		return err
+	}
+
+	return step, nil
 }
 
 // Deregister removes the endpoint from the service registry and from the
@@ -171,7 +241,12 @@ func (e *EndpointOperation) Register(ctx context.Context, opts ...register.Optio
 //
 // By default, it will not return an error if the endpoint does not exist.
 // Please read the Deregister operations section to learn more.
-func (e *EndpointOperation) Deregister(ctx context.Context, opts ...deregister.Option) error {
+func (e *EndpointOperation) Deregister(ctx context.Context, opts ...deregister.Option) (err error) {
+	ctx, span := e.root.startSpan(ctx, "Endpoint.Deregister", e.attributes()...)
+	defer func() { tracing.End(span, err) }()
+	ctx, entry := e.root.startAudit(ctx, wrapper.DeregisterAction, wrapper.EndpointObject, e.pathName)
+	defer func() { e.root.audit(entry, err) }()
+
 	if err := e.checkNames(); err != nil {
 		return err
 	}
@@ -185,6 +260,7 @@ func (e *EndpointOperation) Deregister(ctx context.Context, opts ...deregister.O
 
 	if err := e.op.Delete(ctx); err != nil {
 		if srerr.IsNotFound(err) && !derOpts.FailNotExists {
+			e.root.log().V(logging.Debug).Info("endpoint does not exist, nothing to deregister", e.keysAndValues()...)
 			return nil
 		}
 
@@ -194,6 +270,35 @@ func (e *EndpointOperation) Deregister(ctx context.Context, opts ...deregister.O
 	return nil
 }
 
+func (e *EndpointOperation) planDeregister(ctx context.Context, derOpts *deregister.Options) (*txnStep, error) {
+	ep, err := e.Get(ctx)
+	if err != nil {
+		if srerr.IsNotFound(err) && !derOpts.FailNotExists {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	setAuditBefore(ctx, ep)
+
+	return &txnStep{
+		step: ops.TxnStep{
+			Action:    ops.TxnDelete,
+			Namespace: e.parent.parent.name,
+			Service:   e.parent.name,
+			Endpoint:  e.name,
+			Revision:  ep.Revision,
+		},
+		pathName: e.pathName,
+		apply:    e.op.Delete,
+		revert: func(ctx context.Context) error {
+			_, err := e.op.Create(ctx, ep.Address, ep.Port, ep.Metadata)
+			return err
+		},
+	}, nil
+}
+
 func (e *EndpointOperation) checkNames() error {
 	if e.name == "" {
 		return srerr.EmptyEndpointName
@@ -241,7 +346,7 @@ func (e *EndpointOperation) List(opts ...list.Option) *EndpointsIterator {
 		}
 	}
 
-	endpIterator.iterator = e.op.List(listOpts)
+	endpIterator.iterator = sortEndpoints(e.op.List(listOpts), listOpts)
 	return endpIterator
 }
 
@@ -284,3 +389,19 @@ func (ei *EndpointsIterator) Next(ctx context.Context) (*types.Endpoint, *Endpoi
 
 	return ep, epOp, nil
 }
+
+// NextPageToken returns a token that you can provide to list.WithPageToken
+// to resume listing endpoints right after the last one returned by Next, e.g. in
+// another request. It returns an empty string if there are no more endpoints to
+// list, or if the service registry does not support page tokens.
+//
+// Tokens are not available when endpoints are sorted by the API rather than the
+// service registry, as all of them are retrieved before returning the first
+// one: check out list.WithOrderBy for more details.
+func (ei *EndpointsIterator) NextPageToken() string {
+	if ei.iterator == nil {
+		return ""
+	}
+
+	return ops.NextPageToken(ei.iterator)
+}
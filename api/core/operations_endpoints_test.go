@@ -110,7 +110,7 @@ var _ = Describe("Endpoint operations", func() {
 
 				res, err := eop.Get(ctx)
 				Expect(res).To(BeNil())
-				Expect(err).To(Equal(expectedError))
+				Expect(err).To(MatchError(expectedError))
 			})
 		})
 
@@ -238,7 +238,7 @@ var _ = Describe("Endpoint operations", func() {
 					fop.Create_ = func(_ context.Context, address string, port int32, m map[string]string) (*coretypes.Endpoint, error) {
 						return nil, permD
 					}
-					Expect(eop.Register(ctx)).To(Equal(permD))
+					Expect(eop.Register(ctx)).To(MatchError(permD))
 				})
 
 				By("... or reforwarding from Update", func() {
@@ -246,10 +246,10 @@ var _ = Describe("Endpoint operations", func() {
 					fop.Get_ = func(_ context.Context, _ *get.Options) (*coretypes.Endpoint, error) {
 						return &coretypes.Endpoint{}, nil
 					}
-					fop.Update_ = func(_ context.Context, _ string, _ int32, _ map[string]string) (*coretypes.Endpoint, error) {
+					fop.Update_ = func(_ context.Context, _ string, _ int32, _ map[string]string, _ string) (*coretypes.Endpoint, error) {
 						return nil, permD
 					}
-					Expect(eop.Register(ctx)).To(Equal(permD))
+					Expect(eop.Register(ctx)).To(MatchError(permD))
 				})
 			})
 		})
@@ -412,7 +412,7 @@ var _ = Describe("Endpoint operations", func() {
 				metadata := map[string]string{}
 				address := ""
 				port := int32(0)
-				fop.Update_ = func(_ context.Context, a string, p int32, m map[string]string) (*coretypes.Endpoint, error) {
+				fop.Update_ = func(_ context.Context, a string, p int32, m map[string]string, _ string) (*coretypes.Endpoint, error) {
 					metadata = m
 					address = a
 					port = p
@@ -482,7 +482,7 @@ var _ = Describe("Endpoint operations", func() {
 				metadata := map[string]string{"key": "to-be-reset"}
 				address := "to-be-reset"
 				port := int32(9000)
-				fop.Update_ = func(_ context.Context, a string, p int32, m map[string]string) (*coretypes.Endpoint, error) {
+				fop.Update_ = func(_ context.Context, a string, p int32, m map[string]string, _ string) (*coretypes.Endpoint, error) {
 					metadata = m
 					address = a
 					port = p
@@ -524,7 +524,7 @@ var _ = Describe("Endpoint operations", func() {
 						return nil, srerr.EndpointNotFound
 					}
 					timesCalled := 0
-					fop.Update_ = func(_ context.Context, _ string, _ int32, _ map[string]string) (*coretypes.Endpoint, error) {
+					fop.Update_ = func(_ context.Context, _ string, _ int32, _ map[string]string, _ string) (*coretypes.Endpoint, error) {
 						timesCalled++
 						return nil, fmt.Errorf("another error")
 					}
@@ -551,7 +551,7 @@ var _ = Describe("Endpoint operations", func() {
 						}, nil
 					}
 					timesCalled := 0
-					fop.Update_ = func(_ context.Context, _ string, _ int32, _ map[string]string) (*coretypes.Endpoint, error) {
+					fop.Update_ = func(_ context.Context, _ string, _ int32, _ map[string]string, _ string) (*coretypes.Endpoint, error) {
 						timesCalled++
 						return nil, fmt.Errorf("another error")
 					}
@@ -591,7 +591,7 @@ var _ = Describe("Endpoint operations", func() {
 					}
 
 					err := eop.Deregister(ctx)
-					Expect(err).To(Equal(expErr))
+					Expect(err).To(MatchError(expErr))
 				})
 			})
 			Context("when the error is not found", func() {
@@ -692,7 +692,7 @@ var _ = Describe("Endpoint operations", func() {
 				endp, resOp, err := lister.Next(ctx)
 				Expect(resOp).To(BeNil())
 				Expect(endp).To(BeNil())
-				Expect(err).To(Equal(expErr))
+				Expect(err).To(MatchError(expErr))
 			})
 		})
 
@@ -23,11 +23,14 @@ import (
 
 	"github.com/CloudNativeSDWAN/serego/api/core/types"
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/logging"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
 	"github.com/CloudNativeSDWAN/serego/api/options/deregister"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
 	"github.com/CloudNativeSDWAN/serego/api/options/register"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
 )
 
 // NamespaceOperation contains data and code that will be used to perform
@@ -60,7 +63,10 @@ func (s *ServiceRegistry) Namespace(name string) *NamespaceOperation {
 //
 // Unless cache is disabled, it will first check its cache and later the
 // service registry if not found there.
-func (n *NamespaceOperation) Get(ctx context.Context, opts ...get.Option) (*types.Namespace, error) {
+func (n *NamespaceOperation) Get(ctx context.Context, opts ...get.Option) (ns *types.Namespace, err error) {
+	ctx, span := n.root.startSpan(ctx, "Namespace.Get", n.attributes()...)
+	defer func() { tracing.End(span, err) }()
+
 	if err := n.checkName(); err != nil {
 		return nil, err
 	}
@@ -72,20 +78,16 @@ func (n *NamespaceOperation) Get(ctx context.Context, opts ...get.Option) (*type
 		}
 	}
 
-	ns, err := n.op.Get(ctx, getOptions)
-	if err != nil {
-		return nil, err
-	}
-
-	return ns, nil
+	return n.op.Get(ctx, getOptions)
 }
 
 // Register will insert - or update, if already exists - the namespace with the
 // provided options on the service registry.
-func (n *NamespaceOperation) Register(ctx context.Context, opts ...register.Option) error {
-	if err := n.checkName(); err != nil {
-		return err
-	}
+func (n *NamespaceOperation) Register(ctx context.Context, opts ...register.Option) (err error) {
+	ctx, span := n.root.startSpan(ctx, "Namespace.Register", n.attributes()...)
+	defer func() { tracing.End(span, err) }()
+	ctx, entry := n.root.startAudit(ctx, wrapper.RegisterAction, wrapper.NamespaceObject, n.pathName)
+	defer func() { n.root.audit(entry, err) }()
 
 	regOpts := &register.Options{}
 	for _, opt := range opts {
@@ -94,27 +96,92 @@ func (n *NamespaceOperation) Register(ctx context.Context, opts ...register.Opti
 		}
 	}
 
-	ns, err := n.Get(ctx)
+	step, err := n.planRegister(ctx, regOpts)
+	if err != nil || step == nil {
+		return err
+	}
+
+	return step.apply(ctx)
+}
+
+// planRegister checks the name and the options and returns the step to
+// register the namespace, both for Register and for transactions.
+func (n *NamespaceOperation) planRegister(ctx context.Context, regOpts *register.Options) (*txnStep, error) {
+	if err := n.checkName(); err != nil {
+		return nil, err
+	}
+
+	if len(regOpts.Metadata) > 0 && !n.root.Capabilities().NamespaceMetadata {
+		return nil, srerr.MetadataNotSupported
+	}
+
+	// Check the metadata provided before calling the service registry, and
+	// later the ones that will be actually registered.
+	limits := n.root.Capabilities().NamespaceMetadataLimits
+	if err := limits.check(regOpts.Metadata); err != nil {
+		return nil, err
+	}
+
+	getOpts := []get.Option{}
+	if regOpts.ExpectedRevision != "" {
+		// Cache may be outdated and we need the actual revision.
+		getOpts = append(getOpts, get.WithForceRefresh())
+	}
+
+	ns, err := n.Get(ctx, getOpts...)
 	registerMode, newMetadata, err := prepareRegisterOperation(regOpts, ns, err)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if err := limits.check(newMetadata); err != nil {
+		return nil, err
+	}
+
+	setRegisterMode(ctx, registerMode)
+	setAuditRegister(ctx, registerMode, ns, &types.Namespace{Name: n.name, Metadata: newMetadata})
+	n.root.log().V(logging.Debug).Info("registering namespace",
+		append(n.keysAndValues(), "mode", registerModeName(registerMode))...)
+
+	step := &txnStep{
+		step: ops.TxnStep{
+			Namespace: n.name,
+			Metadata:  newMetadata,
+		},
+		pathName: n.pathName,
 	}
 
 	if registerMode == register.CreateMode {
-		_, err = n.op.Create(ctx, newMetadata)
-	} else {
-		nsToUpdate := &types.Namespace{Name: n.name, Metadata: newMetadata}
-		if ns.DeepEqualTo(nsToUpdate) {
-			// Avoid update if nothing is changed.
-			// Note that if cache is enabled, the previous .Get() operation
-			// already cached the result, so we can safely return here.
-			return nil
+		step.step.Action = ops.TxnCreate
+		step.apply = func(ctx context.Context) error {
+			_, err := n.op.Create(ctx, newMetadata)
+			return err
 		}
+		step.revert = n.op.Delete
+		return step, nil
+	}
+
+	nsToUpdate := &types.Namespace{Name: n.name, Metadata: newMetadata}
+	if ns.DeepEqualTo(nsToUpdate) {
+		// Avoid update if nothing is changed.
+		// Note that if cache is enabled, the previous .Get() operation
+		// already cached the result, so we can safely return here.
+		n.root.log().V(logging.Debug).Info("namespace did not change, not updating it", n.keysAndValues()...)
+		return nil, nil
+	}
 
-		_, err = n.op.Update(ctx, newMetadata)
+	step.step.Action = ops.TxnUpdate
+	step.step.Revision = ns.Revision
+	step.apply = func(ctx context.Context) error {
+		_, err := n.op.Update(ctx, newMetadata, regOpts.ExpectedRevision)
+		return err
+	}
+	step.revert = func(ctx context.Context) error {
+		_, err := n.op.Update(ctx, ns.Metadata, "")
+		return err
 	}
 
-	return err
+	return step, nil
 }
 
 // Deregister removes the namespace from the service registry and from the
@@ -122,7 +189,12 @@ func (n *NamespaceOperation) Register(ctx context.Context, opts ...register.Opti
 //
 // By default, it will not return an error if the namespace does not exist.
 // Please read the Deregister operations section to learn more.
-func (n *NamespaceOperation) Deregister(ctx context.Context, opts ...deregister.Option) error {
+func (n *NamespaceOperation) Deregister(ctx context.Context, opts ...deregister.Option) (err error) {
+	ctx, span := n.root.startSpan(ctx, "Namespace.Deregister", n.attributes()...)
+	defer func() { tracing.End(span, err) }()
+	ctx, entry := n.root.startAudit(ctx, wrapper.DeregisterAction, wrapper.NamespaceObject, n.pathName)
+	defer func() { n.root.audit(entry, err) }()
+
 	if err := n.checkName(); err != nil {
 		return err
 	}
@@ -136,6 +208,7 @@ func (n *NamespaceOperation) Deregister(ctx context.Context, opts ...deregister.
 
 	if err := n.op.Delete(ctx); err != nil {
 		if srerr.IsNotFound(err) && !derOpts.FailNotExists {
+			n.root.log().V(logging.Debug).Info("namespace does not exist, nothing to deregister", n.keysAndValues()...)
 			return nil
 		}
 
@@ -145,6 +218,33 @@ func (n *NamespaceOperation) Deregister(ctx context.Context, opts ...deregister.
 	return nil
 }
 
+func (n *NamespaceOperation) planDeregister(ctx context.Context, derOpts *deregister.Options) (*txnStep, error) {
+	ns, err := n.Get(ctx)
+	if err != nil {
+		if srerr.IsNotFound(err) && !derOpts.FailNotExists {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	setAuditBefore(ctx, ns)
+
+	return &txnStep{
+		step: ops.TxnStep{
+			Action:    ops.TxnDelete,
+			Namespace: n.name,
+			Revision:  ns.Revision,
+		},
+		pathName: n.pathName,
+		apply:    n.op.Delete,
+		revert: func(ctx context.Context) error {
+			_, err := n.op.Create(ctx, ns.Metadata)
+			return err
+		},
+	}, nil
+}
+
 // List returns an iterator that will get a list of namespaces according to the
 // options provided.
 //
@@ -171,7 +271,7 @@ func (n *NamespaceOperation) List(opts ...list.Option) *NamespacesIterator {
 
 	var iterator ops.NamespaceLister
 	if err == nil {
-		iterator = n.op.List(listOpts)
+		iterator = sortNamespaces(n.op.List(listOpts), listOpts)
 	}
 
 	return &NamespacesIterator{
@@ -227,3 +327,19 @@ func (ni *NamespacesIterator) Next(ctx context.Context) (*types.Namespace, *Name
 
 	return ns, nsOp, nil
 }
+
+// NextPageToken returns a token that you can provide to list.WithPageToken
+// to resume listing namespaces right after the last one returned by Next, e.g. in
+// another request. It returns an empty string if there are no more namespaces to
+// list, or if the service registry does not support page tokens.
+//
+// Tokens are not available when namespaces are sorted by the API rather than the
+// service registry, as all of them are retrieved before returning the first
+// one: check out list.WithOrderBy for more details.
+func (ni *NamespacesIterator) NextPageToken() string {
+	if ni.iterator == nil {
+		return ""
+	}
+
+	return ops.NextPageToken(ni.iterator)
+}
@@ -25,6 +25,7 @@ import (
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/pagetoken"
 	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/fake"
 	"github.com/CloudNativeSDWAN/serego/api/options/deregister"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
@@ -78,7 +79,7 @@ var _ = Describe("Namespace Operations", func() {
 
 				res, err := nsop.Get(ctx)
 				Expect(res).To(BeNil())
-				Expect(err).To(Equal(expectedError))
+				Expect(err).To(MatchError(expectedError))
 			})
 		})
 
@@ -148,7 +149,7 @@ var _ = Describe("Namespace Operations", func() {
 					fop.Create_ = func(_ context.Context, _ map[string]string) (*coretypes.Namespace, error) {
 						return nil, permD
 					}
-					Expect(nsop.Register(ctx)).To(Equal(permD))
+					Expect(nsop.Register(ctx)).To(MatchError(permD))
 				})
 
 				By("... or reforwarding from Update", func() {
@@ -156,10 +157,10 @@ var _ = Describe("Namespace Operations", func() {
 					fop.Get_ = func(_ context.Context, _ *get.Options) (*coretypes.Namespace, error) {
 						return &coretypes.Namespace{}, nil
 					}
-					fop.Update_ = func(_ context.Context, _ map[string]string) (*coretypes.Namespace, error) {
+					fop.Update_ = func(_ context.Context, _ map[string]string, _ string) (*coretypes.Namespace, error) {
 						return nil, permD
 					}
-					Expect(nsop.Register(ctx)).To(Equal(permD))
+					Expect(nsop.Register(ctx)).To(MatchError(permD))
 				})
 			})
 		})
@@ -246,7 +247,7 @@ var _ = Describe("Namespace Operations", func() {
 					}, nil
 				}
 				metadata := map[string]string{}
-				fop.Update_ = func(_ context.Context, m map[string]string) (*coretypes.Namespace, error) {
+				fop.Update_ = func(_ context.Context, m map[string]string, _ string) (*coretypes.Namespace, error) {
 					metadata = m
 					return nil, nil
 				}
@@ -298,7 +299,7 @@ var _ = Describe("Namespace Operations", func() {
 						return nil, srerr.NamespaceNotFound
 					}
 					timesCalled := 0
-					fop.Update_ = func(_ context.Context, _ map[string]string) (*coretypes.Namespace, error) {
+					fop.Update_ = func(_ context.Context, _ map[string]string, _ string) (*coretypes.Namespace, error) {
 						timesCalled++
 						return nil, fmt.Errorf("another error")
 					}
@@ -321,7 +322,7 @@ var _ = Describe("Namespace Operations", func() {
 						}, nil
 					}
 					timesCalled := 0
-					fop.Update_ = func(_ context.Context, _ map[string]string) (*coretypes.Namespace, error) {
+					fop.Update_ = func(_ context.Context, _ map[string]string, _ string) (*coretypes.Namespace, error) {
 						timesCalled++
 						return nil, fmt.Errorf("another error")
 					}
@@ -329,6 +330,51 @@ var _ = Describe("Namespace Operations", func() {
 					Expect(timesCalled).To(BeZero())
 				})
 			})
+
+			Context("with an expected revision", func() {
+				It("checks the revision against the current one", func() {
+					forceRefresh := false
+					fop.Get_ = func(_ context.Context, g *get.Options) (*coretypes.Namespace, error) {
+						forceRefresh = g.ForceRefresh
+						return &coretypes.Namespace{
+							Name:     nsName,
+							Metadata: map[string]string{"key-1": "val-1"},
+							Revision: "2",
+						}, nil
+					}
+					expectedRevision := ""
+					fop.Update_ = func(_ context.Context, _ map[string]string, rev string) (*coretypes.Namespace, error) {
+						expectedRevision = rev
+						return nil, nil
+					}
+
+					By("returning a conflict if they differ", func() {
+						err := nsop.Register(ctx,
+							register.WithKV("key-2", "val-2"),
+							register.WithExpectedRevision("1"))
+						Expect(err).To(MatchError(srerr.Conflict))
+						Expect(srerr.IsConflict(err)).To(BeTrue())
+						Expect(forceRefresh).To(BeTrue())
+						Expect(expectedRevision).To(BeEmpty())
+					})
+
+					By("passing it to the service registry if they are equal", func() {
+						err := nsop.Register(ctx,
+							register.WithKV("key-2", "val-2"),
+							register.WithExpectedRevision("2"))
+						Expect(err).NotTo(HaveOccurred())
+						Expect(expectedRevision).To(Equal("2"))
+					})
+
+					By("returning a conflict if it was deleted", func() {
+						fop.Get_ = func(_ context.Context, _ *get.Options) (*coretypes.Namespace, error) {
+							return nil, srerr.NamespaceNotFound
+						}
+						err := nsop.Register(ctx, register.WithExpectedRevision("2"))
+						Expect(err).To(MatchError(srerr.Conflict))
+					})
+				})
+			})
 		})
 	})
 
@@ -350,7 +396,7 @@ var _ = Describe("Namespace Operations", func() {
 					}
 
 					err := nsop.Deregister(ctx)
-					Expect(err).To(Equal(expErr))
+					Expect(err).To(MatchError(expErr))
 				})
 			})
 			Context("when the error is not found", func() {
@@ -417,10 +463,30 @@ var _ = Describe("Namespace Operations", func() {
 				ns, op, err := lister.Next(ctx)
 				Expect(ns).To(BeNil())
 				Expect(op).To(BeNil())
-				Expect(err).To(Equal(expErr))
+				Expect(err).To(MatchError(expErr))
 			})
 		})
 
+		It("returns the token to resume listing", func() {
+			var listOpts *list.Options
+			fop.List_ = func(opts *list.Options) ops.NamespaceLister {
+				listOpts = opts
+				return &fake.FakeNamespaceIterator{
+					NextPageToken_: func() string {
+						return "next-page"
+					},
+				}
+			}
+
+			token := pagetoken.Encode(pagetoken.Token{Page: "page"})
+			lister := sr.Namespace("").List(list.WithPageToken(token))
+			Expect(lister.NextPageToken()).To(Equal("next-page"))
+			Expect(listOpts.PageToken).To(Equal(token))
+
+			By("checking that tokens are not available on uninitialized iterators")
+			Expect((&core.NamespacesIterator{}).NextPageToken()).To(BeEmpty())
+		})
+
 		It("returns the next element", func() {
 			elems := []*coretypes.Namespace{}
 			elemOps := []*fake.NamespaceOperation{}
@@ -23,11 +23,14 @@ import (
 
 	"github.com/CloudNativeSDWAN/serego/api/core/types"
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/logging"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
 	"github.com/CloudNativeSDWAN/serego/api/options/deregister"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
 	"github.com/CloudNativeSDWAN/serego/api/options/register"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
 )
 
 // ServiceOperation contains data and code that will be used to perform
@@ -61,7 +64,10 @@ func (n *NamespaceOperation) Service(name string) *ServiceOperation {
 //
 // Unless cache is disabled, it will first check its cache and later the
 // service registry if not found there.
-func (s *ServiceOperation) Get(ctx context.Context, opts ...get.Option) (*types.Service, error) {
+func (s *ServiceOperation) Get(ctx context.Context, opts ...get.Option) (serv *types.Service, err error) {
+	ctx, span := s.root.startSpan(ctx, "Service.Get", s.attributes()...)
+	defer func() { tracing.End(span, err) }()
+
 	if err := s.checkNames(); err != nil {
 		return nil, err
 	}
@@ -73,20 +79,16 @@ func (s *ServiceOperation) Get(ctx context.Context, opts ...get.Option) (*types.
 		}
 	}
 
-	serv, err := s.op.Get(ctx, getOptions)
-	if err != nil {
-		return nil, err
-	}
-
-	return serv, nil
+	return s.op.Get(ctx, getOptions)
 }
 
 // Register will insert - or update, if already exists - the service with the
 // provided options on the service registry.
-func (s *ServiceOperation) Register(ctx context.Context, opts ...register.Option) error {
-	if err := s.checkNames(); err != nil {
-		return err
-	}
+func (s *ServiceOperation) Register(ctx context.Context, opts ...register.Option) (err error) {
+	ctx, span := s.root.startSpan(ctx, "Service.Register", s.attributes()...)
+	defer func() { tracing.End(span, err) }()
+	ctx, entry := s.root.startAudit(ctx, wrapper.RegisterAction, wrapper.ServiceObject, s.pathName)
+	defer func() { s.root.audit(entry, err) }()
 
 	regOpts := &register.Options{}
 	for _, opt := range opts {
@@ -95,24 +97,84 @@ func (s *ServiceOperation) Register(ctx context.Context, opts ...register.Option
 		}
 	}
 
-	serv, err := s.Get(ctx)
+	step, err := s.planRegister(ctx, regOpts)
+	if err != nil || step == nil {
+		return err
+	}
+
+	return step.apply(ctx)
+}
+
+func (s *ServiceOperation) planRegister(ctx context.Context, regOpts *register.Options) (*txnStep, error) {
+	if err := s.checkNames(); err != nil {
+		return nil, err
+	}
+
+	// Check the metadata provided before calling the service registry, and
+	// later the ones that will be actually registered.
+	limits := s.root.Capabilities().ServiceMetadataLimits
+	if err := limits.check(regOpts.Metadata); err != nil {
+		return nil, err
+	}
+
+	getOpts := []get.Option{}
+	if regOpts.ExpectedRevision != "" {
+		// Cache may be outdated and we need the actual revision.
+		getOpts = append(getOpts, get.WithForceRefresh())
+	}
+
+	serv, err := s.Get(ctx, getOpts...)
 	registerMode, newMetadata, err := prepareRegisterOperation(regOpts, serv, err)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if err := limits.check(newMetadata); err != nil {
+		return nil, err
+	}
+
+	setRegisterMode(ctx, registerMode)
+	setAuditRegister(ctx, registerMode, serv, &types.Service{Name: s.name, Namespace: s.parent.name, Metadata: newMetadata})
+	s.root.log().V(logging.Debug).Info("registering service",
+		append(s.keysAndValues(), "mode", registerModeName(registerMode))...)
+
+	step := &txnStep{
+		step: ops.TxnStep{
+			Namespace: s.parent.name,
+			Service:   s.name,
+			Metadata:  newMetadata,
+		},
+		pathName: s.pathName,
 	}
 
 	if registerMode == register.CreateMode {
-		_, err = s.op.Create(ctx, newMetadata)
-	} else {
-		servToCreate := &types.Service{Name: s.name, Namespace: s.parent.name, Metadata: newMetadata}
-		if serv.DeepEqualTo(servToCreate) {
-			return nil
+		step.step.Action = ops.TxnCreate
+		step.apply = func(ctx context.Context) error {
+			_, err := s.op.Create(ctx, newMetadata)
+			return err
 		}
+		step.revert = s.op.Delete
+		return step, nil
+	}
+
+	servToCreate := &types.Service{Name: s.name, Namespace: s.parent.name, Metadata: newMetadata}
+	if serv.DeepEqualTo(servToCreate) {
+		s.root.log().V(logging.Debug).Info("service did not change, not updating it", s.keysAndValues()...)
+		return nil, nil
+	}
 
-		_, err = s.op.Update(ctx, newMetadata)
+	step.step.Action = ops.TxnUpdate
+	step.step.Revision = serv.Revision
+	step.apply = func(ctx context.Context) error {
+		_, err := s.op.Update(ctx, newMetadata, regOpts.ExpectedRevision)
+		return err
+	}
+	step.revert = func(ctx context.Context) error {
+		_, err := s.op.Update(ctx, serv.Metadata, "")
+		return err
 	}
 
-	return err
+	return step, nil
 }
 
 // Deregister removes the service from the service registry and from the
@@ -120,7 +182,12 @@ func (s *ServiceOperation) Register(ctx context.Context, opts ...register.Option
 //
 // By default, it will not return an error if the service does not exist.
 // Please read the Deregister operations section to learn more.
-func (s *ServiceOperation) Deregister(ctx context.Context, opts ...deregister.Option) error {
+func (s *ServiceOperation) Deregister(ctx context.Context, opts ...deregister.Option) (err error) {
+	ctx, span := s.root.startSpan(ctx, "Service.Deregister", s.attributes()...)
+	defer func() { tracing.End(span, err) }()
+	ctx, entry := s.root.startAudit(ctx, wrapper.DeregisterAction, wrapper.ServiceObject, s.pathName)
+	defer func() { s.root.audit(entry, err) }()
+
 	if err := s.checkNames(); err != nil {
 		return err
 	}
@@ -134,6 +201,7 @@ func (s *ServiceOperation) Deregister(ctx context.Context, opts ...deregister.Op
 
 	if err := s.op.Delete(ctx); err != nil {
 		if srerr.IsNotFound(err) && !derOpts.FailNotExists {
+			s.root.log().V(logging.Debug).Info("service does not exist, nothing to deregister", s.keysAndValues()...)
 			return nil
 		}
 
@@ -143,6 +211,34 @@ func (s *ServiceOperation) Deregister(ctx context.Context, opts ...deregister.Op
 	return nil
 }
 
+func (s *ServiceOperation) planDeregister(ctx context.Context, derOpts *deregister.Options) (*txnStep, error) {
+	serv, err := s.Get(ctx)
+	if err != nil {
+		if srerr.IsNotFound(err) && !derOpts.FailNotExists {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	setAuditBefore(ctx, serv)
+
+	return &txnStep{
+		step: ops.TxnStep{
+			Action:    ops.TxnDelete,
+			Namespace: s.parent.name,
+			Service:   s.name,
+			Revision:  serv.Revision,
+		},
+		pathName: s.pathName,
+		apply:    s.op.Delete,
+		revert: func(ctx context.Context) error {
+			_, err := s.op.Create(ctx, serv.Metadata)
+			return err
+		},
+	}, nil
+}
+
 func (s *ServiceOperation) checkNames() error {
 	if s.name == "" {
 		return srerr.EmptyServiceName
@@ -189,7 +285,7 @@ func (s *ServiceOperation) List(opts ...list.Option) *ServicesIterator {
 		}
 	}
 
-	servIterator.iterator = s.op.List(listOpts)
+	servIterator.iterator = sortServices(s.op.List(listOpts), listOpts)
 	return servIterator
 }
 
@@ -232,3 +328,19 @@ func (si *ServicesIterator) Next(ctx context.Context) (*types.Service, *ServiceO
 
 	return serv, servOp, nil
 }
+
+// NextPageToken returns a token that you can provide to list.WithPageToken
+// to resume listing services right after the last one returned by Next, e.g. in
+// another request. It returns an empty string if there are no more services to
+// list, or if the service registry does not support page tokens.
+//
+// Tokens are not available when services are sorted by the API rather than the
+// service registry, as all of them are retrieved before returning the first
+// one: check out list.WithOrderBy for more details.
+func (si *ServicesIterator) NextPageToken() string {
+	if si.iterator == nil {
+		return ""
+	}
+
+	return ops.NextPageToken(si.iterator)
+}
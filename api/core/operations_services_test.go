@@ -90,7 +90,7 @@ var _ = Describe("Service Operations", func() {
 
 				res, err := sop.Get(ctx)
 				Expect(res).To(BeNil())
-				Expect(err).To(Equal(expectedError))
+				Expect(err).To(MatchError(expectedError))
 			})
 		})
 
@@ -173,7 +173,7 @@ var _ = Describe("Service Operations", func() {
 					fop.Create_ = func(_ context.Context, _ map[string]string) (*coretypes.Service, error) {
 						return nil, permD
 					}
-					Expect(sop.Register(ctx)).To(Equal(permD))
+					Expect(sop.Register(ctx)).To(MatchError(permD))
 				})
 
 				By("... or reforwarding from Update", func() {
@@ -181,10 +181,10 @@ var _ = Describe("Service Operations", func() {
 					fop.Get_ = func(_ context.Context, _ *get.Options) (*coretypes.Service, error) {
 						return &coretypes.Service{}, nil
 					}
-					fop.Update_ = func(_ context.Context, _ map[string]string) (*coretypes.Service, error) {
+					fop.Update_ = func(_ context.Context, _ map[string]string, _ string) (*coretypes.Service, error) {
 						return nil, permD
 					}
-					Expect(sop.Register(ctx)).To(Equal(permD))
+					Expect(sop.Register(ctx)).To(MatchError(permD))
 				})
 			})
 		})
@@ -271,7 +271,7 @@ var _ = Describe("Service Operations", func() {
 					}, nil
 				}
 				metadata := map[string]string{}
-				fop.Update_ = func(_ context.Context, m map[string]string) (*coretypes.Service, error) {
+				fop.Update_ = func(_ context.Context, m map[string]string, _ string) (*coretypes.Service, error) {
 					metadata = m
 					return nil, nil
 				}
@@ -320,7 +320,7 @@ var _ = Describe("Service Operations", func() {
 						return nil, srerr.ServiceNotFound
 					}
 					timesCalled := 0
-					fop.Update_ = func(_ context.Context, _ map[string]string) (*coretypes.Service, error) {
+					fop.Update_ = func(_ context.Context, _ map[string]string, _ string) (*coretypes.Service, error) {
 						timesCalled++
 						return nil, fmt.Errorf("another error")
 					}
@@ -344,7 +344,7 @@ var _ = Describe("Service Operations", func() {
 						}, nil
 					}
 					timesCalled := 0
-					fop.Update_ = func(_ context.Context, _ map[string]string) (*coretypes.Service, error) {
+					fop.Update_ = func(_ context.Context, _ map[string]string, _ string) (*coretypes.Service, error) {
 						timesCalled++
 						return nil, fmt.Errorf("another error")
 					}
@@ -377,7 +377,7 @@ var _ = Describe("Service Operations", func() {
 					}
 
 					err := sop.Deregister(ctx)
-					Expect(err).To(Equal(expErr))
+					Expect(err).To(MatchError(expErr))
 				})
 			})
 			Context("when the error is not found", func() {
@@ -458,7 +458,7 @@ var _ = Describe("Service Operations", func() {
 				serv, resOp, err := lister.Next(ctx)
 				Expect(resOp).To(BeNil())
 				Expect(serv).To(BeNil())
-				Expect(err).To(Equal(expErr))
+				Expect(err).To(MatchError(expErr))
 			})
 		})
 
@@ -0,0 +1,88 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"context"
+	"fmt"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/options/bulk"
+)
+
+// Prefetch loads the namespace with the provided name along with all of its
+// services and their endpoints, so that they are stored on cache and getting
+// them afterwards does not need any call to the service registry. This is
+// useful, for example, at startup.
+//
+// Endpoints of different services are loaded concurrently, as many at the
+// same time as the concurrency in the bulk options.
+//
+// It returns an error if the namespace or its services could not be loaded,
+// or the first error that occurred while loading endpoints.
+//
+// Example:
+// 	if err := sr.Prefetch(ctx, "sales", bulk.WithConcurrency(5)); err != nil {
+// 		log.Println("could not prefetch namespace:", err)
+// 	}
+func (s *ServiceRegistry) Prefetch(ctx context.Context, namespace string, opts ...bulk.Option) error {
+	bulkOpts, err := parseBulkOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	nsOp := s.Namespace(namespace)
+	if _, err := nsOp.Get(ctx); err != nil {
+		return fmt.Errorf("could not get namespace: %w", err)
+	}
+
+	servOps := []*ServiceOperation{}
+	names := []string{}
+	servIt := nsOp.Service(Any).List()
+	for {
+		serv, servOp, err := servIt.Next(ctx)
+		if err != nil {
+			if srerr.IsIteratorDone(err) {
+				break
+			}
+
+			return fmt.Errorf("could not list services: %w", err)
+		}
+
+		servOps = append(servOps, servOp)
+		names = append(names, serv.Name)
+	}
+
+	report := runBulk(ctx, bulkOpts, names, func(ctx context.Context, i int) BulkResult {
+		endpIt := servOps[i].Endpoint(Any).List()
+		for {
+			if _, _, err := endpIt.Next(ctx); err != nil {
+				if srerr.IsIteratorDone(err) {
+					err = nil
+				}
+
+				return BulkResult{Name: names[i], Err: err}
+			}
+		}
+	})
+	if err := report.Err(); err != nil {
+		return fmt.Errorf("could not list endpoints: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/CloudNativeSDWAN/serego/api/core"
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/fake"
+	"github.com/CloudNativeSDWAN/serego/api/options/bulk"
+	"github.com/CloudNativeSDWAN/serego/api/options/get"
+	"github.com/CloudNativeSDWAN/serego/api/options/list"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prefetch", func() {
+	var (
+		sr      *core.ServiceRegistry
+		nsop    *fake.NamespaceOperation
+		ctx     = context.TODO()
+		lock    sync.Mutex
+		listed  []string
+		failOn  string
+		expErr  = fmt.Errorf("whatever")
+		servErr error
+	)
+
+	newService := func(name string) *fake.ServiceOperation {
+		return &fake.ServiceOperation{
+			Name_: name,
+			Endpoint_: func(string) ops.EndpointOperation {
+				return &fake.EndpointOperation{
+					List_: func(_ *list.Options) ops.EndpointLister {
+						i := 0
+						return &fake.FakeEndpointIterator{
+							Next_: func(_ context.Context) (*coretypes.Endpoint, ops.EndpointOperation, error) {
+								if name == failOn {
+									return nil, nil, expErr
+								}
+
+								if i == 2 {
+									return nil, nil, srerr.IteratorDone
+								}
+
+								i++
+								lock.Lock()
+								defer lock.Unlock()
+								endpName := fmt.Sprintf("%s-endp-%d", name, i)
+								listed = append(listed, endpName)
+								return &coretypes.Endpoint{Name: endpName}, &fake.EndpointOperation{}, nil
+							},
+						}
+					},
+				}
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		listed, failOn, servErr = []string{}, "", nil
+		nsop = &fake.NamespaceOperation{
+			Get_: func(_ context.Context, _ *get.Options) (*coretypes.Namespace, error) {
+				return &coretypes.Namespace{Name: "ns"}, nil
+			},
+			Service_: func(string) ops.ServiceOperation {
+				return &fake.ServiceOperation{
+					List_: func(_ *list.Options) ops.ServiceLister {
+						i := 0
+						return &fake.FakeServiceIterator{
+							Next_: func(_ context.Context) (*coretypes.Service, ops.ServiceOperation, error) {
+								if servErr != nil {
+									return nil, nil, servErr
+								}
+
+								if i == 3 {
+									return nil, nil, srerr.IteratorDone
+								}
+
+								i++
+								name := fmt.Sprintf("serv-%d", i)
+								return &coretypes.Service{Name: name, Namespace: "ns"}, newService(name), nil
+							},
+						}
+					},
+				}
+			},
+		}
+		wrp, _ := fake.NewFakeWrapper()
+		wrp.Namespace_ = func(string) ops.NamespaceOperation {
+			return nsop
+		}
+		sr, _ = core.NewServiceRegistryFromWrapper(wrp)
+	})
+
+	It("lists all services and endpoints of the namespace", func() {
+		Expect(sr.Prefetch(ctx, "ns", bulk.WithConcurrency(2))).To(Succeed())
+		Expect(listed).To(ConsistOf(
+			"serv-1-endp-1", "serv-1-endp-2",
+			"serv-2-endp-1", "serv-2-endp-2",
+			"serv-3-endp-1", "serv-3-endp-2",
+		))
+	})
+
+	Context("in case of errors", func() {
+		It("returns the error", func() {
+			By("getting the namespace", func() {
+				nsop.Get_ = func(_ context.Context, _ *get.Options) (*coretypes.Namespace, error) {
+					return nil, srerr.NamespaceNotFound
+				}
+				Expect(sr.Prefetch(ctx, "ns")).To(MatchError(srerr.NamespaceNotFound))
+			})
+
+			By("listing services", func() {
+				nsop.Get_ = func(_ context.Context, _ *get.Options) (*coretypes.Namespace, error) {
+					return &coretypes.Namespace{Name: "ns"}, nil
+				}
+				servErr = expErr
+				Expect(sr.Prefetch(ctx, "ns")).To(MatchError(expErr))
+			})
+
+			By("listing endpoints", func() {
+				servErr, failOn = nil, "serv-2"
+				Expect(sr.Prefetch(ctx, "ns")).To(MatchError(expErr))
+			})
+
+			By("checking the options", func() {
+				Expect(sr.Prefetch(ctx, "ns", bulk.WithConcurrency(0))).
+					To(MatchError(srerr.InvalidConcurrency))
+			})
+		})
+	})
+})
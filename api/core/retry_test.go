@@ -0,0 +1,82 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core_test
+
+import (
+	"context"
+
+	"github.com/CloudNativeSDWAN/serego/api/core"
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/fake"
+	"github.com/CloudNativeSDWAN/serego/api/options/get"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("Retry policy", func() {
+	var (
+		wrp      *fake.FakeWrapper
+		attempts int
+	)
+
+	BeforeEach(func() {
+		attempts = 0
+		nsop := &fake.NamespaceOperation{
+			Get_: func(_ context.Context, _ *get.Options) (*coretypes.Namespace, error) {
+				attempts++
+				if attempts < 3 {
+					return nil, status.Error(codes.Unavailable, "unavailable")
+				}
+
+				return &coretypes.Namespace{Name: "ns"}, nil
+			},
+		}
+		wrp, _ = fake.NewFakeWrapper()
+		wrp.Namespace_ = func(string) ops.NamespaceOperation {
+			return nsop
+		}
+	})
+
+	It("retries operations failed with transient errors", func() {
+		sr, err := core.NewServiceRegistryFromWrapper(wrp, wrapper.WithRetryPolicy(wrapper.RetryPolicy{MaxAttempts: 3}))
+		Expect(err).NotTo(HaveOccurred())
+
+		ns, err := sr.Namespace("ns").Get(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ns.Name).To(Equal("ns"))
+		Expect(attempts).To(Equal(3))
+	})
+
+	It("does not retry if no policy is provided", func() {
+		sr, _ := core.NewServiceRegistryFromWrapper(wrp)
+
+		_, err := sr.Namespace("ns").Get(context.TODO())
+		Expect(srerr.IsTransient(err)).To(BeTrue())
+		Expect(attempts).To(Equal(1))
+	})
+
+	It("returns an error if the policy is not valid", func() {
+		_, err := core.NewServiceRegistryFromWrapper(wrp, wrapper.WithRetryPolicy(wrapper.RetryPolicy{}))
+		Expect(err).To(MatchError(srerr.InvalidRetryPolicy))
+	})
+})
@@ -18,9 +18,17 @@
 package core
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/CloudNativeSDWAN/serego/api/internal/interceptor"
+	"github.com/CloudNativeSDWAN/serego/api/internal/logging"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -50,5 +58,65 @@ const (
 // In order to work, it must be initialized through wrappers and cannot be used
 // directly: use the NewWrapper functions to do so.
 type ServiceRegistry struct {
-	wrapper ops.ServiceRegistryWrapper
+	wrapper      ops.ServiceRegistryWrapper
+	tracer       trace.Tracer
+	logger       logr.Logger
+	backend      string
+	auditSink    wrapper.AuditSink
+	capabilities Capabilities
+}
+
+// newServiceRegistry returns a ServiceRegistry that performs all calls to the
+// provided wrapper through the interceptors provided by the user first, and
+// then through the ones defined by the other options, e.g. to retry the calls
+// that failed. The backend is used to label spans, logs and metrics, if they
+// are enabled.
+func newServiceRegistry(w ops.ServiceRegistryWrapper, wopts *wrapper.Options, backend string) (*ServiceRegistry, error) {
+	m, err := metrics.New(wopts, backend)
+	if err != nil {
+		return nil, fmt.Errorf("could not register metrics: %w", err)
+	}
+
+	// Interceptors provided by the user come first, so that they see each
+	// call only once regardless of retries, and errors already wrapped.
+	interceptors := append([]interceptor.Interceptor{}, wopts.Interceptors...)
+	interceptors = append(interceptors, interceptor.WrapErrors(backend))
+	if wopts.RetryPolicy != nil {
+		interceptors = append(interceptors, interceptor.Retry(wopts.RetryPolicy))
+	}
+
+	// Trace, log and measure each attempt separately.
+	tracer, logger := tracing.New(wopts), logging.New(wopts)
+	interceptors = append(interceptors,
+		interceptor.Trace(tracer, backend),
+		interceptor.Log(logger.WithValues("backend", backend)))
+	if m != nil {
+		// Wrappers of the API record gets themselves, not to count the ones
+		// served from cache as performed on the service registry.
+		var skip []interceptor.Operation
+		if backend != metrics.Custom {
+			skip = append(skip, interceptor.Get)
+		}
+
+		interceptors = append(interceptors, interceptor.Measure(m, skip...))
+	}
+
+	return &ServiceRegistry{
+		wrapper:      interceptor.Wrap(w, interceptors...),
+		tracer:       tracer,
+		logger:       logger,
+		backend:      backend,
+		auditSink:    wopts.AuditSink,
+		capabilities: capabilitiesOf(backend, w),
+	}, nil
+}
+
+// log returns the logger of the service registry, which discards all
+// messages if it was not initialized through the wrapper functions.
+func (s *ServiceRegistry) log() logr.Logger {
+	if s == nil || s.logger.GetSink() == nil {
+		return logr.Discard()
+	}
+
+	return s.logger
 }
@@ -0,0 +1,167 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"context"
+	"sort"
+
+	"github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/options/list"
+)
+
+// sortedItem is an object retrieved by a lister, along with its operation.
+type sortedItem struct {
+	object interface{}
+	op     interface{}
+}
+
+// sortedLister retrieves all objects from a lister the first time it is
+// called, and then returns them sorted according to the list options.
+//
+// This is only used when the service registry can't sort objects itself, in
+// which case wrappers leave the OrderBy options untouched.
+type sortedLister struct {
+	next    func(ctx context.Context) (interface{}, interface{}, error)
+	options *list.Options
+	items   []sortedItem
+	loaded  bool
+	err     error
+}
+
+func (s *sortedLister) nextItem(ctx context.Context) (*sortedItem, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	if !s.loaded {
+		for {
+			object, op, err := s.next(ctx)
+			if err != nil {
+				if srerr.IsIteratorDone(err) {
+					break
+				}
+
+				// The lister may not be able to resume after an error.
+				s.err = err
+				return nil, err
+			}
+
+			s.items = append(s.items, sortedItem{object: object, op: op})
+		}
+
+		sort.SliceStable(s.items, func(i, j int) bool {
+			return s.options.Less(s.items[i].object, s.items[j].object)
+		})
+		s.loaded = true
+	}
+
+	if len(s.items) == 0 {
+		return nil, srerr.IteratorDone
+	}
+
+	item := s.items[0]
+	s.items = s.items[1:]
+	return &item, nil
+}
+
+type sortedNamespaceLister struct {
+	sortedLister
+}
+
+// sortNamespaces returns a lister that returns the namespaces sorted, if any
+// OrderBy option is left to be applied.
+func sortNamespaces(lister ops.NamespaceLister, options *list.Options) ops.NamespaceLister {
+	if len(options.OrderBy) == 0 {
+		return lister
+	}
+
+	return &sortedNamespaceLister{sortedLister{
+		next: func(ctx context.Context) (interface{}, interface{}, error) {
+			return lister.Next(ctx)
+		},
+		options: options,
+	}}
+}
+
+func (s *sortedNamespaceLister) Next(ctx context.Context) (*types.Namespace, ops.NamespaceOperation, error) {
+	item, err := s.nextItem(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return item.object.(*types.Namespace), item.op.(ops.NamespaceOperation), nil
+}
+
+type sortedServiceLister struct {
+	sortedLister
+}
+
+// sortServices returns a lister that returns the services sorted, if any
+// OrderBy option is left to be applied.
+func sortServices(lister ops.ServiceLister, options *list.Options) ops.ServiceLister {
+	if len(options.OrderBy) == 0 {
+		return lister
+	}
+
+	return &sortedServiceLister{sortedLister{
+		next: func(ctx context.Context) (interface{}, interface{}, error) {
+			return lister.Next(ctx)
+		},
+		options: options,
+	}}
+}
+
+func (s *sortedServiceLister) Next(ctx context.Context) (*types.Service, ops.ServiceOperation, error) {
+	item, err := s.nextItem(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return item.object.(*types.Service), item.op.(ops.ServiceOperation), nil
+}
+
+type sortedEndpointLister struct {
+	sortedLister
+}
+
+// sortEndpoints returns a lister that returns the endpoints sorted, if any
+// OrderBy option is left to be applied.
+func sortEndpoints(lister ops.EndpointLister, options *list.Options) ops.EndpointLister {
+	if len(options.OrderBy) == 0 {
+		return lister
+	}
+
+	return &sortedEndpointLister{sortedLister{
+		next: func(ctx context.Context) (interface{}, interface{}, error) {
+			return lister.Next(ctx)
+		},
+		options: options,
+	}}
+}
+
+func (s *sortedEndpointLister) Next(ctx context.Context) (*types.Endpoint, ops.EndpointOperation, error) {
+	item, err := s.nextItem(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return item.object.(*types.Endpoint), item.op.(ops.EndpointOperation), nil
+}
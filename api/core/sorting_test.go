@@ -0,0 +1,159 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CloudNativeSDWAN/serego/api/core"
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/fake"
+	"github.com/CloudNativeSDWAN/serego/api/options/list"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sorting", func() {
+	var (
+		sr      *core.ServiceRegistry
+		ctx     = context.TODO()
+		listed  []*coretypes.Endpoint
+		listErr error
+		sorted  bool
+	)
+
+	BeforeEach(func() {
+		listErr = nil
+		sorted = false
+		listed = []*coretypes.Endpoint{
+			{Name: "c", Address: "10.0.0.10", Port: 80, Metadata: map[string]string{"zone": "eu"}},
+			{Name: "a", Address: "10.0.0.9", Port: 8080, Metadata: map[string]string{"zone": "us"}},
+			{Name: "b", Address: "10.0.0.11", Port: 80, Metadata: map[string]string{"zone": "us"}},
+		}
+
+		wrp, _ := fake.NewFakeWrapper()
+		wrp.Namespace_ = func(string) ops.NamespaceOperation {
+			return &fake.NamespaceOperation{
+				Service_: func(string) ops.ServiceOperation {
+					return &fake.ServiceOperation{
+						Endpoint_: func(string) ops.EndpointOperation {
+							return &fake.EndpointOperation{
+								List_: func(opts *list.Options) ops.EndpointLister {
+									if sorted {
+										// Pretend the service registry
+										// sorts objects itself.
+										opts.OrderBy = []list.OrderBy{}
+									}
+
+									i := 0
+									return &fake.FakeEndpointIterator{
+										Next_: func(_ context.Context) (*coretypes.Endpoint, ops.EndpointOperation, error) {
+											if listErr != nil && i == 1 {
+												return nil, nil, listErr
+											}
+
+											if i == len(listed) {
+												return nil, nil, srerr.IteratorDone
+											}
+
+											i++
+											return listed[i-1], &fake.EndpointOperation{}, nil
+										},
+									}
+								},
+							}
+						},
+					}
+				},
+			}
+		}
+		sr, _ = core.NewServiceRegistryFromWrapper(wrp)
+	})
+
+	names := func(opts ...list.Option) ([]string, error) {
+		result := []string{}
+		it := sr.Namespace("ns").Service("serv").Endpoint(core.Any).List(opts...)
+		for {
+			endp, _, err := it.Next(ctx)
+			if err != nil {
+				if srerr.IsIteratorDone(err) {
+					return result, nil
+				}
+
+				return result, err
+			}
+
+			result = append(result, endp.Name)
+		}
+	}
+
+	Context("with no order", func() {
+		It("returns endpoints as listed", func() {
+			res, err := names()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal([]string{"c", "a", "b"}))
+		})
+	})
+
+	Context("with order", func() {
+		It("returns endpoints sorted", func() {
+			By("sorting by name")
+			res, err := names(list.WithOrderBy(list.OrderByName, list.Ascending))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal([]string{"a", "b", "c"}))
+
+			By("sorting by port and then by name")
+			res, err = names(list.WithOrderBy(list.OrderByPort, list.Descending))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal([]string{"a", "b", "c"}))
+
+			By("sorting by address")
+			res, err = names(list.WithOrderBy(list.OrderByAddress, list.Ascending))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal([]string{"a", "c", "b"}))
+
+			By("sorting by metadata and then by port")
+			res, err = names(
+				list.WithOrderBy(list.OrderByMetadataKey("zone"), list.Descending),
+				list.WithOrderBy(list.OrderByPort, list.Ascending))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal([]string{"b", "a", "c"}))
+		})
+	})
+
+	Context("when the service registry sorts objects", func() {
+		It("does not sort them again", func() {
+			sorted = true
+			res, err := names(list.WithOrderBy(list.OrderByName, list.Ascending))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal([]string{"c", "a", "b"}))
+		})
+	})
+
+	Context("in case of errors while listing", func() {
+		It("returns the error", func() {
+			listErr = fmt.Errorf("whatever")
+			res, err := names(list.WithOrderBy(list.OrderByName, list.Ascending))
+			Expect(err).To(MatchError(listErr))
+			Expect(res).To(BeEmpty())
+		})
+	})
+})
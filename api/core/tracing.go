@@ -0,0 +1,75 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"context"
+
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
+	"github.com/CloudNativeSDWAN/serego/api/options/register"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startSpan starts a span for an operation with the provided name and
+// attributes, along with the backend's. Spans are not recorded if the
+// service registry was not initialized through the wrapper functions.
+func (s *ServiceRegistry) startSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	if s == nil || s.tracer == nil {
+		return trace.NewNoopTracerProvider().Tracer(tracing.InstrumentationName).Start(ctx, name)
+	}
+
+	attributes = append(attributes, tracing.BackendKey.String(s.backend))
+	return s.tracer.Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+func (n *NamespaceOperation) attributes() []attribute.KeyValue {
+	return []attribute.KeyValue{tracing.NamespaceKey.String(n.name)}
+}
+
+func (s *ServiceOperation) attributes() []attribute.KeyValue {
+	attributes := []attribute.KeyValue{tracing.ServiceKey.String(s.name)}
+	if s.parent != nil {
+		attributes = append(attributes, s.parent.attributes()...)
+	}
+
+	return attributes
+}
+
+func (e *EndpointOperation) attributes() []attribute.KeyValue {
+	attributes := []attribute.KeyValue{tracing.EndpointKey.String(e.name)}
+	if e.parent != nil {
+		attributes = append(attributes, e.parent.attributes()...)
+	}
+
+	return attributes
+}
+
+// setRegisterMode records on the span in the context whether the object is
+// going to be created or updated.
+func setRegisterMode(ctx context.Context, mode register.RegisterMode) {
+	trace.SpanFromContext(ctx).SetAttributes(tracing.RegisterModeKey.String(registerModeName(mode)))
+}
+
+func registerModeName(mode register.RegisterMode) string {
+	if mode == register.CreateMode {
+		return "create"
+	}
+
+	return "update"
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core_test
+
+import (
+	"context"
+
+	"github.com/CloudNativeSDWAN/serego/api/core"
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
+	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/fake"
+	"github.com/CloudNativeSDWAN/serego/api/options/get"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var _ = Describe("Tracing", func() {
+	var (
+		sr       *core.ServiceRegistry
+		recorder *tracetest.SpanRecorder
+	)
+
+	BeforeEach(func() {
+		servop := &fake.ServiceOperation{
+			Get_: func(_ context.Context, _ *get.Options) (*coretypes.Service, error) {
+				return nil, srerr.ServiceNotFound
+			},
+			Create_: func(_ context.Context, _ map[string]string) (*coretypes.Service, error) {
+				return &coretypes.Service{Name: "serv", Namespace: "ns"}, nil
+			},
+		}
+		nsop := &fake.NamespaceOperation{
+			Service_: func(string) ops.ServiceOperation {
+				return servop
+			},
+		}
+		wrp, _ := fake.NewFakeWrapper()
+		wrp.Namespace_ = func(string) ops.NamespaceOperation {
+			return nsop
+		}
+
+		recorder = tracetest.NewSpanRecorder()
+		sr, _ = core.NewServiceRegistryFromWrapper(wrp, wrapper.WithTracerProvider(
+			sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))))
+	})
+
+	It("creates spans for operations and the calls they perform", func() {
+		Expect(sr.Namespace("ns").Service("serv").Register(context.TODO())).To(Succeed())
+
+		spans := map[string]sdktrace.ReadOnlySpan{}
+		for _, span := range recorder.Ended() {
+			spans[span.Name()] = span
+		}
+		Expect(spans).To(HaveLen(4))
+		Expect(spans).To(HaveKey("Service.Register"))
+		Expect(spans).To(HaveKey("Service.Get"))
+		Expect(spans).To(HaveKey("custom get service"))
+		Expect(spans).To(HaveKey("custom create service"))
+
+		register := spans["Service.Register"]
+		Expect(register.Parent().IsValid()).To(BeFalse())
+		Expect(register.Attributes()).To(ConsistOf(
+			tracing.BackendKey.String("custom"),
+			tracing.NamespaceKey.String("ns"),
+			tracing.ServiceKey.String("serv"),
+			tracing.RegisterModeKey.String("create"),
+		))
+		Expect(spans["Service.Get"].Parent().SpanID()).To(Equal(register.SpanContext().SpanID()))
+		Expect(spans["custom get service"].Parent().SpanID()).To(Equal(spans["Service.Get"].SpanContext().SpanID()))
+		Expect(spans["custom create service"].Parent().SpanID()).To(Equal(register.SpanContext().SpanID()))
+	})
+})
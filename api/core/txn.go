@@ -0,0 +1,312 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/options/deregister"
+	"github.com/CloudNativeSDWAN/serego/api/options/register"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+)
+
+// TxnOperation is an operation that can be part of a transaction, i.e. a
+// *NamespaceOperation, a *ServiceOperation or an *EndpointOperation.
+type TxnOperation interface {
+	planRegister(ctx context.Context, regOpts *register.Options) (*txnStep, error)
+	planDeregister(ctx context.Context, derOpts *deregister.Options) (*txnStep, error)
+	auditTarget() (wrapper.Object, string)
+}
+
+// txnStep is a step of a transaction that has already been resolved, along
+// with the functions to apply and revert it on service registries that do
+// not support transactions.
+type txnStep struct {
+	step     ops.TxnStep
+	pathName string
+	apply    func(context.Context) error
+	revert   func(context.Context) error
+}
+
+type txnOperation struct {
+	target     TxnOperation
+	deregister bool
+	regOpts    *register.Options
+	derOpts    *deregister.Options
+}
+
+// Txn groups multiple Register and Deregister operations so that they are
+// applied together when Commit is called.
+//
+// On service registries that support transactions, i.e. etcd, all operations
+// are performed atomically: either all of them are applied or none is.
+// On all others, operations are applied one by one in the same order as they
+// were added, and if one of them fails all the ones that were already applied
+// are reverted as best as possible. Note that reverting the deregistration of
+// a namespace or a service will only re-create that object and not its
+// children.
+//
+// Note that etcd limits how many operations a transaction can have, i.e. 128
+// by default: each registration counts as one and each deregistration as two,
+// and Commit returns TooManyTxnOperations if there are more.
+//
+// You should not use this directly but rather initialize one through the Txn
+// function from the ServiceRegistry struct, for example:
+// 	err := sr.Txn(ctx).
+// 		Register(sr.Namespace("sales").Service("payroll")).
+// 		Register(sr.Namespace("sales").Service("payroll").Endpoint("payroll-1"),
+// 			register.WithAddress("10.10.10.10"),
+// 			register.WithPort(8080)).
+// 		Deregister(sr.Namespace("sales").Service("old-payroll")).
+// 		Commit()
+type Txn struct {
+	ctx        context.Context
+	root       *ServiceRegistry
+	operations []txnOperation
+	err        error
+}
+
+// Txn starts a new transaction on the service registry.
+//
+// Nothing is performed until Commit is called.
+func (s *ServiceRegistry) Txn(ctx context.Context) *Txn {
+	return &Txn{
+		ctx:  ctx,
+		root: s,
+	}
+}
+
+// Register adds the registration of the provided namespace, service or
+// endpoint to the transaction, with the same behavior and options that you
+// would use on its Register function.
+func (t *Txn) Register(op TxnOperation, opts ...register.Option) *Txn {
+	if t.err != nil {
+		return t
+	}
+
+	if op == nil {
+		t.err = srerr.NoOperationSet
+		return t
+	}
+
+	regOpts := &register.Options{}
+	for _, opt := range opts {
+		if err := opt(regOpts); err != nil {
+			t.err = err
+			return t
+		}
+	}
+
+	t.operations = append(t.operations, txnOperation{
+		target:  op,
+		regOpts: regOpts,
+	})
+	return t
+}
+
+// Deregister adds the deregistration of the provided namespace, service or
+// endpoint to the transaction, with the same behavior and options that you
+// would use on its Deregister function.
+func (t *Txn) Deregister(op TxnOperation, opts ...deregister.Option) *Txn {
+	if t.err != nil {
+		return t
+	}
+
+	if op == nil {
+		t.err = srerr.NoOperationSet
+		return t
+	}
+
+	derOpts := &deregister.Options{}
+	for _, opt := range opts {
+		if err := opt(derOpts); err != nil {
+			t.err = err
+			return t
+		}
+	}
+
+	t.operations = append(t.operations, txnOperation{
+		target:     op,
+		deregister: true,
+		derOpts:    derOpts,
+	})
+	return t
+}
+
+// Commit applies all operations of the transaction.
+//
+// If any of the provided options was not valid, an error is returned and
+// nothing is applied. The same happens if any of the objects appears more
+// than once in the transaction or if an object is registered under a parent
+// that neither exists nor is registered earlier in the same transaction.
+//
+// On etcd, errors.Conflict is returned if any of the objects was modified by
+// someone else between the time the transaction was prepared and the time it
+// was committed.
+//
+// If an audit sink was provided, all operations are recorded on it with the
+// error returned by Commit, if any.
+func (t *Txn) Commit() (err error) {
+	if t.root == nil {
+		return srerr.UninitializedOperation
+	}
+
+	if t.err != nil {
+		return t.err
+	}
+
+	steps, entries, err := t.plan()
+	defer func() {
+		for _, entry := range entries {
+			t.root.audit(entry, err)
+		}
+	}()
+	if err != nil {
+		return err
+	}
+
+	if len(steps) == 0 {
+		return nil
+	}
+
+	if txner, ok := t.root.wrapper.(ops.Transactioner); ok {
+		txnSteps := make([]ops.TxnStep, len(steps))
+		for i, step := range steps {
+			txnSteps[i] = step.step
+		}
+
+		return txner.Commit(t.ctx, txnSteps)
+	}
+
+	for i, step := range steps {
+		if err := step.apply(t.ctx); err != nil {
+			if revertErr := t.revert(steps[:i]); revertErr != nil {
+				return fmt.Errorf("could not apply step %d of transaction: %w, and could not revert previous steps: %s", i, err, revertErr)
+			}
+
+			return fmt.Errorf("could not apply step %d of transaction, previous steps were reverted: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// plan resolves all operations of the transaction, and returns their steps
+// along with the audit entries of the ones that were resolved so far, even if
+// it fails.
+func (t *Txn) plan() ([]*txnStep, []*wrapper.AuditEntry, error) {
+	steps := []*txnStep{}
+	entries := []*wrapper.AuditEntry{}
+	planned := map[string]ops.TxnAction{}
+
+	for i, operation := range t.operations {
+		var (
+			step *txnStep
+			err  error
+		)
+
+		action := wrapper.RegisterAction
+		if operation.deregister {
+			action = wrapper.DeregisterAction
+		}
+		object, pathName := operation.target.auditTarget()
+		ctx, entry := t.root.startAudit(t.ctx, action, object, pathName)
+		if entry != nil {
+			entries = append(entries, entry)
+		}
+
+		if operation.deregister {
+			step, err = operation.target.planDeregister(ctx, operation.derOpts)
+		} else {
+			step, err = operation.target.planRegister(ctx, operation.regOpts)
+		}
+		if err != nil {
+			return nil, entries, fmt.Errorf("could not prepare step %d of transaction: %w", i, err)
+		}
+
+		if step == nil {
+			// Nothing to do for this one.
+			continue
+		}
+
+		if _, exists := planned[step.pathName]; exists {
+			return nil, entries, fmt.Errorf("could not prepare step %d of transaction: %w", i, srerr.DuplicateTxnObject)
+		}
+
+		if step.step.Action == ops.TxnCreate {
+			if err := t.checkParent(step, planned); err != nil {
+				return nil, entries, fmt.Errorf("could not prepare step %d of transaction: %w", i, err)
+			}
+		}
+
+		planned[step.pathName] = step.step.Action
+		steps = append(steps, step)
+	}
+
+	return steps, entries, nil
+}
+
+// checkParent makes sure that the parent of an object that is going to be
+// created either already exists or is going to be created earlier in the
+// same transaction.
+func (t *Txn) checkParent(step *txnStep, planned map[string]ops.TxnAction) error {
+	if step.step.Service == "" {
+		// Namespaces don't have parents.
+		return nil
+	}
+
+	parentPath := path.Dir(path.Dir(step.pathName))
+	notFoundErr := srerr.NamespaceNotFound
+	if step.step.Endpoint != "" {
+		notFoundErr = srerr.ServiceNotFound
+	}
+
+	if action, exists := planned[parentPath]; exists {
+		if action == ops.TxnDelete {
+			return notFoundErr
+		}
+
+		return nil
+	}
+
+	nsOp := t.root.Namespace(step.step.Namespace)
+	if step.step.Endpoint == "" {
+		_, err := nsOp.Get(t.ctx)
+		return err
+	}
+
+	_, err := nsOp.Service(step.step.Service).Get(t.ctx)
+	return err
+}
+
+func (t *Txn) revert(applied []*txnStep) error {
+	var revertErr error
+
+	// Go backwards, so that children are reverted before their parents.
+	for i := len(applied) - 1; i >= 0; i-- {
+		if err := applied[i].revert(t.ctx); err != nil && revertErr == nil {
+			revertErr = err
+		}
+	}
+
+	return revertErr
+}
@@ -0,0 +1,270 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package core_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CloudNativeSDWAN/serego/api/core"
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/fake"
+	"github.com/CloudNativeSDWAN/serego/api/options/deregister"
+	"github.com/CloudNativeSDWAN/serego/api/options/get"
+	"github.com/CloudNativeSDWAN/serego/api/options/register"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Transactions", func() {
+	const (
+		nsName   = "ns"
+		servName = "serv"
+	)
+
+	var (
+		sr     *core.ServiceRegistry
+		nsop   *fake.NamespaceOperation
+		servop *fake.ServiceOperation
+		newEp  *fake.EndpointOperation
+		oldEp  *fake.EndpointOperation
+		ctx    = context.TODO()
+		calls  []string
+	)
+
+	BeforeEach(func() {
+		calls = []string{}
+		nsop = &fake.NamespaceOperation{
+			Get_: func(_ context.Context, _ *get.Options) (*coretypes.Namespace, error) {
+				return &coretypes.Namespace{Name: nsName, Metadata: map[string]string{}}, nil
+			},
+		}
+		servop = &fake.ServiceOperation{
+			Get_: func(_ context.Context, _ *get.Options) (*coretypes.Service, error) {
+				return nil, srerr.ServiceNotFound
+			},
+			Create_: func(_ context.Context, _ map[string]string) (*coretypes.Service, error) {
+				calls = append(calls, "create serv")
+				return nil, nil
+			},
+			Delete_: func(_ context.Context) error {
+				calls = append(calls, "delete serv")
+				return nil
+			},
+		}
+		newEp = &fake.EndpointOperation{
+			Get_: func(_ context.Context, _ *get.Options) (*coretypes.Endpoint, error) {
+				return nil, srerr.EndpointNotFound
+			},
+			Create_: func(_ context.Context, _ string, _ int32, _ map[string]string) (*coretypes.Endpoint, error) {
+				calls = append(calls, "create new")
+				return nil, nil
+			},
+			Delete_: func(_ context.Context) error {
+				calls = append(calls, "delete new")
+				return nil
+			},
+		}
+		oldEp = &fake.EndpointOperation{
+			Get_: func(_ context.Context, _ *get.Options) (*coretypes.Endpoint, error) {
+				return &coretypes.Endpoint{
+					Name:      "old",
+					Namespace: nsName,
+					Service:   servName,
+					Address:   "10.10.10.9",
+					Port:      80,
+					Metadata:  map[string]string{"key": "val"},
+					Revision:  "3",
+				}, nil
+			},
+			Create_: func(_ context.Context, address string, port int32, metadata map[string]string) (*coretypes.Endpoint, error) {
+				calls = append(calls, fmt.Sprintf("create old %s:%d %v", address, port, metadata))
+				return nil, nil
+			},
+			Delete_: func(_ context.Context) error {
+				calls = append(calls, "delete old")
+				return nil
+			},
+		}
+		servop.Endpoint_ = func(name string) ops.EndpointOperation {
+			if name == "old" {
+				return oldEp
+			}
+
+			return newEp
+		}
+		nsop.Service_ = func(string) ops.ServiceOperation {
+			return servop
+		}
+	})
+
+	newTxn := func() *core.Txn {
+		serv := sr.Namespace(nsName).Service(servName)
+		return sr.Txn(ctx).
+			Register(serv, register.WithKV("key", "val")).
+			Register(serv.Endpoint("new"),
+				register.WithAddress("10.10.10.10"),
+				register.WithPort(8080)).
+			Deregister(serv.Endpoint("old"))
+	}
+
+	Context("on service registries that do not support transactions", func() {
+		BeforeEach(func() {
+			wrp, _ := fake.NewFakeWrapper()
+			wrp.Namespace_ = func(string) ops.NamespaceOperation {
+				return nsop
+			}
+			sr, _ = core.NewServiceRegistryFromWrapper(wrp)
+		})
+
+		It("applies all steps in order", func() {
+			Expect(newTxn().Commit()).To(Succeed())
+			Expect(calls).To(Equal([]string{"create serv", "create new", "delete old"}))
+		})
+
+		It("reverts already applied steps in case of errors", func() {
+			expErr := fmt.Errorf("whatever")
+			oldEp.Delete_ = func(_ context.Context) error {
+				return expErr
+			}
+
+			err := newTxn().Commit()
+			Expect(err).To(MatchError(expErr))
+			Expect(calls).To(Equal([]string{"create serv", "create new", "delete new", "delete serv"}))
+		})
+
+		It("re-creates deregistered objects when reverting", func() {
+			expErr := fmt.Errorf("whatever")
+			servop.Create_ = func(_ context.Context, _ map[string]string) (*coretypes.Service, error) {
+				return nil, expErr
+			}
+
+			err := sr.Txn(ctx).
+				Deregister(sr.Namespace(nsName).Service(servName).Endpoint("old")).
+				Register(sr.Namespace(nsName).Service("another"), register.WithCreateMode()).
+				Commit()
+			Expect(err).To(MatchError(expErr))
+			Expect(calls).To(Equal([]string{"delete old", "create old 10.10.10.9:80 map[key:val]"}))
+		})
+	})
+
+	Context("on service registries that support transactions", func() {
+		var (
+			txnWrp *fake.FakeTransactionalWrapper
+		)
+
+		BeforeEach(func() {
+			txnWrp, _ = fake.NewFakeTransactionalWrapper()
+			txnWrp.Namespace_ = func(string) ops.NamespaceOperation {
+				return nsop
+			}
+			sr, _ = core.NewServiceRegistryFromWrapper(txnWrp)
+		})
+
+		It("commits all steps at once", func() {
+			var committed []ops.TxnStep
+			txnWrp.Commit_ = func(_ context.Context, steps []ops.TxnStep) error {
+				committed = steps
+				return nil
+			}
+
+			Expect(newTxn().Commit()).To(Succeed())
+			Expect(calls).To(BeEmpty())
+			Expect(committed).To(Equal([]ops.TxnStep{
+				{
+					Action:    ops.TxnCreate,
+					Namespace: nsName,
+					Service:   servName,
+					Metadata:  map[string]string{"key": "val"},
+				},
+				{
+					Action:    ops.TxnCreate,
+					Namespace: nsName,
+					Service:   servName,
+					Endpoint:  "new",
+					Address:   "10.10.10.10",
+					Port:      8080,
+					Metadata:  map[string]string{},
+				},
+				{
+					Action:    ops.TxnDelete,
+					Namespace: nsName,
+					Service:   servName,
+					Endpoint:  "old",
+					Revision:  "3",
+				},
+			}))
+		})
+
+		It("returns the same error", func() {
+			txnWrp.Commit_ = func(_ context.Context, _ []ops.TxnStep) error {
+				return srerr.Conflict
+			}
+
+			Expect(newTxn().Commit()).To(MatchError(srerr.Conflict))
+		})
+	})
+
+	Context("in case of user errors", func() {
+		BeforeEach(func() {
+			wrp, _ := fake.NewFakeWrapper()
+			wrp.Namespace_ = func(string) ops.NamespaceOperation {
+				return nsop
+			}
+			sr, _ = core.NewServiceRegistryFromWrapper(wrp)
+		})
+
+		It("returns an error and does nothing", func() {
+			By("checking the options", func() {
+				err := newTxn().
+					Register(sr.Namespace(nsName), register.WithPort(-1)).
+					Commit()
+				Expect(err).To(MatchError(srerr.InvalidPort))
+
+				err = newTxn().
+					Deregister(nil, deregister.WithFailIfNotExists()).
+					Commit()
+				Expect(err).To(MatchError(srerr.NoOperationSet))
+			})
+
+			By("checking that objects appear only once", func() {
+				err := newTxn().
+					Deregister(sr.Namespace(nsName).Service(servName).Endpoint("old")).
+					Commit()
+				Expect(err).To(MatchError(srerr.DuplicateTxnObject))
+			})
+
+			By("checking that parents exist", func() {
+				err := sr.Txn(ctx).
+					Register(sr.Namespace(nsName).Service(servName).Endpoint("new")).
+					Commit()
+				Expect(err).To(MatchError(srerr.ServiceNotFound))
+
+				err = newTxn().
+					Deregister(sr.Namespace(nsName)).
+					Register(sr.Namespace(nsName).Service("another")).
+					Commit()
+				Expect(err).To(MatchError(srerr.NamespaceNotFound))
+			})
+
+			Expect(calls).To(BeEmpty())
+		})
+	})
+})
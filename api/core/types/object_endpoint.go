@@ -47,6 +47,16 @@ type Endpoint struct {
 	//
 	// Check out the main documentation for examples.
 	Metadata map[string]string `json:"metadata" yaml:"metadata"`
+	// Revision is an opaque token that changes every time the endpoint is
+	// modified on the service registry.
+	//
+	// You can pass it to register.WithExpectedRevision to make sure nobody
+	// else modified the endpoint since you last retrieved it.
+	Revision string `json:"revision,omitempty" yaml:"-"`
+	// Stale is true if the endpoint could not be retrieved from the service
+	// registry because of a temporary error, and it was taken from an expired
+	// cache entry instead. Read wrapper.WithStaleWhileError to learn more.
+	Stale bool `json:"-" yaml:"-"`
 	// OriginalObject is a pointer to the endpoint object as it is stored on
 	// the service registry and is provided in case you need data or
 	// information that is specific or unique to that service registry and is
@@ -72,8 +82,8 @@ type Endpoint struct {
 // 	- they have the same combination of keys and values in their metadata,
 // 	  including the number of keys but excluding the order.
 //
-// Note that this will *not* compare the OriginalObject field and, therefore,
-// you will have to do that on your own.
+// Note that this will *not* compare the Revision, Stale and OriginalObject
+// fields and, therefore, you will have to do that on your own.
 func (e *Endpoint) DeepEqualTo(ep *Endpoint) bool {
 	if ep == nil {
 		return false
@@ -101,6 +111,8 @@ func (e *Endpoint) Clone() *Endpoint {
 		Address:        e.Address,
 		Port:           e.Port,
 		Metadata:       deepCopyMap(e.Metadata),
+		Revision:       e.Revision,
+		Stale:          e.Stale,
 		OriginalObject: e.OriginalObject,
 	}
 }
@@ -31,6 +31,16 @@ type Namespace struct {
 	//
 	// Check out the main documentation for examples.
 	Metadata map[string]string `json:"metadata" yaml:"metadata"`
+	// Revision is an opaque token that changes every time the namespace is
+	// modified on the service registry.
+	//
+	// You can pass it to register.WithExpectedRevision to make sure nobody
+	// else modified the namespace since you last retrieved it.
+	Revision string `json:"revision,omitempty" yaml:"-"`
+	// Stale is true if the namespace could not be retrieved from the service
+	// registry because of a temporary error, and it was taken from an expired
+	// cache entry instead. Read wrapper.WithStaleWhileError to learn more.
+	Stale bool `json:"-" yaml:"-"`
 	// OriginalObject is a pointer to the namespace object as it is stored on
 	// the service registry and is provided in case you need data or
 	// information that is specific or unique to that service registry and is
@@ -52,8 +62,8 @@ type Namespace struct {
 // 	- they have the same combination of keys and values in their metadata,
 // 	  including the number of keys but excluding the order.
 //
-// Note that this will *not* compare the OriginalObject field and, therefore,
-// you will have to do that on your own.
+// Note that this will *not* compare the Revision, Stale and OriginalObject
+// fields and, therefore, you will have to do that on your own.
 func (n *Namespace) DeepEqualTo(namespace *Namespace) bool {
 	return n.Name == namespace.Name &&
 		reflect.DeepEqual(n.Metadata, namespace.Metadata)
@@ -69,6 +79,8 @@ func (n *Namespace) Clone() *Namespace {
 	return &Namespace{
 		Name:           n.Name,
 		Metadata:       deepCopyMap(n.Metadata),
+		Revision:       n.Revision,
+		Stale:          n.Stale,
 		OriginalObject: n.OriginalObject,
 	}
 }
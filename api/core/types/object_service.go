@@ -33,6 +33,16 @@ type Service struct {
 	//
 	// Check out the main documentation for examples.
 	Metadata map[string]string `json:"metadata" yaml:"metadata"`
+	// Revision is an opaque token that changes every time the service is
+	// modified on the service registry.
+	//
+	// You can pass it to register.WithExpectedRevision to make sure nobody
+	// else modified the service since you last retrieved it.
+	Revision string `json:"revision,omitempty" yaml:"-"`
+	// Stale is true if the service could not be retrieved from the service
+	// registry because of a temporary error, and it was taken from an expired
+	// cache entry instead. Read wrapper.WithStaleWhileError to learn more.
+	Stale bool `json:"-" yaml:"-"`
 	// OriginalObject is a pointer to the service object as it is stored on
 	// the service registry and is provided in case you need data or
 	// information that is specific or unique to that service registry and is
@@ -55,8 +65,8 @@ type Service struct {
 // 	- they have the same combination of keys and values in their metadata,
 // 	  including the number of keys but excluding the order.
 //
-// Note that this will *not* compare the OriginalObject field and, therefore,
-// you will have to do that on your own.
+// Note that this will *not* compare the Revision, Stale and OriginalObject
+// fields and, therefore, you will have to do that on your own.
 func (s *Service) DeepEqualTo(service *Service) bool {
 	return s.Name == service.Name &&
 		s.Namespace == service.Namespace &&
@@ -74,6 +84,8 @@ func (s *Service) Clone() *Service {
 		Name:           s.Name,
 		Namespace:      s.Namespace,
 		Metadata:       deepCopyMap(s.Metadata),
+		Revision:       s.Revision,
+		Stale:          s.Stale,
 		OriginalObject: s.OriginalObject,
 	}
 }
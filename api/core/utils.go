@@ -35,6 +35,8 @@ func prepareRegisterOperation(opts interface{}, getObj interface{}, getErr error
 		regOpts          = reflect.ValueOf(opts)
 		registerMode     = register.RegisterMode(
 			regOpts.Elem().FieldByName("RegisterMode").Uint())
+		expectedRevision = regOpts.Elem().FieldByName("ExpectedRevision").String()
+		revision         string
 	)
 
 	switch getObj.(type) {
@@ -56,6 +58,7 @@ func prepareRegisterOperation(opts interface{}, getObj interface{}, getErr error
 				FieldByName("Metadata").
 				Interface().(map[string]string)
 			metadata = deepCopyMap(elemMetadata)
+			revision = val.Elem().FieldByName("Revision").String()
 		}
 	}
 
@@ -64,11 +67,19 @@ func prepareRegisterOperation(opts interface{}, getObj interface{}, getErr error
 		if registerMode == register.CreateMode {
 			return register.CreateOrUpdateMode, nil, errAlreadyExists
 		}
+		if expectedRevision != "" && revision != expectedRevision {
+			return register.CreateOrUpdateMode, nil, srerr.Conflict
+		}
 		registerMode = register.UpdateMode
 	case srerr.IsNotFound(getErr):
 		if registerMode == register.UpdateMode {
 			return register.CreateOrUpdateMode, nil, errNotFound
 		}
+		if expectedRevision != "" {
+			// The object was there when the revision was retrieved, but
+			// someone else deleted it in the meantime.
+			return register.CreateOrUpdateMode, nil, srerr.Conflict
+		}
 		registerMode = register.CreateMode
 	default:
 		return register.CreateOrUpdateMode, nil, fmt.Errorf("error while checking if object exists: %w", getErr)
@@ -22,6 +22,7 @@ import (
 
 	servicedirectory "cloud.google.com/go/servicedirectory/apiv1"
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
 	cmw "github.com/CloudNativeSDWAN/serego/api/internal/wrappers/aws/cloudmap"
 	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/etcd"
@@ -42,7 +43,10 @@ import (
 //
 // NOTE: this *needs* a region and project ID, please look at the example.
 func NewServiceRegistryFromServiceDirectory(client *servicedirectory.RegistrationClient, option ...wrapper.Option) (*ServiceRegistry, error) {
-	wopts := &wrapper.Options{CacheExpirationTime: wrapper.DefaultCacheExpirationTime}
+	wopts := &wrapper.Options{
+		CacheExpirationTime:         wrapper.DefaultCacheExpirationTime,
+		NegativeCacheExpirationTime: wrapper.DefaultNegativeCacheExpirationTime,
+	}
 	for _, wo := range option {
 		if err := wo(wopts); err != nil {
 			return nil, err
@@ -54,9 +58,7 @@ func NewServiceRegistryFromServiceDirectory(client *servicedirectory.Registratio
 		return nil, fmt.Errorf("could not get wrapper for Service Directory: %w", err)
 	}
 
-	return &ServiceRegistry{
-		wrapper: wrapper,
-	}, nil
+	return newServiceRegistry(wrapper, wopts, metrics.ServiceDirectory)
 }
 
 // NewServiceRegistryFromCloudMap starts a new ServiceRegistry wrapper on top
@@ -64,7 +66,10 @@ func NewServiceRegistryFromServiceDirectory(client *servicedirectory.Registratio
 //
 // It returns an error if the client is nil.
 func NewServiceRegistryFromCloudMap(client *servicediscovery.Client, option ...wrapper.Option) (*ServiceRegistry, error) {
-	wopts := &wrapper.Options{CacheExpirationTime: wrapper.DefaultCacheExpirationTime}
+	wopts := &wrapper.Options{
+		CacheExpirationTime:         wrapper.DefaultCacheExpirationTime,
+		NegativeCacheExpirationTime: wrapper.DefaultNegativeCacheExpirationTime,
+	}
 	for _, wo := range option {
 		if err := wo(wopts); err != nil {
 			return nil, err
@@ -76,9 +81,7 @@ func NewServiceRegistryFromCloudMap(client *servicediscovery.Client, option ...w
 		return nil, fmt.Errorf("could not get wrapper for Cloud Map: %w", err)
 	}
 
-	return &ServiceRegistry{
-		wrapper: wrapper,
-	}, nil
+	return newServiceRegistry(wrapper, wopts, metrics.CloudMap)
 }
 
 // NewServiceRegistryFromEtcd uses etcd to create a service registry.
@@ -88,7 +91,10 @@ func NewServiceRegistryFromCloudMap(client *servicediscovery.Client, option ...w
 // NOTE: you will have to provide a prefix for etcd, please look at the
 // example.
 func NewServiceRegistryFromEtcd(client *clientv3.Client, option ...wrapper.Option) (*ServiceRegistry, error) {
-	wopts := &wrapper.Options{CacheExpirationTime: wrapper.DefaultCacheExpirationTime}
+	wopts := &wrapper.Options{
+		CacheExpirationTime:         wrapper.DefaultCacheExpirationTime,
+		NegativeCacheExpirationTime: wrapper.DefaultNegativeCacheExpirationTime,
+	}
 	for _, wo := range option {
 		if err := wo(wopts); err != nil {
 			return nil, err
@@ -100,9 +106,7 @@ func NewServiceRegistryFromEtcd(client *clientv3.Client, option ...wrapper.Optio
 		return nil, fmt.Errorf("could not get wrapper for etcd: %w", err)
 	}
 
-	return &ServiceRegistry{
-		wrapper: wrapper,
-	}, nil
+	return newServiceRegistry(wrapper, wopts, metrics.Etcd)
 }
 
 // NewServiceRegistryFromWrapper returns a ServiceRegistry wrapper with a
@@ -111,14 +115,17 @@ func NewServiceRegistryFromEtcd(client *clientv3.Client, option ...wrapper.Optio
 // You should not use this function to create a ServiceRegistry wrapper, but
 // rather use one of the other provided functions as this one is mostly used
 // for testing and may be deprecated or removed in future.
-func NewServiceRegistryFromWrapper(wrapper ops.ServiceRegistryWrapper, wopt ...wrapper.Option) (*ServiceRegistry, error) {
-	if wrapper == nil {
+func NewServiceRegistryFromWrapper(wrp ops.ServiceRegistryWrapper, wopt ...wrapper.Option) (*ServiceRegistry, error) {
+	if wrp == nil {
 		return nil, srerr.NoOperationSet
 	}
 
-	sr := &ServiceRegistry{
-		wrapper: wrapper,
+	wopts := &wrapper.Options{}
+	for _, wo := range wopt {
+		if err := wo(wopts); err != nil {
+			return nil, err
+		}
 	}
 
-	return sr, nil
+	return newServiceRegistry(wrp, wopts, metrics.Custom)
 }
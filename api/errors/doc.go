@@ -26,6 +26,10 @@
 // can still check with either one of these functions, or with golang's errors
 // package, or the service registry's API errors.
 //
+// Errors returned by calls to the service registry are wrapped in an
+// OperationError, which tells which operation failed on which object and
+// which service registry.
+//
 // The Variables section of this package contains the errors that are "native"
 // to this package, and their usage or reasons for being returned should be
 // self explanatory.
@@ -18,7 +18,9 @@
 package errors
 
 import (
+	"context"
 	"errors"
+	"net"
 
 	"github.com/aws/aws-sdk-go-v2/service/servicediscovery/types"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
@@ -27,6 +29,17 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// awsThrottlingCodes are the error codes AWS returns when requests are
+// throttled.
+var awsThrottlingCodes = map[string]bool{
+	"ThrottlingException":       true,
+	"Throttling":                true,
+	"ThrottledException":        true,
+	"RequestThrottledException": true,
+	"TooManyRequestsException":  true,
+	"RequestLimitExceeded":      true,
+}
+
 var (
 	IteratorDone                = errors.New("iterator done")
 	NotFound                    = errors.New("not found")
@@ -46,7 +59,7 @@ var (
 	NoPortsProvided             = errors.New("no ports provided")
 	InvalidAddress              = errors.New("invalid address provided")
 	InvalidNamePrefixFilter     = errors.New("invalid name prefix filter provided")
-	IncompatibleNameFilters     = errors.New(`"NameIn" and "NamePrefix" filters cannot be used together`)
+	IncompatibleNameFilters     = errors.New(`"NameIn" filter cannot be used together with other name filters`)
 	EmptyName                   = errors.New("empty name provided")
 	EmptyNameInFilter           = errors.New("empty nameIn filter provided")
 	EmptyMetadataKeysFilter     = errors.New("empty metadata keys filter provided")
@@ -54,10 +67,10 @@ var (
 	EmptyMetadataKey            = errors.New("metadata contains an empty key")
 	EmptyMetadataFilter         = errors.New("empty metadata filter provided")
 	InvalidResultsNumber        = errors.New("invalid results number provided")
-	IncompatibleMetadataFilters = errors.New("'noMetadata' and 'metadata' filters cannot be used together'")
+	IncompatibleMetadataFilters = errors.New("incompatible metadata filters provided, e.g. 'noMetadata' and 'metadata'")
 	UnsupportedAddressFamily    = errors.New("invalid address family provided")
 	InvalidCIDRProvided         = errors.New("invalid CIDR provided")
-	IncompatiblePortFilters     = errors.New("'portIn' and 'portRange' cannot be used together")
+	IncompatiblePortFilters     = errors.New("incompatible port filters provided")
 	EmptyPortInFilter           = errors.New("empty 'portIn' filter provided")
 	InvalidPortRange            = errors.New("invalid port range provided")
 	NoOptionsProvided           = errors.New("no options provided")
@@ -75,6 +88,55 @@ var (
 	InvalidObjectToFilter       = errors.New("object to filter is invalid")
 	InvalidRegionProvided       = errors.New("empty or invalid region provided")
 	InvalidProjectProvided      = errors.New("empty or invalid project provided")
+	EmptyRevision               = errors.New("empty revision provided")
+	Conflict                    = errors.New("object was modified by someone else: revision mismatch")
+	DuplicateTxnObject          = errors.New("object appears more than once in the same transaction")
+	TooManyTxnOperations        = errors.New("too many operations in the same transaction")
+	InvalidConcurrency          = errors.New("invalid concurrency provided")
+	InvalidRetryPolicy          = errors.New("invalid retry policy provided")
+	InvalidRateLimit            = errors.New("invalid rate limit provided")
+	RateLimitExceeded           = errors.New("client-side rate limit exceeded")
+	InvalidStaleGracePeriod     = errors.New("invalid stale grace period provided")
+	InvalidCircuitBreaker       = errors.New("invalid circuit breaker provided")
+	InvalidCacheSize            = errors.New("invalid cache size provided")
+	NoCacheProvided             = errors.New("no cache provided")
+	CircuitOpen                 = errors.New("circuit breaker is open: service registry is unhealthy")
+	NoServiceRegistryProvided   = errors.New("no service registry provided")
+	NoNamespacesProvided        = errors.New("no namespaces provided")
+	InvalidResyncPeriod         = errors.New("invalid resync period provided")
+	EmptyIndexerName            = errors.New("empty indexer name provided")
+	NoIndexFuncProvided         = errors.New("no index function provided")
+	IndexerAlreadyExists        = errors.New("indexer already exists")
+	IndexerNotFound             = errors.New("indexer not found")
+	InformerAlreadyStarted      = errors.New("informer has already been started")
+	EmptyCachePath              = errors.New("empty cache path provided")
+	InvalidCacheMaxAge          = errors.New("invalid cache max age provided")
+	NoRegistererProvided        = errors.New("no metrics registerer provided")
+	NoTracerProviderProvided    = errors.New("no tracer provider provided")
+	NoLoggerProvided            = errors.New("no logger provided")
+	NoInterceptorProvided       = errors.New("no interceptor provided")
+	NoAuditSinkProvided         = errors.New("no audit sink provided")
+	EmptyAuditLogPath           = errors.New("empty audit log path provided")
+	MetadataNotSupported        = errors.New("metadata are not supported for this object")
+	TooManyMetadata             = errors.New("too many metadata provided")
+	MetadataKeyTooLong          = errors.New("metadata key is too long")
+	MetadataValueTooLong        = errors.New("metadata value is too long")
+	MetadataTooLong             = errors.New("metadata are too long")
+	InvalidOrderBy              = errors.New("invalid order by provided")
+	InvalidPageToken            = errors.New("invalid page token provided")
+	InvalidNameSuffixFilter     = errors.New("invalid name suffix filter provided")
+	InvalidNameRegexFilter      = errors.New("invalid name regex filter provided")
+	MissingOriginalObject       = errors.New("object does not have its original object")
+	EmptyNameNotInFilter        = errors.New("empty nameNotIn filter provided")
+	EmptyMetadataValuesFilter   = errors.New("empty metadata values filter provided")
+	NoCIDRProvided              = errors.New("no CIDR provided")
+	EmptySelector               = errors.New("empty selector provided")
+	InvalidSelector             = errors.New("invalid selector provided")
+	UnsupportedSelectorOperator = errors.New("unsupported selector operator")
+	EmptyExpression             = errors.New("empty expression provided")
+	InvalidExpression           = errors.New("invalid expression provided")
+	InvalidExpressionType       = errors.New("invalid type in expression")
+	StaleObject                 = errors.New("object is stale: service registry could not be reached")
 )
 
 // IsIteratorDone returns true if the error provided as argument is
@@ -150,19 +212,47 @@ func IsNotFound(err error) bool {
 
 // IsPermissionsError returns true if the error provided as argument was
 // thrown by the service registry because of insufficient errors.
+//
+// Deprecated: use IsPermissionDenied instead.
 func IsPermissionsError(err error) bool {
+	return IsPermissionDenied(err)
+}
+
+// IsPermissionDenied returns true if the error provided as argument was
+// thrown by the service registry because the credentials you are using are
+// not allowed to perform the operation.
+//
+// This includes gRPC PermissionDenied errors from Service Directory and etcd
+// and access denied errors from Cloud Map.
+func IsPermissionDenied(err error) bool {
 	if err == nil {
 		// Finished unwrapping
 		return false
 	}
 
+	// Service Directory and etcd gRPC errors.
 	if status.Code(err) == codes.PermissionDenied {
 		return true
 	}
 
-	// TODO: other situations
+	// Etcd errors
+	if etcdErrorCode(err) == codes.PermissionDenied {
+		return true
+	}
+
+	// Cloud Map Errors
+	{
+		var apiErr interface{ ErrorCode() string }
+		if errors.As(err, &apiErr) {
+			switch apiErr.ErrorCode() {
+			case "AccessDeniedException", "AccessDenied",
+				"UnauthorizedOperation", "UnrecognizedClientException":
+				return true
+			}
+		}
+	}
 
-	return IsPermissionsError(errors.Unwrap(err))
+	return IsPermissionDenied(errors.Unwrap(err))
 }
 
 // IsAlreadyExists returns true if the error provided as argument was
@@ -192,10 +282,184 @@ func IsAlreadyExists(err error) bool {
 		}
 	}
 
-	// Service Directory gRPC errors.
-	if status.Code(err) == codes.AlreadyExists {
+	// Service Directory and etcd gRPC errors.
+	if status.Code(err) == codes.AlreadyExists ||
+		etcdErrorCode(err) == codes.AlreadyExists {
 		return true
 	}
 
 	return IsAlreadyExists(errors.Unwrap(err))
 }
+
+// IsThrottled returns true if the error provided as argument was thrown
+// because too many requests were performed, either by the service registry
+// or by the API itself because of the rate limits you provided.
+//
+// This includes gRPC ResourceExhausted errors from Service Directory and
+// etcd, throttling errors from Cloud Map and RateLimitExceeded.
+func IsThrottled(err error) bool {
+	if err == nil {
+		// Finished unwrapping
+		return false
+	}
+
+	if errors.Is(err, RateLimitExceeded) {
+		return true
+	}
+
+	// Service Directory and etcd gRPC errors.
+	if status.Code(err) == codes.ResourceExhausted ||
+		etcdErrorCode(err) == codes.ResourceExhausted {
+		return true
+	}
+
+	// Cloud Map Errors
+	{
+		var (
+			rle    *types.RequestLimitExceeded
+			apiErr interface{ ErrorCode() string }
+		)
+
+		if errors.As(err, &rle) {
+			return true
+		}
+
+		if errors.As(err, &apiErr) && awsThrottlingCodes[apiErr.ErrorCode()] {
+			return true
+		}
+	}
+
+	return IsThrottled(errors.Unwrap(err))
+}
+
+// IsInvalidArgument returns true if the error provided as argument was thrown
+// because the request was not valid, e.g. because of a name or a port that
+// the service registry does not accept.
+//
+// This includes gRPC InvalidArgument errors from Service Directory and etcd,
+// InvalidInput errors from Cloud Map and the errors of this package returned
+// when names, addresses, ports or metadata are not valid, including metadata
+// that exceed the limits of the service registry.
+func IsInvalidArgument(err error) bool {
+	if err == nil {
+		// Finished unwrapping
+		return false
+	}
+
+	// Our internal errors
+	if errors.Is(err, EmptyNamespaceName) ||
+		errors.Is(err, EmptyServiceName) ||
+		errors.Is(err, EmptyEndpointName) ||
+		errors.Is(err, InvalidPort) ||
+		errors.Is(err, InvalidAddress) ||
+		errors.Is(err, EmptyMetadataKey) ||
+		errors.Is(err, MetadataNotSupported) ||
+		errors.Is(err, TooManyMetadata) ||
+		errors.Is(err, MetadataKeyTooLong) ||
+		errors.Is(err, MetadataValueTooLong) ||
+		errors.Is(err, MetadataTooLong) ||
+		errors.Is(err, NameTooLong) ||
+		errors.Is(err, NameIsNotRFC1035) {
+		return true
+	}
+
+	// Service Directory and etcd gRPC errors.
+	if status.Code(err) == codes.InvalidArgument ||
+		etcdErrorCode(err) == codes.InvalidArgument {
+		return true
+	}
+
+	// Cloud Map Errors
+	{
+		var ii *types.InvalidInput
+		if errors.As(err, &ii) {
+			return true
+		}
+	}
+
+	return IsInvalidArgument(errors.Unwrap(err))
+}
+
+// IsConflict returns true if the error provided as argument was thrown
+// because the object was modified by someone else after you retrieved it,
+// i.e. when its revision does not match the one you expected.
+func IsConflict(err error) bool {
+	return errors.Is(err, Conflict)
+}
+
+// IsTransient returns true if the error provided as argument is a temporary
+// one, e.g. a network error or the service registry throttling requests, and
+// thus the operation that caused it may succeed if you try again later.
+//
+// This includes connection errors, e.g. when the service registry's address
+// cannot be resolved or refuses connections, gRPC Unavailable and
+// ResourceExhausted errors from Service Directory and etcd, throttling errors
+// from Cloud Map, errors due to a leader change or a timeout on etcd,
+// RateLimitExceeded and CircuitOpen.
+func IsTransient(err error) bool {
+	if err == nil {
+		// Finished unwrapping
+		return false
+	}
+
+	// Requests throttled or stopped by the API itself.
+	if errors.Is(err, RateLimitExceeded) || errors.Is(err, CircuitOpen) {
+		return true
+	}
+
+	// Connection errors, e.g. from dialing or resolving the address of the
+	// service registry. This includes *url.Error, as returned by Cloud Map.
+	// Requests canceled or timed out by the caller are not transient, even
+	// though they are returned as network errors as well.
+	{
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			return !errors.Is(err, context.Canceled) &&
+				!errors.Is(err, context.DeadlineExceeded)
+		}
+	}
+
+	// Service Directory and etcd gRPC errors.
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true
+	}
+
+	// Etcd errors
+	switch etcdErrorCode(err) {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true
+	}
+
+	// Cloud Map Errors
+	{
+		var (
+			rle    *types.RequestLimitExceeded
+			apiErr interface{ ErrorCode() string }
+		)
+
+		if errors.As(err, &rle) {
+			return true
+		}
+
+		if errors.As(err, &apiErr) {
+			code := apiErr.ErrorCode()
+			if awsThrottlingCodes[code] || code == "ServiceUnavailable" {
+				return true
+			}
+		}
+	}
+
+	return IsTransient(errors.Unwrap(err))
+}
+
+// etcdErrorCode returns the gRPC code of the etcd error, or OK if the error
+// is not an etcd one.
+func etcdErrorCode(err error) codes.Code {
+	var etcdErr rpctypes.EtcdError
+	if errors.As(err, &etcdErr) {
+		return etcdErr.Code()
+	}
+
+	return codes.OK
+}
@@ -19,6 +19,7 @@ package errors_test
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 
 	"github.com/CloudNativeSDWAN/serego/api/core"
@@ -73,7 +74,7 @@ func ExampleIsNotFound() {
 	}
 }
 
-func ExampleIsPermissionsError() {
+func ExampleIsPermissionDenied() {
 	var sr *core.ServiceRegistry
 	// Define sr here... (look at the documentation)
 
@@ -82,7 +83,7 @@ func ExampleIsPermissionsError() {
 		switch {
 		case errors.IsNotFound(err):
 			fmt.Println("namespace does not exist, do you want to create it?")
-		case errors.IsPermissionsError(err):
+		case errors.IsPermissionDenied(err):
 			fmt.Println("permission denied:", err)
 			return
 		default:
@@ -103,7 +104,7 @@ func ExampleIsAlreadyExists() {
 		switch {
 		case errors.IsAlreadyExists(err):
 			fmt.Println("namespace already exists")
-		case errors.IsPermissionsError(err):
+		case errors.IsPermissionDenied(err):
 			fmt.Println("permission denied:", err)
 		default:
 			// some other error happened which is dependent of the underlying service registry
@@ -115,3 +116,64 @@ func ExampleIsAlreadyExists() {
 
 	fmt.Println("namespace 'production' was created exist!")
 }
+
+func ExampleIsTransient() {
+	var sr *core.ServiceRegistry
+
+	err := sr.Namespace("production").Register(context.Background())
+	if err != nil {
+		switch {
+		case errors.IsTransient(err):
+			// The service registry may be unavailable or throttling
+			// requests: you may want to try again later, or to provide a
+			// retry policy to the wrapper to do it automatically.
+			fmt.Println("temporary error, try again later:", err)
+		default:
+			fmt.Println("error while registering namespace:", err)
+		}
+
+		return
+	}
+
+	fmt.Println("namespace 'production' was registered!")
+}
+
+func ExampleIsThrottled() {
+	var sr *core.ServiceRegistry
+
+	err := sr.Namespace("production").Register(context.Background())
+	if err != nil {
+		switch {
+		case errors.IsThrottled(err):
+			// You may want to lower the rate of your requests, e.g. with the
+			// WithRateLimit wrapper option.
+			fmt.Println("too many requests, slow down:", err)
+		case errors.IsInvalidArgument(err):
+			fmt.Println("the service registry does not accept the namespace:", err)
+		default:
+			fmt.Println("error while registering namespace:", err)
+		}
+
+		return
+	}
+
+	fmt.Println("namespace 'production' was registered!")
+}
+
+func ExampleOperationError() {
+	var sr *core.ServiceRegistry
+
+	_, err := sr.Namespace("production").Service("payroll").Get(context.Background())
+	if err != nil {
+		var opErr *errors.OperationError
+		if stderrors.As(err, &opErr) {
+			fmt.Printf("could not %s %s on %s: %s\n", opErr.Operation, opErr.Path, opErr.Backend, opErr.Err)
+			return
+		}
+
+		fmt.Println("error while getting service:", err)
+		return
+	}
+
+	fmt.Println("service 'payroll' does exist!")
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package errors
+
+import "fmt"
+
+// OperationError is returned by all calls to the service registry that
+// failed, and tells which operation was performed on which object and which
+// service registry, along with the error that caused it, which you can still
+// check with all other functions of this package.
+//
+// Errors returned before any call is performed, e.g. EmptyNamespaceName, are
+// never wrapped in an OperationError.
+type OperationError struct {
+	// Backend is the type of service registry, e.g. etcd.
+	Backend string
+	// Operation is the call that failed, e.g. get or create.
+	Operation string
+	// Object is the type of object, e.g. service, or transaction for
+	// transactions.
+	Object string
+	// Namespace, Service and Endpoint are the names of the object and its
+	// parents, if any. When listing objects only the names of their parents
+	// are set.
+	Namespace string
+	Service   string
+	Endpoint  string
+	// Path of the object, e.g. namespaces/sales/services/support.
+	Path string
+	// Err is the error that caused the operation to fail.
+	Err error
+}
+
+func (e *OperationError) Error() string {
+	target := e.Object
+	if e.Path != "" {
+		target = fmt.Sprintf("%s %s", e.Object, e.Path)
+	}
+
+	return fmt.Sprintf("%s: could not %s %s: %s", e.Backend, e.Operation, target, e.Err)
+}
+
+// Unwrap returns the error that caused the operation to fail.
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package breaker contains a circuit breaker that prevents wrappers from
+// performing calls to an unhealthy service registry.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+)
+
+type state int
+
+const (
+	closed state = iota
+	open
+	halfOpen
+)
+
+// Breaker opens after a number of consecutive calls failed with a transient
+// error, making all calls fail immediately until a timeout passes. After
+// that, a single call is allowed: the breaker closes if it succeeds and opens
+// again otherwise.
+//
+// A nil Breaker is valid and always allows calls, so that wrappers can use it
+// without checking if it was set in the options.
+type Breaker struct {
+	threshold   int
+	openTimeout time.Duration
+
+	lock     sync.Mutex
+	state    state
+	failures int
+	openedAt time.Time
+}
+
+// New returns a Breaker as defined in the provided options, or nil if no
+// circuit breaker is set.
+func New(wopts *wrapper.Options) *Breaker {
+	if wopts.CircuitBreaker == nil {
+		return nil
+	}
+
+	return &Breaker{
+		threshold:   wopts.CircuitBreaker.FailureThreshold,
+		openTimeout: wopts.CircuitBreaker.OpenTimeout,
+	}
+}
+
+// Allow returns nil if a call can be performed or errors.CircuitOpen if it
+// must not. Every call that is allowed must be followed by a call to Record
+// with its outcome.
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.state {
+	case open:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return srerr.CircuitOpen
+		}
+
+		// Let this call check if the service registry is healthy again,
+		// while others keep failing.
+		b.state = halfOpen
+		return nil
+	case halfOpen:
+		return srerr.CircuitOpen
+	default:
+		return nil
+	}
+}
+
+// Record updates the breaker with the outcome of a call that was allowed.
+// Only transient errors are considered failures, as any other error means
+// that the service registry is reachable.
+func (b *Breaker) Record(err error) {
+	if b == nil {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		// Nothing can be told about the service registry.
+		if b.state == halfOpen {
+			b.state = open
+		}
+	case srerr.IsTransient(err), errors.Is(err, context.DeadlineExceeded):
+		b.failures++
+		if b.state == halfOpen || b.failures >= b.threshold {
+			b.state = open
+			b.openedAt = time.Now()
+		}
+	default:
+		b.state = closed
+		b.failures = 0
+	}
+}
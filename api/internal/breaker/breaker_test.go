@@ -0,0 +1,83 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package breaker_test
+
+import (
+	"time"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/breaker"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("Circuit breaker", func() {
+	var (
+		transientErr = status.Error(codes.Unavailable, "unavailable")
+	)
+
+	It("always allows calls if nil", func() {
+		b := breaker.New(&wrapper.Options{})
+		Expect(b).To(BeNil())
+
+		for i := 0; i < 10; i++ {
+			Expect(b.Allow()).To(Succeed())
+			b.Record(transientErr)
+		}
+	})
+
+	It("opens after consecutive transient errors", func() {
+		b := breaker.New(&wrapper.Options{
+			CircuitBreaker: &wrapper.CircuitBreaker{FailureThreshold: 2, OpenTimeout: 20 * time.Millisecond},
+		})
+
+		By("counting only consecutive transient errors", func() {
+			Expect(b.Allow()).To(Succeed())
+			b.Record(transientErr)
+			Expect(b.Allow()).To(Succeed())
+			b.Record(srerr.NamespaceNotFound)
+			Expect(b.Allow()).To(Succeed())
+			b.Record(transientErr)
+			Expect(b.Allow()).To(Succeed())
+			b.Record(transientErr)
+			Expect(b.Allow()).To(MatchError(srerr.CircuitOpen))
+		})
+
+		By("allowing a single call after the timeout", func() {
+			time.Sleep(25 * time.Millisecond)
+			Expect(b.Allow()).To(Succeed())
+			Expect(b.Allow()).To(MatchError(srerr.CircuitOpen))
+		})
+
+		By("opening again if that call fails", func() {
+			b.Record(transientErr)
+			Expect(b.Allow()).To(MatchError(srerr.CircuitOpen))
+		})
+
+		By("closing if that call succeeds", func() {
+			time.Sleep(25 * time.Millisecond)
+			Expect(b.Allow()).To(Succeed())
+			b.Record(nil)
+			Expect(b.Allow()).To(Succeed())
+			Expect(b.Allow()).To(Succeed())
+		})
+	})
+})
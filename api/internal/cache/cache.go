@@ -0,0 +1,483 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cache contains the cache used by wrappers to store objects
+// retrieved from the service registry.
+package cache
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/logging"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	"github.com/go-logr/logr"
+)
+
+// maxWriters is how many objects can be written on the persistent cache at
+// the same time.
+const maxWriters = 8
+
+// Cache stores objects on the cache provided in the options for a limited
+// time and, optionally, keeps them for a grace period after they expire so
+// that they can be returned in case the service registry can't be reached.
+//
+// A nil Cache is valid and never stores anything, so that wrappers can use it
+// without checking if cache is disabled.
+type Cache struct {
+	items       wrapper.Cache
+	ttl         time.Duration
+	gracePeriod time.Duration
+	negativeTTL time.Duration
+	persistent  wrapper.PersistentCache
+	stats       wrapper.CacheStatsRecorder
+	peeker      wrapper.CachePeeker
+	metrics     *metrics.Metrics
+	logger      logr.Logger
+
+	// generation is increased by Purge, so that entries stored before it
+	// are ignored.
+	generation uint64
+
+	// Objects are saved on the persistent cache in background, not to slow
+	// down calls with a disk write each time the cache is filled. pending
+	// has the objects to save, or nil for those to delete, and writing the
+	// ones being saved, so that they are loaded even before they are saved.
+	// writing is nil when no goroutines are writing them.
+	lock    sync.Mutex
+	pending map[string]*persistedObject
+	writing map[string]*persistedObject
+}
+
+// entry is what is stored on cache for each key: either the object or, if
+// it was not found on the service registry, the error returned, so that a
+// single lookup tells both and storing the object when it is created replaces
+// the error.
+type entry struct {
+	object     interface{}
+	err        error
+	expires    time.Time
+	generation uint64
+}
+
+// persistedObject is how objects are saved on the persistent cache: only one
+// of its fields is set.
+type persistedObject struct {
+	Namespace *coretypes.Namespace `json:"namespace,omitempty"`
+	Service   *coretypes.Service   `json:"service,omitempty"`
+	Endpoint  *coretypes.Endpoint  `json:"endpoint,omitempty"`
+}
+
+// New returns a cache that stores objects for the provided time, and keeps
+// them for the grace period in the options after they expire.
+//
+// Objects are stored on the cache in the options or, if it is nil, on a new
+// LRU cache with the default maximum number of entries. Namespaces, services
+// and endpoints are also saved on the persistent cache in the options, if
+// any.
+func New(wopts *wrapper.Options, ttl time.Duration) *Cache {
+	items := wopts.Cache
+	if items == nil {
+		items, _ = wrapper.NewLRUCache(wrapper.DefaultCacheMaxEntries)
+	}
+
+	stats, _ := items.(wrapper.CacheStatsRecorder)
+	peeker, _ := items.(wrapper.CachePeeker)
+	return &Cache{
+		items:       items,
+		stats:       stats,
+		peeker:      peeker,
+		ttl:         ttl,
+		gracePeriod: wopts.StaleGracePeriod,
+		negativeTTL: wopts.NegativeCacheExpirationTime,
+		persistent:  wopts.PersistentCache,
+		logger:      logr.Discard(),
+	}
+}
+
+// SetMetrics makes the cache record the result of each lookup on the provided
+// metrics.
+func (c *Cache) SetMetrics(m *metrics.Metrics) {
+	if c != nil {
+		c.metrics = m
+	}
+}
+
+// SetLogger makes the cache log the result of each lookup, and objects
+// returned after they expired, with the provided logger.
+func (c *Cache) SetLogger(logger logr.Logger) {
+	if c != nil {
+		c.logger = logger
+	}
+}
+
+// Set stores the object with the default expiration time.
+func (c *Cache) Set(key string, object interface{}) {
+	if c == nil {
+		return
+	}
+
+	c.SetWithTTL(key, object, c.ttl)
+}
+
+// SetWithTTL stores the object with the provided expiration time instead of
+// the default one.
+func (c *Cache) SetWithTTL(key string, object interface{}, ttl time.Duration) {
+	if c == nil {
+		return
+	}
+
+	c.items.Set(key, &entry{
+		object:     object,
+		expires:    time.Now().Add(ttl),
+		generation: atomic.LoadUint64(&c.generation),
+	}, ttl+c.gracePeriod)
+	c.save(key, object)
+}
+
+// Get returns the object stored with the provided key, or nil if it is not
+// there, it is expired or it was not found on the service registry.
+func (c *Cache) Get(key string) interface{} {
+	object, _, _ := c.Lookup(key)
+	return object
+}
+
+// Lookup returns the object stored with the provided key and true or, if it
+// was not found on the service registry, the error stored with SetNotFound
+// and true. It returns false if there is nothing valid for the key.
+func (c *Cache) Lookup(key string) (interface{}, bool, error) {
+	if c == nil {
+		return nil, false, nil
+	}
+
+	e := c.get(key)
+	if e == nil || time.Now().After(e.expires) {
+		c.record(metrics.CacheMiss)
+		c.logger.V(logging.Debug).Info("object not found on cache", "key", key)
+		return nil, false, nil
+	}
+
+	if e.err != nil {
+		c.record(metrics.CacheNegativeHit)
+		c.logger.V(logging.Debug).Info("object found on cache as not existing", "key", key)
+		return nil, true, e.err
+	}
+
+	c.record(metrics.CacheHit)
+	c.logger.V(logging.Debug).Info("object found on cache", "key", key)
+	return e.object, true, nil
+}
+
+// Peek is like Get, but it does not record the lookup and, if the cache in
+// the options supports it, it does not mark the object as recently used.
+// Use it to look up objects for internal purposes, e.g. to check if they
+// have to be removed.
+func (c *Cache) Peek(key string) interface{} {
+	if c == nil {
+		return nil
+	}
+
+	var e *entry
+	if c.peeker != nil {
+		if value, found := c.peeker.Peek(key); found {
+			e = c.valid(key, value.(*entry))
+		}
+	} else {
+		e = c.get(key)
+	}
+
+	if e == nil || e.err != nil || time.Now().After(e.expires) {
+		return nil
+	}
+
+	return e.object
+}
+
+// Purge removes all objects, e.g. because they may have been modified on the
+// service registry without knowing which ones. Objects saved on the
+// persistent cache are kept, as they are only returned in case of errors.
+func (c *Cache) Purge() {
+	if c == nil {
+		return
+	}
+
+	atomic.AddUint64(&c.generation, 1)
+}
+
+// SetNotFound stores err as the result of getting the object with the
+// provided key, for the negative expiration time in the options. Storing the
+// object with the same key afterwards, e.g. because it was created, replaces
+// it. Without a negative expiration time, the object is just removed.
+func (c *Cache) SetNotFound(key string, err error) {
+	if c == nil {
+		return
+	}
+
+	// The object does not exist anymore.
+	c.persist(key, nil)
+
+	if c.negativeTTL <= 0 {
+		c.items.Delete(key)
+		return
+	}
+
+	c.items.Set(key, &entry{
+		err:        err,
+		expires:    time.Now().Add(c.negativeTTL),
+		generation: atomic.LoadUint64(&c.generation),
+	}, c.negativeTTL)
+}
+
+// GetStaleOnError returns the object stored with the provided key even if it
+// is expired, as long as it is still in its grace period and err is a
+// transient error. Namespaces, services and endpoints are returned as copies
+// with their Stale field set to true.
+//
+// If the object is not on cache, it is loaded from the persistent cache in
+// the options, if any.
+//
+// It returns nil if the object is not there or err is not transient, in which
+// case err should be returned instead.
+func (c *Cache) GetStaleOnError(key string, err error) interface{} {
+	if !srerr.IsTransient(err) {
+		return nil
+	}
+
+	var object interface{}
+	if e := c.get(key); e != nil {
+		if e.err != nil {
+			// The object does not exist.
+			return nil
+		}
+
+		object = e.object
+	} else {
+		object = c.load(key)
+	}
+
+	if object == nil {
+		return nil
+	}
+
+	c.logger.Info("returning object from cache after error", "key", key, "error", err.Error())
+	switch object := object.(type) {
+	case *coretypes.Namespace:
+		stale := object.Clone()
+		stale.Stale = true
+		return stale
+	case *coretypes.Service:
+		stale := object.Clone()
+		stale.Stale = true
+		return stale
+	case *coretypes.Endpoint:
+		stale := object.Clone()
+		stale.Stale = true
+		return stale
+	default:
+		return object
+	}
+}
+
+// Delete removes the object stored with the provided key.
+func (c *Cache) Delete(key string) {
+	if c == nil {
+		return
+	}
+
+	c.items.Delete(key)
+	c.persist(key, nil)
+}
+
+// record records the result of a lookup on the metrics and on the statistics
+// of the cache in the options, if it keeps them.
+func (c *Cache) record(result string) {
+	c.metrics.ObserveCacheLookup(result)
+	if c.stats != nil {
+		c.stats.RecordLookup(result != metrics.CacheMiss)
+	}
+}
+
+func (c *Cache) get(key string) *entry {
+	if c == nil {
+		return nil
+	}
+
+	e, found := c.items.Get(key)
+	if !found {
+		return nil
+	}
+
+	return c.valid(key, e.(*entry))
+}
+
+// valid returns the entry, or nil if it was stored before the last Purge, in
+// which case it is also removed.
+func (c *Cache) valid(key string, e *entry) *entry {
+	if e.generation != atomic.LoadUint64(&c.generation) {
+		c.items.Delete(key)
+		return nil
+	}
+
+	return e
+}
+
+// save saves namespaces, services and endpoints on the persistent cache.
+func (c *Cache) save(key string, object interface{}) {
+	switch object := object.(type) {
+	case *coretypes.Namespace:
+		c.persist(key, &persistedObject{Namespace: object})
+	case *coretypes.Service:
+		c.persist(key, &persistedObject{Service: object})
+	case *coretypes.Endpoint:
+		c.persist(key, &persistedObject{Endpoint: object})
+	}
+}
+
+// persist saves the object on the persistent cache or, if it is nil, deletes
+// the one saved with the provided key. This is done in background, by a
+// goroutine started only while there are objects to write.
+func (c *Cache) persist(key string, persisted *persistedObject) {
+	if c.persistent == nil {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.pending == nil {
+		c.pending = map[string]*persistedObject{}
+	}
+
+	c.pending[key] = persisted
+	if c.writing == nil {
+		c.writing = map[string]*persistedObject{}
+		go c.write()
+	}
+}
+
+// write writes the pending objects on the persistent cache until there are
+// no more. Up to maxWriters objects are written concurrently, so that
+// persistent caches that batch writes, like FileCache, can write them
+// together. Errors are only logged, as objects are on the in-memory cache
+// anyways.
+func (c *Cache) write() {
+	for {
+		c.lock.Lock()
+		if len(c.pending) == 0 {
+			c.writing = nil
+			c.lock.Unlock()
+			return
+		}
+
+		c.writing, c.pending = c.pending, map[string]*persistedObject{}
+		writing := c.writing
+		c.lock.Unlock()
+
+		keys := make(chan string)
+		var wg sync.WaitGroup
+		for i := 0; i < maxWriters && i < len(writing); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for key := range keys {
+					c.writeOne(key, writing[key])
+				}
+			}()
+		}
+
+		for key := range writing {
+			keys <- key
+		}
+
+		close(keys)
+		wg.Wait()
+	}
+}
+
+// writeOne saves the object on the persistent cache or, if it is nil,
+// deletes the one saved with the provided key.
+func (c *Cache) writeOne(key string, persisted *persistedObject) {
+	if persisted == nil {
+		if err := c.persistent.Delete(key); err != nil {
+			c.logger.Error(err, "could not delete object from persistent cache", "key", key)
+		}
+
+		return
+	}
+
+	data, err := json.Marshal(persisted)
+	if err != nil {
+		return
+	}
+
+	if err := c.persistent.Save(key, data); err != nil {
+		c.logger.Error(err, "could not save object on persistent cache", "key", key)
+	}
+}
+
+// load returns the object saved on the persistent cache with the provided
+// key, or nil if there is none.
+func (c *Cache) load(key string) interface{} {
+	if c == nil || c.persistent == nil {
+		return nil
+	}
+
+	persisted, found := c.unwritten(key)
+	if !found {
+		data, found := c.persistent.Load(key)
+		if !found {
+			return nil
+		}
+
+		persisted = &persistedObject{}
+		if err := json.Unmarshal(data, persisted); err != nil {
+			return nil
+		}
+	}
+
+	switch {
+	case persisted == nil:
+		return nil
+	case persisted.Namespace != nil:
+		return persisted.Namespace
+	case persisted.Service != nil:
+		return persisted.Service
+	case persisted.Endpoint != nil:
+		return persisted.Endpoint
+	}
+
+	return nil
+}
+
+// unwritten returns the object with the provided key that is not written on
+// the persistent cache yet, or nil and true if it is going to be deleted.
+func (c *Cache) unwritten(key string) (*persistedObject, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if persisted, found := c.pending[key]; found {
+		return persisted, true
+	}
+
+	persisted, found := c.writing[key]
+	return persisted, found
+}
@@ -0,0 +1,350 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/cache"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache", func() {
+	var (
+		ns = &coretypes.Namespace{
+			Name:     "ns",
+			Metadata: map[string]string{"key": "val"},
+		}
+	)
+
+	It("does nothing if nil", func() {
+		var c *cache.Cache
+		c.Set("ns", ns)
+		Expect(c.Get("ns")).To(BeNil())
+		Expect(c.GetStaleOnError("ns", srerr.CircuitOpen)).To(BeNil())
+		c.Delete("ns")
+	})
+
+	It("returns objects until they expire", func() {
+		c := cache.New(&wrapper.Options{}, 20*time.Millisecond)
+		c.Set("ns", ns)
+		Expect(c.Get("ns")).To(Equal(ns))
+
+		c.Delete("ns")
+		Expect(c.Get("ns")).To(BeNil())
+
+		c.Set("ns", ns)
+		Eventually(func() interface{} {
+			return c.Get("ns")
+		}).WithTimeout(time.Second).Should(BeNil())
+		Expect(c.GetStaleOnError("ns", srerr.CircuitOpen)).To(BeNil())
+	})
+
+	Context("with a grace period", func() {
+		It("returns stale copies of expired objects on transient errors", func() {
+			c := cache.New(&wrapper.Options{StaleGracePeriod: time.Hour}, time.Millisecond)
+			c.Set("ns", ns)
+			c.SetWithTTL("ns/id", "ns-id", time.Hour)
+			time.Sleep(2 * time.Millisecond)
+
+			Expect(c.Get("ns")).To(BeNil())
+			Expect(c.Get("ns/id")).To(Equal("ns-id"))
+			Expect(c.GetStaleOnError("ns", fmt.Errorf("whatever"))).To(BeNil())
+			Expect(c.GetStaleOnError("ns", srerr.CircuitOpen)).To(Equal(&coretypes.Namespace{
+				Name:     "ns",
+				Metadata: map[string]string{"key": "val"},
+				Stale:    true,
+			}))
+			Expect(ns.Stale).To(BeFalse())
+		})
+	})
+	It("logs lookups with the provided logger", func() {
+		logs := []string{}
+		c := cache.New(&wrapper.Options{
+			StaleGracePeriod:            time.Hour,
+			NegativeCacheExpirationTime: time.Hour,
+		}, time.Hour)
+		c.SetLogger(funcr.New(func(_, args string) {
+			logs = append(logs, args)
+		}, funcr.Options{Verbosity: 1}))
+
+		c.Set("ns", ns)
+		c.SetNotFound("another", srerr.NamespaceNotFound)
+		c.Get("ns")
+		c.Get("whatever")
+		c.Lookup("another")
+		c.GetStaleOnError("ns", srerr.CircuitOpen)
+		Expect(logs).To(Equal([]string{
+			`"level"=1 "msg"="object found on cache" "key"="ns"`,
+			`"level"=1 "msg"="object not found on cache" "key"="whatever"`,
+			`"level"=1 "msg"="object found on cache as not existing" "key"="another"`,
+			`"level"=0 "msg"="returning object from cache after error" "key"="ns" "error"="` + srerr.CircuitOpen.Error() + `"`,
+		}))
+	})
+
+	It("stores objects on the cache in the options", func() {
+		lru, _ := wrapper.NewLRUCache(1)
+		c := cache.New(&wrapper.Options{Cache: lru}, time.Hour)
+		c.Set("ns", ns)
+		c.Set("another", ns)
+
+		Expect(c.Get("ns")).To(BeNil())
+		Expect(c.Get("another")).To(Equal(ns))
+		Expect(lru.Stats()).To(Equal(wrapper.CacheStats{
+			Hits:      1,
+			Misses:    1,
+			Evictions: 1,
+			Entries:   1,
+		}))
+	})
+
+	It("records exactly one result for each lookup", func() {
+		lru, _ := wrapper.NewLRUCache(10)
+		c := cache.New(&wrapper.Options{
+			Cache:                       lru,
+			StaleGracePeriod:            time.Hour,
+			NegativeCacheExpirationTime: time.Hour,
+		}, time.Hour)
+		c.Set("fresh", ns)
+		c.SetWithTTL("expired", ns, -time.Second)
+		c.SetNotFound("not-found", srerr.NamespaceNotFound)
+
+		Expect(c.Get("fresh")).To(Equal(ns))
+		Expect(lru.Stats()).To(Equal(wrapper.CacheStats{Hits: 1, Entries: 3}))
+
+		Expect(c.Get("expired")).To(BeNil())
+		Expect(lru.Stats()).To(Equal(wrapper.CacheStats{Hits: 1, Misses: 1, Entries: 3}))
+
+		Expect(c.Get("missing")).To(BeNil())
+		Expect(lru.Stats()).To(Equal(wrapper.CacheStats{Hits: 1, Misses: 2, Entries: 3}))
+
+		_, found, err := c.Lookup("not-found")
+		Expect(found).To(BeTrue())
+		Expect(err).To(MatchError(srerr.NamespaceNotFound))
+		Expect(lru.Stats()).To(Equal(wrapper.CacheStats{Hits: 2, Misses: 2, Entries: 3}))
+
+		By("not recording peeks", func() {
+			Expect(c.Peek("fresh")).To(Equal(ns))
+			Expect(c.Peek("expired")).To(BeNil())
+			Expect(c.Peek("missing")).To(BeNil())
+			Expect(c.Peek("not-found")).To(BeNil())
+			Expect(lru.Stats()).To(Equal(wrapper.CacheStats{Hits: 2, Misses: 2, Entries: 3}))
+		})
+	})
+
+	It("removes all objects when purged", func() {
+		for _, wopts := range []*wrapper.Options{{}, {Cache: &mapCache{}}} {
+			c := cache.New(wopts, time.Hour)
+			c.Set("ns", ns)
+			c.Purge()
+			Expect(c.Peek("ns")).To(BeNil())
+			Expect(c.Get("ns")).To(BeNil())
+			Expect(c.GetStaleOnError("ns", srerr.CircuitOpen)).To(BeNil())
+
+			c.Set("ns", ns)
+			Expect(c.Get("ns")).To(Equal(ns))
+		}
+	})
+
+	It("remembers objects that were not found", func() {
+		lru, _ := wrapper.NewLRUCache(10)
+		c := cache.New(&wrapper.Options{Cache: lru, NegativeCacheExpirationTime: time.Hour}, time.Hour)
+		c.SetNotFound("ns", srerr.NamespaceNotFound)
+		object, found, err := c.Lookup("ns")
+		Expect(object).To(BeNil())
+		Expect(found).To(BeTrue())
+		Expect(err).To(MatchError(srerr.NamespaceNotFound))
+		Expect(c.Get("ns")).To(BeNil())
+		Expect(c.GetStaleOnError("ns", srerr.CircuitOpen)).To(BeNil())
+
+		c.Set("ns", ns)
+		object, found, err = c.Lookup("ns")
+		Expect(object).To(Equal(ns))
+		Expect(found).To(BeTrue())
+		Expect(err).NotTo(HaveOccurred())
+
+		// Both are answered by the same entry, with a single lookup each.
+		Expect(lru.Stats()).To(Equal(wrapper.CacheStats{Hits: 3, Entries: 1}))
+
+		By("not remembering them without a negative expiration time", func() {
+			c := cache.New(&wrapper.Options{}, time.Hour)
+			c.Set("ns", ns)
+			c.SetNotFound("ns", srerr.NamespaceNotFound)
+			_, found, err := c.Lookup("ns")
+			Expect(found).To(BeFalse())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Get("ns")).To(BeNil())
+		})
+	})
+
+	It("saves objects on the persistent cache in the options", func() {
+		fc, _ := wrapper.NewFileCache(filepath.Join(GinkgoT().TempDir(), "cache.db"), time.Hour)
+		defer fc.Close()
+
+		c := cache.New(&wrapper.Options{PersistentCache: fc}, time.Hour)
+		c.Set("ns", ns)
+		c.Set("ns-id", "not saved")
+		Eventually(func() bool {
+			_, saved := fc.Load("ns")
+			return saved
+		}).Should(BeTrue())
+		_, saved := fc.Load("ns-id")
+		Expect(saved).To(BeFalse())
+
+		By("returning them after a restart on transient errors", func() {
+			c := cache.New(&wrapper.Options{PersistentCache: fc}, time.Hour)
+			Expect(c.Get("ns")).To(BeNil())
+			Expect(c.GetStaleOnError("ns", fmt.Errorf("whatever"))).To(BeNil())
+
+			stale := c.GetStaleOnError("ns", srerr.CircuitOpen).(*coretypes.Namespace)
+			Expect(stale.Stale).To(BeTrue())
+			Expect(stale.DeepEqualTo(ns)).To(BeTrue())
+		})
+
+		By("removing them when they are deleted or not found", func() {
+			c.SetNotFound("ns", srerr.NamespaceNotFound)
+			Eventually(func() interface{} {
+				return cache.New(&wrapper.Options{PersistentCache: fc}, time.Hour).
+					GetStaleOnError("ns", srerr.CircuitOpen)
+			}).Should(BeNil())
+
+			c.Set("ns", ns)
+			c.Delete("ns")
+			Expect(c.GetStaleOnError("ns", srerr.CircuitOpen)).To(BeNil())
+			Eventually(func() interface{} {
+				return cache.New(&wrapper.Options{PersistentCache: fc}, time.Hour).
+					GetStaleOnError("ns", srerr.CircuitOpen)
+			}).Should(BeNil())
+		})
+	})
+
+	It("returns objects not saved on the persistent cache yet", func() {
+		fc, _ := wrapper.NewFileCache(filepath.Join(GinkgoT().TempDir(), "cache.db"), time.Hour)
+		defer fc.Close()
+
+		c := cache.New(&wrapper.Options{PersistentCache: fc}, time.Hour)
+		for i := 0; i < 100; i++ {
+			c.Set(fmt.Sprintf("ns-%d", i), ns)
+		}
+
+		// Only the persistent cache has them now.
+		c = cache.New(&wrapper.Options{PersistentCache: fc}, time.Hour)
+		Eventually(func() interface{} {
+			return c.GetStaleOnError("ns-99", srerr.CircuitOpen)
+		}).ShouldNot(BeNil())
+	})
+
+	It("writes a limited number of objects at once on the persistent cache", func() {
+		sc := &slowCache{}
+		c := cache.New(&wrapper.Options{PersistentCache: sc}, time.Hour)
+		for i := 0; i < 100; i++ {
+			c.Set(fmt.Sprintf("ns-%d", i), ns)
+		}
+
+		Eventually(func() int {
+			sc.lock.Lock()
+			defer sc.lock.Unlock()
+			return sc.saved
+		}).Should(Equal(100))
+		Expect(sc.maxInFlight).To(BeNumerically("<=", 8))
+	})
+
+	It("returns stale objects when the service registry is unreachable", func() {
+		c := cache.New(&wrapper.Options{StaleGracePeriod: time.Hour}, time.Hour)
+		c.SetWithTTL("ns", ns, -time.Second)
+
+		_, err := net.Dial("tcp", "127.0.0.1:1")
+		Expect(err).To(HaveOccurred())
+		Expect(c.GetStaleOnError("ns", fmt.Errorf("cannot get namespace: %w", err))).NotTo(BeNil())
+
+		_, err = http.Get("http://127.0.0.1:1")
+		Expect(err).To(HaveOccurred())
+		Expect(c.GetStaleOnError("ns", err)).NotTo(BeNil())
+
+		By("not returning them if the request was canceled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://127.0.0.1:1", nil)
+			_, err := http.DefaultClient.Do(req)
+			Expect(err).To(HaveOccurred())
+			Expect(c.GetStaleOnError("ns", err)).To(BeNil())
+		})
+	})
+})
+
+// mapCache is a cache that can't peek values nor record statistics.
+type mapCache struct {
+	items sync.Map
+}
+
+func (m *mapCache) Get(key string) (interface{}, bool) {
+	return m.items.Load(key)
+}
+
+func (m *mapCache) Set(key string, value interface{}, _ time.Duration) {
+	m.items.Store(key, value)
+}
+
+func (m *mapCache) Delete(key string) {
+	m.items.Delete(key)
+}
+
+// slowCache is a persistent cache that takes a while to save each object and
+// records how many of them are saved at the same time.
+type slowCache struct {
+	lock        sync.Mutex
+	inFlight    int
+	maxInFlight int
+	saved       int
+}
+
+func (s *slowCache) Load(_ string) ([]byte, bool) {
+	return nil, false
+}
+
+func (s *slowCache) Save(_ string, _ []byte) error {
+	s.lock.Lock()
+	s.inFlight++
+	if s.inFlight > s.maxInFlight {
+		s.maxInFlight = s.inFlight
+	}
+	s.lock.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.inFlight--
+	s.saved++
+	return nil
+}
+
+func (s *slowCache) Delete(_ string) error {
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Group coalesces concurrent calls with the same key, so that only one of
+// them is actually performed and its result is shared with all the others.
+//
+// The zero value is ready to use.
+type Group struct {
+	calls singleflight.Group
+}
+
+// Do performs fetch with the provided context, unless another call with the
+// same key is already in progress, in which case it waits for it and returns
+// its result.
+//
+// If the call that was in progress failed because its own context was done,
+// fetch is performed again with the provided context, so that callers are
+// not affected by others canceling their calls.
+func (g *Group) Do(ctx context.Context, key string, fetch func(context.Context) (interface{}, error)) (interface{}, error) {
+	result, err, shared := g.calls.Do(key, func() (interface{}, error) {
+		return fetch(ctx)
+	})
+
+	if shared && ctx.Err() == nil &&
+		(errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return fetch(ctx)
+	}
+
+	return result, err
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/CloudNativeSDWAN/serego/api/internal/cache"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Group", func() {
+	var (
+		group   *cache.Group
+		lock    sync.Mutex
+		calls   int
+		release chan struct{}
+	)
+
+	BeforeEach(func() {
+		group = &cache.Group{}
+		calls = 0
+		release = make(chan struct{})
+	})
+
+	fetch := func(ctx context.Context) (interface{}, error) {
+		lock.Lock()
+		calls++
+		lock.Unlock()
+
+		select {
+		case <-release:
+			return "value", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	waitCalls := func(expected int) {
+		Eventually(func() int {
+			lock.Lock()
+			defer lock.Unlock()
+			return calls
+		}).Should(Equal(expected))
+	}
+
+	It("shares the result of concurrent calls with the same key", func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				value, err := group.Do(context.Background(), "key", fetch)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(value).To(Equal("value"))
+			}()
+		}
+
+		waitCalls(1)
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		Expect(calls).To(Equal(1))
+
+		By("calling again once they are done", func() {
+			_, err := group.Do(context.Background(), "key", func(context.Context) (interface{}, error) {
+				return nil, fmt.Errorf("whatever")
+			})
+			Expect(err).To(MatchError("whatever"))
+		})
+	})
+
+	It("calls again if the shared call was canceled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			_, err := group.Do(ctx, "key", fetch)
+			done <- err
+		}()
+		waitCalls(1)
+
+		result := make(chan interface{})
+		go func() {
+			value, _ := group.Do(context.Background(), "key", fetch)
+			result <- value
+		}()
+		time.Sleep(20 * time.Millisecond)
+
+		cancel()
+		Expect(<-done).To(MatchError(context.Canceled))
+		waitCalls(2)
+		close(release)
+		Expect(<-result).To(Equal("value"))
+	})
+})
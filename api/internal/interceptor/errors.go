@@ -0,0 +1,56 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package interceptor
+
+import (
+	"context"
+	"errors"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+)
+
+// WrapErrors returns an interceptor that wraps the errors returned by calls
+// in an errors.OperationError with the provided backend and the information
+// about the call.
+//
+// Errors that are already an OperationError and IteratorDone, which is not
+// an actual failure, are returned as they are.
+func WrapErrors(backend string) Interceptor {
+	return func(ctx context.Context, call *Call, next Handler) error {
+		err := next(ctx)
+		if err == nil || srerr.IsIteratorDone(err) {
+			return err
+		}
+
+		var opErr *srerr.OperationError
+		if errors.As(err, &opErr) {
+			return err
+		}
+
+		return &srerr.OperationError{
+			Backend:   backend,
+			Operation: string(call.Operation),
+			Object:    string(call.Object),
+			Namespace: call.Namespace,
+			Service:   call.Service,
+			Endpoint:  call.Endpoint,
+			Path:      call.Path,
+			Err:       err,
+		}
+	}
+}
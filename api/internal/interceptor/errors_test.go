@@ -0,0 +1,125 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package interceptor_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/interceptor"
+	"github.com/aws/aws-sdk-go-v2/service/servicediscovery/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("WrapErrors", func() {
+	var (
+		ctx  = context.TODO()
+		wrap = interceptor.WrapErrors("etcd")
+		call = &interceptor.Call{
+			Operation: interceptor.Get,
+			Object:    interceptor.ServiceObject,
+			Namespace: "ns",
+			Service:   "serv",
+			Path:      "namespaces/ns/services/serv",
+		}
+	)
+
+	It("wraps errors with the information about the call", func() {
+		err := wrap(ctx, call, func(context.Context) error {
+			return fmt.Errorf("could not get it: %w", srerr.ServiceNotFound)
+		})
+
+		var opErr *srerr.OperationError
+		Expect(errors.As(err, &opErr)).To(BeTrue())
+		Expect(opErr.Backend).To(Equal("etcd"))
+		Expect(opErr.Operation).To(Equal("get"))
+		Expect(opErr.Object).To(Equal("service"))
+		Expect(opErr.Namespace).To(Equal("ns"))
+		Expect(opErr.Service).To(Equal("serv"))
+		Expect(opErr.Endpoint).To(BeEmpty())
+		Expect(opErr.Path).To(Equal("namespaces/ns/services/serv"))
+		Expect(err).To(MatchError("etcd: could not get service namespaces/ns/services/serv: could not get it: service not found"))
+		Expect(srerr.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("does not wrap errors twice", func() {
+		opErr := &srerr.OperationError{Backend: "custom", Err: srerr.Conflict}
+		err := wrap(ctx, call, func(context.Context) error {
+			return opErr
+		})
+		Expect(err).To(BeIdenticalTo(opErr))
+	})
+
+	It("does not wrap successful calls and the end of iterators", func() {
+		Expect(wrap(ctx, call, func(context.Context) error {
+			return nil
+		})).To(Succeed())
+
+		err := wrap(ctx, &interceptor.Call{Operation: interceptor.List}, func(context.Context) error {
+			return srerr.IteratorDone
+		})
+		Expect(err).To(BeIdenticalTo(srerr.IteratorDone))
+	})
+
+	It("keeps the error checkable with the errors package", func() {
+		for _, cause := range []error{
+			srerr.NamespaceAlreadyExists,
+			srerr.RateLimitExceeded,
+			srerr.InvalidPort,
+		} {
+			err := wrap(ctx, call, func(context.Context) error {
+				return cause
+			})
+			Expect(err).To(MatchError(cause))
+		}
+
+		err := wrap(ctx, call, func(context.Context) error {
+			return srerr.RateLimitExceeded
+		})
+		Expect(srerr.IsThrottled(err)).To(BeTrue())
+		Expect(srerr.IsTransient(err)).To(BeTrue())
+		Expect(srerr.IsInvalidArgument(err)).To(BeFalse())
+
+		checks := []struct {
+			cause error
+			is    func(error) bool
+		}{
+			{status.Error(codes.PermissionDenied, "denied"), srerr.IsPermissionDenied},
+			{rpctypes.ErrPermissionDenied, srerr.IsPermissionDenied},
+			{status.Error(codes.AlreadyExists, "exists"), srerr.IsAlreadyExists},
+			{&types.ServiceAlreadyExists{}, srerr.IsAlreadyExists},
+			{rpctypes.ErrTooManyRequests, srerr.IsThrottled},
+			{&types.RequestLimitExceeded{}, srerr.IsThrottled},
+			{status.Error(codes.InvalidArgument, "invalid"), srerr.IsInvalidArgument},
+			{rpctypes.ErrEmptyKey, srerr.IsInvalidArgument},
+			{&types.InvalidInput{}, srerr.IsInvalidArgument},
+		}
+		for _, check := range checks {
+			err := wrap(ctx, call, func(context.Context) error {
+				return check.cause
+			})
+			Expect(check.is(err)).To(BeTrue(), "%v", check.cause)
+		}
+	})
+})
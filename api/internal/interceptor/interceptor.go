@@ -0,0 +1,120 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package interceptor contains code that decorates a service registry
+// wrapper so that each one of its calls goes through a chain of
+// interceptors, e.g. to retry calls that failed or to limit their rate.
+package interceptor
+
+import (
+	"context"
+
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+)
+
+// Aliases of the types in the wrapper options, so that interceptors provided
+// by users are called like the ones defined here.
+type (
+	Operation   = wrapper.Operation
+	Object      = wrapper.Object
+	Call        = wrapper.Call
+	Handler     = wrapper.Handler
+	Interceptor = wrapper.Interceptor
+)
+
+const (
+	Get    = wrapper.GetOperation
+	Create = wrapper.CreateOperation
+	Update = wrapper.UpdateOperation
+	Delete = wrapper.DeleteOperation
+	List   = wrapper.ListOperation
+	Commit = wrapper.CommitOperation
+)
+
+const (
+	NamespaceObject   = wrapper.NamespaceObject
+	ServiceObject     = wrapper.ServiceObject
+	EndpointObject    = wrapper.EndpointObject
+	TransactionObject = wrapper.TransactionObject
+)
+
+// Chain returns an interceptor that calls all the provided ones in order,
+// i.e. the first one is the outermost.
+func Chain(interceptors ...Interceptor) Interceptor {
+	return func(ctx context.Context, call *Call, next Handler) error {
+		handler := next
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			intc, nextHandler := interceptors[i], handler
+			handler = func(ctx context.Context) error {
+				return intc(ctx, call, nextHandler)
+			}
+		}
+
+		return handler(ctx)
+	}
+}
+
+// Wrap returns a wrapper that performs each call of the provided one through
+// the provided interceptors, in order. If no interceptor is provided the
+// same wrapper is returned.
+//
+// If the wrapper provided supports transactions, the returned one does
+// as well.
+func Wrap(wrapper ops.ServiceRegistryWrapper, interceptors ...Interceptor) ops.ServiceRegistryWrapper {
+	if len(interceptors) == 0 {
+		return wrapper
+	}
+
+	intcWrapper := &interceptedWrapper{
+		wrapper:   wrapper,
+		intercept: Chain(interceptors...),
+	}
+
+	if txner, ok := wrapper.(ops.Transactioner); ok {
+		return &interceptedTxnWrapper{
+			interceptedWrapper: intcWrapper,
+			txner:              txner,
+		}
+	}
+
+	return intcWrapper
+}
+
+type interceptedWrapper struct {
+	wrapper   ops.ServiceRegistryWrapper
+	intercept Interceptor
+}
+
+func (w *interceptedWrapper) Namespace(name string) ops.NamespaceOperation {
+	return &namespaceOperation{
+		op:        w.wrapper.Namespace(name),
+		name:      name,
+		intercept: w.intercept,
+	}
+}
+
+type interceptedTxnWrapper struct {
+	*interceptedWrapper
+	txner ops.Transactioner
+}
+
+func (w *interceptedTxnWrapper) Commit(ctx context.Context, steps []ops.TxnStep) error {
+	return w.intercept(ctx, &Call{Operation: Commit, Object: TransactionObject}, func(ctx context.Context) error {
+		return w.txner.Commit(ctx, steps)
+	})
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package interceptor_test
+
+import (
+	"context"
+
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	"github.com/CloudNativeSDWAN/serego/api/internal/interceptor"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/fake"
+	"github.com/CloudNativeSDWAN/serego/api/options/get"
+	"github.com/CloudNativeSDWAN/serego/api/options/list"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Interceptors", func() {
+	var (
+		ctx    = context.TODO()
+		calls  []interceptor.Call
+		record = func(ctx context.Context, call *interceptor.Call, next interceptor.Handler) error {
+			err := next(ctx)
+			calls = append(calls, *call)
+			return err
+		}
+		wrp *fake.FakeWrapper
+	)
+
+	BeforeEach(func() {
+		calls = []interceptor.Call{}
+		endpop := &fake.EndpointOperation{
+			Delete_: func(_ context.Context) error {
+				return nil
+			},
+		}
+		servop := &fake.ServiceOperation{
+			Get_: func(_ context.Context, _ *get.Options) (*coretypes.Service, error) {
+				return &coretypes.Service{Name: "serv", Namespace: "ns"}, nil
+			},
+			Endpoint_: func(string) ops.EndpointOperation {
+				return endpop
+			},
+		}
+		nsop := &fake.NamespaceOperation{
+			List_: func(_ *list.Options) ops.NamespaceLister {
+				return &fake.FakeNamespaceIterator{
+					Next_: func(_ context.Context) (*coretypes.Namespace, ops.NamespaceOperation, error) {
+						return &coretypes.Namespace{Name: "listed"}, &fake.NamespaceOperation{}, nil
+					},
+				}
+			},
+			Service_: func(string) ops.ServiceOperation {
+				return servop
+			},
+		}
+		wrp, _ = fake.NewFakeWrapper()
+		wrp.Namespace_ = func(string) ops.NamespaceOperation {
+			return nsop
+		}
+	})
+
+	It("returns the same wrapper if no interceptors are provided", func() {
+		Expect(interceptor.Wrap(wrp)).To(BeIdenticalTo(wrp))
+	})
+
+	It("calls the interceptors with the call information", func() {
+		w := interceptor.Wrap(wrp, record)
+
+		getOpts, listOpts := &get.Options{}, &list.Options{}
+		serv, err := w.Namespace("ns").Service("serv").Get(ctx, getOpts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(serv).To(Equal(&coretypes.Service{Name: "serv", Namespace: "ns"}))
+
+		err = w.Namespace("ns").Service("serv").Endpoint("endp").Delete(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		ns, nsop, err := w.Namespace("").List(listOpts).Next(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		_, ok := nsop.(*fake.NamespaceOperation)
+		Expect(ok).To(BeFalse())
+
+		Expect(calls).To(Equal([]interceptor.Call{
+			{
+				Operation: interceptor.Get,
+				Object:    interceptor.ServiceObject,
+				Namespace: "ns",
+				Service:   "serv",
+				Path:      "namespaces/ns/services/serv",
+				Options:   getOpts,
+				Result:    serv,
+			},
+			{
+				Operation: interceptor.Delete,
+				Object:    interceptor.EndpointObject,
+				Namespace: "ns",
+				Service:   "serv",
+				Endpoint:  "endp",
+				Path:      "namespaces/ns/services/serv/endpoints/endp",
+			},
+			{
+				Operation: interceptor.List,
+				Object:    interceptor.NamespaceObject,
+				Path:      "namespaces",
+				Options:   listOpts,
+				Result:    ns,
+			},
+		}))
+	})
+
+	It("calls the interceptors in order", func() {
+		order := []string{}
+		named := func(name string) interceptor.Interceptor {
+			return func(ctx context.Context, call *interceptor.Call, next interceptor.Handler) error {
+				order = append(order, name)
+				err := next(ctx)
+				order = append(order, name)
+				return err
+			}
+		}
+
+		w := interceptor.Wrap(wrp, named("first"), named("second"))
+		_, err := w.Namespace("ns").Service("serv").Get(ctx, &get.Options{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(order).To(Equal([]string{"first", "second", "second", "first"}))
+	})
+
+	It("preserves transactions", func() {
+		txnWrp, _ := fake.NewFakeTransactionalWrapper()
+		committed := false
+		txnWrp.Commit_ = func(_ context.Context, _ []ops.TxnStep) error {
+			committed = true
+			return nil
+		}
+
+		_, ok := interceptor.Wrap(wrp, record).(ops.Transactioner)
+		Expect(ok).To(BeFalse())
+
+		txner, ok := interceptor.Wrap(txnWrp, record).(ops.Transactioner)
+		Expect(ok).To(BeTrue())
+		Expect(txner.Commit(ctx, []ops.TxnStep{})).To(Succeed())
+		Expect(committed).To(BeTrue())
+		Expect(calls).To(Equal([]interceptor.Call{
+			{Operation: interceptor.Commit, Object: interceptor.TransactionObject},
+		}))
+	})
+})
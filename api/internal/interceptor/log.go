@@ -0,0 +1,66 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"github.com/CloudNativeSDWAN/serego/api/internal/logging"
+	"github.com/go-logr/logr"
+)
+
+// Log returns an interceptor that logs each call with the provided logger,
+// along with how long it took and the error it returned, if any.
+//
+// List calls are not logged, as most of them do not perform any call to the
+// service registry.
+func Log(logger logr.Logger) Interceptor {
+	return func(ctx context.Context, call *Call, next Handler) error {
+		if call.Operation == List || !logger.V(logging.Debug).Enabled() {
+			return next(ctx)
+		}
+
+		start := time.Now()
+		err := next(ctx)
+
+		keysAndValues := []interface{}{
+			"operation", call.Operation,
+			"object", call.Object,
+		}
+		if call.Namespace != "" {
+			keysAndValues = append(keysAndValues, "namespace", call.Namespace)
+		}
+
+		if call.Service != "" {
+			keysAndValues = append(keysAndValues, "service", call.Service)
+		}
+
+		if call.Endpoint != "" {
+			keysAndValues = append(keysAndValues, "endpoint", call.Endpoint)
+		}
+
+		keysAndValues = append(keysAndValues, "duration", time.Since(start))
+		if err != nil {
+			keysAndValues = append(keysAndValues, "error", err.Error())
+		}
+
+		logger.V(logging.Debug).Info("performed call", keysAndValues...)
+		return err
+	}
+}
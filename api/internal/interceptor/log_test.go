@@ -0,0 +1,75 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package interceptor_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CloudNativeSDWAN/serego/api/internal/interceptor"
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Log", func() {
+	var (
+		ctx  = context.TODO()
+		logs []string
+	)
+
+	newLogger := func(verbosity int) interceptor.Interceptor {
+		return interceptor.Log(funcr.New(func(_, args string) {
+			logs = append(logs, args)
+		}, funcr.Options{Verbosity: verbosity}))
+	}
+
+	BeforeEach(func() {
+		logs = []string{}
+	})
+
+	It("logs each call", func() {
+		call := &interceptor.Call{
+			Operation: interceptor.Delete,
+			Object:    interceptor.ServiceObject,
+			Namespace: "ns",
+			Service:   "serv",
+		}
+		Expect(newLogger(1)(ctx, call, func(context.Context) error {
+			return fmt.Errorf("whatever")
+		})).To(MatchError("whatever"))
+
+		Expect(logs).To(HaveLen(1))
+		Expect(logs[0]).To(HavePrefix(`"level"=1 "msg"="performed call" "operation"="delete" "object"="service" "namespace"="ns" "service"="serv" "duration"=`))
+		Expect(logs[0]).To(HaveSuffix(`"error"="whatever"`))
+	})
+
+	It("does not log list calls or with lower verbosity", func() {
+		listCall := &interceptor.Call{Operation: interceptor.List, Object: interceptor.EndpointObject}
+		Expect(newLogger(1)(ctx, listCall, func(context.Context) error {
+			return nil
+		})).To(Succeed())
+
+		getCall := &interceptor.Call{Operation: interceptor.Get, Object: interceptor.NamespaceObject, Namespace: "ns"}
+		Expect(newLogger(0)(ctx, getCall, func(context.Context) error {
+			return nil
+		})).To(Succeed())
+
+		Expect(logs).To(BeEmpty())
+	})
+})
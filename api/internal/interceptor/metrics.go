@@ -0,0 +1,51 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
+)
+
+// Measure returns an interceptor that records the number and duration of
+// calls on the provided metrics, except for the ones of the operations to
+// skip, e.g. because the wrapper records them itself only when they are
+// performed on the service registry, and not served from cache.
+//
+// List calls are never recorded, as wrappers record each page they list
+// instead.
+func Measure(m *metrics.Metrics, skip ...Operation) Interceptor {
+	return func(ctx context.Context, call *Call, next Handler) error {
+		if call.Operation == List {
+			return next(ctx)
+		}
+
+		for _, op := range skip {
+			if call.Operation == op {
+				return next(ctx)
+			}
+		}
+
+		start := time.Now()
+		err := next(ctx)
+		m.ObserveOperation(string(call.Object), string(call.Operation), start, err)
+		return err
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package interceptor_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/CloudNativeSDWAN/serego/api/internal/interceptor"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("Measure", func() {
+	var (
+		ctx = context.TODO()
+		reg *prometheus.Registry
+		m   *metrics.Metrics
+	)
+
+	BeforeEach(func() {
+		reg = prometheus.NewRegistry()
+		m, _ = metrics.New(&wrapper.Options{MetricsRegisterer: reg}, metrics.Etcd)
+	})
+
+	It("records the outcome of each call", func() {
+		getCall := &interceptor.Call{Operation: interceptor.Get, Object: interceptor.ServiceObject}
+		Expect(interceptor.Measure(m)(ctx, getCall, func(context.Context) error {
+			return nil
+		})).To(Succeed())
+
+		expErr := fmt.Errorf("whatever")
+		Expect(interceptor.Measure(m)(ctx, getCall, func(context.Context) error {
+			return expErr
+		})).To(MatchError(expErr))
+
+		Expect(testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP serego_operations_total Number of operations performed on the service registry.
+# TYPE serego_operations_total counter
+serego_operations_total{backend="etcd",object="service",operation="get",result="error"} 1
+serego_operations_total{backend="etcd",object="service",operation="get",result="success"} 1
+`), "serego_operations_total")).To(Succeed())
+	})
+
+	It("does not record calls of the operations to skip", func() {
+		getCall := &interceptor.Call{Operation: interceptor.Get, Object: interceptor.ServiceObject}
+		Expect(interceptor.Measure(m, interceptor.Get)(ctx, getCall, func(context.Context) error {
+			return nil
+		})).To(Succeed())
+
+		count, err := testutil.GatherAndCount(reg, "serego_operations_total")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(BeZero())
+	})
+
+	It("does not record list calls", func() {
+		listCall := &interceptor.Call{Operation: interceptor.List, Object: interceptor.EndpointObject}
+		Expect(interceptor.Measure(m)(ctx, listCall, func(context.Context) error {
+			return nil
+		})).To(Succeed())
+
+		count, err := testutil.GatherAndCount(reg, "serego_operations_total")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(BeZero())
+	})
+})
@@ -0,0 +1,376 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package interceptor
+
+import (
+	"context"
+	"path"
+
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/options/get"
+	"github.com/CloudNativeSDWAN/serego/api/options/list"
+)
+
+const (
+	pathNamespaces string = "namespaces"
+	pathServices   string = "services"
+	pathEndpoints  string = "endpoints"
+)
+
+// setResult sets the object returned by a call as its result, if it
+// succeeded.
+func setResult(call *Call, object interface{}, err error) {
+	if err == nil {
+		call.Result = object
+	}
+}
+
+type namespaceOperation struct {
+	op        ops.NamespaceOperation
+	name      string
+	intercept Interceptor
+}
+
+func (n *namespaceOperation) call(operation Operation) *Call {
+	return &Call{
+		Operation: operation,
+		Object:    NamespaceObject,
+		Namespace: n.name,
+		Path:      path.Join(pathNamespaces, n.name),
+	}
+}
+
+func (n *namespaceOperation) Get(ctx context.Context, opts *get.Options) (ns *coretypes.Namespace, err error) {
+	call := n.call(Get)
+	call.Options = opts
+	err = n.intercept(ctx, call, func(ctx context.Context) (opErr error) {
+		ns, opErr = n.op.Get(ctx, opts)
+		setResult(call, ns, opErr)
+		return
+	})
+
+	return
+}
+
+func (n *namespaceOperation) Create(ctx context.Context, metadata map[string]string) (ns *coretypes.Namespace, err error) {
+	call := n.call(Create)
+	call.Metadata = metadata
+	err = n.intercept(ctx, call, func(ctx context.Context) (opErr error) {
+		ns, opErr = n.op.Create(ctx, metadata)
+		setResult(call, ns, opErr)
+		return
+	})
+
+	return
+}
+
+func (n *namespaceOperation) Update(ctx context.Context, metadata map[string]string, expectedRevision string) (ns *coretypes.Namespace, err error) {
+	call := n.call(Update)
+	call.Metadata, call.ExpectedRevision = metadata, expectedRevision
+	err = n.intercept(ctx, call, func(ctx context.Context) (opErr error) {
+		ns, opErr = n.op.Update(ctx, metadata, expectedRevision)
+		setResult(call, ns, opErr)
+		return
+	})
+
+	return
+}
+
+func (n *namespaceOperation) Delete(ctx context.Context) error {
+	return n.intercept(ctx, n.call(Delete), func(ctx context.Context) error {
+		return n.op.Delete(ctx)
+	})
+}
+
+func (n *namespaceOperation) List(opts *list.Options) ops.NamespaceLister {
+	return &namespaceLister{
+		lister:    n.op.List(opts),
+		options:   opts,
+		intercept: n.intercept,
+	}
+}
+
+func (n *namespaceOperation) Service(name string) ops.ServiceOperation {
+	return &serviceOperation{
+		op:        n.op.Service(name),
+		nsName:    n.name,
+		name:      name,
+		intercept: n.intercept,
+	}
+}
+
+type namespaceLister struct {
+	lister    ops.NamespaceLister
+	options   *list.Options
+	intercept Interceptor
+}
+
+func (l *namespaceLister) Next(ctx context.Context) (ns *coretypes.Namespace, nsop ops.NamespaceOperation, err error) {
+	call := &Call{
+		Operation: List,
+		Object:    NamespaceObject,
+		Path:      pathNamespaces,
+		Options:   l.options,
+	}
+	err = l.intercept(ctx, call, func(ctx context.Context) (opErr error) {
+		ns, nsop, opErr = l.lister.Next(ctx)
+		setResult(call, ns, opErr)
+		return
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ns, &namespaceOperation{
+		op:        nsop,
+		name:      ns.Name,
+		intercept: l.intercept,
+	}, nil
+}
+
+func (l *namespaceLister) NextPageToken() string {
+	return ops.NextPageToken(l.lister)
+}
+
+type serviceOperation struct {
+	op        ops.ServiceOperation
+	nsName    string
+	name      string
+	intercept Interceptor
+}
+
+func (s *serviceOperation) call(operation Operation) *Call {
+	return &Call{
+		Operation: operation,
+		Object:    ServiceObject,
+		Namespace: s.nsName,
+		Service:   s.name,
+		Path:      path.Join(pathNamespaces, s.nsName, pathServices, s.name),
+	}
+}
+
+func (s *serviceOperation) Get(ctx context.Context, opts *get.Options) (serv *coretypes.Service, err error) {
+	call := s.call(Get)
+	call.Options = opts
+	err = s.intercept(ctx, call, func(ctx context.Context) (opErr error) {
+		serv, opErr = s.op.Get(ctx, opts)
+		setResult(call, serv, opErr)
+		return
+	})
+
+	return
+}
+
+func (s *serviceOperation) Create(ctx context.Context, metadata map[string]string) (serv *coretypes.Service, err error) {
+	call := s.call(Create)
+	call.Metadata = metadata
+	err = s.intercept(ctx, call, func(ctx context.Context) (opErr error) {
+		serv, opErr = s.op.Create(ctx, metadata)
+		setResult(call, serv, opErr)
+		return
+	})
+
+	return
+}
+
+func (s *serviceOperation) Update(ctx context.Context, metadata map[string]string, expectedRevision string) (serv *coretypes.Service, err error) {
+	call := s.call(Update)
+	call.Metadata, call.ExpectedRevision = metadata, expectedRevision
+	err = s.intercept(ctx, call, func(ctx context.Context) (opErr error) {
+		serv, opErr = s.op.Update(ctx, metadata, expectedRevision)
+		setResult(call, serv, opErr)
+		return
+	})
+
+	return
+}
+
+func (s *serviceOperation) Delete(ctx context.Context) error {
+	return s.intercept(ctx, s.call(Delete), func(ctx context.Context) error {
+		return s.op.Delete(ctx)
+	})
+}
+
+func (s *serviceOperation) List(opts *list.Options) ops.ServiceLister {
+	return &serviceLister{
+		lister:    s.op.List(opts),
+		nsName:    s.nsName,
+		options:   opts,
+		intercept: s.intercept,
+	}
+}
+
+func (s *serviceOperation) Endpoint(name string) ops.EndpointOperation {
+	return &endpointOperation{
+		op:        s.op.Endpoint(name),
+		nsName:    s.nsName,
+		servName:  s.name,
+		name:      name,
+		intercept: s.intercept,
+	}
+}
+
+type serviceLister struct {
+	lister    ops.ServiceLister
+	nsName    string
+	options   *list.Options
+	intercept Interceptor
+}
+
+func (l *serviceLister) Next(ctx context.Context) (serv *coretypes.Service, servop ops.ServiceOperation, err error) {
+	call := &Call{
+		Operation: List,
+		Object:    ServiceObject,
+		Namespace: l.nsName,
+		Path:      path.Join(pathNamespaces, l.nsName, pathServices),
+		Options:   l.options,
+	}
+	err = l.intercept(ctx, call, func(ctx context.Context) (opErr error) {
+		serv, servop, opErr = l.lister.Next(ctx)
+		setResult(call, serv, opErr)
+		return
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return serv, &serviceOperation{
+		op:        servop,
+		nsName:    l.nsName,
+		name:      serv.Name,
+		intercept: l.intercept,
+	}, nil
+}
+
+func (l *serviceLister) NextPageToken() string {
+	return ops.NextPageToken(l.lister)
+}
+
+type endpointOperation struct {
+	op        ops.EndpointOperation
+	nsName    string
+	servName  string
+	name      string
+	intercept Interceptor
+}
+
+func (e *endpointOperation) call(operation Operation) *Call {
+	return &Call{
+		Operation: operation,
+		Object:    EndpointObject,
+		Namespace: e.nsName,
+		Service:   e.servName,
+		Endpoint:  e.name,
+		Path:      path.Join(pathNamespaces, e.nsName, pathServices, e.servName, pathEndpoints, e.name),
+	}
+}
+
+func (e *endpointOperation) Get(ctx context.Context, opts *get.Options) (endp *coretypes.Endpoint, err error) {
+	call := e.call(Get)
+	call.Options = opts
+	err = e.intercept(ctx, call, func(ctx context.Context) (opErr error) {
+		endp, opErr = e.op.Get(ctx, opts)
+		setResult(call, endp, opErr)
+		return
+	})
+
+	return
+}
+
+func (e *endpointOperation) Create(ctx context.Context, address string, port int32, metadata map[string]string) (endp *coretypes.Endpoint, err error) {
+	call := e.call(Create)
+	call.Address, call.Port, call.Metadata = address, port, metadata
+	err = e.intercept(ctx, call, func(ctx context.Context) (opErr error) {
+		endp, opErr = e.op.Create(ctx, address, port, metadata)
+		setResult(call, endp, opErr)
+		return
+	})
+
+	return
+}
+
+func (e *endpointOperation) Update(ctx context.Context, address string, port int32, metadata map[string]string, expectedRevision string) (endp *coretypes.Endpoint, err error) {
+	call := e.call(Update)
+	call.Address, call.Port, call.Metadata, call.ExpectedRevision = address, port, metadata, expectedRevision
+	err = e.intercept(ctx, call, func(ctx context.Context) (opErr error) {
+		endp, opErr = e.op.Update(ctx, address, port, metadata, expectedRevision)
+		setResult(call, endp, opErr)
+		return
+	})
+
+	return
+}
+
+func (e *endpointOperation) Delete(ctx context.Context) error {
+	return e.intercept(ctx, e.call(Delete), func(ctx context.Context) error {
+		return e.op.Delete(ctx)
+	})
+}
+
+func (e *endpointOperation) List(opts *list.Options) ops.EndpointLister {
+	return &endpointLister{
+		lister:    e.op.List(opts),
+		nsName:    e.nsName,
+		servName:  e.servName,
+		options:   opts,
+		intercept: e.intercept,
+	}
+}
+
+type endpointLister struct {
+	lister    ops.EndpointLister
+	nsName    string
+	servName  string
+	options   *list.Options
+	intercept Interceptor
+}
+
+func (l *endpointLister) Next(ctx context.Context) (endp *coretypes.Endpoint, endpop ops.EndpointOperation, err error) {
+	call := &Call{
+		Operation: List,
+		Object:    EndpointObject,
+		Namespace: l.nsName,
+		Service:   l.servName,
+		Path:      path.Join(pathNamespaces, l.nsName, pathServices, l.servName, pathEndpoints),
+		Options:   l.options,
+	}
+	err = l.intercept(ctx, call, func(ctx context.Context) (opErr error) {
+		endp, endpop, opErr = l.lister.Next(ctx)
+		setResult(call, endp, opErr)
+		return
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return endp, &endpointOperation{
+		op:        endpop,
+		nsName:    l.nsName,
+		servName:  l.servName,
+		name:      endp.Name,
+		intercept: l.intercept,
+	}, nil
+}
+
+func (l *endpointLister) NextPageToken() string {
+	return ops.NextPageToken(l.lister)
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Retry returns an interceptor that retries calls failed with a transient
+// error, or that exceeded the attempt timeout, according to the provided
+// policy.
+//
+// List calls are never retried, as iterators may not be able to resume after
+// an error.
+func Retry(policy *wrapper.RetryPolicy) Interceptor {
+	return func(ctx context.Context, call *Call, next Handler) error {
+		if call.Operation == List {
+			return next(ctx)
+		}
+
+		var err error
+		for attempt := 0; attempt < policy.MaxAttempts; attempt++ {
+			if attempt > 0 {
+				timer := time.NewTimer(backoff(policy, attempt))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return err
+				case <-timer.C:
+				}
+			}
+
+			err = tryOnce(ctx, policy, next)
+			if err == nil || !shouldRetry(ctx, err) {
+				return err
+			}
+		}
+
+		return err
+	}
+}
+
+func tryOnce(ctx context.Context, policy *wrapper.RetryPolicy, next Handler) error {
+	if policy.AttemptTimeout <= 0 {
+		return next(ctx)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, policy.AttemptTimeout)
+	defer cancel()
+
+	return next(attemptCtx)
+}
+
+// shouldRetry returns true if the error is transient or if it is due to the
+// attempt timeout expiring, rather than the context of the call.
+func shouldRetry(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded {
+		return true
+	}
+
+	return srerr.IsTransient(err)
+}
+
+// backoff returns the time to wait before the provided attempt, with
+// attempt 1 being the first retry.
+func backoff(policy *wrapper.RetryPolicy, attempt int) time.Duration {
+	multiplier := policy.Multiplier
+	if multiplier == 0 {
+		multiplier = 1
+	}
+
+	wait := float64(policy.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if policy.MaxBackoff > 0 && wait > float64(policy.MaxBackoff) {
+		wait = float64(policy.MaxBackoff)
+	}
+
+	wait -= wait * policy.Jitter * rand.Float64()
+	return time.Duration(wait)
+}
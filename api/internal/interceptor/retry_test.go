@@ -0,0 +1,140 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package interceptor_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/interceptor"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	"github.com/aws/aws-sdk-go-v2/service/servicediscovery/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeAPIError struct {
+	code string
+}
+
+func (e *fakeAPIError) Error() string {
+	return e.code
+}
+
+func (e *fakeAPIError) ErrorCode() string {
+	return e.code
+}
+
+var _ = Describe("Retry", func() {
+	var (
+		ctx    = context.TODO()
+		policy = &wrapper.RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			Multiplier:     2,
+			Jitter:         0.5,
+		}
+		getCall = &interceptor.Call{Operation: interceptor.Get, Object: interceptor.NamespaceObject}
+	)
+
+	failing := func(errs ...error) (interceptor.Handler, *int) {
+		attempts := 0
+		return func(_ context.Context) error {
+			attempts++
+			if attempts <= len(errs) {
+				return errs[attempts-1]
+			}
+
+			return nil
+		}, &attempts
+	}
+
+	It("retries transient errors", func() {
+		for _, transientErr := range []error{
+			status.Error(codes.Unavailable, "unavailable"),
+			status.Error(codes.ResourceExhausted, "quota exceeded"),
+			rpctypes.ErrLeaderChanged,
+			fmt.Errorf("could not get namespace: %w", &types.RequestLimitExceeded{}),
+			&fakeAPIError{code: "ThrottlingException"},
+		} {
+			handler, attempts := failing(transientErr, transientErr)
+			err := interceptor.Retry(policy)(ctx, getCall, handler)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*attempts).To(Equal(3))
+		}
+	})
+
+	It("returns the last error after all attempts", func() {
+		transientErr := status.Error(codes.Unavailable, "unavailable")
+		handler, attempts := failing(transientErr, transientErr, transientErr, transientErr)
+		err := interceptor.Retry(policy)(ctx, getCall, handler)
+		Expect(err).To(MatchError(transientErr))
+		Expect(*attempts).To(Equal(3))
+	})
+
+	It("does not retry other errors", func() {
+		handler, attempts := failing(srerr.NamespaceNotFound)
+		err := interceptor.Retry(policy)(ctx, getCall, handler)
+		Expect(err).To(MatchError(srerr.NamespaceNotFound))
+		Expect(*attempts).To(Equal(1))
+
+		handler, attempts = failing(status.Error(codes.Unavailable, "unavailable"))
+		err = interceptor.Retry(policy)(ctx, &interceptor.Call{Operation: interceptor.List}, handler)
+		Expect(err).To(HaveOccurred())
+		Expect(*attempts).To(Equal(1))
+	})
+
+	It("retries attempts that exceed the timeout", func() {
+		attempts := 0
+		timeoutPolicy := *policy
+		timeoutPolicy.AttemptTimeout = 10 * time.Millisecond
+
+		err := interceptor.Retry(&timeoutPolicy)(ctx, getCall, func(ctx context.Context) error {
+			attempts++
+			if attempts == 1 {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+
+			_, hasDeadline := ctx.Deadline()
+			Expect(hasDeadline).To(BeTrue())
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(attempts).To(Equal(2))
+	})
+
+	It("stops when the context is done", func() {
+		canceledCtx, cancel := context.WithCancel(ctx)
+		transientErr := status.Error(codes.Unavailable, "unavailable")
+		attempts := 0
+
+		err := interceptor.Retry(policy)(canceledCtx, getCall, func(_ context.Context) error {
+			attempts++
+			cancel()
+			return transientErr
+		})
+		Expect(err).To(MatchError(transientErr))
+		Expect(attempts).To(Equal(1))
+	})
+})
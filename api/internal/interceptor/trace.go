@@ -0,0 +1,61 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package interceptor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Trace returns an interceptor that creates a span with the provided tracer
+// for each call, with the backend as attribute along with the names of the
+// objects.
+//
+// List calls are not traced, as wrappers create a span for each page they
+// list instead.
+func Trace(tracer trace.Tracer, backend string) Interceptor {
+	return func(ctx context.Context, call *Call, next Handler) error {
+		if call.Operation == List {
+			return next(ctx)
+		}
+
+		attributes := []attribute.KeyValue{tracing.BackendKey.String(backend)}
+		if call.Namespace != "" {
+			attributes = append(attributes, tracing.NamespaceKey.String(call.Namespace))
+		}
+
+		if call.Service != "" {
+			attributes = append(attributes, tracing.ServiceKey.String(call.Service))
+		}
+
+		if call.Endpoint != "" {
+			attributes = append(attributes, tracing.EndpointKey.String(call.Endpoint))
+		}
+
+		ctx, span := tracer.Start(ctx, fmt.Sprintf("%s %s %s", backend, call.Operation, call.Object),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attributes...))
+		err := next(ctx)
+		tracing.End(span, err)
+		return err
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package interceptor_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CloudNativeSDWAN/serego/api/internal/interceptor"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ = Describe("Trace", func() {
+	var (
+		ctx      = context.TODO()
+		recorder *tracetest.SpanRecorder
+		tracer   trace.Tracer
+	)
+
+	BeforeEach(func() {
+		recorder = tracetest.NewSpanRecorder()
+		tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	})
+
+	It("creates a span for each call", func() {
+		call := &interceptor.Call{
+			Operation: interceptor.Create,
+			Object:    interceptor.EndpointObject,
+			Namespace: "ns",
+			Service:   "serv",
+			Endpoint:  "endp",
+		}
+		expErr := fmt.Errorf("whatever")
+		err := interceptor.Trace(tracer, "cloudmap")(ctx, call, func(ctx context.Context) error {
+			Expect(trace.SpanFromContext(ctx).IsRecording()).To(BeTrue())
+			return expErr
+		})
+		Expect(err).To(MatchError(expErr))
+
+		Expect(recorder.Ended()).To(HaveLen(1))
+		span := recorder.Ended()[0]
+		Expect(span.Name()).To(Equal("cloudmap create endpoint"))
+		Expect(span.SpanKind()).To(Equal(trace.SpanKindClient))
+		Expect(span.Attributes()).To(ConsistOf(
+			tracing.BackendKey.String("cloudmap"),
+			tracing.NamespaceKey.String("ns"),
+			tracing.ServiceKey.String("serv"),
+			tracing.EndpointKey.String("endp"),
+		))
+		Expect(span.Status().Code).To(Equal(codes.Error))
+	})
+
+	It("does not create spans for list calls", func() {
+		listCall := &interceptor.Call{Operation: interceptor.List, Object: interceptor.NamespaceObject}
+		Expect(interceptor.Trace(tracer, "etcd")(ctx, listCall, func(context.Context) error {
+			return nil
+		})).To(Succeed())
+		Expect(recorder.Started()).To(BeEmpty())
+	})
+})
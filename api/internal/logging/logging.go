@@ -0,0 +1,41 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package logging contains the logger used to log what the API does, e.g.
+// calls performed to the service registry and decisions taken on cache.
+package logging
+
+import (
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	"github.com/go-logr/logr"
+)
+
+// Debug is the verbosity of messages about what happens on each operation,
+// e.g. calls to the service registry or objects found on cache. Messages
+// about something unusual, e.g. objects deleted automatically, are logged with
+// the default verbosity instead.
+const Debug int = 1
+
+// New returns the logger provided in the wrapper options with the "serego"
+// name, or one that discards all messages if there is none.
+func New(wopts *wrapper.Options) logr.Logger {
+	if wopts.Logger.GetSink() == nil {
+		return logr.Discard()
+	}
+
+	return wopts.Logger.WithName("serego")
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logging_test
+
+import (
+	"github.com/CloudNativeSDWAN/serego/api/internal/logging"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logging", func() {
+	It("discards messages without a logger", func() {
+		logger := logging.New(&wrapper.Options{})
+		Expect(logger.Enabled()).To(BeFalse())
+		logger.Info("whatever")
+	})
+
+	It("logs messages with the logger in the options", func() {
+		logs := []string{}
+		logger := logging.New(&wrapper.Options{Logger: funcr.New(func(prefix, args string) {
+			logs = append(logs, prefix+" "+args)
+		}, funcr.Options{})})
+
+		logger.Info("whatever", "key", "val")
+		logger.V(logging.Debug).Info("not logged")
+		Expect(logs).To(Equal([]string{`serego "level"=0 "msg"="whatever" "key"="val"`}))
+	})
+})
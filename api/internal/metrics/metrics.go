@@ -0,0 +1,169 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package metrics contains the Prometheus metrics about calls performed by
+// wrappers to the service registry and about their cache.
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Backends of the service registry, used as value of the backend label.
+const (
+	Etcd             string = "etcd"
+	CloudMap         string = "cloudmap"
+	ServiceDirectory string = "servicedirectory"
+	Custom           string = "custom"
+)
+
+// Objects that operations are performed on, used as value of the object
+// label.
+const (
+	NamespaceObject string = "namespace"
+	ServiceObject   string = "service"
+	EndpointObject  string = "endpoint"
+)
+
+// ListPage is the operation of listing a page of objects from the service
+// registry.
+const ListPage string = "list_page"
+
+// Results of looking up an object on cache.
+const (
+	CacheHit         string = "hit"
+	CacheNegativeHit string = "negative_hit"
+	CacheMiss        string = "miss"
+)
+
+// Metrics records metrics for a single backend on the registerer provided in
+// the wrapper options.
+//
+// A nil Metrics is valid and never records anything, so that wrappers can use
+// it without checking if metrics were enabled in the options.
+type Metrics struct {
+	operations     *prometheus.CounterVec
+	durations      prometheus.ObserverVec
+	cacheLookups   *prometheus.CounterVec
+	operationPolls prometheus.Counter
+}
+
+// New returns Metrics for the provided backend registered on the registerer
+// in the options, or nil if there is none.
+//
+// Metrics already registered, e.g. by another service registry using the same
+// registerer, are reused.
+func New(wopts *wrapper.Options, backend string) (*Metrics, error) {
+	if wopts.MetricsRegisterer == nil {
+		return nil, nil
+	}
+
+	operations, err := register(wopts.MetricsRegisterer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "serego",
+		Name:      "operations_total",
+		Help:      "Number of operations performed on the service registry.",
+	}, []string{"backend", "object", "operation", "result"}))
+	if err != nil {
+		return nil, err
+	}
+
+	durations, err := register(wopts.MetricsRegisterer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "serego",
+		Name:      "operation_duration_seconds",
+		Help:      "Duration of operations performed on the service registry.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"backend", "object", "operation"}))
+	if err != nil {
+		return nil, err
+	}
+
+	cacheLookups, err := register(wopts.MetricsRegisterer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "serego",
+		Name:      "cache_lookups_total",
+		Help:      "Number of objects looked up on cache, by result: hit, negative_hit or miss.",
+	}, []string{"backend", "result"}))
+	if err != nil {
+		return nil, err
+	}
+
+	operationPolls, err := register(wopts.MetricsRegisterer, prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "serego",
+		Name:      "cloudmap_operation_polls_total",
+		Help:      "Number of times the status of a Cloud Map operation was polled.",
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	backendLabel := prometheus.Labels{"backend": backend}
+	return &Metrics{
+		operations:     operations.(*prometheus.CounterVec).MustCurryWith(backendLabel),
+		durations:      durations.(*prometheus.HistogramVec).MustCurryWith(backendLabel),
+		cacheLookups:   cacheLookups.(*prometheus.CounterVec).MustCurryWith(backendLabel),
+		operationPolls: operationPolls.(prometheus.Counter),
+	}, nil
+}
+
+// register registers the collector, or returns the one that was already
+// registered in its place.
+func register(reg prometheus.Registerer, collector prometheus.Collector) (prometheus.Collector, error) {
+	if err := reg.Register(collector); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			return are.ExistingCollector, nil
+		}
+
+		return nil, err
+	}
+
+	return collector, nil
+}
+
+// ObserveOperation records an operation performed on an object that started
+// at the provided time and returned err.
+func (m *Metrics) ObserveOperation(object, operation string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	m.operations.WithLabelValues(object, operation, result).Inc()
+	m.durations.WithLabelValues(object, operation).Observe(time.Since(start).Seconds())
+}
+
+// ObserveCacheLookup records a lookup on cache with the provided result.
+func (m *Metrics) ObserveCacheLookup(result string) {
+	if m != nil {
+		m.cacheLookups.WithLabelValues(result).Inc()
+	}
+}
+
+// ObserveOperationPoll records a poll of the status of a Cloud Map
+// operation.
+func (m *Metrics) ObserveOperationPoll() {
+	if m != nil {
+		m.operationPolls.Inc()
+	}
+}
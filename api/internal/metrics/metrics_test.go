@@ -0,0 +1,91 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics_test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("Metrics", func() {
+	var reg *prometheus.Registry
+
+	BeforeEach(func() {
+		reg = prometheus.NewRegistry()
+	})
+
+	It("does nothing if nil", func() {
+		m, err := metrics.New(&wrapper.Options{}, metrics.Etcd)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m).To(BeNil())
+
+		m.ObserveOperation(metrics.NamespaceObject, "get", time.Now(), nil)
+		m.ObserveCacheLookup(metrics.CacheHit)
+		m.ObserveOperationPoll()
+	})
+
+	It("records metrics for each backend", func() {
+		etcdMetrics, err := metrics.New(&wrapper.Options{MetricsRegisterer: reg}, metrics.Etcd)
+		Expect(err).NotTo(HaveOccurred())
+		cmMetrics, err := metrics.New(&wrapper.Options{MetricsRegisterer: reg}, metrics.CloudMap)
+		Expect(err).NotTo(HaveOccurred())
+
+		etcdMetrics.ObserveOperation(metrics.NamespaceObject, "get", time.Now(), nil)
+		etcdMetrics.ObserveOperation(metrics.NamespaceObject, "get", time.Now(), fmt.Errorf("whatever"))
+		cmMetrics.ObserveOperation(metrics.EndpointObject, metrics.ListPage, time.Now(), nil)
+		etcdMetrics.ObserveCacheLookup(metrics.CacheHit)
+		etcdMetrics.ObserveCacheLookup(metrics.CacheMiss)
+		cmMetrics.ObserveOperationPoll()
+
+		Expect(testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP serego_operations_total Number of operations performed on the service registry.
+# TYPE serego_operations_total counter
+serego_operations_total{backend="cloudmap",object="endpoint",operation="list_page",result="success"} 1
+serego_operations_total{backend="etcd",object="namespace",operation="get",result="error"} 1
+serego_operations_total{backend="etcd",object="namespace",operation="get",result="success"} 1
+# HELP serego_cache_lookups_total Number of objects looked up on cache, by result: hit, negative_hit or miss.
+# TYPE serego_cache_lookups_total counter
+serego_cache_lookups_total{backend="etcd",result="hit"} 1
+serego_cache_lookups_total{backend="etcd",result="miss"} 1
+# HELP serego_cloudmap_operation_polls_total Number of times the status of a Cloud Map operation was polled.
+# TYPE serego_cloudmap_operation_polls_total counter
+serego_cloudmap_operation_polls_total 1
+`), "serego_operations_total", "serego_cache_lookups_total", "serego_cloudmap_operation_polls_total")).To(Succeed())
+		Expect(testutil.CollectAndCount(reg, "serego_operation_duration_seconds")).To(Equal(2))
+	})
+
+	Context("in case of errors", func() {
+		It("returns an error if other metrics have the same name", func() {
+			reg.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{
+				Name: "serego_operations_total",
+				Help: "Something else.",
+			}))
+
+			_, err := metrics.New(&wrapper.Options{MetricsRegisterer: reg}, metrics.Etcd)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -32,7 +32,7 @@ type ServiceRegistryWrapper interface {
 type NamespaceOperation interface {
 	Get(ctx context.Context, opts *get.Options) (*types.Namespace, error)
 	Create(ctx context.Context, metadata map[string]string) (*types.Namespace, error)
-	Update(ctx context.Context, metadata map[string]string) (*types.Namespace, error)
+	Update(ctx context.Context, metadata map[string]string, expectedRevision string) (*types.Namespace, error)
 	Delete(ctx context.Context) error
 	List(opts *list.Options) NamespaceLister
 	Service(string) ServiceOperation
@@ -45,7 +45,7 @@ type NamespaceLister interface {
 type ServiceOperation interface {
 	Get(ctx context.Context, opts *get.Options) (*types.Service, error)
 	Create(ctx context.Context, metadata map[string]string) (*types.Service, error)
-	Update(ctx context.Context, metadata map[string]string) (*types.Service, error)
+	Update(ctx context.Context, metadata map[string]string, expectedRevision string) (*types.Service, error)
 	Delete(ctx context.Context) error
 	List(opts *list.Options) ServiceLister
 	Endpoint(string) EndpointOperation
@@ -58,7 +58,7 @@ type ServiceLister interface {
 type EndpointOperation interface {
 	Get(ctx context.Context, opts *get.Options) (*types.Endpoint, error)
 	Create(ctx context.Context, address string, port int32, metadata map[string]string) (*types.Endpoint, error)
-	Update(ctx context.Context, address string, port int32, metadata map[string]string) (*types.Endpoint, error)
+	Update(ctx context.Context, address string, port int32, metadata map[string]string, expectedRevision string) (*types.Endpoint, error)
 	Delete(ctx context.Context) error
 	List(opts *list.Options) EndpointLister
 }
@@ -66,3 +66,82 @@ type EndpointOperation interface {
 type EndpointLister interface {
 	Next(context.Context) (*types.Endpoint, EndpointOperation, error)
 }
+
+// PageTokener is implemented by listers that can return a token to resume
+// listing right after the last object they returned.
+type PageTokener interface {
+	NextPageToken() string
+}
+
+// NextPageToken returns the token to resume listing from the lister, or an
+// empty string if it does not implement PageTokener.
+func NextPageToken(lister interface{}) string {
+	if tokener, ok := lister.(PageTokener); ok {
+		return tokener.NextPageToken()
+	}
+
+	return ""
+}
+
+type parentKey struct{}
+
+// WithParent returns a copy of ctx carrying the namespace or service that the
+// caller already retrieved, e.g. before registering many of its children in
+// bulk, so that wrappers don't retrieve it again for each one of them.
+func WithParent(ctx context.Context, parent interface{}) context.Context {
+	return context.WithValue(ctx, parentKey{}, parent)
+}
+
+// ParentNamespace returns the namespace with the provided name carried by
+// ctx, or nil if there is none or if it is stale, as it may not exist anymore.
+func ParentNamespace(ctx context.Context, name string) *types.Namespace {
+	if ns, ok := ctx.Value(parentKey{}).(*types.Namespace); ok && !ns.Stale &&
+		ns.Name == name {
+		return ns
+	}
+
+	return nil
+}
+
+// ParentService returns the service with the provided name and namespace
+// carried by ctx, or nil if there is none or if it is stale, as it may not
+// exist anymore.
+func ParentService(ctx context.Context, namespace, name string) *types.Service {
+	if serv, ok := ctx.Value(parentKey{}).(*types.Service); ok && !serv.Stale &&
+		serv.Namespace == namespace && serv.Name == name {
+		return serv
+	}
+
+	return nil
+}
+
+// TxnAction is the action that a step of a transaction performs on an object.
+type TxnAction int
+
+const (
+	TxnCreate TxnAction = iota
+	TxnUpdate
+	TxnDelete
+)
+
+// TxnStep is a single step of a transaction. Steps are already resolved by
+// the time they reach the wrapper, i.e. there is no need to check whether the
+// object exists or to merge its metadata: wrappers just have to perform them.
+type TxnStep struct {
+	Action    TxnAction
+	Namespace string
+	Service   string
+	Endpoint  string
+	Address   string
+	Port      int32
+	Metadata  map[string]string
+	// Revision is the revision the object had when the step was resolved.
+	// It is empty for creations.
+	Revision string
+}
+
+// Transactioner is implemented by wrappers of service registries that can
+// execute multiple steps atomically, i.e. either all of them or none.
+type Transactioner interface {
+	Commit(ctx context.Context, steps []TxnStep) error
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package pagetoken encodes and decodes the tokens returned to users to
+// resume listing objects, so that they are opaque and can be safely used in
+// URLs regardless of the service registry they come from.
+package pagetoken
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+)
+
+// Token is the position to resume listing objects from.
+type Token struct {
+	// Page is the token of the page to fetch from the service registry, or
+	// the last key returned for service registries that don't paginate
+	// results, e.g. etcd.
+	Page string `json:"p,omitempty"`
+	// Skip is the number of objects to skip from the page, i.e. those that
+	// were already returned.
+	Skip int `json:"s,omitempty"`
+}
+
+// Encode returns the token as a string, or an empty string if the token is
+// empty, meaning that there is nothing left to list.
+func Encode(token Token) string {
+	if token == (Token{}) {
+		return ""
+	}
+
+	// This can't fail as the token only has strings and numbers.
+	data, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode parses a string returned by Encode. An empty string is decoded
+// as an empty token, i.e. listing from the first object.
+func Decode(value string) (Token, error) {
+	var token Token
+	if value == "" {
+		return token, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return token, srerr.InvalidPageToken
+	}
+
+	if err := json.Unmarshal(data, &token); err != nil || token.Skip < 0 {
+		return Token{}, srerr.InvalidPageToken
+	}
+
+	return token, nil
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package pagetoken_test
+
+import (
+	"encoding/base64"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/pagetoken"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Page tokens", func() {
+	Context("with empty tokens", func() {
+		It("encodes and decodes them as empty strings", func() {
+			Expect(pagetoken.Encode(pagetoken.Token{})).To(BeEmpty())
+
+			token, err := pagetoken.Decode("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal(pagetoken.Token{}))
+		})
+	})
+
+	Context("with valid tokens", func() {
+		It("decodes what it encoded", func() {
+			expToken := pagetoken.Token{Page: "/ns-1?&=", Skip: 3}
+			value := pagetoken.Encode(expToken)
+			Expect(value).NotTo(ContainSubstring("/"))
+			Expect(value).NotTo(ContainSubstring("="))
+
+			token, err := pagetoken.Decode(value)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal(expToken))
+		})
+	})
+
+	Context("with invalid tokens", func() {
+		It("returns an error", func() {
+			for _, value := range []string{
+				"not-valid",
+				base64.RawURLEncoding.EncodeToString([]byte("not-json")),
+				base64.RawURLEncoding.EncodeToString([]byte(`{"s":-1}`)),
+			} {
+				token, err := pagetoken.Decode(value)
+				Expect(err).To(Equal(srerr.InvalidPageToken))
+				Expect(token).To(Equal(pagetoken.Token{}))
+			}
+		})
+	})
+})
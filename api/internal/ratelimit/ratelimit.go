@@ -0,0 +1,141 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ratelimit contains code that limits the rate of calls performed by
+// wrappers to the service registry, according to the wrapper options.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	"golang.org/x/time/rate"
+)
+
+// Limiter limits the rate of read and write calls to the service registry.
+//
+// A nil Limiter is valid and never limits any call, so that wrappers can use
+// it without checking if rate limits were set in the options.
+type Limiter struct {
+	all      *rate.Limiter
+	read     *rate.Limiter
+	write    *rate.Limiter
+	failFast bool
+}
+
+// New returns a Limiter with the rate limits defined in the provided
+// options, or nil if no rate limit is set.
+func New(wopts *wrapper.Options) *Limiter {
+	if wopts.RateLimit == nil && wopts.ReadRateLimit == nil && wopts.WriteRateLimit == nil {
+		return nil
+	}
+
+	return &Limiter{
+		all:      newRateLimiter(wopts.RateLimit),
+		read:     newRateLimiter(wopts.ReadRateLimit),
+		write:    newRateLimiter(wopts.WriteRateLimit),
+		failFast: wopts.RateLimitFailFast,
+	}
+}
+
+func newRateLimiter(rateLimit *wrapper.RateLimit) *rate.Limiter {
+	if rateLimit == nil {
+		return nil
+	}
+
+	return rate.NewLimiter(rate.Limit(rateLimit.RequestsPerSecond), rateLimit.Burst)
+}
+
+// AcquireRead must be called before performing a call that reads from the
+// service registry. It returns nil if the call can be performed or an error
+// if it must not, i.e. because the context is done or because the rate
+// limit was exceeded in fail fast mode.
+func (l *Limiter) AcquireRead(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	return l.acquire(ctx, l.all, l.read)
+}
+
+// AcquireWrite must be called before performing a call that modifies the
+// service registry. It returns nil if the call can be performed or an error
+// if it must not, i.e. because the context is done or because the rate
+// limit was exceeded in fail fast mode.
+func (l *Limiter) AcquireWrite(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	return l.acquire(ctx, l.all, l.write)
+}
+
+func (l *Limiter) acquire(ctx context.Context, limiters ...*rate.Limiter) error {
+	if l.failFast {
+		return l.reserve(limiters)
+	}
+
+	for _, limiter := range limiters {
+		if limiter == nil {
+			continue
+		}
+
+		if err := limiter.Wait(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			// The context's deadline would expire before the call is
+			// allowed.
+			return fmt.Errorf("%w: %s", srerr.RateLimitExceeded, err)
+		}
+	}
+
+	return nil
+}
+
+// reserve takes a token from all limiters if they all have one available
+// now, and none otherwise.
+func (l *Limiter) reserve(limiters []*rate.Limiter) error {
+	var (
+		now          = time.Now()
+		reservations = []*rate.Reservation{}
+	)
+
+	for _, limiter := range limiters {
+		if limiter == nil {
+			continue
+		}
+
+		reservation := limiter.ReserveN(now, 1)
+		if !reservation.OK() || reservation.DelayFrom(now) > 0 {
+			reservation.CancelAt(now)
+			for _, r := range reservations {
+				r.CancelAt(now)
+			}
+
+			return srerr.RateLimitExceeded
+		}
+
+		reservations = append(reservations, reservation)
+	}
+
+	return nil
+}
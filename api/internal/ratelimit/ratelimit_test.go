@@ -0,0 +1,96 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit_test
+
+import (
+	"context"
+	"time"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/ratelimit"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rate limiter", func() {
+	ctx := context.TODO()
+
+	It("does not limit anything if no limits are set", func() {
+		limiter := ratelimit.New(&wrapper.Options{RateLimitFailFast: true})
+		Expect(limiter).To(BeNil())
+
+		for i := 0; i < 100; i++ {
+			Expect(limiter.AcquireRead(ctx)).To(Succeed())
+			Expect(limiter.AcquireWrite(ctx)).To(Succeed())
+		}
+	})
+
+	Context("in fail fast mode", func() {
+		It("fails calls that exceed the limits", func() {
+			limiter := ratelimit.New(&wrapper.Options{
+				RateLimit:         &wrapper.RateLimit{RequestsPerSecond: 0.001, Burst: 3},
+				WriteRateLimit:    &wrapper.RateLimit{RequestsPerSecond: 0.001, Burst: 1},
+				RateLimitFailFast: true,
+			})
+
+			By("checking the limit of the call kind", func() {
+				Expect(limiter.AcquireWrite(ctx)).To(Succeed())
+				Expect(limiter.AcquireWrite(ctx)).To(MatchError(srerr.RateLimitExceeded))
+			})
+
+			By("checking the limit of all calls", func() {
+				Expect(limiter.AcquireRead(ctx)).To(Succeed())
+				Expect(limiter.AcquireRead(ctx)).To(Succeed())
+				Expect(limiter.AcquireRead(ctx)).To(MatchError(srerr.RateLimitExceeded))
+			})
+		})
+	})
+
+	Context("in blocking mode", func() {
+		It("waits until calls are allowed", func() {
+			limiter := ratelimit.New(&wrapper.Options{
+				ReadRateLimit: &wrapper.RateLimit{RequestsPerSecond: 20, Burst: 1},
+			})
+
+			start := time.Now()
+			Expect(limiter.AcquireRead(ctx)).To(Succeed())
+			Expect(limiter.AcquireRead(ctx)).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically(">=", 40*time.Millisecond))
+
+			Expect(limiter.AcquireWrite(ctx)).To(Succeed())
+		})
+
+		It("stops waiting when the context is done", func() {
+			limiter := ratelimit.New(&wrapper.Options{
+				RateLimit: &wrapper.RateLimit{RequestsPerSecond: 0.001, Burst: 1},
+			})
+			Expect(limiter.AcquireWrite(ctx)).To(Succeed())
+
+			canceledCtx, cancel := context.WithCancel(ctx)
+			cancel()
+			Expect(limiter.AcquireWrite(canceledCtx)).To(MatchError(context.Canceled))
+
+			timeoutCtx, cancel := context.WithTimeout(ctx, time.Second)
+			defer cancel()
+			err := limiter.AcquireWrite(timeoutCtx)
+			Expect(err).To(MatchError(srerr.RateLimitExceeded))
+			Expect(srerr.IsTransient(err)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,57 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package revision
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// FromContent derives a revision for service registries that do not provide
+// one natively, by hashing the name of the object, its metadata and any other
+// value - e.g. address and port - that can be modified.
+//
+// The result is deterministic, so the same content always produces the same
+// revision regardless of the order of the metadata keys.
+func FromContent(name string, metadata map[string]string, values ...string) string {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := fnv.New64a()
+
+	// Separators are included so that "ab"+"c" and "a"+"bc" produce different
+	// hashes.
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{'='})
+		h.Write([]byte(metadata[k]))
+		h.Write([]byte{0})
+	}
+	for _, v := range values {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+
+	return fmt.Sprintf("%016x", h.Sum64())
+}
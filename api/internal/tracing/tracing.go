@@ -0,0 +1,77 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tracing contains the OpenTelemetry spans of operations performed
+// on the service registry and of the calls they are made of.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the tracer used to create spans.
+const InstrumentationName string = "github.com/CloudNativeSDWAN/serego/api"
+
+// Attributes set on spans.
+const (
+	BackendKey      = attribute.Key("serego.backend")
+	NamespaceKey    = attribute.Key("serego.namespace")
+	ServiceKey      = attribute.Key("serego.service")
+	EndpointKey     = attribute.Key("serego.endpoint")
+	RegisterModeKey = attribute.Key("serego.register_mode")
+	CacheHitKey     = attribute.Key("serego.cache_hit")
+)
+
+// New returns a tracer from the tracer provider in the wrapper options, or
+// one that does not record anything if there is none.
+func New(wopts *wrapper.Options) trace.Tracer {
+	if wopts.TracerProvider == nil {
+		return trace.NewNoopTracerProvider().Tracer(InstrumentationName)
+	}
+
+	return wopts.TracerProvider.Tracer(InstrumentationName)
+}
+
+// End ends the span, recording the error on it if not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// SetCacheHit records on the span in the context whether the object was
+// found on cache, including when it was found as not existing.
+func SetCacheHit(ctx context.Context, hit bool) {
+	trace.SpanFromContext(ctx).SetAttributes(CacheHitKey.Bool(hit))
+}
+
+// StartListPage starts a span for listing a page of objects of the provided
+// kind from the backend.
+func StartListPage(ctx context.Context, tracer trace.Tracer, backend, object string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, fmt.Sprintf("%s list_page %s", backend, object),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(BackendKey.String(backend)))
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tracing_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var _ = Describe("Tracing", func() {
+	var (
+		ctx      = context.TODO()
+		recorder *tracetest.SpanRecorder
+		provider *sdktrace.TracerProvider
+	)
+
+	BeforeEach(func() {
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	})
+
+	It("does not record anything without a tracer provider", func() {
+		_, span := tracing.New(&wrapper.Options{}).Start(ctx, "whatever")
+		Expect(span.IsRecording()).To(BeFalse())
+		tracing.End(span, fmt.Errorf("whatever"))
+	})
+
+	It("records spans for list pages", func() {
+		expErr := fmt.Errorf("whatever")
+		spanCtx, span := tracing.StartListPage(ctx, tracing.New(&wrapper.Options{TracerProvider: provider}), "etcd", "service")
+		tracing.SetCacheHit(spanCtx, false)
+		tracing.End(span, expErr)
+
+		Expect(recorder.Ended()).To(HaveLen(1))
+		ended := recorder.Ended()[0]
+		Expect(ended.Name()).To(Equal("etcd list_page service"))
+		Expect(ended.Attributes()).To(ConsistOf(
+			tracing.BackendKey.String("etcd"),
+			tracing.CacheHitKey.Bool(false),
+		))
+		Expect(ended.Status().Code).To(Equal(codes.Error))
+		Expect(ended.Status().Description).To(Equal(expErr.Error()))
+		Expect(ended.Events()).To(HaveLen(1))
+	})
+})
@@ -27,7 +27,10 @@ import (
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
 	"github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/revision"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -43,12 +46,45 @@ type cmEndpointOperation struct {
 }
 
 func (e *cmEndpointOperation) Get(ctx context.Context, opts *get.Options) (*coretypes.Endpoint, error) {
+	endp, err := e.lookup(ctx, opts)
+	if err != nil {
+		if stale, ok := e.wrapper.getStaleOnError(e.pathName, err).(*coretypes.Endpoint); ok {
+			return stale, nil
+		}
+
+		return nil, err
+	}
+
+	return endp, nil
+}
+
+// lookup is like Get, but it never returns a stale endpoint, as its callers
+// need the current one from Cloud Map.
+func (e *cmEndpointOperation) lookup(ctx context.Context, opts *get.Options) (*coretypes.Endpoint, error) {
 	if !opts.ForceRefresh {
-		if endp := e.wrapper.getFromCache(e.pathName); endp != nil {
+		if endp, found, err := e.wrapper.lookupOnCache(e.pathName); found {
+			tracing.SetCacheHit(ctx, true)
+			if err != nil {
+				return nil, err
+			}
+
 			return endp.(*coretypes.Endpoint), nil
 		}
+
+		tracing.SetCacheHit(ctx, false)
+	}
+
+	endp, err := e.wrapper.shared(ctx, e.pathName, metrics.EndpointObject, opts.ForceRefresh, func(ctx context.Context) (interface{}, error) {
+		return e.get(ctx)
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	return endp.(*coretypes.Endpoint), nil
+}
+
+func (e *cmEndpointOperation) get(ctx context.Context) (*coretypes.Endpoint, error) {
 	serviceID, err := e.parentOp.getID(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error while getting parent service: %w", err)
@@ -59,6 +95,10 @@ func (e *cmEndpointOperation) Get(ctx context.Context, opts *get.Options) (*core
 		ServiceId:  serviceID,
 	})
 	if err != nil {
+		if errors.IsNotFound(err) {
+			e.wrapper.putNotFoundOnCache(e.pathName, err)
+		}
+
 		return nil, err
 	}
 
@@ -107,15 +147,28 @@ func (e *cmEndpointOperation) Create(ctx context.Context, address string, port i
 		return nil, err
 	}
 
-	_, err = pollOperationStatus(ctx, e.wrapper.client, aws.ToString(out.OperationId))
+	_, err = e.wrapper.pollOperation(ctx, aws.ToString(out.OperationId))
 	if err != nil {
 		return nil, fmt.Errorf("error while checking operation status: %w", err)
 	}
 
-	return e.Get(ctx, &get.Options{})
+	return e.lookup(ctx, &get.Options{ForceRefresh: true})
 }
 
-func (e *cmEndpointOperation) Update(ctx context.Context, address string, port int32, metadata map[string]string) (*coretypes.Endpoint, error) {
+func (e *cmEndpointOperation) Update(ctx context.Context, address string, port int32, metadata map[string]string, expectedRevision string) (*coretypes.Endpoint, error) {
+	if expectedRevision != "" {
+		// Cloud Map does not support conditional updates, so the best we can
+		// do is checking it again right before updating it.
+		curr, err := e.lookup(ctx, &get.Options{ForceRefresh: true})
+		if err != nil {
+			return nil, err
+		}
+
+		if curr.Revision != expectedRevision {
+			return nil, errors.Conflict
+		}
+	}
+
 	return e.Create(ctx, address, port, metadata)
 }
 
@@ -135,7 +188,7 @@ func (e *cmEndpointOperation) Delete(ctx context.Context) error {
 		return err
 	}
 
-	_, err = pollOperationStatus(ctx, e.wrapper.client, aws.ToString(out.OperationId))
+	_, err = e.wrapper.pollOperation(ctx, aws.ToString(out.OperationId))
 	if err != nil {
 		return fmt.Errorf("error while checking operation status: %w", err)
 	}
@@ -148,45 +201,55 @@ func (e *cmEndpointOperation) deleteFromCache() {
 }
 
 func (e *cmEndpointOperation) putOnCache(endpoint *coretypes.Endpoint) {
-	e.wrapper.cache.SetDefault(e.pathName, endpoint)
+	e.wrapper.cache.Set(e.pathName, endpoint)
 }
 
 func (e *cmEndpointOperation) List(opts *list.Options) ops.EndpointLister {
+	// Invalid tokens are returned by Next, as any other error.
+	cursor, nextToken, err := newPageCursor(opts)
+
 	return &cloudMapEndpointsIterator{
-		wrapper:  e.wrapper,
-		parentOp: e.parentOp,
-		options:  opts,
-		hasMore:  true,
+		wrapper:   e.wrapper,
+		parentOp:  e.parentOp,
+		options:   opts,
+		hasMore:   true,
+		nextToken: nextToken,
+		cursor:    cursor,
+		err:       err,
 	}
 }
 
 type cloudMapEndpointsIterator struct {
-	wrapper    *AwsCloudMapWrapper
-	options    *list.Options
-	parentOp   *cmServiceOperation
-	parentServ *coretypes.Service
-	parentID   *string
-	currIndex  int
-	nextToken  *string
-	elements   []types.InstanceSummary
-	hasMore    bool
+	wrapper   *AwsCloudMapWrapper
+	options   *list.Options
+	parentOp  *cmServiceOperation
+	parentID  *string
+	currIndex int
+	nextToken *string
+	elements  []types.InstanceSummary
+	hasMore   bool
+	cursor    pageCursor
+	err       error
 }
 
 func (ei *cloudMapEndpointsIterator) Next(ctx context.Context) (*coretypes.Endpoint, ops.EndpointOperation, error) {
+	if ei.err != nil {
+		return nil, nil, ei.err
+	}
+
 	client := ei.wrapper.client
 
 	if ei.parentOp.name == "" || ei.parentOp.parentOp.name == "" {
 		return nil, nil, fmt.Errorf("cannot get the next element: %w", errors.MissingName)
 	}
 
-	if ei.parentServ == nil && ei.hasMore {
-		serv, err := ei.parentOp.Get(ctx, &get.Options{})
+	if ei.parentID == nil && ei.hasMore {
+		servID, err := ei.parentOp.getID(ctx)
 		if err != nil {
 			ei.hasMore = false
 			return nil, nil, fmt.Errorf("error while getting parent service: %w", err)
 		}
-		ei.parentServ = serv
-		ei.parentID = ei.parentServ.OriginalObject.(*types.Service).Id
+		ei.parentID = servID
 	}
 
 	for i := ei.currIndex; i < len(ei.elements); i++ {
@@ -213,7 +276,7 @@ func (ei *cloudMapEndpointsIterator) Next(ctx context.Context) (*coretypes.Endpo
 		}
 
 		for j := range elemsToFilter {
-			inst := toCoreEndpoint(ei.parentServ.Namespace, ei.parentServ.Name, &elemsToFilter[j])
+			inst := toCoreEndpoint(ei.parentOp.parentOp.name, ei.parentOp.name, &elemsToFilter[j])
 
 			if passed, _ := ei.options.Filter(inst); passed {
 				newOp := ei.parentOp.Endpoint(inst.Name).(*cmEndpointOperation)
@@ -225,16 +288,21 @@ func (ei *cloudMapEndpointsIterator) Next(ctx context.Context) (*coretypes.Endpo
 	}
 
 	if ei.hasMore {
-		out, err := client.ListInstances(ctx, &servicediscovery.ListInstancesInput{
-			ServiceId:  ei.parentID,
-			MaxResults: aws.Int32(ei.options.Results),
-			NextToken:  ei.nextToken,
+		key := pageKey(path.Join(ei.parentOp.pathName, pathEndpoints), ei.nextToken, ei.options.Results)
+		page, err := ei.wrapper.sharedPage(ctx, key, metrics.EndpointObject, func(ctx context.Context) (interface{}, error) {
+			return client.ListInstances(ctx, &servicediscovery.ListInstancesInput{
+				ServiceId:  ei.parentID,
+				MaxResults: aws.Int32(ei.options.Results),
+				NextToken:  ei.nextToken,
+			})
 		})
 		if err != nil {
 			ei.hasMore = false
 			return nil, nil, fmt.Errorf("error while getting new resources: %w", err)
 		}
 
+		out := page.(*servicediscovery.ListInstancesOutput)
+		ei.currIndex = ei.cursor.fetched(ei.nextToken, len(ei.elements), len(out.Instances))
 		ei.elements = append(ei.elements, out.Instances...)
 		if out.NextToken != nil {
 			ei.nextToken = out.NextToken
@@ -250,6 +318,10 @@ func (ei *cloudMapEndpointsIterator) Next(ctx context.Context) (*coretypes.Endpo
 	return nil, nil, errors.IteratorDone
 }
 
+func (ei *cloudMapEndpointsIterator) NextPageToken() string {
+	return ei.cursor.nextPageToken(ei.currIndex, len(ei.elements), ei.nextToken, ei.hasMore)
+}
+
 func toCoreEndpoint(namespace, service string, inst interface{}) *coretypes.Endpoint {
 	instValue := reflect.ValueOf(inst).Elem()
 	attributes := instValue.FieldByName("Attributes").
@@ -280,13 +352,15 @@ func toCoreEndpoint(namespace, service string, inst interface{}) *coretypes.Endp
 		}
 	}
 
+	name := instValue.FieldByName("Id").Elem().String()
 	return &coretypes.Endpoint{
-		Name:      instValue.FieldByName("Id").Elem().String(),
+		Name:      name,
 		Namespace: namespace,
 		Service:   service,
 		Port:      port,
 		Address:   address,
 		Metadata:  metadata,
+		Revision:  revision.FromContent(name, metadata, address, strconv.Itoa(int(port))),
 		OriginalObject: func() *types.Instance {
 			if summary, ok := inst.(*types.InstanceSummary); ok {
 				return fromSummaryToInstance(summary)
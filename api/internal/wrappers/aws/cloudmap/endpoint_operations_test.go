@@ -30,6 +30,7 @@ import (
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/revision"
 	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/aws/cloudmap"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
@@ -115,6 +116,7 @@ var _ = Describe("EndpointOperations", func() {
 					Namespace: *ns.Name,
 					Service:   *serv.Name,
 					Metadata:  endpMetas,
+					Revision:  revision.FromContent(*endp.Id, endpMetas, ip, strconv.Itoa(port)),
 					Address:   ip,
 					Port:      int32(port),
 					OriginalObject: &types.Instance{
@@ -136,6 +138,7 @@ var _ = Describe("EndpointOperations", func() {
 					Namespace: *ns.Name,
 					Service:   *serv.Name,
 					Metadata:  endpMetas,
+					Revision:  revision.FromContent(*endp.Id, endpMetas, ip, strconv.Itoa(port)),
 					Address:   ip,
 					Port:      int32(port),
 					OriginalObject: &types.Instance{
@@ -179,6 +182,7 @@ var _ = Describe("EndpointOperations", func() {
 						Namespace: *ns.Name,
 						Service:   *serv.Name,
 						Metadata:  endpMetas,
+						Revision:  revision.FromContent(*endp.Id, endpMetas, "", "0"),
 						Address:   "",
 						Port:      0,
 						OriginalObject: &types.Instance{
@@ -241,6 +245,7 @@ var _ = Describe("EndpointOperations", func() {
 					Namespace: *ns.Name,
 					Service:   *serv.Name,
 					Metadata:  endpMetas,
+					Revision:  revision.FromContent(*endp.Id, endpMetas, ipv6, strconv.Itoa(port)),
 					Address:   ipv6,
 					Port:      int32(port),
 					OriginalObject: &types.Instance{
@@ -550,6 +555,7 @@ var _ = Describe("EndpointOperations", func() {
 				Namespace: *ns.Name,
 				Service:   *serv.Name,
 				Metadata:  endpMetas,
+				Revision:  revision.FromContent(*endp.Id, endpMetas, ip, strconv.Itoa(port)),
 				Address:   ip,
 				Port:      int32(port),
 				OriginalObject: &types.Instance{
@@ -569,6 +575,7 @@ var _ = Describe("EndpointOperations", func() {
 				Namespace: *ns.Name,
 				Service:   *serv.Name,
 				Metadata:  endpMetas,
+				Revision:  revision.FromContent(*endp.Id, endpMetas, ip, strconv.Itoa(port)),
 				Address:   ip,
 				Port:      int32(port),
 				OriginalObject: &types.Instance{
@@ -0,0 +1,302 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cloudmap
+
+import (
+	"context"
+
+	"github.com/CloudNativeSDWAN/serego/api/internal/breaker"
+	"github.com/CloudNativeSDWAN/serego/api/internal/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
+)
+
+// guardedClient waits for the rate limiter and checks the circuit breaker
+// before each call to Cloud Map.
+type guardedClient struct {
+	client  cloudMapClientIface
+	limiter *ratelimit.Limiter
+	breaker *breaker.Breaker
+}
+
+func (g *guardedClient) before(ctx context.Context, acquire func(context.Context) error) error {
+	if err := acquire(ctx); err != nil {
+		return err
+	}
+
+	return g.breaker.Allow()
+}
+
+func (g *guardedClient) CreateHttpNamespace(ctx context.Context, params *servicediscovery.CreateHttpNamespaceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.CreateHttpNamespaceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.CreateHttpNamespace(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) CreatePrivateDnsNamespace(ctx context.Context, params *servicediscovery.CreatePrivateDnsNamespaceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.CreatePrivateDnsNamespaceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.CreatePrivateDnsNamespace(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) CreatePublicDnsNamespace(ctx context.Context, params *servicediscovery.CreatePublicDnsNamespaceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.CreatePublicDnsNamespaceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.CreatePublicDnsNamespace(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) CreateService(ctx context.Context, params *servicediscovery.CreateServiceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.CreateServiceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.CreateService(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) DeleteNamespace(ctx context.Context, params *servicediscovery.DeleteNamespaceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.DeleteNamespaceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.DeleteNamespace(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) DeleteService(ctx context.Context, params *servicediscovery.DeleteServiceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.DeleteServiceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.DeleteService(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) DeregisterInstance(ctx context.Context, params *servicediscovery.DeregisterInstanceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.DeregisterInstanceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.DeregisterInstance(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) DiscoverInstances(ctx context.Context, params *servicediscovery.DiscoverInstancesInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.DiscoverInstancesOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.DiscoverInstances(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) GetInstance(ctx context.Context, params *servicediscovery.GetInstanceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.GetInstanceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.GetInstance(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) GetInstancesHealthStatus(ctx context.Context, params *servicediscovery.GetInstancesHealthStatusInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.GetInstancesHealthStatusOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.GetInstancesHealthStatus(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) GetNamespace(ctx context.Context, params *servicediscovery.GetNamespaceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.GetNamespaceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.GetNamespace(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) GetOperation(ctx context.Context, params *servicediscovery.GetOperationInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.GetOperationOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.GetOperation(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) GetService(ctx context.Context, params *servicediscovery.GetServiceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.GetServiceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.GetService(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) ListInstances(ctx context.Context, params *servicediscovery.ListInstancesInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.ListInstancesOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.ListInstances(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) ListNamespaces(ctx context.Context, params *servicediscovery.ListNamespacesInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.ListNamespacesOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.ListNamespaces(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) ListOperations(ctx context.Context, params *servicediscovery.ListOperationsInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.ListOperationsOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.ListOperations(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) ListServices(ctx context.Context, params *servicediscovery.ListServicesInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.ListServicesOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.ListServices(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) ListTagsForResource(ctx context.Context, params *servicediscovery.ListTagsForResourceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.ListTagsForResourceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.ListTagsForResource(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) RegisterInstance(ctx context.Context, params *servicediscovery.RegisterInstanceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.RegisterInstanceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.RegisterInstance(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) TagResource(ctx context.Context, params *servicediscovery.TagResourceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.TagResourceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.TagResource(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) UntagResource(ctx context.Context, params *servicediscovery.UntagResourceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.UntagResourceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.UntagResource(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) UpdateHttpNamespace(ctx context.Context, params *servicediscovery.UpdateHttpNamespaceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.UpdateHttpNamespaceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.UpdateHttpNamespace(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) UpdateInstanceCustomHealthStatus(ctx context.Context, params *servicediscovery.UpdateInstanceCustomHealthStatusInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.UpdateInstanceCustomHealthStatusOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.UpdateInstanceCustomHealthStatus(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) UpdatePrivateDnsNamespace(ctx context.Context, params *servicediscovery.UpdatePrivateDnsNamespaceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.UpdatePrivateDnsNamespaceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.UpdatePrivateDnsNamespace(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) UpdatePublicDnsNamespace(ctx context.Context, params *servicediscovery.UpdatePublicDnsNamespaceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.UpdatePublicDnsNamespaceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.UpdatePublicDnsNamespace(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
+
+func (g *guardedClient) UpdateService(ctx context.Context, params *servicediscovery.UpdateServiceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.UpdateServiceOutput, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	out, err := g.client.UpdateService(ctx, params, optFns...)
+	g.breaker.Record(err)
+	return out, err
+}
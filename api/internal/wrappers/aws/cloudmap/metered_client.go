@@ -0,0 +1,37 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cloudmap
+
+import (
+	"context"
+
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
+	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
+)
+
+// meteredClient records metrics about calls to Cloud Map that are not
+// recorded elsewhere, i.e. polls of the status of operations.
+type meteredClient struct {
+	cloudMapClientIface
+	metrics *metrics.Metrics
+}
+
+func (m *meteredClient) GetOperation(ctx context.Context, params *servicediscovery.GetOperationInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.GetOperationOutput, error) {
+	m.metrics.ObserveOperationPoll()
+	return m.cloudMapClientIface.GetOperation(ctx, params, optFns...)
+}
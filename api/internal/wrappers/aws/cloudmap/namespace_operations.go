@@ -26,7 +26,10 @@ import (
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
 	"github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
 	"github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/revision"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -41,12 +44,46 @@ type cmNamespaceOperation struct {
 }
 
 func (n *cmNamespaceOperation) Get(ctx context.Context, opts *get.Options) (*coretypes.Namespace, error) {
+	ns, err := n.lookup(ctx, opts)
+	if err != nil {
+		if stale, ok := n.wrapper.getStaleOnError(n.pathName, err).(*coretypes.Namespace); ok {
+			return stale, nil
+		}
+
+		return nil, err
+	}
+
+	return ns, nil
+}
+
+// lookup is like Get, but it never returns a stale namespace: those may have
+// been loaded from the persistent cache without their original object, which
+// is needed to update or delete the namespace and to get its ID.
+func (n *cmNamespaceOperation) lookup(ctx context.Context, opts *get.Options) (*coretypes.Namespace, error) {
 	if !opts.ForceRefresh {
-		if ns := n.wrapper.getFromCache(n.pathName); ns != nil {
+		if ns, found, err := n.wrapper.lookupOnCache(n.pathName); found {
+			tracing.SetCacheHit(ctx, true)
+			if err != nil {
+				return nil, err
+			}
+
 			return ns.(*coretypes.Namespace), nil
 		}
+
+		tracing.SetCacheHit(ctx, false)
 	}
 
+	ns, err := n.wrapper.shared(ctx, n.pathName, metrics.NamespaceObject, opts.ForceRefresh, func(ctx context.Context) (interface{}, error) {
+		return n.get(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ns.(*coretypes.Namespace), nil
+}
+
+func (n *cmNamespaceOperation) get(ctx context.Context) (*coretypes.Namespace, error) {
 	if nsID := n.wrapper.getFromCache(path.Join(n.pathName, pathID)); nsID != nil {
 		// We already have its ID, nice! It means we can load it instantly.
 		return n.getByID(ctx, nsID.(*string))
@@ -57,6 +94,7 @@ func (n *cmNamespaceOperation) Get(ctx context.Context, opts *get.Options) (*cor
 	ns, _, err := nsit.Next(ctx)
 	if err != nil {
 		if errors.IsIteratorDone(err) {
+			n.wrapper.putNotFoundOnCache(n.pathName, errors.NamespaceNotFound)
 			return nil, errors.NamespaceNotFound
 		}
 
@@ -71,8 +109,16 @@ func (n *cmNamespaceOperation) getByID(ctx context.Context, nsID *string) (*core
 		Id: nsID,
 	})
 	if err != nil {
-		n.deleteFromCache()
-		return nil, fmt.Errorf("cannot get namespace from ID %s: %w", *nsID, err)
+		if !errors.IsTransient(err) {
+			n.deleteFromCache()
+		}
+
+		err = fmt.Errorf("cannot get namespace from ID %s: %w", *nsID, err)
+		if errors.IsNotFound(err) {
+			n.wrapper.putNotFoundOnCache(n.pathName, err)
+		}
+
+		return nil, err
 	}
 
 	outTags, err := n.wrapper.client.ListTagsForResource(ctx, &servicediscovery.ListTagsForResourceInput{
@@ -97,12 +143,25 @@ func (n *cmNamespaceOperation) getID(ctx context.Context) (*string, error) {
 		return nsID.(*string), nil
 	}
 
-	namespace, err := n.Get(ctx, &get.Options{})
+	// The caller may have already retrieved it, e.g. to register many
+	// services on it.
+	if ns := operations.ParentNamespace(ctx, n.name); ns != nil {
+		if original, ok := ns.OriginalObject.(*types.Namespace); ok {
+			return original.Id, nil
+		}
+	}
+
+	namespace, err := n.lookup(ctx, &get.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := originalNamespace(namespace)
 	if err != nil {
 		return nil, err
 	}
 
-	return namespace.OriginalObject.(*types.Namespace).Id, nil
+	return original.Id, nil
 }
 
 func (n *cmNamespaceOperation) deleteFromCache() {
@@ -112,11 +171,15 @@ func (n *cmNamespaceOperation) deleteFromCache() {
 }
 
 func (n *cmNamespaceOperation) putOnCache(namespace *coretypes.Namespace) {
-	n.wrapper.cache.SetDefault(n.pathName, namespace)
+	n.wrapper.cache.Set(n.pathName, namespace)
 
-	original := namespace.OriginalObject.(*types.Namespace)
-	n.wrapper.cache.Set(path.Join(n.pathName, pathID), original.Id, time.Hour)
-	n.wrapper.cache.Set(path.Join(n.pathName, pathARN), original.Arn, time.Hour)
+	original, err := originalNamespace(namespace)
+	if err != nil {
+		return
+	}
+
+	n.wrapper.cache.SetWithTTL(path.Join(n.pathName, pathID), original.Id, time.Hour)
+	n.wrapper.cache.SetWithTTL(path.Join(n.pathName, pathARN), original.Arn, time.Hour)
 }
 
 func (n *cmNamespaceOperation) Create(ctx context.Context, metadata map[string]string) (*coretypes.Namespace, error) {
@@ -128,7 +191,7 @@ func (n *cmNamespaceOperation) Create(ctx context.Context, metadata map[string]s
 		return nil, err
 	}
 
-	awsOperation, err := pollOperationStatus(ctx, n.wrapper.client, aws.ToString(out.OperationId))
+	awsOperation, err := n.wrapper.pollOperation(ctx, aws.ToString(out.OperationId))
 	if err != nil {
 		return nil,
 			fmt.Errorf("error while checking operation status %s: %w",
@@ -142,17 +205,26 @@ func (n *cmNamespaceOperation) Create(ctx context.Context, metadata map[string]s
 	return n.getByID(ctx, &nsID)
 }
 
-func (n *cmNamespaceOperation) Update(ctx context.Context, metadata map[string]string) (*coretypes.Namespace, error) {
+func (n *cmNamespaceOperation) Update(ctx context.Context, metadata map[string]string, expectedRevision string) (*coretypes.Namespace, error) {
 	// You cannot edit the name of namespace, so the only thing you can update
 	// in a namespace is its tags.
 	var ns *types.Namespace
 	{
-		namespace, err := n.Get(ctx, &get.Options{})
+		// Cloud Map does not support conditional updates, so if a revision is
+		// expected the best we can do is checking it again right before
+		// updating it.
+		namespace, err := n.lookup(ctx, &get.Options{ForceRefresh: expectedRevision != ""})
 		if err != nil {
 			return nil, fmt.Errorf("error while checking if namespace exists: %w", err)
 		}
 
-		ns = namespace.OriginalObject.(*types.Namespace)
+		if expectedRevision != "" && namespace.Revision != expectedRevision {
+			return nil, errors.Conflict
+		}
+
+		if ns, err = originalNamespace(namespace); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := updateTags(ctx, n.wrapper.client, *ns.Arn, metadata); err != nil {
@@ -167,12 +239,14 @@ func (n *cmNamespaceOperation) Delete(ctx context.Context) error {
 
 	var ns *types.Namespace
 	{
-		namespace, err := n.Get(ctx, &get.Options{})
+		namespace, err := n.lookup(ctx, &get.Options{})
 		if err != nil {
 			return fmt.Errorf("error while checking if namespace exists: %w", err)
 		}
 
-		ns = namespace.OriginalObject.(*types.Namespace)
+		if ns, err = originalNamespace(namespace); err != nil {
+			return err
+		}
 	}
 
 	out, err := n.wrapper.client.DeleteNamespace(ctx, &servicediscovery.DeleteNamespaceInput{
@@ -182,7 +256,7 @@ func (n *cmNamespaceOperation) Delete(ctx context.Context) error {
 		return err
 	}
 
-	_, err = pollOperationStatus(ctx, n.wrapper.client, aws.ToString(out.OperationId))
+	_, err = n.wrapper.pollOperation(ctx, aws.ToString(out.OperationId))
 	if err != nil {
 		return fmt.Errorf("error while checking operation status: %w", err)
 	}
@@ -208,12 +282,18 @@ func (n *cmNamespaceOperation) List(opts *list.Options) operations.NamespaceList
 		opts.Results = list.DefaultListResultsNumber
 	}
 
+	// Invalid tokens are returned by Next, as any other error.
+	cursor, nextToken, err := newPageCursor(opts)
+
 	return &cloudMapNamespaceIterator{
 		wrapper:   n.wrapper,
 		hasMore:   true,
 		currIndex: 0,
 		elements:  []types.NamespaceSummary{},
 		options:   opts,
+		nextToken: nextToken,
+		cursor:    cursor,
+		err:       err,
 	}
 }
 
@@ -224,13 +304,19 @@ type cloudMapNamespaceIterator struct {
 	nextToken *string
 	elements  []types.NamespaceSummary
 	hasMore   bool
+	cursor    pageCursor
+	err       error
 }
 
 func (ni *cloudMapNamespaceIterator) Next(ctx context.Context) (*coretypes.Namespace, operations.NamespaceOperation, error) {
+	if ni.err != nil {
+		return nil, nil, ni.err
+	}
+
 	client := ni.wrapper.client
 
 	for i := ni.currIndex; i < len(ni.elements); i++ {
-		ns := toCoreNamespace(&ni.elements[i], []types.Tag{})
+		tags := []types.Tag{}
 
 		outTags, err := client.ListTagsForResource(ctx, &servicediscovery.ListTagsForResourceInput{
 			ResourceARN: ni.elements[i].Arn,
@@ -243,11 +329,19 @@ func (ni *cloudMapNamespaceIterator) Next(ctx context.Context) (*coretypes.Names
 				continue
 			}
 
+			if errors.IsTransient(err) || ctx.Err() != nil {
+				// Don't return it without tags: we may get them if we try
+				// again later.
+				return nil, nil, err
+			}
+
 			// Keep empty tags
 		} else {
-			ns.Metadata = fromTagsSliceToMap(outTags.Tags)
+			tags = outTags.Tags
 		}
 
+		ns := toCoreNamespace(&ni.elements[i], tags)
+
 		if passed, _ := ni.options.Filter(ns); passed {
 			ni.currIndex = i + 1
 			newWrapper := ni.wrapper.Namespace(ns.Name).(*cmNamespaceOperation)
@@ -257,15 +351,20 @@ func (ni *cloudMapNamespaceIterator) Next(ctx context.Context) (*coretypes.Names
 	}
 
 	if ni.hasMore {
-		out, err := client.ListNamespaces(ctx, &servicediscovery.ListNamespacesInput{
-			MaxResults: aws.Int32(ni.options.Results),
-			NextToken:  ni.nextToken,
+		key := pageKey(pathNamespaces, ni.nextToken, ni.options.Results)
+		page, err := ni.wrapper.sharedPage(ctx, key, metrics.NamespaceObject, func(ctx context.Context) (interface{}, error) {
+			return client.ListNamespaces(ctx, &servicediscovery.ListNamespacesInput{
+				MaxResults: aws.Int32(ni.options.Results),
+				NextToken:  ni.nextToken,
+			})
 		})
 		if err != nil {
 			ni.hasMore = false
 			return nil, nil, fmt.Errorf("error while getting next page: %w", err)
 		}
 
+		out := page.(*servicediscovery.ListNamespacesOutput)
+		ni.currIndex = ni.cursor.fetched(ni.nextToken, len(ni.elements), len(out.Namespaces))
 		ni.elements = append(ni.elements, out.Namespaces...)
 		if out.NextToken != nil {
 			ni.nextToken = out.NextToken
@@ -281,6 +380,10 @@ func (ni *cloudMapNamespaceIterator) Next(ctx context.Context) (*coretypes.Names
 	return nil, nil, errors.IteratorDone
 }
 
+func (ni *cloudMapNamespaceIterator) NextPageToken() string {
+	return ni.cursor.nextPageToken(ni.currIndex, len(ni.elements), ni.nextToken, ni.hasMore)
+}
+
 func (n *cmNamespaceOperation) Service(name string) operations.ServiceOperation {
 	var (
 		pathName string
@@ -301,15 +404,18 @@ func (n *cmNamespaceOperation) Service(name string) operations.ServiceOperation
 func toCoreNamespace(ns interface{}, tags []types.Tag) *coretypes.Namespace {
 	// ns is either a *types.Namespace or *types.NamespaceSummary.
 	nsValue := reflect.ValueOf(ns).Elem()
-	return &coretypes.Namespace{
-		Name:     nsValue.FieldByName("Name").Elem().String(),
-		Metadata: fromTagsSliceToMap(tags),
-		OriginalObject: func() *types.Namespace {
-			if summary, ok := ns.(*types.NamespaceSummary); ok {
-				return fromSummaryToNamespace(summary)
-			}
+	original, ok := ns.(*types.Namespace)
+	if summary, isSummary := ns.(*types.NamespaceSummary); !ok && isSummary {
+		original = fromSummaryToNamespace(summary)
+	}
 
-			return ns.(*types.Namespace)
-		}(),
+	namespace := &coretypes.Namespace{
+		Name:           nsValue.FieldByName("Name").Elem().String(),
+		Metadata:       fromTagsSliceToMap(tags),
+		OriginalObject: original,
 	}
+	namespace.Revision = revision.FromContent(namespace.Name, namespace.Metadata,
+		aws.ToString(original.Id))
+
+	return namespace
 }
@@ -20,6 +20,8 @@ package cloudmap_test
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	sd "github.com/aws/aws-sdk-go-v2/service/servicediscovery"
@@ -29,6 +31,8 @@ import (
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/revision"
 	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/aws/cloudmap"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
@@ -104,6 +108,7 @@ var _ = Describe("Namespace Operations", func() {
 			Expect(createdNs).To(Equal(&coretypes.Namespace{
 				Name:     *ns.Name,
 				Metadata: nsMetas,
+				Revision: revision.FromContent(*ns.Name, nsMetas, *ns.Id),
 				OriginalObject: &types.Namespace{
 					Arn:  ns.Arn,
 					Id:   ns.Id,
@@ -121,6 +126,7 @@ var _ = Describe("Namespace Operations", func() {
 			Expect(createdNs).To(Equal(&coretypes.Namespace{
 				Name:     *ns.Name,
 				Metadata: nsMetas,
+				Revision: revision.FromContent(*ns.Name, nsMetas, *ns.Id),
 				OriginalObject: &types.Namespace{
 					Arn:  ns.Arn,
 					Id:   ns.Id,
@@ -262,6 +268,67 @@ var _ = Describe("Namespace Operations", func() {
 			})
 		})
 
+		Context("with page tokens", func() {
+			It("resumes listing after the last namespace returned", func() {
+				f._ListNamespaces = func(ctx context.Context, params *sd.ListNamespacesInput, optFns ...func(*sd.Options)) (*sd.ListNamespacesOutput, error) {
+					if aws.ToString(params.NextToken) == "" {
+						return &sd.ListNamespacesOutput{
+							Namespaces: namespaces[:2],
+							NextToken:  aws.String("next-1"),
+						}, nil
+					}
+
+					Expect(params.NextToken).To(Equal(aws.String("next-1")))
+					return &sd.ListNamespacesOutput{
+						Namespaces: namespaces[2:],
+					}, nil
+				}
+				resume := func(token string) *coretypes.Namespace {
+					it := w.Namespace("").List(&list.Options{
+						Results:   2,
+						PageToken: token,
+					})
+					Expect(ops.NextPageToken(it)).To(Equal(token))
+
+					val, _, err := it.Next(context.Background())
+					Expect(err).NotTo(HaveOccurred())
+					return val
+				}
+
+				By("resuming from the middle of a page")
+				it := w.Namespace("").List(&list.Options{Results: 2})
+				val, _, err := it.Next(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(val.Name).To(Equal(*namespaces[0].Name))
+				token := ops.NextPageToken(it)
+				Expect(token).NotTo(BeEmpty())
+				Expect(resume(token).Name).To(Equal(*namespaces[1].Name))
+
+				By("resuming from the next page")
+				_, _, err = it.Next(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				token = ops.NextPageToken(it)
+				Expect(resume(token).Name).To(Equal(*namespaces[2].Name))
+
+				By("checking that there is nothing left to list")
+				for err == nil {
+					_, _, err = it.Next(context.Background())
+				}
+				Expect(err).To(Equal(srerr.IteratorDone))
+				Expect(ops.NextPageToken(it)).To(BeEmpty())
+			})
+
+			It("returns an error if the token is not valid", func() {
+				it := w.Namespace("").List(&list.Options{
+					PageToken: "not-valid",
+				})
+				val, valOp, err := it.Next(context.Background())
+				Expect(err).To(Equal(srerr.InvalidPageToken))
+				Expect(val).To(BeNil())
+				Expect(valOp).To(BeNil())
+			})
+		})
+
 		Context("with name filters", func() {
 			It("should only return requested namespaces", func() {
 				it := w.Namespace("ns-name-1").List(&list.Options{
@@ -398,6 +465,7 @@ var _ = Describe("Namespace Operations", func() {
 				Expect(op).To(Equal(&coretypes.Namespace{
 					Name:     *ns.Name,
 					Metadata: nsMetas,
+					Revision: revision.FromContent(*ns.Name, nsMetas, *ns.Id),
 					OriginalObject: &types.Namespace{
 						Arn:  ns.Arn,
 						Id:   ns.Id,
@@ -425,6 +493,7 @@ var _ = Describe("Namespace Operations", func() {
 				Expect(op).To(Equal(&coretypes.Namespace{
 					Name:     *ns.Name,
 					Metadata: nsMetas,
+					Revision: revision.FromContent(*ns.Name, nsMetas, *ns.Id),
 					OriginalObject: &types.Namespace{
 						Arn:  ns.Arn,
 						Id:   ns.Id,
@@ -685,11 +754,12 @@ var _ = Describe("Namespace Operations", func() {
 			}
 
 			newMaps := map[string]string{"key-1": "value-1-edited", "new-key": "new-val"}
-			updNs, err := w.Namespace(*ns.Name).Update(context.Background(), newMaps)
+			updNs, err := w.Namespace(*ns.Name).Update(context.Background(), newMaps, "")
 			Expect(err).To(BeNil())
 			Expect(updNs).To(Equal(&coretypes.Namespace{
 				Name:           *ns.Name,
 				Metadata:       newMaps,
+				Revision:       revision.FromContent(*ns.Name, newMaps, *ns.Id),
 				OriginalObject: expNs,
 			}))
 
@@ -710,7 +780,7 @@ var _ = Describe("Namespace Operations", func() {
 					f._ListNamespaces = func(ctx context.Context, params *sd.ListNamespacesInput, optFns ...func(*sd.Options)) (*sd.ListNamespacesOutput, error) {
 						return nil, expErr
 					}
-					ns, err := w.Namespace("whatever").Update(context.Background(), map[string]string{})
+					ns, err := w.Namespace("whatever").Update(context.Background(), map[string]string{}, "")
 					Expect(ns).To(BeNil())
 					Expect(err).To(MatchError(expErr))
 				})
@@ -722,7 +792,7 @@ var _ = Describe("Namespace Operations", func() {
 					}
 					ns, err := w.Namespace(*namespaces[0].Name).Update(context.Background(), map[string]string{
 						"key": "val",
-					})
+					}, "")
 					Expect(ns).To(BeNil())
 					Expect(err).To(MatchError(expErr))
 				})
@@ -737,7 +807,7 @@ var _ = Describe("Namespace Operations", func() {
 					}
 					ns, err := w.Namespace(*namespaces[0].Name).Update(context.Background(), map[string]string{
 						"key": "val",
-					})
+					}, "")
 					Expect(ns).To(BeNil())
 					Expect(err).To(MatchError(expErr))
 				})
@@ -752,7 +822,7 @@ var _ = Describe("Namespace Operations", func() {
 					}
 					ns, err := w.Namespace(*namespaces[0].Name).Update(context.Background(), map[string]string{
 						"key": "val",
-					})
+					}, "")
 					Expect(ns).To(BeNil())
 					Expect(err).To(MatchError(expErr))
 				})
@@ -771,11 +841,51 @@ var _ = Describe("Namespace Operations", func() {
 					}
 					ns, err := w.Namespace(*namespaces[0].Name).Update(context.Background(), map[string]string{
 						"key": "val",
-					})
+					}, "")
 					Expect(ns).To(BeNil())
 					Expect(err).To(MatchError(nsExpErr))
 				})
 			})
 		})
 	})
+
+	Describe("Using namespaces saved on the persistent cache", func() {
+		It("only returns them to the user", func() {
+			fc, err := wrapper.NewFileCache(filepath.Join(GinkgoT().TempDir(), "cache.db"), time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			defer fc.Close()
+
+			opts := &wrapper.Options{CacheExpirationTime: wrapper.DefaultCacheExpirationTime, PersistentCache: fc}
+			w, _ = cloudmap.NewCloudMapWrapper(f, opts)
+			_, err = w.Namespace(*ns.Name).Get(context.Background(), &get.Options{})
+			Expect(err).NotTo(HaveOccurred())
+
+			// Simulate a restart while Cloud Map is unreachable: any call to
+			// update or delete it would panic, as they are not defined.
+			f = &fakeCloudMapClient{}
+			f._ListNamespaces = func(ctx context.Context, params *sd.ListNamespacesInput, optFns ...func(*sd.Options)) (*sd.ListNamespacesOutput, error) {
+				return nil, srerr.CircuitOpen
+			}
+			w, _ = cloudmap.NewCloudMapWrapper(f, opts)
+			nsop := w.Namespace(*ns.Name)
+
+			// Objects are saved on the persistent cache in background.
+			var stale *coretypes.Namespace
+			Eventually(func() error {
+				stale, err = nsop.Get(context.Background(), &get.Options{})
+				return err
+			}).Should(Succeed())
+			Expect(stale.Stale).To(BeTrue())
+			Expect(stale.OriginalObject).To(BeNil())
+
+			updated, err := nsop.Update(context.Background(), nsMetas, "")
+			Expect(updated).To(BeNil())
+			Expect(err).To(MatchError(srerr.CircuitOpen))
+
+			Expect(nsop.Delete(context.Background())).To(MatchError(srerr.CircuitOpen))
+
+			_, _, err = nsop.Service("whatever").List(&list.Options{}).Next(context.Background())
+			Expect(err).To(MatchError(srerr.CircuitOpen))
+		})
+	})
 })
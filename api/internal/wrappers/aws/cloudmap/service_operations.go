@@ -26,7 +26,10 @@ import (
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
 	"github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/revision"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -47,12 +50,46 @@ type cmServiceOperation struct {
 }
 
 func (s *cmServiceOperation) Get(ctx context.Context, opts *get.Options) (*coretypes.Service, error) {
+	serv, err := s.lookup(ctx, opts)
+	if err != nil {
+		if stale, ok := s.wrapper.getStaleOnError(s.pathName, err).(*coretypes.Service); ok {
+			return stale, nil
+		}
+
+		return nil, err
+	}
+
+	return serv, nil
+}
+
+// lookup is like Get, but it never returns a stale service: those may have
+// been loaded from the persistent cache without their original object, which
+// is needed to update or delete the service and to get its ID.
+func (s *cmServiceOperation) lookup(ctx context.Context, opts *get.Options) (*coretypes.Service, error) {
 	if !opts.ForceRefresh {
-		if serv := s.wrapper.getFromCache(s.pathName); serv != nil {
+		if serv, found, err := s.wrapper.lookupOnCache(s.pathName); found {
+			tracing.SetCacheHit(ctx, true)
+			if err != nil {
+				return nil, err
+			}
+
 			return serv.(*coretypes.Service), nil
 		}
+
+		tracing.SetCacheHit(ctx, false)
 	}
 
+	serv, err := s.wrapper.shared(ctx, s.pathName, metrics.ServiceObject, opts.ForceRefresh, func(ctx context.Context) (interface{}, error) {
+		return s.get(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return serv.(*coretypes.Service), nil
+}
+
+func (s *cmServiceOperation) get(ctx context.Context) (*coretypes.Service, error) {
 	if servID := s.wrapper.getFromCache(path.Join(s.pathName, pathID)); servID != nil {
 		// We already have its ID, nice! It means we can load it instantly.
 		return s.getByID(ctx, servID.(*string))
@@ -63,6 +100,7 @@ func (s *cmServiceOperation) Get(ctx context.Context, opts *get.Options) (*coret
 	serv, _, err := sl.Next(ctx)
 	if err != nil {
 		if errors.IsIteratorDone(err) {
+			s.wrapper.putNotFoundOnCache(s.pathName, errors.ServiceNotFound)
 			return nil, errors.ServiceNotFound
 		}
 
@@ -77,7 +115,14 @@ func (s *cmServiceOperation) getByID(ctx context.Context, servID *string) (*core
 		Id: servID,
 	})
 	if err != nil {
-		s.deleteFromCache()
+		if !errors.IsTransient(err) {
+			s.deleteFromCache()
+		}
+
+		if errors.IsNotFound(err) {
+			s.wrapper.putNotFoundOnCache(s.pathName, err)
+		}
+
 		return nil, err
 	}
 
@@ -103,12 +148,25 @@ func (s *cmServiceOperation) getID(ctx context.Context) (*string, error) {
 		return servID.(*string), nil
 	}
 
-	serv, err := s.Get(ctx, &get.Options{})
+	// The caller may have already retrieved it, e.g. to register many
+	// endpoints on it.
+	if serv := ops.ParentService(ctx, s.parentOp.name, s.name); serv != nil {
+		if original, ok := serv.OriginalObject.(*types.Service); ok {
+			return original.Id, nil
+		}
+	}
+
+	serv, err := s.lookup(ctx, &get.Options{})
 	if err != nil {
 		return nil, err
 	}
 
-	return serv.OriginalObject.(*types.Service).Id, nil
+	original, err := originalService(serv)
+	if err != nil {
+		return nil, err
+	}
+
+	return original.Id, nil
 }
 
 func (s *cmServiceOperation) deleteFromCache() {
@@ -118,11 +176,15 @@ func (s *cmServiceOperation) deleteFromCache() {
 }
 
 func (s *cmServiceOperation) putOnCache(service *coretypes.Service) {
-	s.wrapper.cache.SetDefault(s.pathName, service)
+	s.wrapper.cache.Set(s.pathName, service)
 
-	original := service.OriginalObject.(*types.Service)
-	s.wrapper.cache.Set(path.Join(s.pathName, pathID), original.Id, time.Hour)
-	s.wrapper.cache.Set(path.Join(s.pathName, pathARN), original.Arn, time.Hour)
+	original, err := originalService(service)
+	if err != nil {
+		return
+	}
+
+	s.wrapper.cache.SetWithTTL(path.Join(s.pathName, pathID), original.Id, time.Hour)
+	s.wrapper.cache.SetWithTTL(path.Join(s.pathName, pathARN), original.Arn, time.Hour)
 }
 
 func (s *cmServiceOperation) Create(ctx context.Context, metadata map[string]string) (*coretypes.Service, error) {
@@ -146,17 +208,26 @@ func (s *cmServiceOperation) Create(ctx context.Context, metadata map[string]str
 	return s.getByID(ctx, out.Service.Id)
 }
 
-func (s *cmServiceOperation) Update(ctx context.Context, metadata map[string]string) (*coretypes.Service, error) {
+func (s *cmServiceOperation) Update(ctx context.Context, metadata map[string]string, expectedRevision string) (*coretypes.Service, error) {
 	// You cannot edit the name of service, so the only thing you can update
 	// is its name
 	var serv *types.Service
 	{
-		service, err := s.Get(ctx, &get.Options{})
+		// Cloud Map does not support conditional updates, so if a revision is
+		// expected the best we can do is checking it again right before
+		// updating it.
+		service, err := s.lookup(ctx, &get.Options{ForceRefresh: expectedRevision != ""})
 		if err != nil {
 			return nil, fmt.Errorf("error while checking if service exists: %w", err)
 		}
 
-		serv = service.OriginalObject.(*types.Service)
+		if expectedRevision != "" && service.Revision != expectedRevision {
+			return nil, errors.Conflict
+		}
+
+		if serv, err = originalService(service); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := updateTags(ctx, s.wrapper.client, *serv.Arn, metadata); err != nil {
@@ -171,12 +242,14 @@ func (s *cmServiceOperation) Delete(ctx context.Context) error {
 
 	var serv *types.Service
 	{
-		service, err := s.Get(ctx, &get.Options{})
+		service, err := s.lookup(ctx, &get.Options{})
 		if err != nil {
 			return fmt.Errorf("error while checking if service exists: %w", err)
 		}
 
-		serv = service.OriginalObject.(*types.Service)
+		if serv, err = originalService(service); err != nil {
+			return err
+		}
 	}
 
 	_, err := s.wrapper.client.DeleteService(ctx, &servicediscovery.DeleteServiceInput{
@@ -203,11 +276,17 @@ func (s *cmServiceOperation) List(opts *list.Options) ops.ServiceLister {
 		opts.Results = list.DefaultListResultsNumber
 	}
 
+	// Invalid tokens are returned by Next, as any other error.
+	cursor, nextToken, err := newPageCursor(opts)
+
 	return &cloudMapServicesIterator{
-		wrapper:  s.wrapper,
-		parentOp: s.parentOp,
-		options:  opts,
-		hasMore:  true,
+		wrapper:   s.wrapper,
+		parentOp:  s.parentOp,
+		options:   opts,
+		hasMore:   true,
+		nextToken: nextToken,
+		cursor:    cursor,
+		err:       err,
 	}
 }
 
@@ -215,34 +294,37 @@ type cloudMapServicesIterator struct {
 	wrapper   *AwsCloudMapWrapper
 	options   *list.Options
 	parentOp  *cmNamespaceOperation
-	parentNs  *coretypes.Namespace
 	parentID  *string
 	currIndex int
 	nextToken *string
 	elements  []types.ServiceSummary
 	hasMore   bool
+	cursor    pageCursor
+	err       error
 }
 
 func (si *cloudMapServicesIterator) Next(ctx context.Context) (*coretypes.Service, ops.ServiceOperation, error) {
+	if si.err != nil {
+		return nil, nil, si.err
+	}
+
 	client := si.wrapper.client
 
 	if si.parentOp.name == "" {
 		return nil, nil, fmt.Errorf("cannot load next element: %w", errors.EmptyNamespaceName)
 	}
 
-	if si.parentNs == nil && si.hasMore {
-		ns, err := si.parentOp.Get(ctx, &get.Options{})
+	if si.parentID == nil && si.hasMore {
+		nsID, err := si.parentOp.getID(ctx)
 		if err != nil {
 			si.hasMore = false
 			return nil, nil, fmt.Errorf("error while getting parent namespace: %w", err)
 		}
-		si.parentNs = ns
-		si.parentID = si.parentNs.OriginalObject.(*types.Namespace).Id
+		si.parentID = nsID
 	}
 
 	for i := si.currIndex; i < len(si.elements); i++ {
-		serv := toCoreService(si.parentNs.Name, &si.elements[i], []types.Tag{})
-		serv.OriginalObject.(*types.Service).NamespaceId = si.parentID
+		tags := []types.Tag{}
 
 		outTags, err := client.ListTagsForResource(ctx, &servicediscovery.ListTagsForResourceInput{
 			ResourceARN: si.elements[i].Arn,
@@ -255,9 +337,20 @@ func (si *cloudMapServicesIterator) Next(ctx context.Context) (*coretypes.Servic
 				continue
 			}
 
+			if errors.IsTransient(err) || ctx.Err() != nil {
+				// Don't return it without tags: we may get them if we try
+				// again later.
+				return nil, nil, err
+			}
+
 			// Keep empty tags
 		} else {
-			serv.Metadata = fromTagsSliceToMap(outTags.Tags)
+			tags = outTags.Tags
+		}
+
+		serv := toCoreService(si.parentOp.name, &si.elements[i], tags)
+		if original, ok := serv.OriginalObject.(*types.Service); ok {
+			original.NamespaceId = si.parentID
 		}
 
 		if passed, _ := si.options.Filter(serv); passed {
@@ -270,22 +363,27 @@ func (si *cloudMapServicesIterator) Next(ctx context.Context) (*coretypes.Servic
 	}
 
 	if si.hasMore {
-		out, err := client.ListServices(ctx, &servicediscovery.ListServicesInput{
-			Filters: []types.ServiceFilter{
-				{
-					Name:      types.ServiceFilterNameNamespaceId,
-					Condition: types.FilterConditionEq,
-					Values:    []string{*si.parentID},
+		key := pageKey(path.Join(si.parentOp.pathName, pathServices), si.nextToken, si.options.Results)
+		page, err := si.wrapper.sharedPage(ctx, key, metrics.ServiceObject, func(ctx context.Context) (interface{}, error) {
+			return client.ListServices(ctx, &servicediscovery.ListServicesInput{
+				Filters: []types.ServiceFilter{
+					{
+						Name:      types.ServiceFilterNameNamespaceId,
+						Condition: types.FilterConditionEq,
+						Values:    []string{*si.parentID},
+					},
 				},
-			},
-			MaxResults: aws.Int32(si.options.Results),
-			NextToken:  si.nextToken,
+				MaxResults: aws.Int32(si.options.Results),
+				NextToken:  si.nextToken,
+			})
 		})
 		if err != nil {
 			si.hasMore = false
 			return nil, nil, fmt.Errorf("error while getting new resources: %w", err)
 		}
 
+		out := page.(*servicediscovery.ListServicesOutput)
+		si.currIndex = si.cursor.fetched(si.nextToken, len(si.elements), len(out.Services))
 		si.elements = append(si.elements, out.Services...)
 		if out.NextToken != nil {
 			si.nextToken = out.NextToken
@@ -301,6 +399,10 @@ func (si *cloudMapServicesIterator) Next(ctx context.Context) (*coretypes.Servic
 	return nil, nil, errors.IteratorDone
 }
 
+func (si *cloudMapServicesIterator) NextPageToken() string {
+	return si.cursor.nextPageToken(si.currIndex, len(si.elements), si.nextToken, si.hasMore)
+}
+
 func (s *cmServiceOperation) Endpoint(name string) ops.EndpointOperation {
 	pathName := path.Join(s.pathName, pathEndpoints, name)
 
@@ -315,16 +417,19 @@ func (s *cmServiceOperation) Endpoint(name string) ops.EndpointOperation {
 func toCoreService(namespaceName string, serv interface{}, tags []types.Tag) *coretypes.Service {
 	// serv is either a *types.Service or *types.ServiceSummary.
 	servValue := reflect.ValueOf(serv).Elem()
-	return &coretypes.Service{
-		Name:      servValue.FieldByName("Name").Elem().String(),
-		Namespace: namespaceName,
-		Metadata:  fromTagsSliceToMap(tags),
-		OriginalObject: func() *types.Service {
-			if s, ok := serv.(*types.ServiceSummary); ok {
-				return fromSummaryToService(s)
-			}
+	original, ok := serv.(*types.Service)
+	if summary, isSummary := serv.(*types.ServiceSummary); !ok && isSummary {
+		original = fromSummaryToService(summary)
+	}
 
-			return serv.(*types.Service)
-		}(),
+	service := &coretypes.Service{
+		Name:           servValue.FieldByName("Name").Elem().String(),
+		Namespace:      namespaceName,
+		Metadata:       fromTagsSliceToMap(tags),
+		OriginalObject: original,
 	}
+	service.Revision = revision.FromContent(service.Name, service.Metadata,
+		aws.ToString(original.Id))
+
+	return service
 }
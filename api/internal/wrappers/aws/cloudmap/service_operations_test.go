@@ -23,6 +23,8 @@ import (
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/revision"
 	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/aws/cloudmap"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
@@ -116,6 +118,7 @@ var _ = Describe("Service Operations", func() {
 				Name:      *serv.Name,
 				Namespace: *ns.Name,
 				Metadata:  servMetas,
+				Revision:  revision.FromContent(*serv.Name, servMetas, *serv.Id),
 				OriginalObject: &types.Service{
 					Arn:         serv.Arn,
 					Id:          serv.Id,
@@ -135,6 +138,7 @@ var _ = Describe("Service Operations", func() {
 				Name:      *serv.Name,
 				Namespace: *ns.Name,
 				Metadata:  servMetas,
+				Revision:  revision.FromContent(*serv.Name, servMetas, *serv.Id),
 				OriginalObject: &types.Service{
 					Arn:         serv.Arn,
 					Id:          serv.Id,
@@ -144,6 +148,31 @@ var _ = Describe("Service Operations", func() {
 			}))
 		})
 
+		It("uses the namespace retrieved by the caller", func() {
+			f._ListNamespaces = func(ctx context.Context, params *sd.ListNamespacesInput, optFns ...func(*sd.Options)) (*sd.ListNamespacesOutput, error) {
+				Fail("got namespace from cloud map instead of context")
+				return nil, nil
+			}
+			f._CreateService = func(ctx context.Context, params *sd.CreateServiceInput, optFns ...func(*sd.Options)) (*sd.CreateServiceOutput, error) {
+				Expect(params.NamespaceId).To(Equal(ns.Id))
+				return &sd.CreateServiceOutput{
+					Service: &types.Service{Arn: serv.Arn, Id: serv.Id, NamespaceId: ns.Id, Name: serv.Name},
+				}, nil
+			}
+			f._GetService = func(ctx context.Context, params *sd.GetServiceInput, optFns ...func(*sd.Options)) (*sd.GetServiceOutput, error) {
+				return &sd.GetServiceOutput{
+					Service: &types.Service{Arn: serv.Arn, Id: serv.Id, NamespaceId: ns.Id, Name: serv.Name},
+				}, nil
+			}
+
+			ctx := ops.WithParent(context.Background(), &coretypes.Namespace{
+				Name:           *ns.Name,
+				OriginalObject: &types.Namespace{Id: ns.Id},
+			})
+			_, err := w.Namespace(*ns.Name).Service(*serv.Name).Create(ctx, servMetas)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
 		Context("in case of errors", func() {
 			It("returns the same error", func() {
 				By("checking if the namespace exists", func() {
@@ -221,6 +250,7 @@ var _ = Describe("Service Operations", func() {
 						Name:      *services[i].Name,
 						Namespace: *ns.Name,
 						Metadata:  metas[i],
+						Revision:  revision.FromContent(*services[i].Name, metas[i], *services[i].Id),
 						OriginalObject: &types.Service{
 							Arn:         services[i].Arn,
 							Name:        services[i].Name,
@@ -537,11 +567,12 @@ var _ = Describe("Service Operations", func() {
 				}, nil
 			}
 			s, err := w.Namespace(*ns.Name).Service(*serv.Name).
-				Update(ctxtodo, newMetadata)
+				Update(ctxtodo, newMetadata, "")
 			Expect(s).To(Equal(&coretypes.Service{
 				Name:      *serv.Name,
 				Namespace: *ns.Name,
 				Metadata:  newMetadata,
+				Revision:  revision.FromContent(*serv.Name, newMetadata, *serv.Id),
 				OriginalObject: &types.Service{
 					Arn:         serv.Arn,
 					Name:        serv.Name,
@@ -567,13 +598,13 @@ var _ = Describe("Service Operations", func() {
 			It("returns the same error", func() {
 				By("checking that namespace exists", func() {
 					s, err := w.Namespace("whatever").
-						Service("whatever").Update(ctxtodo, map[string]string{})
+						Service("whatever").Update(ctxtodo, map[string]string{}, "")
 					Expect(s).To(BeNil())
 					Expect(err).To(MatchError(srerr.NamespaceNotFound))
 				})
 				By("checking that service exists", func() {
 					s, err := w.Namespace(*ns.Name).
-						Service("whatever").Update(ctxtodo, map[string]string{})
+						Service("whatever").Update(ctxtodo, map[string]string{}, "")
 					Expect(s).To(BeNil())
 					Expect(err).To(MatchError(srerr.ServiceNotFound))
 				})
@@ -588,7 +619,7 @@ var _ = Describe("Service Operations", func() {
 						return nil, expErr2
 					}
 					s, err := w.Namespace(*ns.Name).
-						Service(*serv.Name).Update(ctxtodo, map[string]string{})
+						Service(*serv.Name).Update(ctxtodo, map[string]string{}, "")
 					Expect(s).To(BeNil())
 					Expect(err).To(MatchError(expErr2))
 				})
@@ -605,7 +636,7 @@ var _ = Describe("Service Operations", func() {
 					}
 					ns, err := w.Namespace(*ns.Name).Service(*serv.Name).Update(ctxtodo, map[string]string{
 						"key": "val",
-					})
+					}, "")
 					Expect(ns).To(BeNil())
 					Expect(err).To(MatchError(expErr))
 				})
@@ -22,9 +22,18 @@ import (
 	"fmt"
 	"time"
 
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	"github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/logging"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
+	"github.com/CloudNativeSDWAN/serego/api/internal/pagetoken"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
+	"github.com/CloudNativeSDWAN/serego/api/options/list"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
 	"github.com/aws/aws-sdk-go-v2/service/servicediscovery/types"
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -36,6 +45,26 @@ var (
 	PollTick = defaultPollTick
 )
 
+// originalNamespace returns the Cloud Map namespace of ns, or an error if it
+// does not have it, e.g. because ns was loaded from the persistent cache.
+func originalNamespace(ns *coretypes.Namespace) (*types.Namespace, error) {
+	if original, ok := ns.OriginalObject.(*types.Namespace); ok && original != nil {
+		return original, nil
+	}
+
+	return nil, fmt.Errorf("cannot use namespace %s: %w", ns.Name, errors.MissingOriginalObject)
+}
+
+// originalService returns the Cloud Map service of serv, or an error if it
+// does not have it, e.g. because serv was loaded from the persistent cache.
+func originalService(serv *coretypes.Service) (*types.Service, error) {
+	if original, ok := serv.OriginalObject.(*types.Service); ok && original != nil {
+		return original, nil
+	}
+
+	return nil, fmt.Errorf("cannot use service %s: %w", serv.Name, errors.MissingOriginalObject)
+}
+
 func fromSummaryToNamespace(summary *types.NamespaceSummary) *types.Namespace {
 	return &types.Namespace{
 		Arn:          summary.Arn,
@@ -90,7 +119,18 @@ func fromTagsSliceToMap(tags []types.Tag) map[string]string {
 	return metadata
 }
 
-func pollOperationStatus(ctx context.Context, client cloudMapClientIface, operationID string) (*types.Operation, error) {
+// pollOperation waits until the Cloud Map operation with the provided ID is
+// completed, returning an error if it failed.
+func (c *AwsCloudMapWrapper) pollOperation(ctx context.Context, operationID string) (*types.Operation, error) {
+	ctx, span := c.tracer.Start(ctx, "cloudmap poll operation",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(tracing.BackendKey.String(metrics.CloudMap)))
+	op, err := pollOperationStatus(ctx, c.client, c.logger.WithValues("operationID", operationID), operationID)
+	tracing.End(span, err)
+	return op, err
+}
+
+func pollOperationStatus(ctx context.Context, client cloudMapClientIface, logger logr.Logger, operationID string) (*types.Operation, error) {
 	ticker := time.NewTicker(defaultPollTick)
 
 	for {
@@ -106,6 +146,7 @@ func pollOperationStatus(ctx context.Context, client cloudMapClientIface, operat
 			}
 			opCanc()
 
+			logger.V(logging.Debug).Info("polled operation", "status", op.Operation.Status)
 			switch op.Operation.Status {
 			case types.OperationStatusPending, types.OperationStatusSubmitted:
 				continue
@@ -157,3 +198,60 @@ func updateTags(ctx context.Context, client cloudMapClientIface, arn string, met
 
 	return nil
 }
+
+// pageCursor keeps track of the page of results that an iterator is reading,
+// so that it can return a token to resume listing right after the last
+// element it returned.
+type pageCursor struct {
+	// pageToken is the token that was used to fetch the page being read.
+	pageToken *string
+	// pageStart is the index of the first element of the page being read.
+	pageStart int
+	// skip is the number of elements to skip from the first page, in case
+	// listing is resumed from a token.
+	skip int
+}
+
+// newPageCursor returns a cursor that resumes listing from the token in the
+// options, along with the token of the first page to fetch.
+func newPageCursor(opts *list.Options) (pageCursor, *string, error) {
+	token, err := pagetoken.Decode(opts.PageToken)
+	if err != nil || token.Page == "" {
+		return pageCursor{skip: token.Skip}, nil, err
+	}
+
+	return pageCursor{skip: token.Skip}, aws.String(token.Page), nil
+}
+
+// fetched must be called after a page with the provided number of elements
+// has been fetched with pageToken and appended after the first start ones.
+// It returns the index of the first element to read.
+func (c *pageCursor) fetched(pageToken *string, start, size int) int {
+	skip := c.skip
+	if skip > size {
+		skip = size
+	}
+
+	c.pageToken, c.pageStart, c.skip = pageToken, start, 0
+	return start + skip
+}
+
+// nextPageToken returns the token to resume listing from the element at
+// index, or an empty string if there is nothing left to list.
+func (c *pageCursor) nextPageToken(index, elements int, nextToken *string, hasMore bool) string {
+	if index < elements {
+		return pagetoken.Encode(pagetoken.Token{
+			Page: aws.ToString(c.pageToken),
+			Skip: index - c.pageStart,
+		})
+	}
+
+	if !hasMore {
+		return ""
+	}
+
+	return pagetoken.Encode(pagetoken.Token{
+		Page: aws.ToString(nextToken),
+		Skip: c.skip,
+	})
+}
@@ -18,13 +18,23 @@
 package cloudmap
 
 import (
+	"context"
+	"fmt"
 	"path"
 	"time"
 
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/breaker"
+	"github.com/CloudNativeSDWAN/serego/api/internal/cache"
+	"github.com/CloudNativeSDWAN/serego/api/internal/logging"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/ratelimit"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
 	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
-	"github.com/patrickmn/go-cache"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -37,8 +47,13 @@ const (
 )
 
 type AwsCloudMapWrapper struct {
-	client cloudMapClientIface
-	cache  *cache.Cache
+	client  cloudMapClientIface
+	cache   *cache.Cache
+	metrics *metrics.Metrics
+	tracer  trace.Tracer
+	logger  logr.Logger
+	// calls coalesces concurrent reads of the same objects and pages.
+	calls cache.Group
 }
 
 func NewCloudMapWrapper(client cloudMapClientIface, wopts *wrapper.Options) (*AwsCloudMapWrapper, error) {
@@ -46,35 +61,96 @@ func NewCloudMapWrapper(client cloudMapClientIface, wopts *wrapper.Options) (*Aw
 		return nil, srerr.NoClientProvided
 	}
 
-	return &AwsCloudMapWrapper{
-		client: client,
+	m, err := metrics.New(wopts, metrics.CloudMap)
+	if err != nil {
+		return nil, fmt.Errorf("could not register metrics: %w", err)
+	}
+
+	limiter, brk := ratelimit.New(wopts), breaker.New(wopts)
+	if limiter != nil || brk != nil {
+		client = &guardedClient{client: client, limiter: limiter, breaker: brk}
+	}
+
+	if m != nil {
+		client = &meteredClient{cloudMapClientIface: client, metrics: m}
+	}
+
+	c := &AwsCloudMapWrapper{
+		client:  client,
+		metrics: m,
+		tracer:  tracing.New(wopts),
+		logger:  logging.New(wopts).WithValues("backend", metrics.CloudMap),
 		cache: func() *cache.Cache {
 			if wopts.CacheExpirationTime == 0 {
-				return cache.New(time.Nanosecond, wrapper.DefaultCacheCleanUpTime)
+				return cache.New(wopts, time.Nanosecond)
 			}
 
-			return cache.New(wopts.CacheExpirationTime, wrapper.DefaultCacheCleanUpTime)
+			return cache.New(wopts, wopts.CacheExpirationTime)
 		}(),
-	}, nil
+	}
+	c.cache.SetMetrics(m)
+	c.cache.SetLogger(c.logger)
+
+	return c, nil
 }
 
 func (c *AwsCloudMapWrapper) putOnCache(pathName string, object interface{}) {
-	if c.cache != nil {
-		c.cache.SetDefault(pathName, object)
-	}
+	c.cache.Set(pathName, object)
 }
 
 func (c *AwsCloudMapWrapper) getFromCache(pathName string) interface{} {
-	if c.cache == nil {
-		return nil
+	return c.cache.Get(pathName)
+}
+
+func (c *AwsCloudMapWrapper) putNotFoundOnCache(pathName string, err error) {
+	c.cache.SetNotFound(pathName, err)
+}
+
+func (c *AwsCloudMapWrapper) lookupOnCache(pathName string) (interface{}, bool, error) {
+	return c.cache.Lookup(pathName)
+}
+
+func (c *AwsCloudMapWrapper) getStaleOnError(pathName string, err error) interface{} {
+	return c.cache.GetStaleOnError(pathName, err)
+}
+
+// shared performs fetch to get an object of the provided kind, sharing the
+// call with all the concurrent ones with the same key, unless forceRefresh is
+// true, as in that case the object may have been modified after those
+// started. Only calls actually performed are recorded on metrics, not the
+// ones served from cache.
+func (c *AwsCloudMapWrapper) shared(ctx context.Context, key, object string, forceRefresh bool, fetch func(context.Context) (interface{}, error)) (interface{}, error) {
+	measured := func(ctx context.Context) (interface{}, error) {
+		start := time.Now()
+		obj, err := fetch(ctx)
+		c.metrics.ObserveOperation(object, string(wrapper.GetOperation), start, err)
+		return obj, err
 	}
 
-	object, found := c.cache.Get(pathName)
-	if !found {
-		return nil
+	if forceRefresh {
+		return measured(ctx)
 	}
 
-	return object
+	return c.calls.Do(ctx, key, measured)
+}
+
+// sharedPage performs fetch to list a page of objects of the provided kind,
+// sharing the call with all the concurrent ones with the same key.
+func (c *AwsCloudMapWrapper) sharedPage(ctx context.Context, key, object string, fetch func(context.Context) (interface{}, error)) (interface{}, error) {
+	ctx, span := tracing.StartListPage(ctx, c.tracer, metrics.CloudMap, object)
+	page, err := c.calls.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		start := time.Now()
+		page, err := fetch(ctx)
+		c.metrics.ObserveOperation(object, metrics.ListPage, start, err)
+		return page, err
+	})
+	tracing.End(span, err)
+	return page, err
+}
+
+// pageKey returns the key to share calls that list a page of objects.
+func pageKey(pathName string, nextToken *string, maxResults int32) string {
+	return fmt.Sprintf("%s?token=%s&limit=%d", pathName, aws.ToString(nextToken), maxResults)
 }
 
 func (c *AwsCloudMapWrapper) Namespace(name string) ops.NamespaceOperation {
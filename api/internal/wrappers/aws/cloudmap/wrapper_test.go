@@ -18,10 +18,18 @@
 package cloudmap_test
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
 	cm "github.com/CloudNativeSDWAN/serego/api/internal/wrappers/aws/cloudmap"
+	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
+	sd "github.com/aws/aws-sdk-go-v2/service/servicediscovery"
+	"github.com/aws/aws-sdk-go-v2/service/servicediscovery/types"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -40,4 +48,77 @@ var _ = Describe("Wrapper", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 	})
+	Describe("Using rate limits", func() {
+		It("limits calls to Cloud Map", func() {
+			calls := 0
+			f := &fakeCloudMapClient{
+				_ListNamespaces: func(ctx context.Context, params *sd.ListNamespacesInput, optFns ...func(*sd.Options)) (*sd.ListNamespacesOutput, error) {
+					calls++
+					return &sd.ListNamespacesOutput{}, nil
+				},
+			}
+			w, _ := cm.NewCloudMapWrapper(f, &wrapper.Options{
+				RateLimit:         &wrapper.RateLimit{RequestsPerSecond: 0.001, Burst: 1},
+				RateLimitFailFast: true,
+			})
+
+			_, err := w.Namespace("ns").Get(context.Background(), &get.Options{})
+			Expect(err).To(MatchError(srerr.NamespaceNotFound))
+
+			_, err = w.Namespace("ns").Get(context.Background(), &get.Options{})
+			Expect(err).To(MatchError(srerr.RateLimitExceeded))
+			Expect(calls).To(Equal(1))
+		})
+	})
+	Describe("Getting the same object concurrently", func() {
+		It("calls Cloud Map only once", func() {
+			var (
+				lock    sync.Mutex
+				calls   = 0
+				release = make(chan struct{})
+				wg      sync.WaitGroup
+			)
+			f := &fakeCloudMapClient{
+				_ListNamespaces: func(ctx context.Context, params *sd.ListNamespacesInput, optFns ...func(*sd.Options)) (*sd.ListNamespacesOutput, error) {
+					lock.Lock()
+					calls++
+					lock.Unlock()
+					<-release
+
+					return &sd.ListNamespacesOutput{
+						Namespaces: []types.NamespaceSummary{
+							{Name: aws.String("ns"), Id: aws.String("ns-id"), Arn: aws.String("ns-arn")},
+						},
+					}, nil
+				},
+				_ListTagsForResource: func(ctx context.Context, params *sd.ListTagsForResourceInput, optFns ...func(*sd.Options)) (*sd.ListTagsForResourceOutput, error) {
+					return &sd.ListTagsForResourceOutput{}, nil
+				},
+			}
+			w, _ := cm.NewCloudMapWrapper(f, &wrapper.Options{})
+
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					ns, err := w.Namespace("ns").Get(context.Background(), &get.Options{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(ns.Name).To(Equal("ns"))
+				}()
+			}
+
+			Eventually(func() int {
+				lock.Lock()
+				defer lock.Unlock()
+				return calls
+			}).Should(Equal(1))
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			Expect(calls).To(Equal(1))
+		})
+	})
 })
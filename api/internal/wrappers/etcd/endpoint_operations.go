@@ -24,7 +24,11 @@ import (
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/logging"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/pagetoken"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
 	"go.etcd.io/etcd/api/v3/mvccpb"
@@ -41,10 +45,29 @@ type etcdEndpointOperation struct {
 }
 
 func (e *etcdEndpointOperation) Get(ctx context.Context, opts *get.Options) (*coretypes.Endpoint, error) {
-	if _, err := e.parentOp.Get(ctx, &get.Options{}); err != nil {
+	endp, err := e.lookup(ctx, opts)
+	if err != nil {
+		if stale, ok := e.wrapper.getStaleOnError(e.pathName, err).(*coretypes.Endpoint); ok {
+			return stale, nil
+		}
+
+		return nil, err
+	}
+
+	return endp, nil
+}
+
+// lookup is like Get, but it never returns a stale endpoint, as its callers
+// need the current one, e.g. to check its revision before updating it.
+func (e *etcdEndpointOperation) lookup(ctx context.Context, opts *get.Options) (*coretypes.Endpoint, error) {
+	if _, err := e.getParent(ctx); err != nil {
 		if srerr.IsNotFound(err) {
 			// Auto-correct any misconfiguration.
-			e.Delete(ctx)
+			logger := e.wrapper.logger.WithValues("namespace", e.parentOp.parentOp.name, "service", e.parentOp.name, "endpoint", e.name)
+			logger.V(logging.Debug).Info("deleting endpoint as its service does not exist")
+			if err := e.Delete(ctx); err != nil {
+				logger.Error(err, "could not delete endpoint without service")
+			}
 			return nil, srerr.ServiceNotFound
 		}
 
@@ -52,13 +75,24 @@ func (e *etcdEndpointOperation) Get(ctx context.Context, opts *get.Options) (*co
 	}
 
 	if !opts.ForceRefresh {
-		if endp := e.wrapper.getFromCache(e.pathName); endp != nil {
+		if endp, found, err := e.wrapper.lookupOnCache(e.pathName); found {
+			tracing.SetCacheHit(ctx, true)
+			if err != nil {
+				return nil, err
+			}
+
 			return endp.(*coretypes.Endpoint), nil
 		}
+
+		tracing.SetCacheHit(ctx, false)
 	}
 
-	keyValue, err := getOne(ctx, e.kv, e.name)
+	keyValue, err := e.wrapper.getOneShared(ctx, e.pathName, metrics.EndpointObject, e.kv, e.name, opts.ForceRefresh)
 	if err != nil {
+		if srerr.IsNotFound(err) {
+			e.wrapper.putNotFoundOnCache(e.pathName, err)
+		}
+
 		return nil, err
 	}
 
@@ -66,15 +100,34 @@ func (e *etcdEndpointOperation) Get(ctx context.Context, opts *get.Options) (*co
 	if err := yaml.Unmarshal(keyValue.Value, &endp); err != nil {
 		return nil, fmt.Errorf("error while trying to decode the resource: %w", err)
 	}
+	endp.Revision = revisionOf(keyValue)
 	endp.OriginalObject = keyValue
 	e.wrapper.putOnCache(e.pathName, &endp)
 
 	return &endp, nil
 }
 
+// getParent returns the parent service, unless the caller already retrieved
+// it and provided it in the context.
+func (e *etcdEndpointOperation) getParent(ctx context.Context) (*coretypes.Service, error) {
+	if serv := ops.ParentService(ctx, e.parentOp.parentOp.name, e.parentOp.name); serv != nil {
+		return serv, nil
+	}
+
+	return e.parentOp.lookup(ctx, &get.Options{})
+}
+
 func (e *etcdEndpointOperation) Create(ctx context.Context, address string, port int32, metadata map[string]string) (*coretypes.Endpoint, error) {
+	return e.put(ctx, address, port, metadata, "")
+}
+
+func (e *etcdEndpointOperation) Update(ctx context.Context, address string, port int32, metadata map[string]string, expectedRevision string) (*coretypes.Endpoint, error) {
+	return e.put(ctx, address, port, metadata, expectedRevision)
+}
+
+func (e *etcdEndpointOperation) put(ctx context.Context, address string, port int32, metadata map[string]string, expectedRevision string) (*coretypes.Endpoint, error) {
 	// Do the parents exist, though?
-	if _, err := e.parentOp.Get(ctx, &get.Options{}); err != nil {
+	if _, err := e.getParent(ctx); err != nil {
 		return nil, fmt.Errorf(`error while getting parent service "%s" before creating endpoint: %w`, e.parentOp.name, err)
 	}
 
@@ -90,19 +143,15 @@ func (e *etcdEndpointOperation) Create(ctx context.Context, address string, port
 		Port:      port,
 		Metadata:  metadata,
 	})
-	if _, err := e.kv.Put(ctx, prependSlash(e.name), string(endpBytes)); err != nil {
+	if err := putOne(ctx, e.kv, e.name, string(endpBytes), expectedRevision); err != nil {
 		return nil, err
 	}
 
-	return e.Get(ctx, &get.Options{})
-}
-
-func (e *etcdEndpointOperation) Update(ctx context.Context, address string, port int32, metadata map[string]string) (*coretypes.Endpoint, error) {
-	return e.Create(ctx, address, port, metadata)
+	return e.lookup(ctx, &get.Options{ForceRefresh: true})
 }
 
 func (e *etcdEndpointOperation) Delete(ctx context.Context) error {
-	defer e.wrapper.cache.Delete(e.pathName)
+	defer e.wrapper.removeFromCache(e.pathName)
 
 	// Note that WithPrefix() doesn't really matter here, as endpoints are at
 	// the bottom of the hierarchy.
@@ -131,12 +180,21 @@ func (e *etcdEndpointOperation) List(opts *list.Options) ops.EndpointLister {
 		opts.Results = int32(list.DefaultListResultsNumber)
 	}
 
+	// Invalid tokens are returned by Next, as any other error.
+	token, err := pagetoken.Decode(opts.PageToken)
+	lastKey := "/"
+	if token.Page != "" {
+		lastKey = token.Page
+	}
+
 	return &EtcdEndpointsIterator{
 		wrapper:  e.wrapper,
+		pathName: path.Join(e.parentOp.pathName, pathEndpoints),
 		kv:       e.kv,
 		options:  opts,
 		hasMore:  true,
-		lastKey:  "/",
+		lastKey:  lastKey,
+		err:      err,
 		servName: e.parentOp.name,
 		nsName:   e.parentOp.parentOp.name,
 	}
@@ -144,6 +202,7 @@ func (e *etcdEndpointOperation) List(opts *list.Options) ops.EndpointLister {
 
 type EtcdEndpointsIterator struct {
 	wrapper   *EtcdWrapper
+	pathName  string
 	kv        clientv3.KV
 	currIndex int
 	hasMore   bool
@@ -152,9 +211,14 @@ type EtcdEndpointsIterator struct {
 	options   *list.Options
 	nsName    string
 	servName  string
+	err       error
 }
 
 func (ei *EtcdEndpointsIterator) Next(ctx context.Context) (*coretypes.Endpoint, ops.EndpointOperation, error) {
+	if ei.err != nil {
+		return nil, nil, ei.err
+	}
+
 	if ei.nsName == "" {
 		return nil, nil, srerr.EmptyNamespaceName
 	}
@@ -181,6 +245,7 @@ func (ei *EtcdEndpointsIterator) Next(ctx context.Context) (*coretypes.Endpoint,
 				Service(endp.Service).Endpoint(endp.Name).(*etcdEndpointOperation)
 			ei.lastKey = prependSlash(endp.Name)
 			ei.currIndex = i + 1
+			endp.Revision = revisionOf(currKeyValue)
 			endp.OriginalObject = currKeyValue
 			ei.wrapper.putOnCache(newOp.pathName, &endp)
 
@@ -189,7 +254,7 @@ func (ei *EtcdEndpointsIterator) Next(ctx context.Context) (*coretypes.Endpoint,
 	}
 
 	if ei.hasMore {
-		values, err := getList(ctx, ei.kv, ei.lastKey, ei.options.Results)
+		values, err := ei.wrapper.getListShared(ctx, ei.pathName, metrics.EndpointObject, ei.kv, ei.lastKey, ei.options.Results)
 		if err != nil {
 			ei.hasMore = false
 			return nil, nil, fmt.Errorf("could not get next results: %w", err)
@@ -211,3 +276,13 @@ func (ei *EtcdEndpointsIterator) Next(ctx context.Context) (*coretypes.Endpoint,
 
 	return nil, nil, srerr.IteratorDone
 }
+
+// NextPageToken returns the last key returned by Next as the token, so that
+// listing can be resumed right after it.
+func (ei *EtcdEndpointsIterator) NextPageToken() string {
+	if !ei.hasMore && ei.currIndex >= len(ei.keyValues) {
+		return ""
+	}
+
+	return pagetoken.Encode(pagetoken.Token{Page: ei.lastKey})
+}
@@ -140,3 +140,45 @@ func (f *fakeKV) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, e
 func (f *fakeKV) Txn(ctx context.Context) clientv3.Txn {
 	return f._Txn(ctx)
 }
+
+type fakeTxn struct {
+	cmps    []clientv3.Cmp
+	thenOps []clientv3.Op
+	elseOps []clientv3.Op
+	_Commit func(t *fakeTxn) (*clientv3.TxnResponse, error)
+}
+
+func (t *fakeTxn) If(cs ...clientv3.Cmp) clientv3.Txn {
+	t.cmps = append(t.cmps, cs...)
+	return t
+}
+
+func (t *fakeTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	t.thenOps = append(t.thenOps, ops...)
+	return t
+}
+
+func (t *fakeTxn) Else(ops ...clientv3.Op) clientv3.Txn {
+	t.elseOps = append(t.elseOps, ops...)
+	return t
+}
+
+func (t *fakeTxn) Commit() (*clientv3.TxnResponse, error) {
+	return t._Commit(t)
+}
+
+type fakeWatcher struct {
+	_Watch func(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan
+}
+
+func (f *fakeWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	return f._Watch(ctx, key, opts...)
+}
+
+func (f *fakeWatcher) RequestProgress(ctx context.Context) error {
+	return nil
+}
+
+func (f *fakeWatcher) Close() error {
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package etcd
+
+import (
+	"context"
+
+	"github.com/CloudNativeSDWAN/serego/api/internal/breaker"
+	"github.com/CloudNativeSDWAN/serego/api/internal/ratelimit"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// guardedKV waits for the rate limiter and checks the circuit breaker before
+// each call to etcd.
+type guardedKV struct {
+	clientv3.KV
+	limiter *ratelimit.Limiter
+	breaker *breaker.Breaker
+}
+
+func (g *guardedKV) before(ctx context.Context, acquire func(context.Context) error) error {
+	if err := acquire(ctx); err != nil {
+		return err
+	}
+
+	return g.breaker.Allow()
+}
+
+func (g *guardedKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	resp, err := g.KV.Get(ctx, key, opts...)
+	g.breaker.Record(err)
+	return resp, err
+}
+
+func (g *guardedKV) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	resp, err := g.KV.Put(ctx, key, val, opts...)
+	g.breaker.Record(err)
+	return resp, err
+}
+
+func (g *guardedKV) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	resp, err := g.KV.Delete(ctx, key, opts...)
+	g.breaker.Record(err)
+	return resp, err
+}
+
+func (g *guardedKV) Compact(ctx context.Context, rev int64, opts ...clientv3.CompactOption) (*clientv3.CompactResponse, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	resp, err := g.KV.Compact(ctx, rev, opts...)
+	g.breaker.Record(err)
+	return resp, err
+}
+
+func (g *guardedKV) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	acquire := g.limiter.AcquireWrite
+	if op.IsGet() {
+		acquire = g.limiter.AcquireRead
+	}
+
+	if err := g.before(ctx, acquire); err != nil {
+		return clientv3.OpResponse{}, err
+	}
+
+	resp, err := g.KV.Do(ctx, op)
+	g.breaker.Record(err)
+	return resp, err
+}
+
+func (g *guardedKV) Txn(ctx context.Context) clientv3.Txn {
+	return &guardedTxn{
+		Txn: g.KV.Txn(ctx),
+		ctx: ctx,
+		kv:  g,
+	}
+}
+
+// guardedTxn guards the commit of the transaction, which is always
+// considered as a write.
+type guardedTxn struct {
+	clientv3.Txn
+	ctx context.Context
+	kv  *guardedKV
+}
+
+func (g *guardedTxn) If(cs ...clientv3.Cmp) clientv3.Txn {
+	g.Txn = g.Txn.If(cs...)
+	return g
+}
+
+func (g *guardedTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	g.Txn = g.Txn.Then(ops...)
+	return g
+}
+
+func (g *guardedTxn) Else(ops ...clientv3.Op) clientv3.Txn {
+	g.Txn = g.Txn.Else(ops...)
+	return g
+}
+
+func (g *guardedTxn) Commit() (*clientv3.TxnResponse, error) {
+	if err := g.kv.before(g.ctx, g.kv.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	resp, err := g.Txn.Commit()
+	g.kv.breaker.Record(err)
+	return resp, err
+}
@@ -24,7 +24,10 @@ import (
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/pagetoken"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
 	"go.etcd.io/etcd/api/v3/mvccpb"
@@ -40,14 +43,40 @@ type etcdNamespaceOperation struct {
 }
 
 func (n *etcdNamespaceOperation) Get(ctx context.Context, opts *get.Options) (*coretypes.Namespace, error) {
+	ns, err := n.lookup(ctx, opts)
+	if err != nil {
+		if stale, ok := n.wrapper.getStaleOnError(n.pathName, err).(*coretypes.Namespace); ok {
+			return stale, nil
+		}
+
+		return nil, err
+	}
+
+	return ns, nil
+}
+
+// lookup is like Get, but it never returns a stale namespace, as its callers
+// need the current one, e.g. to check its revision before updating it.
+func (n *etcdNamespaceOperation) lookup(ctx context.Context, opts *get.Options) (*coretypes.Namespace, error) {
 	if !opts.ForceRefresh {
-		if ns := n.wrapper.getFromCache(n.pathName); ns != nil {
+		if ns, found, err := n.wrapper.lookupOnCache(n.pathName); found {
+			tracing.SetCacheHit(ctx, true)
+			if err != nil {
+				return nil, err
+			}
+
 			return ns.(*coretypes.Namespace), nil
 		}
+
+		tracing.SetCacheHit(ctx, false)
 	}
 
-	keyValue, err := getOne(ctx, n.kv, n.name)
+	keyValue, err := n.wrapper.getOneShared(ctx, n.pathName, metrics.NamespaceObject, n.kv, n.name, opts.ForceRefresh)
 	if err != nil {
+		if srerr.IsNotFound(err) {
+			n.wrapper.putNotFoundOnCache(n.pathName, err)
+		}
+
 		return nil, err
 	}
 
@@ -55,6 +84,7 @@ func (n *etcdNamespaceOperation) Get(ctx context.Context, opts *get.Options) (*c
 	if err := yaml.Unmarshal(keyValue.Value, &ns); err != nil {
 		return nil, fmt.Errorf("error while trying to decode the resource: %w", err)
 	}
+	ns.Revision = revisionOf(keyValue)
 	ns.OriginalObject = keyValue
 	n.wrapper.putOnCache(n.pathName, &ns)
 
@@ -62,6 +92,14 @@ func (n *etcdNamespaceOperation) Get(ctx context.Context, opts *get.Options) (*c
 }
 
 func (n *etcdNamespaceOperation) Create(ctx context.Context, metadata map[string]string) (*coretypes.Namespace, error) {
+	return n.put(ctx, metadata, "")
+}
+
+func (n *etcdNamespaceOperation) Update(ctx context.Context, metadata map[string]string, expectedRevision string) (*coretypes.Namespace, error) {
+	return n.put(ctx, metadata, expectedRevision)
+}
+
+func (n *etcdNamespaceOperation) put(ctx context.Context, metadata map[string]string, expectedRevision string) (*coretypes.Namespace, error) {
 	if metadata == nil {
 		metadata = map[string]string{}
 	}
@@ -70,19 +108,15 @@ func (n *etcdNamespaceOperation) Create(ctx context.Context, metadata map[string
 		Name:     n.name,
 		Metadata: metadata,
 	})
-	if _, err := n.kv.Put(ctx, prependSlash(n.name), string(nsBytes)); err != nil {
+	if err := putOne(ctx, n.kv, n.name, string(nsBytes), expectedRevision); err != nil {
 		return nil, err
 	}
 
-	return n.Get(ctx, &get.Options{})
-}
-
-func (n *etcdNamespaceOperation) Update(ctx context.Context, metadata map[string]string) (*coretypes.Namespace, error) {
-	return n.Create(ctx, metadata)
+	return n.lookup(ctx, &get.Options{ForceRefresh: true})
 }
 
 func (n *etcdNamespaceOperation) Delete(ctx context.Context) error {
-	defer n.wrapper.cache.Delete(n.pathName)
+	defer n.wrapper.removeFromCache(n.pathName)
 
 	// TODO: as of now, we delete all children of this. In future we will
 	// return an error if resource is not empty and an option to override
@@ -112,26 +146,41 @@ func (n *etcdNamespaceOperation) List(opts *list.Options) ops.NamespaceLister {
 		opts.Results = int32(list.DefaultListResultsNumber)
 	}
 
+	// Invalid tokens are returned by Next, as any other error.
+	token, err := pagetoken.Decode(opts.PageToken)
+	lastKey := "/"
+	if token.Page != "" {
+		lastKey = token.Page
+	}
+
 	return &EtcdNamespacesIterator{
-		wrapper: n.wrapper,
-		kv:      n.kv,
-		options: opts,
-		hasMore: true,
-		lastKey: "/",
+		wrapper:  n.wrapper,
+		pathName: pathNamespaces,
+		kv:       n.kv,
+		options:  opts,
+		hasMore:  true,
+		lastKey:  lastKey,
+		err:      err,
 	}
 }
 
 type EtcdNamespacesIterator struct {
 	wrapper   *EtcdWrapper
+	pathName  string
 	kv        clientv3.KV
 	currIndex int
 	hasMore   bool
 	lastKey   string
 	keyValues []*mvccpb.KeyValue
 	options   *list.Options
+	err       error
 }
 
 func (ni *EtcdNamespacesIterator) Next(ctx context.Context) (*coretypes.Namespace, ops.NamespaceOperation, error) {
+	if ni.err != nil {
+		return nil, nil, ni.err
+	}
+
 	for i := ni.currIndex; i < len(ni.keyValues); i++ {
 		currKeyValue := ni.keyValues[i]
 
@@ -145,6 +194,7 @@ func (ni *EtcdNamespacesIterator) Next(ctx context.Context) (*coretypes.Namespac
 			// This is not a valid namespace
 			continue
 		}
+		ns.Revision = revisionOf(currKeyValue)
 		ns.OriginalObject = currKeyValue
 
 		if passed, _ := ni.options.Filter(&ns); passed {
@@ -158,7 +208,7 @@ func (ni *EtcdNamespacesIterator) Next(ctx context.Context) (*coretypes.Namespac
 	}
 
 	if ni.hasMore {
-		values, err := getList(ctx, ni.kv, ni.lastKey, ni.options.Results)
+		values, err := ni.wrapper.getListShared(ctx, ni.pathName, metrics.NamespaceObject, ni.kv, ni.lastKey, ni.options.Results)
 		if err != nil {
 			ni.hasMore = false
 			return nil, nil, fmt.Errorf("could not get next results: %w", err)
@@ -181,6 +231,16 @@ func (ni *EtcdNamespacesIterator) Next(ctx context.Context) (*coretypes.Namespac
 	return nil, nil, srerr.IteratorDone
 }
 
+// NextPageToken returns the last key returned by Next as the token, so that
+// listing can be resumed right after it.
+func (ni *EtcdNamespacesIterator) NextPageToken() string {
+	if !ni.hasMore && ni.currIndex >= len(ni.keyValues) {
+		return ""
+	}
+
+	return pagetoken.Encode(pagetoken.Token{Page: ni.lastKey})
+}
+
 func (n *etcdNamespaceOperation) Service(name string) ops.ServiceOperation {
 	return &etcdServiceOperation{
 		name:     name,
@@ -198,6 +198,63 @@ var _ = Describe("Namespace Operations", func() {
 		})
 	})
 
+	Describe("Updating a namespace", func() {
+		Context("with an expected revision", func() {
+			It("performs a conditional update", func() {
+				succeeded := false
+				etcd.NewKV = func(kv clientv3.KV, prefix string) clientv3.KV {
+					return &fakeKV{
+						_Do: func(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+							// Just to make this work
+							return clientv3.OpResponse{}, nil
+						},
+						_Put: func(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+							Fail("should not perform an unconditional put")
+							return nil, nil
+						},
+						_Txn: func(ctx context.Context) clientv3.Txn {
+							return &fakeTxn{
+								_Commit: func(t *fakeTxn) (*clientv3.TxnResponse, error) {
+									Expect(t.cmps).To(Equal([]clientv3.Cmp{
+										clientv3.Compare(clientv3.ModRevision("/"+ns.Name), "=", 5),
+									}))
+									Expect(t.thenOps).To(HaveLen(1))
+									Expect(t.thenOps[0].IsPut()).To(BeTrue())
+									Expect(string(t.thenOps[0].KeyBytes())).To(Equal("/" + ns.Name))
+									return &clientv3.TxnResponse{Succeeded: succeeded}, nil
+								},
+							}
+						},
+						_Get: func(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+							return &clientv3.GetResponse{
+								Kvs: []*mvccpb.KeyValue{kvsNamespaces[0]},
+							}, nil
+						},
+					}
+				}
+
+				By("returning a conflict if the revision does not match", func() {
+					res, err := e.Namespace(ns.Name).Update(ctx, ns.Metadata, "5")
+					Expect(res).To(BeNil())
+					Expect(err).To(MatchError(srerr.Conflict))
+				})
+
+				By("updating it if the revision matches", func() {
+					succeeded = true
+					res, err := e.Namespace(ns.Name).Update(ctx, ns.Metadata, "5")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(res).To(Equal(ns))
+				})
+
+				By("returning a conflict if the revision is not valid", func() {
+					res, err := e.Namespace(ns.Name).Update(ctx, ns.Metadata, "not-valid")
+					Expect(res).To(BeNil())
+					Expect(err).To(MatchError(srerr.Conflict))
+				})
+			})
+		})
+	})
+
 	Describe("Deleting a namespace", func() {
 		It("deletes the namespace and its children", func() {
 			getCalled := false
@@ -351,6 +408,56 @@ var _ = Describe("Namespace Operations", func() {
 			Expect(nop).To(BeNil())
 		})
 
+		Context("with page tokens", func() {
+			It("resumes listing after the last namespace returned", func() {
+				keys := []string{}
+				etcd.NewKV = func(kv clientv3.KV, prefix string) clientv3.KV {
+					return &fakeKV{
+						_Get: func(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+							keys = append(keys, key)
+							for i := range kvsNamespaces {
+								if string(kvsNamespaces[i].Key) == key {
+									return &clientv3.GetResponse{Kvs: kvsNamespaces[i:]}, nil
+								}
+							}
+
+							return &clientv3.GetResponse{Kvs: kvsNamespaces}, nil
+						},
+					}
+				}
+
+				nit := e.Namespace("").List(&list.Options{}).(*etcd.EtcdNamespacesIterator)
+				val, _, err := nit.Next(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(val.Name).To(Equal(namespaces[0].Name))
+				token := nit.NextPageToken()
+				Expect(token).NotTo(BeEmpty())
+
+				nit = e.Namespace("").List(&list.Options{
+					PageToken: token,
+				}).(*etcd.EtcdNamespacesIterator)
+				for i := 1; i < len(namespaces); i++ {
+					val, _, err := nit.Next(ctx)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(val.Name).To(Equal(namespaces[i].Name))
+				}
+				_, _, err = nit.Next(ctx)
+				Expect(err).To(MatchError(srerr.IteratorDone))
+				Expect(nit.NextPageToken()).To(BeEmpty())
+				Expect(keys).To(Equal([]string{"/", "/" + namespaces[0].Name}))
+			})
+
+			It("returns an error if the token is not valid", func() {
+				nit := e.Namespace("").List(&list.Options{
+					PageToken: "not-valid",
+				})
+				val, nop, err := nit.Next(ctx)
+				Expect(err).To(MatchError(srerr.InvalidPageToken))
+				Expect(val).To(BeNil())
+				Expect(nop).To(BeNil())
+			})
+		})
+
 		Context("with name filters", func() {
 			It("should only return requested namespaces", func() {
 				timesCalled := 0
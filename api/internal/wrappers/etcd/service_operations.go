@@ -25,7 +25,11 @@ import (
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/logging"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/pagetoken"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
 	"go.etcd.io/etcd/api/v3/mvccpb"
@@ -42,10 +46,29 @@ type etcdServiceOperation struct {
 }
 
 func (s *etcdServiceOperation) Get(ctx context.Context, opts *get.Options) (*coretypes.Service, error) {
-	if _, err := s.parentOp.Get(ctx, &get.Options{}); err != nil {
+	serv, err := s.lookup(ctx, opts)
+	if err != nil {
+		if stale, ok := s.wrapper.getStaleOnError(s.pathName, err).(*coretypes.Service); ok {
+			return stale, nil
+		}
+
+		return nil, err
+	}
+
+	return serv, nil
+}
+
+// lookup is like Get, but it never returns a stale service, as its callers
+// need the current one, e.g. to check its revision before updating it.
+func (s *etcdServiceOperation) lookup(ctx context.Context, opts *get.Options) (*coretypes.Service, error) {
+	if _, err := s.getParent(ctx); err != nil {
 		if srerr.IsNotFound(err) {
 			// Auto-correct any misconfiguration.
-			s.Delete(ctx)
+			logger := s.wrapper.logger.WithValues("namespace", s.parentOp.name, "service", s.name)
+			logger.V(logging.Debug).Info("deleting service as its namespace does not exist")
+			if err := s.Delete(ctx); err != nil {
+				logger.Error(err, "could not delete service without namespace")
+			}
 			return nil, srerr.NamespaceNotFound
 		}
 
@@ -53,13 +76,24 @@ func (s *etcdServiceOperation) Get(ctx context.Context, opts *get.Options) (*cor
 	}
 
 	if !opts.ForceRefresh {
-		if serv := s.wrapper.getFromCache(s.pathName); serv != nil {
+		if serv, found, err := s.wrapper.lookupOnCache(s.pathName); found {
+			tracing.SetCacheHit(ctx, true)
+			if err != nil {
+				return nil, err
+			}
+
 			return serv.(*coretypes.Service), nil
 		}
+
+		tracing.SetCacheHit(ctx, false)
 	}
 
-	keyValue, err := getOne(ctx, s.kv, s.name)
+	keyValue, err := s.wrapper.getOneShared(ctx, s.pathName, metrics.ServiceObject, s.kv, s.name, opts.ForceRefresh)
 	if err != nil {
+		if srerr.IsNotFound(err) {
+			s.wrapper.putNotFoundOnCache(s.pathName, err)
+		}
+
 		return nil, err
 	}
 
@@ -69,15 +103,34 @@ func (s *etcdServiceOperation) Get(ctx context.Context, opts *get.Options) (*cor
 		Decode(&serv); err != nil {
 		return nil, fmt.Errorf("error while trying to decode the resource: %w", err)
 	}
+	serv.Revision = revisionOf(keyValue)
 	serv.OriginalObject = keyValue
 	s.wrapper.putOnCache(s.pathName, &serv)
 
 	return &serv, nil
 }
 
+// getParent returns the parent namespace, unless the caller already
+// retrieved it and provided it in the context.
+func (s *etcdServiceOperation) getParent(ctx context.Context) (*coretypes.Namespace, error) {
+	if ns := ops.ParentNamespace(ctx, s.parentOp.name); ns != nil {
+		return ns, nil
+	}
+
+	return s.parentOp.lookup(ctx, &get.Options{})
+}
+
 func (s *etcdServiceOperation) Create(ctx context.Context, metadata map[string]string) (*coretypes.Service, error) {
+	return s.put(ctx, metadata, "")
+}
+
+func (s *etcdServiceOperation) Update(ctx context.Context, metadata map[string]string, expectedRevision string) (*coretypes.Service, error) {
+	return s.put(ctx, metadata, expectedRevision)
+}
+
+func (s *etcdServiceOperation) put(ctx context.Context, metadata map[string]string, expectedRevision string) (*coretypes.Service, error) {
 	// Does the namespace exist, though?
-	if _, err := s.parentOp.Get(ctx, &get.Options{}); err != nil {
+	if _, err := s.getParent(ctx); err != nil {
 		return nil, fmt.Errorf(`error while getting parent namespace "%s" before creating service: %w`, s.parentOp.name, err)
 	}
 
@@ -90,19 +143,15 @@ func (s *etcdServiceOperation) Create(ctx context.Context, metadata map[string]s
 		Namespace: s.parentOp.name,
 		Metadata:  metadata,
 	})
-	if _, err := s.kv.Put(ctx, prependSlash(s.name), string(nsBytes)); err != nil {
+	if err := putOne(ctx, s.kv, s.name, string(nsBytes), expectedRevision); err != nil {
 		return nil, err
 	}
 
-	return s.Get(ctx, &get.Options{})
-}
-
-func (s *etcdServiceOperation) Update(ctx context.Context, metadata map[string]string) (*coretypes.Service, error) {
-	return s.Create(ctx, metadata)
+	return s.lookup(ctx, &get.Options{ForceRefresh: true})
 }
 
 func (s *etcdServiceOperation) Delete(ctx context.Context) error {
-	defer s.wrapper.cache.Delete(s.pathName)
+	defer s.wrapper.removeFromCache(s.pathName)
 
 	// TODO: as of now, we delete all children of this. In future we will
 	// return an error if resource is not empty and an option to override
@@ -132,18 +181,28 @@ func (s *etcdServiceOperation) List(opts *list.Options) ops.ServiceLister {
 		opts.Results = int32(list.DefaultListResultsNumber)
 	}
 
+	// Invalid tokens are returned by Next, as any other error.
+	token, err := pagetoken.Decode(opts.PageToken)
+	lastKey := "/"
+	if token.Page != "" {
+		lastKey = token.Page
+	}
+
 	return &EtcdServicesIterator{
-		wrapper: s.wrapper,
-		kv:      s.kv,
-		options: opts,
-		hasMore: true,
-		lastKey: "/",
-		nsName:  s.parentOp.name,
+		wrapper:  s.wrapper,
+		pathName: path.Join(s.parentOp.pathName, pathServices),
+		kv:       s.kv,
+		options:  opts,
+		hasMore:  true,
+		lastKey:  lastKey,
+		err:      err,
+		nsName:   s.parentOp.name,
 	}
 }
 
 type EtcdServicesIterator struct {
 	wrapper   *EtcdWrapper
+	pathName  string
 	kv        clientv3.KV
 	currIndex int
 	hasMore   bool
@@ -151,9 +210,14 @@ type EtcdServicesIterator struct {
 	keyValues []*mvccpb.KeyValue
 	options   *list.Options
 	nsName    string
+	err       error
 }
 
 func (si *EtcdServicesIterator) Next(ctx context.Context) (*coretypes.Service, ops.ServiceOperation, error) {
+	if si.err != nil {
+		return nil, nil, si.err
+	}
+
 	if si.nsName == "" {
 		return nil, nil, fmt.Errorf("cannot get next resource: %w", srerr.EmptyNamespaceName)
 	}
@@ -171,6 +235,7 @@ func (si *EtcdServicesIterator) Next(ctx context.Context) (*coretypes.Service, o
 			// This is not a valid service
 			continue
 		}
+		serv.Revision = revisionOf(currKeyValue)
 		serv.OriginalObject = currKeyValue
 
 		if passed, _ := si.options.Filter(&serv); passed {
@@ -185,7 +250,7 @@ func (si *EtcdServicesIterator) Next(ctx context.Context) (*coretypes.Service, o
 	}
 
 	if si.hasMore {
-		values, err := getList(ctx, si.kv, si.lastKey, si.options.Results)
+		values, err := si.wrapper.getListShared(ctx, si.pathName, metrics.ServiceObject, si.kv, si.lastKey, si.options.Results)
 		if err != nil {
 			si.hasMore = false
 			return nil, nil, fmt.Errorf("could not get next results: %w", err)
@@ -208,6 +273,16 @@ func (si *EtcdServicesIterator) Next(ctx context.Context) (*coretypes.Service, o
 	return nil, nil, srerr.IteratorDone
 }
 
+// NextPageToken returns the last key returned by Next as the token, so that
+// listing can be resumed right after it.
+func (si *EtcdServicesIterator) NextPageToken() string {
+	if !si.hasMore && si.currIndex >= len(si.keyValues) {
+		return ""
+	}
+
+	return pagetoken.Encode(pagetoken.Token{Page: si.lastKey})
+}
+
 func (s *etcdServiceOperation) Endpoint(name string) ops.EndpointOperation {
 	return &etcdEndpointOperation{
 		name:     name,
@@ -44,7 +44,6 @@ var _ = Describe("Service Operations", func() {
 		cli  *clientv3.Client
 		serv *coretypes.Service
 		e    *etcd.EtcdWrapper
-		ctx  context.Context
 	)
 
 	BeforeEach(func() {
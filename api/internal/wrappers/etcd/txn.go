@@ -0,0 +1,166 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// maxTxnOps is the default maximum number of conditions and operations that
+// etcd accepts in a single transaction, i.e. its --max-txn-ops flag.
+const maxTxnOps = 128
+
+// Commit performs all steps in a single etcd transaction.
+//
+// Creations are only performed if the key does not exist yet and updates are
+// only performed if the key was not modified since the step was resolved. The
+// same goes for the parents of the objects that are created, which must still
+// exist unless they are created in the same transaction. If any of these
+// conditions is not met, nothing is applied and srerr.Conflict is returned.
+//
+// Each creation or update takes one operation and up to two conditions, and
+// each deletion two operations, as children are deleted as well: if there
+// are more than maxTxnOps of either, srerr.TooManyTxnOperations is returned
+// without contacting etcd, as it would reject the transaction anyways.
+func (c *EtcdWrapper) Commit(ctx context.Context, steps []ops.TxnStep) error {
+	var (
+		conditions = []clientv3.Cmp{}
+		operations = []clientv3.Op{}
+		created    = map[string]bool{}
+	)
+
+	for _, step := range steps {
+		key := stepKey(step)
+
+		switch step.Action {
+		case ops.TxnCreate, ops.TxnUpdate:
+			value, err := stepValue(step)
+			if err != nil {
+				return err
+			}
+
+			if step.Action == ops.TxnCreate {
+				conditions = append(conditions,
+					clientv3.Compare(clientv3.CreateRevision(key), "=", 0))
+				created[key] = true
+
+				if parentKey := path.Dir(path.Dir(key)); step.Service != "" && !created[parentKey] {
+					conditions = append(conditions,
+						clientv3.Compare(clientv3.CreateRevision(parentKey), ">", 0))
+				}
+			} else if step.Revision != "" {
+				modRevision, err := strconv.ParseInt(step.Revision, 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid revision %s: %w", step.Revision, srerr.Conflict)
+				}
+
+				conditions = append(conditions,
+					clientv3.Compare(clientv3.ModRevision(key), "=", modRevision))
+			}
+
+			operations = append(operations, clientv3.OpPut(key, value))
+		case ops.TxnDelete:
+			// Delete the object and all of its children.
+			operations = append(operations,
+				clientv3.OpDelete(key),
+				clientv3.OpDelete(key+"/", clientv3.WithPrefix()))
+		}
+	}
+
+	if len(conditions) > maxTxnOps || len(operations) > maxTxnOps {
+		return fmt.Errorf("%w: %d steps need %d conditions and %d operations, while etcd allows up to %d of each by default",
+			srerr.TooManyTxnOperations, len(steps), len(conditions), len(operations), maxTxnOps)
+	}
+
+	resp, err := NewKV(c.kv, "").Txn(ctx).
+		If(conditions...).
+		Then(operations...).
+		Commit()
+	if err != nil {
+		return err
+	}
+
+	if !resp.Succeeded {
+		return srerr.Conflict
+	}
+
+	for _, step := range steps {
+		c.removeFromCache(stepKey(step))
+	}
+
+	return nil
+}
+
+func stepKey(step ops.TxnStep) string {
+	key := path.Join(pathNamespaces, step.Namespace)
+	if step.Service != "" {
+		key = path.Join(key, pathServices, step.Service)
+	}
+	if step.Endpoint != "" {
+		key = path.Join(key, pathEndpoints, step.Endpoint)
+	}
+
+	return key
+}
+
+func stepValue(step ops.TxnStep) (string, error) {
+	metadata := step.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	var object interface{}
+	switch {
+	case step.Endpoint != "":
+		object = &coretypes.Endpoint{
+			Name:      step.Endpoint,
+			Namespace: step.Namespace,
+			Service:   step.Service,
+			Address:   step.Address,
+			Port:      step.Port,
+			Metadata:  metadata,
+		}
+	case step.Service != "":
+		object = &coretypes.Service{
+			Name:      step.Service,
+			Namespace: step.Namespace,
+			Metadata:  metadata,
+		}
+	default:
+		object = &coretypes.Namespace{
+			Name:     step.Namespace,
+			Metadata: metadata,
+		}
+	}
+
+	value, err := yaml.Marshal(object)
+	if err != nil {
+		return "", fmt.Errorf("error while trying to encode the resource: %w", err)
+	}
+
+	return string(value), nil
+}
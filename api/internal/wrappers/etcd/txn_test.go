@@ -0,0 +1,157 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package etcd_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/etcd"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	etcdns "go.etcd.io/etcd/client/v3/namespace"
+	"gopkg.in/yaml.v3"
+)
+
+var _ = Describe("Transactions", func() {
+	var (
+		e     *etcd.EtcdWrapper
+		steps = []ops.TxnStep{
+			{
+				Action:    ops.TxnCreate,
+				Namespace: "ns",
+				Service:   "serv",
+				Metadata:  map[string]string{"key": "val"},
+			},
+			{
+				Action:    ops.TxnUpdate,
+				Namespace: "ns",
+				Service:   "serv",
+				Endpoint:  "endp",
+				Address:   "10.10.10.10",
+				Port:      80,
+				Revision:  "4",
+			},
+			{
+				Action:    ops.TxnDelete,
+				Namespace: "ns",
+				Service:   "serv",
+				Endpoint:  "old",
+			},
+		}
+	)
+
+	BeforeEach(func() {
+		e, _ = etcd.NewEtcdWrapper(&clientv3.Client{}, &wrapper.Options{CacheExpirationTime: time.Minute})
+		etcd.NewKV = etcdns.NewKV
+	})
+
+	It("performs all steps in a single transaction", func() {
+		succeeded := true
+		etcd.NewKV = func(kv clientv3.KV, prefix string) clientv3.KV {
+			Expect(prefix).To(BeEmpty())
+			return &fakeKV{
+				_Txn: func(ctx context.Context) clientv3.Txn {
+					return &fakeTxn{
+						_Commit: func(t *fakeTxn) (*clientv3.TxnResponse, error) {
+							Expect(t.cmps).To(Equal([]clientv3.Cmp{
+								clientv3.Compare(clientv3.CreateRevision("/namespaces/ns/services/serv"), "=", 0),
+								clientv3.Compare(clientv3.CreateRevision("/namespaces/ns"), ">", 0),
+								clientv3.Compare(clientv3.ModRevision("/namespaces/ns/services/serv/endpoints/endp"), "=", 4),
+							}))
+
+							servBytes, _ := yaml.Marshal(&coretypes.Service{
+								Name:      "serv",
+								Namespace: "ns",
+								Metadata:  map[string]string{"key": "val"},
+							})
+							endpBytes, _ := yaml.Marshal(&coretypes.Endpoint{
+								Name:      "endp",
+								Namespace: "ns",
+								Service:   "serv",
+								Address:   "10.10.10.10",
+								Port:      80,
+								Metadata:  map[string]string{},
+							})
+							Expect(t.thenOps).To(Equal([]clientv3.Op{
+								clientv3.OpPut("/namespaces/ns/services/serv", string(servBytes)),
+								clientv3.OpPut("/namespaces/ns/services/serv/endpoints/endp", string(endpBytes)),
+								clientv3.OpDelete("/namespaces/ns/services/serv/endpoints/old"),
+								clientv3.OpDelete("/namespaces/ns/services/serv/endpoints/old/", clientv3.WithPrefix()),
+							}))
+
+							return &clientv3.TxnResponse{Succeeded: succeeded}, nil
+						},
+					}
+				},
+			}
+		}
+
+		By("returning no errors if it succeeded", func() {
+			Expect(e.Commit(ctx, steps)).To(Succeed())
+		})
+
+		By("returning a conflict if any condition was not met", func() {
+			succeeded = false
+			Expect(e.Commit(ctx, steps)).To(MatchError(srerr.Conflict))
+		})
+	})
+
+	Context("in case of errors", func() {
+		It("returns the same error", func() {
+			expErr := fmt.Errorf("whatever")
+			etcd.NewKV = func(kv clientv3.KV, prefix string) clientv3.KV {
+				return &fakeKV{
+					_Txn: func(ctx context.Context) clientv3.Txn {
+						return &fakeTxn{
+							_Commit: func(t *fakeTxn) (*clientv3.TxnResponse, error) {
+								return nil, expErr
+							},
+						}
+					},
+				}
+			}
+
+			Expect(e.Commit(ctx, steps)).To(MatchError(expErr))
+		})
+
+		It("does not exceed the operations allowed by etcd", func() {
+			etcd.NewKV = func(kv clientv3.KV, prefix string) clientv3.KV {
+				Fail("should not perform the transaction")
+				return nil
+			}
+
+			deletions := []ops.TxnStep{}
+			for i := 0; i < 65; i++ {
+				deletions = append(deletions, ops.TxnStep{
+					Action:    ops.TxnDelete,
+					Namespace: "ns",
+					Service:   fmt.Sprintf("serv-%d", i),
+				})
+			}
+
+			Expect(e.Commit(ctx, deletions)).To(MatchError(srerr.TooManyTxnOperations))
+		})
+	})
+})
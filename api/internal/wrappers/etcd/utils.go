@@ -19,6 +19,8 @@ package etcd
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"strings"
 
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
@@ -39,6 +41,47 @@ func getOne(ctx context.Context, kv clientv3.KV, name string) (*mvccpb.KeyValue,
 	return resp.Kvs[0], nil
 }
 
+// putOne puts the value on the given key. If an expected revision is provided,
+// the value is only put if the key was not modified since that revision, in
+// which case the check and the put are done atomically in a transaction.
+func putOne(ctx context.Context, kv clientv3.KV, name, value, expectedRevision string) error {
+	key := prependSlash(name)
+
+	if expectedRevision == "" {
+		_, err := kv.Put(ctx, key, value)
+		return err
+	}
+
+	modRevision, err := strconv.ParseInt(expectedRevision, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid revision %s: %w", expectedRevision, srerr.Conflict)
+	}
+
+	resp, err := kv.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", modRevision)).
+		Then(clientv3.OpPut(key, value)).
+		Commit()
+	if err != nil {
+		return err
+	}
+
+	if !resp.Succeeded {
+		return srerr.Conflict
+	}
+
+	return nil
+}
+
+// revisionOf returns the revision of the key value as a string, or an empty
+// string if the key value has never been stored on etcd.
+func revisionOf(kv *mvccpb.KeyValue) string {
+	if kv.ModRevision == 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(kv.ModRevision, 10)
+}
+
 func getList(ctx context.Context, kv clientv3.KV, name string, limit int32) ([]*mvccpb.KeyValue, error) {
 	etcdLimit := int64(limit)
 	resp, err := kv.Get(ctx, prependSlash(name), clientv3.WithFromKey(), clientv3.WithLimit(etcdLimit))
@@ -0,0 +1,101 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package etcd
+
+import (
+	"context"
+	"time"
+
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	"github.com/CloudNativeSDWAN/serego/api/internal/logging"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// rewatchDelay is the time to wait before watching again after the watch
+// was interrupted, e.g. because etcd was not reachable.
+var rewatchDelay = time.Second
+
+// watch watches all keys of namespaces, services and endpoints and removes
+// the ones that change from cache, so that objects modified by other
+// processes are not returned from cache. It returns when ctx is done.
+func (c *EtcdWrapper) watch(ctx context.Context) {
+	var lastRevision int64
+	for {
+		opts := []clientv3.OpOption{clientv3.WithPrefix()}
+		if lastRevision > 0 {
+			// Don't miss events that happened while not watching.
+			opts = append(opts, clientv3.WithRev(lastRevision+1))
+		}
+
+		for resp := range c.client.Watch(clientv3.WithRequireLeader(ctx), pathNamespaces, opts...) {
+			if resp.CompactRevision > 0 {
+				// Events until the compacted revision are lost, so any object
+				// on cache may have been modified: remove them all and watch
+				// again from the oldest revision still available.
+				c.logger.Info("could not watch all changes, as they were compacted, removing all objects from cache", "revision", lastRevision+1, "compactRevision", resp.CompactRevision)
+				c.cache.Purge()
+				lastRevision = resp.CompactRevision - 1
+			}
+
+			for _, ev := range resp.Events {
+				c.invalidate(ev)
+				lastRevision = ev.Kv.ModRevision
+			}
+		}
+
+		if ctx.Err() == nil {
+			c.logger.V(logging.Debug).Info("watch interrupted, watching again", "delay", rewatchDelay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(rewatchDelay):
+		}
+	}
+}
+
+// invalidate removes the object of the key that changed from cache, unless
+// it is the same revision that is already on cache, e.g. because it was
+// modified by this process.
+func (c *EtcdWrapper) invalidate(ev *clientv3.Event) {
+	pathName := string(ev.Kv.Key)
+	if ev.Type == mvccpb.PUT {
+		// Peek, not to count this as a lookup or a use of the object.
+		if cachedRevision(c.peekOnCache(pathName)) == revisionOf(ev.Kv) {
+			return
+		}
+	}
+
+	c.logger.V(logging.Debug).Info("removing object modified on etcd from cache", "key", pathName)
+	c.removeFromCache(pathName)
+}
+
+func cachedRevision(object interface{}) string {
+	switch obj := object.(type) {
+	case *coretypes.Namespace:
+		return obj.Revision
+	case *coretypes.Service:
+		return obj.Revision
+	case *coretypes.Endpoint:
+		return obj.Revision
+	}
+
+	return ""
+}
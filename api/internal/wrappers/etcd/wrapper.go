@@ -18,14 +18,25 @@
 package etcd
 
 import (
+	"context"
+	"fmt"
 	"path"
+	"time"
 
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/breaker"
+	"github.com/CloudNativeSDWAN/serego/api/internal/cache"
+	"github.com/CloudNativeSDWAN/serego/api/internal/logging"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/ratelimit"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
 	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
-	"github.com/patrickmn/go-cache"
+	"github.com/go-logr/logr"
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	etcdns "go.etcd.io/etcd/client/v3/namespace"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -44,7 +55,15 @@ func init() {
 
 type EtcdWrapper struct {
 	client *clientv3.Client
-	cache  *cache.Cache
+	// kv is the client's KV, guarded by the rate limiter and circuit
+	// breaker if required by the options.
+	kv      clientv3.KV
+	cache   *cache.Cache
+	metrics *metrics.Metrics
+	tracer  trace.Tracer
+	logger  logr.Logger
+	// calls coalesces concurrent reads of the same objects and pages.
+	calls cache.Group
 }
 
 func NewEtcdWrapper(client *clientv3.Client, wopts *wrapper.Options) (*EtcdWrapper, error) {
@@ -52,35 +71,117 @@ func NewEtcdWrapper(client *clientv3.Client, wopts *wrapper.Options) (*EtcdWrapp
 		return nil, srerr.NoClientProvided
 	}
 
-	return &EtcdWrapper{
-		client: client,
+	var kv clientv3.KV = client.KV
+	limiter, brk := ratelimit.New(wopts), breaker.New(wopts)
+	if limiter != nil || brk != nil {
+		kv = &guardedKV{KV: kv, limiter: limiter, breaker: brk}
+	}
+
+	m, err := metrics.New(wopts, metrics.Etcd)
+	if err != nil {
+		return nil, fmt.Errorf("could not register metrics: %w", err)
+	}
+
+	e := &EtcdWrapper{
+		client:  client,
+		kv:      kv,
+		metrics: m,
+		tracer:  tracing.New(wopts),
+		logger:  logging.New(wopts).WithValues("backend", metrics.Etcd),
 		cache: func() *cache.Cache {
 			if wopts.CacheExpirationTime == 0 {
 				return nil
 			}
 
-			return cache.New(wopts.CacheExpirationTime, wrapper.DefaultCacheCleanUpTime)
+			return cache.New(wopts, wopts.CacheExpirationTime)
 		}(),
-	}, nil
+	}
+	e.cache.SetMetrics(m)
+	e.cache.SetLogger(e.logger)
+
+	if wopts.CacheInvalidation && e.cache != nil {
+		// The client's context is canceled when it is closed.
+		ctx := client.Ctx()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		go e.watch(ctx)
+	}
+
+	return e, nil
 }
 
 func (c *EtcdWrapper) putOnCache(pathName string, object interface{}) {
-	if c.cache != nil {
-		c.cache.SetDefault(pathName, object)
-	}
+	c.cache.Set(pathName, object)
 }
 
-func (c *EtcdWrapper) getFromCache(pathName string) interface{} {
-	if c.cache == nil {
-		return nil
+func (c *EtcdWrapper) removeFromCache(pathName string) {
+	c.cache.Delete(pathName)
+}
+
+func (c *EtcdWrapper) peekOnCache(pathName string) interface{} {
+	return c.cache.Peek(pathName)
+}
+
+func (c *EtcdWrapper) putNotFoundOnCache(pathName string, err error) {
+	c.cache.SetNotFound(pathName, err)
+}
+
+func (c *EtcdWrapper) lookupOnCache(pathName string) (interface{}, bool, error) {
+	return c.cache.Lookup(pathName)
+}
+
+func (c *EtcdWrapper) getStaleOnError(pathName string, err error) interface{} {
+	return c.cache.GetStaleOnError(pathName, err)
+}
+
+// getOneShared gets the object with the provided name from kv, sharing the
+// call with all the concurrent ones for the same path unless forceRefresh is
+// true, as in that case it may have been modified after those started. Only
+// calls actually performed are recorded on metrics, as an operation on an
+// object of the provided kind, not the ones served from cache.
+func (c *EtcdWrapper) getOneShared(ctx context.Context, pathName, object string, kv clientv3.KV, name string, forceRefresh bool) (*mvccpb.KeyValue, error) {
+	measured := func(ctx context.Context) (interface{}, error) {
+		start := time.Now()
+		keyValue, err := getOne(ctx, kv, name)
+		c.metrics.ObserveOperation(object, string(wrapper.GetOperation), start, err)
+		return keyValue, err
+	}
+
+	var (
+		keyValue interface{}
+		err      error
+	)
+	if forceRefresh {
+		keyValue, err = measured(ctx)
+	} else {
+		keyValue, err = c.calls.Do(ctx, pathName, measured)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	return keyValue.(*mvccpb.KeyValue), nil
+}
 
-	object, found := c.cache.Get(pathName)
-	if !found {
-		return nil
+// getListShared gets a page of objects of the provided kind from kv, sharing
+// the call with all the concurrent ones for the same page of the same path.
+func (c *EtcdWrapper) getListShared(ctx context.Context, pathName, object string, kv clientv3.KV, name string, limit int32) ([]*mvccpb.KeyValue, error) {
+	ctx, span := tracing.StartListPage(ctx, c.tracer, metrics.Etcd, object)
+	key := fmt.Sprintf("%s?from=%s&limit=%d", pathName, name, limit)
+	keyValues, err := c.calls.Do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		start := time.Now()
+		keyValues, err := getList(ctx, kv, name, limit)
+		c.metrics.ObserveOperation(object, metrics.ListPage, start, err)
+		return keyValues, err
+	})
+	tracing.End(span, err)
+	if err != nil {
+		return nil, err
 	}
 
-	return object
+	return keyValues.([]*mvccpb.KeyValue), nil
 }
 
 func (c *EtcdWrapper) Namespace(name string) ops.NamespaceOperation {
@@ -88,6 +189,6 @@ func (c *EtcdWrapper) Namespace(name string) ops.NamespaceOperation {
 		name:     name,
 		pathName: path.Join(pathNamespaces, name),
 		wrapper:  c,
-		kv:       NewKV(c.client.KV, pathNamespaces),
+		kv:       NewKV(c.kv, pathNamespaces),
 	}
 }
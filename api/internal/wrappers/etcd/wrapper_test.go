@@ -18,12 +18,20 @@
 package etcd_test
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	etcdns "go.etcd.io/etcd/client/v3/namespace"
 
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
 	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/etcd"
+	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
 )
 
@@ -43,4 +51,225 @@ var _ = Describe("Wrapper", func() {
 			})
 		})
 	})
+	Describe("Using rate limits", func() {
+		AfterEach(func() {
+			etcd.NewKV = etcdns.NewKV
+		})
+
+		It("limits calls to etcd", func() {
+			calls := 0
+			etcd.NewKV = func(kv clientv3.KV, _ string) clientv3.KV {
+				return kv
+			}
+			cl := &clientv3.Client{
+				KV: &fakeKV{
+					_Get: func(_ context.Context, key string, _ ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+						calls++
+						return &clientv3.GetResponse{Kvs: []*mvccpb.KeyValue{kvsNamespaces[0]}}, nil
+					},
+				},
+			}
+			e, _ := etcd.NewEtcdWrapper(cl, &wrapper.Options{
+				RateLimit:         &wrapper.RateLimit{RequestsPerSecond: 0.001, Burst: 1},
+				RateLimitFailFast: true,
+			})
+
+			_, err := e.Namespace("ns-1").Get(ctx, &get.Options{})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = e.Namespace("ns-1").Get(ctx, &get.Options{})
+			Expect(err).To(MatchError(srerr.RateLimitExceeded))
+			Expect(calls).To(Equal(1))
+		})
+	})
+
+	Describe("Serving stale objects", func() {
+		AfterEach(func() {
+			etcd.NewKV = etcdns.NewKV
+		})
+
+		It("returns expired objects when etcd is unavailable", func() {
+			var (
+				calls  = 0
+				getErr error
+			)
+			etcd.NewKV = func(kv clientv3.KV, _ string) clientv3.KV {
+				return kv
+			}
+			cl := &clientv3.Client{
+				KV: &fakeKV{
+					_Get: func(_ context.Context, key string, _ ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+						calls++
+						if getErr != nil {
+							return nil, getErr
+						}
+
+						return &clientv3.GetResponse{Kvs: []*mvccpb.KeyValue{kvsNamespaces[0]}}, nil
+					},
+				},
+			}
+			e, _ := etcd.NewEtcdWrapper(cl, &wrapper.Options{
+				CacheExpirationTime: time.Millisecond,
+				StaleGracePeriod:    time.Hour,
+				CircuitBreaker:      &wrapper.CircuitBreaker{FailureThreshold: 1, OpenTimeout: time.Hour},
+			})
+
+			ns, err := e.Namespace("ns-1").Get(ctx, &get.Options{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ns.Stale).To(BeFalse())
+			time.Sleep(2 * time.Millisecond)
+
+			By("returning errors that are not transient", func() {
+				getErr = fmt.Errorf("whatever")
+				_, err := e.Namespace("ns-1").Get(ctx, &get.Options{})
+				Expect(err).To(MatchError(getErr))
+			})
+
+			By("flagging them as stale", func() {
+				getErr = rpctypes.ErrLeaderChanged
+				staleNs, err := e.Namespace("ns-1").Get(ctx, &get.Options{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(staleNs.Stale).To(BeTrue())
+				Expect(staleNs.Name).To(Equal(ns.Name))
+				Expect(ns.Stale).To(BeFalse())
+			})
+
+			By("not calling etcd while the circuit is open", func() {
+				_, err := e.Namespace("ns-2").Get(ctx, &get.Options{})
+				Expect(err).To(MatchError(srerr.CircuitOpen))
+				Expect(calls).To(Equal(3))
+			})
+		})
+	})
+	Describe("Getting objects that do not exist", func() {
+		AfterEach(func() {
+			etcd.NewKV = etcdns.NewKV
+		})
+
+		It("remembers that they were not found until they are created", func() {
+			var (
+				calls   = 0
+				created = false
+			)
+			etcd.NewKV = func(kv clientv3.KV, _ string) clientv3.KV {
+				return kv
+			}
+			cl := &clientv3.Client{
+				KV: &fakeKV{
+					_Get: func(_ context.Context, key string, _ ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+						calls++
+						if !created {
+							return &clientv3.GetResponse{}, nil
+						}
+
+						return &clientv3.GetResponse{Kvs: []*mvccpb.KeyValue{kvsNamespaces[0]}}, nil
+					},
+					_Put: func(_ context.Context, _, _ string, _ ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+						created = true
+						return &clientv3.PutResponse{}, nil
+					},
+				},
+			}
+			e, _ := etcd.NewEtcdWrapper(cl, &wrapper.Options{
+				CacheExpirationTime:         time.Minute,
+				NegativeCacheExpirationTime: time.Minute,
+			})
+
+			for i := 0; i < 3; i++ {
+				_, err := e.Namespace("ns-1").Get(ctx, &get.Options{})
+				Expect(err).To(MatchError(srerr.NotFound))
+			}
+			Expect(calls).To(Equal(1))
+
+			_, err := e.Namespace("ns-1").Create(ctx, map[string]string{})
+			Expect(err).NotTo(HaveOccurred())
+			ns, err := e.Namespace("ns-1").Get(ctx, &get.Options{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ns.Name).To(Equal("ns-1"))
+			Expect(calls).To(Equal(2))
+		})
+	})
+
+	Describe("Invalidating cache", func() {
+		AfterEach(func() {
+			etcd.NewKV = etcdns.NewKV
+		})
+
+		It("removes objects modified by others from cache", func() {
+			var (
+				calls            = 0
+				events           = make(chan clientv3.WatchResponse)
+				watchCtx, cancel = context.WithCancel(ctx)
+				keyValue         = &mvccpb.KeyValue{
+					Key:         []byte("/namespaces/ns-1"),
+					Value:       kvsNamespaces[0].Value,
+					ModRevision: 5,
+				}
+			)
+			defer cancel()
+
+			etcd.NewKV = func(kv clientv3.KV, _ string) clientv3.KV {
+				return kv
+			}
+			// The watch is stopped when the client's context is canceled.
+			cl := clientv3.NewCtxClient(watchCtx)
+			cl.KV = &fakeKV{
+				_Get: func(_ context.Context, key string, _ ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+					calls++
+					return &clientv3.GetResponse{Kvs: []*mvccpb.KeyValue{keyValue}}, nil
+				},
+			}
+			cl.Watcher = &fakeWatcher{
+				_Watch: func(_ context.Context, key string, _ ...clientv3.OpOption) clientv3.WatchChan {
+					defer GinkgoRecover()
+					Expect(key).To(Equal("/namespaces"))
+					return events
+				},
+			}
+			lru, _ := wrapper.NewLRUCache(10)
+			e, _ := etcd.NewEtcdWrapper(cl, &wrapper.Options{
+				Cache:               lru,
+				CacheExpirationTime: time.Minute,
+				CacheInvalidation:   true,
+			})
+			getNamespace := func() int {
+				_, err := e.Namespace("ns-1").Get(ctx, &get.Options{})
+				Expect(err).NotTo(HaveOccurred())
+				return calls
+			}
+
+			Expect(getNamespace()).To(Equal(1))
+
+			By("keeping objects with the same revision", func() {
+				stats := lru.Stats()
+				events <- clientv3.WatchResponse{Events: []*clientv3.Event{
+					{Type: mvccpb.PUT, Kv: keyValue},
+				}}
+				// The first event was handled when the next one is received.
+				events <- clientv3.WatchResponse{}
+				Expect(lru.Stats()).To(Equal(stats), "checking the revision is not a lookup")
+				Expect(getNamespace()).To(Equal(1))
+			})
+
+			By("removing objects that were modified", func() {
+				events <- clientv3.WatchResponse{Events: []*clientv3.Event{
+					{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: keyValue.Key, ModRevision: 6}},
+				}}
+				Eventually(getNamespace).Should(Equal(2))
+			})
+
+			By("removing objects that were deleted", func() {
+				events <- clientv3.WatchResponse{Events: []*clientv3.Event{
+					{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: keyValue.Key, ModRevision: 7}},
+				}}
+				Eventually(getNamespace).Should(Equal(3))
+			})
+
+			By("removing all objects if changes were compacted", func() {
+				Expect(getNamespace()).To(Equal(3))
+				events <- clientv3.WatchResponse{CompactRevision: 10}
+				Eventually(getNamespace).Should(Equal(4))
+			})
+		})
+	})
 })
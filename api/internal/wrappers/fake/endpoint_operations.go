@@ -30,7 +30,7 @@ type EndpointOperation struct {
 	Name_   string
 	Get_    func(context.Context, *get.Options) (*coretypes.Endpoint, error)
 	Create_ func(context.Context, string, int32, map[string]string) (*coretypes.Endpoint, error)
-	Update_ func(context.Context, string, int32, map[string]string) (*coretypes.Endpoint, error)
+	Update_ func(context.Context, string, int32, map[string]string, string) (*coretypes.Endpoint, error)
 	Delete_ func(context.Context) error
 	List_   func(*list.Options) ops.EndpointLister
 }
@@ -43,8 +43,8 @@ func (e *EndpointOperation) Create(ctx context.Context, address string, port int
 	return e.Create_(ctx, address, port, metadata)
 }
 
-func (e *EndpointOperation) Update(ctx context.Context, address string, port int32, metadata map[string]string) (*coretypes.Endpoint, error) {
-	return e.Update_(ctx, address, port, metadata)
+func (e *EndpointOperation) Update(ctx context.Context, address string, port int32, metadata map[string]string, expectedRevision string) (*coretypes.Endpoint, error) {
+	return e.Update_(ctx, address, port, metadata, expectedRevision)
 }
 
 func (e *EndpointOperation) Delete(ctx context.Context) error {
@@ -56,9 +56,18 @@ func (e *EndpointOperation) List(opts *list.Options) ops.EndpointLister {
 }
 
 type FakeEndpointIterator struct {
-	Next_ func(ctx context.Context) (*coretypes.Endpoint, ops.EndpointOperation, error)
+	Next_          func(ctx context.Context) (*coretypes.Endpoint, ops.EndpointOperation, error)
+	NextPageToken_ func() string
 }
 
 func (e *FakeEndpointIterator) Next(ctx context.Context) (*coretypes.Endpoint, ops.EndpointOperation, error) {
 	return e.Next_(ctx)
 }
+
+func (e *FakeEndpointIterator) NextPageToken() string {
+	if e.NextPageToken_ == nil {
+		return ""
+	}
+
+	return e.NextPageToken_()
+}
@@ -30,7 +30,7 @@ type NamespaceOperation struct {
 	Name_    string
 	Get_     func(context.Context, *get.Options) (*coretypes.Namespace, error)
 	Create_  func(context.Context, map[string]string) (*coretypes.Namespace, error)
-	Update_  func(context.Context, map[string]string) (*coretypes.Namespace, error)
+	Update_  func(context.Context, map[string]string, string) (*coretypes.Namespace, error)
 	Delete_  func(context.Context) error
 	List_    func(*list.Options) ops.NamespaceLister
 	Service_ func(string) ops.ServiceOperation
@@ -44,8 +44,8 @@ func (n *NamespaceOperation) Create(ctx context.Context, metadata map[string]str
 	return n.Create_(ctx, metadata)
 }
 
-func (n *NamespaceOperation) Update(ctx context.Context, metadata map[string]string) (*coretypes.Namespace, error) {
-	return n.Update_(ctx, metadata)
+func (n *NamespaceOperation) Update(ctx context.Context, metadata map[string]string, expectedRevision string) (*coretypes.Namespace, error) {
+	return n.Update_(ctx, metadata, expectedRevision)
 }
 
 func (n *NamespaceOperation) Delete(ctx context.Context) error {
@@ -57,13 +57,22 @@ func (n *NamespaceOperation) List(opts *list.Options) ops.NamespaceLister {
 }
 
 type FakeNamespaceIterator struct {
-	Next_ func(context.Context) (*coretypes.Namespace, ops.NamespaceOperation, error)
+	Next_          func(context.Context) (*coretypes.Namespace, ops.NamespaceOperation, error)
+	NextPageToken_ func() string
 }
 
 func (ni *FakeNamespaceIterator) Next(ctx context.Context) (*coretypes.Namespace, ops.NamespaceOperation, error) {
 	return ni.Next_(ctx)
 }
 
+func (ni *FakeNamespaceIterator) NextPageToken() string {
+	if ni.NextPageToken_ == nil {
+		return ""
+	}
+
+	return ni.NextPageToken_()
+}
+
 func (n *NamespaceOperation) Service(name string) ops.ServiceOperation {
 	return n.Service_(name)
 }
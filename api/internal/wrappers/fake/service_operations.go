@@ -30,7 +30,7 @@ type ServiceOperation struct {
 	Name_     string
 	Get_      func(context.Context, *get.Options) (*coretypes.Service, error)
 	Create_   func(context.Context, map[string]string) (*coretypes.Service, error)
-	Update_   func(context.Context, map[string]string) (*coretypes.Service, error)
+	Update_   func(context.Context, map[string]string, string) (*coretypes.Service, error)
 	Delete_   func(context.Context) error
 	List_     func(*list.Options) ops.ServiceLister
 	Endpoint_ func(name string) ops.EndpointOperation
@@ -44,8 +44,8 @@ func (s *ServiceOperation) Create(ctx context.Context, metadata map[string]strin
 	return s.Create_(ctx, metadata)
 }
 
-func (s *ServiceOperation) Update(ctx context.Context, metadata map[string]string) (*coretypes.Service, error) {
-	return s.Update_(ctx, metadata)
+func (s *ServiceOperation) Update(ctx context.Context, metadata map[string]string, expectedRevision string) (*coretypes.Service, error) {
+	return s.Update_(ctx, metadata, expectedRevision)
 }
 
 func (s *ServiceOperation) Delete(ctx context.Context) error {
@@ -57,13 +57,22 @@ func (s *ServiceOperation) List(opts *list.Options) ops.ServiceLister {
 }
 
 type FakeServiceIterator struct {
-	Next_ func(ctx context.Context) (*coretypes.Service, ops.ServiceOperation, error)
+	Next_          func(ctx context.Context) (*coretypes.Service, ops.ServiceOperation, error)
+	NextPageToken_ func() string
 }
 
 func (s *FakeServiceIterator) Next(ctx context.Context) (*coretypes.Service, ops.ServiceOperation, error) {
 	return s.Next_(ctx)
 }
 
+func (s *FakeServiceIterator) NextPageToken() string {
+	if s.NextPageToken_ == nil {
+		return ""
+	}
+
+	return s.NextPageToken_()
+}
+
 func (s *ServiceOperation) Endpoint(name string) ops.EndpointOperation {
 	return s.Endpoint_(name)
 }
@@ -18,6 +18,8 @@
 package fake
 
 import (
+	"context"
+
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
 )
 
@@ -32,3 +34,16 @@ func NewFakeWrapper() (*FakeWrapper, error) {
 func (m *FakeWrapper) Namespace(name string) ops.NamespaceOperation {
 	return m.Namespace_(name)
 }
+
+type FakeTransactionalWrapper struct {
+	*FakeWrapper
+	Commit_ func(context.Context, []ops.TxnStep) error
+}
+
+func NewFakeTransactionalWrapper() (*FakeTransactionalWrapper, error) {
+	return &FakeTransactionalWrapper{FakeWrapper: &FakeWrapper{}}, nil
+}
+
+func (m *FakeTransactionalWrapper) Commit(ctx context.Context, steps []ops.TxnStep) error {
+	return m.Commit_(ctx, steps)
+}
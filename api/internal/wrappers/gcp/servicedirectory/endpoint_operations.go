@@ -20,9 +20,14 @@ package servicedirectory
 import (
 	"context"
 	"path"
+	"strconv"
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/revision"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
 	pb "google.golang.org/genproto/googleapis/cloud/servicedirectory/v1"
@@ -37,20 +42,48 @@ type sdEndpointOperation struct {
 }
 
 func (e *sdEndpointOperation) Get(ctx context.Context, opts *get.Options) (*coretypes.Endpoint, error) {
+	endp, err := e.lookup(ctx, opts)
+	if err != nil {
+		if stale, ok := e.wrapper.getStaleOnError(e.pathName, err).(*coretypes.Endpoint); ok {
+			return stale, nil
+		}
+
+		return nil, err
+	}
+
+	return endp, nil
+}
+
+// lookup is like Get, but it never returns a stale endpoint, as its callers
+// need the current one, e.g. to check its revision before updating it.
+func (e *sdEndpointOperation) lookup(ctx context.Context, opts *get.Options) (*coretypes.Endpoint, error) {
 	if !opts.ForceRefresh {
-		if ep := e.wrapper.getFromCache(e.pathName); ep != nil {
+		if ep, found, err := e.wrapper.lookupOnCache(e.pathName); found {
+			tracing.SetCacheHit(ctx, true)
+			if err != nil {
+				return nil, err
+			}
+
 			return ep.(*coretypes.Endpoint), nil
 		}
+
+		tracing.SetCacheHit(ctx, false)
 	}
 
-	ep, err := e.wrapper.client.GetEndpoint(ctx, &pb.GetEndpointRequest{
-		Name: e.pathName,
+	ep, err := e.wrapper.shared(ctx, e.pathName, metrics.EndpointObject, opts.ForceRefresh, func(ctx context.Context) (interface{}, error) {
+		return e.wrapper.client.GetEndpoint(ctx, &pb.GetEndpointRequest{
+			Name: e.pathName,
+		})
 	})
 	if err != nil {
+		if srerr.IsNotFound(err) {
+			e.wrapper.putNotFoundOnCache(e.pathName, err)
+		}
+
 		return nil, err
 	}
 
-	endpoint := toCoreEndpoint(ep)
+	endpoint := toCoreEndpoint(ep.(*pb.Endpoint))
 	e.wrapper.putOnCache(e.pathName, endpoint)
 
 	return endpoint, nil
@@ -77,7 +110,20 @@ func (e *sdEndpointOperation) Create(ctx context.Context, address string, port i
 	return endpoint, nil
 }
 
-func (e *sdEndpointOperation) Update(ctx context.Context, address string, port int32, metadata map[string]string) (*coretypes.Endpoint, error) {
+func (e *sdEndpointOperation) Update(ctx context.Context, address string, port int32, metadata map[string]string, expectedRevision string) (*coretypes.Endpoint, error) {
+	if expectedRevision != "" {
+		// Service Directory does not support conditional updates, so the
+		// best we can do is checking it again right before updating it.
+		curr, err := e.lookup(ctx, &get.Options{ForceRefresh: true})
+		if err != nil {
+			return nil, err
+		}
+
+		if curr.Revision != expectedRevision {
+			return nil, srerr.Conflict
+		}
+	}
+
 	res, err := e.wrapper.client.UpdateEndpoint(ctx, &pb.UpdateEndpointRequest{
 		Endpoint: &pb.Endpoint{
 			Name:        e.pathName,
@@ -100,7 +146,7 @@ func (e *sdEndpointOperation) Update(ctx context.Context, address string, port i
 }
 
 func (e *sdEndpointOperation) Delete(ctx context.Context) error {
-	e.wrapper.cache.Delete(e.pathName)
+	e.wrapper.removeFromCache(e.pathName)
 
 	return e.wrapper.client.DeleteEndpoint(ctx, &pb.DeleteEndpointRequest{
 		Name: e.pathName,
@@ -116,10 +162,21 @@ func (e *sdEndpointOperation) List(opts *list.Options) ops.EndpointLister {
 		opts.NameFilters.In = append(opts.NameFilters.In, e.name)
 	}
 
+	// Done here rather than in Next, so that the API knows whether it has
+	// to sort objects itself right after calling this.
+	orderBy := getRequestOrderBy(
+		path.Join(e.parentOp.pathName, pathEndpoints), opts)
+
+	// Invalid tokens are returned by Next, as any other error.
+	cursor, err := newPageCursor(opts)
+
 	return &ServiceDirectoryEndpointIterator{
 		wrapper:  e.wrapper,
 		parentOp: e.parentOp,
 		options:  opts,
+		orderBy:  orderBy,
+		cursor:   cursor,
+		err:      err,
 	}
 }
 
@@ -127,6 +184,9 @@ type ServiceDirectoryEndpointIterator struct {
 	wrapper  *GoogleServiceDirectoryWrapper
 	parentOp *sdServiceOperation
 	options  *list.Options
+	orderBy  string
+	cursor   *pageCursor
+	err      error
 
 	// Request is the actual request that will be sent to Service Directory.
 	// Here it is exported so that it could mocked and tested.
@@ -139,7 +199,9 @@ type ServiceDirectoryEndpointIterator struct {
 }
 
 func (e *ServiceDirectoryEndpointIterator) Next(ctx context.Context) (*coretypes.Endpoint, ops.EndpointOperation, error) {
-	client := e.wrapper.client
+	if e.err != nil {
+		return nil, nil, e.err
+	}
 
 	if e.Request == nil {
 		req := &pb.ListEndpointsRequest{
@@ -153,11 +215,13 @@ func (e *ServiceDirectoryEndpointIterator) Next(ctx context.Context) (*coretypes
 		if reqFilters != "" {
 			req.Filter = reqFilters
 		}
+		req.OrderBy = e.orderBy
+		req.PageToken = e.cursor.pageToken
 		e.Request = req
 	}
 
 	if e.Iterator == nil {
-		e.Iterator = client.ListEndpoints(ctx, e.Request)
+		e.Iterator = e.wrapper.listEndpoints(ctx, e.Request, e.cursor)
 	}
 
 	var (
@@ -165,10 +229,21 @@ func (e *ServiceDirectoryEndpointIterator) Next(ctx context.Context) (*coretypes
 		pathName string
 	)
 	for endp == nil {
+		fetching, err := e.wrapper.beforePage(ctx, e.Iterator)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		next, err := e.Iterator.Next()
+		e.wrapper.afterPage(fetching, err)
 		if err != nil {
 			return nil, nil, err
 		}
+
+		if e.cursor.next() {
+			continue
+		}
+
 		endpToFilter := toCoreEndpoint(next)
 
 		if passed, _ := e.options.Filter(endpToFilter); passed {
@@ -181,6 +256,14 @@ func (e *ServiceDirectoryEndpointIterator) Next(ctx context.Context) (*coretypes
 	return endp, e.parentOp.Endpoint(path.Base(endp.Name)), nil
 }
 
+func (e *ServiceDirectoryEndpointIterator) NextPageToken() string {
+	if e.cursor == nil {
+		return ""
+	}
+
+	return e.cursor.token()
+}
+
 func toCoreEndpoint(endp *pb.Endpoint) *coretypes.Endpoint {
 	metadata := map[string]string{}
 	if endp.Annotations != nil {
@@ -203,6 +286,7 @@ func toCoreEndpoint(endp *pb.Endpoint) *coretypes.Endpoint {
 		Address:        endp.Address,
 		Port:           endp.Port,
 		Metadata:       metadata,
+		Revision:       revision.FromContent(endp.Name, metadata, endp.Address, strconv.Itoa(int(endp.Port))),
 		OriginalObject: endp,
 	}
 }
@@ -21,10 +21,12 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"strconv"
 	"time"
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/revision"
 	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/gcp/servicedirectory"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
@@ -60,6 +62,7 @@ var _ = Describe("EndpointOperations", func() {
 			Address:   addr,
 			Port:      port,
 			Metadata:  metadata,
+			Revision:  revision.FromContent(epPathName, metadata, addr, strconv.Itoa(int(port))),
 			OriginalObject: &pb.Endpoint{
 				Name:        epPathName,
 				Address:     addr,
@@ -278,7 +281,7 @@ var _ = Describe("EndpointOperations", func() {
 				}
 
 				updEndp, err := w.Namespace(nsName).Service(servName).
-					Endpoint(epName).Update(context.TODO(), addr, port, metadata)
+					Endpoint(epName).Update(context.TODO(), addr, port, metadata, "")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(updEndp).To(Equal(expectedEndp))
 
@@ -287,7 +290,7 @@ var _ = Describe("EndpointOperations", func() {
 					return nil, nil
 				}
 				updEndp, err = w.Namespace(nsName).Service(servName).
-					Endpoint(epName).Update(context.TODO(), addr, port, metadata)
+					Endpoint(epName).Update(context.TODO(), addr, port, metadata, "")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(updEndp).To(Equal(expectedEndp))
 			})
@@ -309,7 +312,7 @@ var _ = Describe("EndpointOperations", func() {
 					Region:    region,
 				})
 				w.Namespace(nsName).Service(servName).
-					Endpoint(epName).Update(context.TODO(), addr, port, metadata)
+					Endpoint(epName).Update(context.TODO(), addr, port, metadata, "")
 
 				called := false
 				f._getEndpoint = func(ctx context.Context, ger *pb.GetEndpointRequest, co ...gax.CallOption) (*pb.Endpoint, error) {
@@ -335,7 +338,7 @@ var _ = Describe("EndpointOperations", func() {
 				}
 
 				res, err := w.Namespace(nsName).Service(servName).
-					Endpoint(epName).Update(context.TODO(), addr, port, map[string]string{})
+					Endpoint(epName).Update(context.TODO(), addr, port, map[string]string{}, "")
 				Expect(res).To(BeNil())
 				Expect(err).To(MatchError(expErr))
 			})
@@ -477,6 +480,42 @@ var _ = Describe("EndpointOperations", func() {
 			})
 		})
 
+		Context("with order by", func() {
+			It("includes it in the request if supported", func() {
+				opts := &list.Options{
+					OrderBy: []list.OrderBy{
+						{Field: list.OrderByPort, Direction: list.Descending},
+						{Field: list.OrderByName, Direction: list.Ascending},
+					},
+				}
+				it := w.Namespace(nsName).Service(servName).Endpoint("").
+					List(opts).(*servicedirectory.ServiceDirectoryEndpointIterator)
+				it.Iterator = fakeIt
+				Expect(opts.OrderBy).To(BeEmpty())
+
+				it.Next(context.Background())
+				Expect(it.Request).To(Equal(&pb.ListEndpointsRequest{
+					Parent:  parent,
+					OrderBy: "port desc, name",
+				}))
+			})
+
+			It("leaves addresses to the API", func() {
+				orderBy := []list.OrderBy{
+					{Field: list.OrderByPort, Direction: list.Descending},
+					{Field: list.OrderByAddress, Direction: list.Ascending},
+				}
+				opts := &list.Options{OrderBy: orderBy}
+				it := w.Namespace(nsName).Service(servName).Endpoint("").
+					List(opts).(*servicedirectory.ServiceDirectoryEndpointIterator)
+				it.Iterator = fakeIt
+				Expect(opts.OrderBy).To(Equal(orderBy))
+
+				it.Next(context.Background())
+				Expect(it.Request).To(Equal(&pb.ListEndpointsRequest{Parent: parent}))
+			})
+		})
+
 		Context("with results", func() {
 			It("returns a correct data", func() {
 				it := w.Namespace(nsName).Service(servName).Endpoint("").
@@ -531,6 +570,8 @@ var _ = Describe("EndpointOperations", func() {
 					},
 					Address: "10.10.10.2",
 					Port:    81,
+					Revision: revision.FromContent(path.Join(parent, "endpoints", "should-pass"),
+						metadata, "10.10.10.2", "81"),
 					OriginalObject: &pb.Endpoint{
 						Name:    path.Join(parent, "endpoints", "should-pass"),
 						Address: "10.10.10.2",
@@ -558,6 +599,8 @@ var _ = Describe("EndpointOperations", func() {
 					},
 					Address: "10.10.10.2",
 					Port:    81,
+					Revision: revision.FromContent(path.Join(parent, "endpoints", "should-pass"),
+						metadata, "10.10.10.2", "81"),
 					OriginalObject: &pb.Endpoint{
 						Name:    path.Join(parent, "endpoints", "should-pass"),
 						Address: "10.10.10.2",
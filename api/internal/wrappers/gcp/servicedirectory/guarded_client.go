@@ -0,0 +1,195 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package servicedirectory
+
+import (
+	"context"
+
+	"github.com/CloudNativeSDWAN/serego/api/internal/breaker"
+	"github.com/CloudNativeSDWAN/serego/api/internal/ratelimit"
+	"github.com/googleapis/gax-go/v2"
+	pb "google.golang.org/genproto/googleapis/cloud/servicedirectory/v1"
+	iampb "google.golang.org/genproto/googleapis/iam/v1"
+)
+
+// guardedClient waits for the rate limiter and checks the circuit breaker
+// before each call to Service Directory. List calls are not guarded here, as
+// pages are fetched lazily by iterators, which guard them on their own.
+type guardedClient struct {
+	regClient
+	limiter *ratelimit.Limiter
+	breaker *breaker.Breaker
+}
+
+func (g *guardedClient) before(ctx context.Context, acquire func(context.Context) error) error {
+	if err := acquire(ctx); err != nil {
+		return err
+	}
+
+	return g.breaker.Allow()
+}
+
+func (g *guardedClient) CreateEndpoint(ctx context.Context, req *pb.CreateEndpointRequest, opts ...gax.CallOption) (*pb.Endpoint, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	res, err := g.regClient.CreateEndpoint(ctx, req, opts...)
+	g.breaker.Record(err)
+	return res, err
+}
+
+func (g *guardedClient) CreateNamespace(ctx context.Context, req *pb.CreateNamespaceRequest, opts ...gax.CallOption) (*pb.Namespace, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	res, err := g.regClient.CreateNamespace(ctx, req, opts...)
+	g.breaker.Record(err)
+	return res, err
+}
+
+func (g *guardedClient) CreateService(ctx context.Context, req *pb.CreateServiceRequest, opts ...gax.CallOption) (*pb.Service, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	res, err := g.regClient.CreateService(ctx, req, opts...)
+	g.breaker.Record(err)
+	return res, err
+}
+
+func (g *guardedClient) DeleteEndpoint(ctx context.Context, req *pb.DeleteEndpointRequest, opts ...gax.CallOption) error {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return err
+	}
+
+	err := g.regClient.DeleteEndpoint(ctx, req, opts...)
+	g.breaker.Record(err)
+	return err
+}
+
+func (g *guardedClient) DeleteNamespace(ctx context.Context, req *pb.DeleteNamespaceRequest, opts ...gax.CallOption) error {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return err
+	}
+
+	err := g.regClient.DeleteNamespace(ctx, req, opts...)
+	g.breaker.Record(err)
+	return err
+}
+
+func (g *guardedClient) DeleteService(ctx context.Context, req *pb.DeleteServiceRequest, opts ...gax.CallOption) error {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return err
+	}
+
+	err := g.regClient.DeleteService(ctx, req, opts...)
+	g.breaker.Record(err)
+	return err
+}
+
+func (g *guardedClient) GetEndpoint(ctx context.Context, req *pb.GetEndpointRequest, opts ...gax.CallOption) (*pb.Endpoint, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	res, err := g.regClient.GetEndpoint(ctx, req, opts...)
+	g.breaker.Record(err)
+	return res, err
+}
+
+func (g *guardedClient) GetIamPolicy(ctx context.Context, req *iampb.GetIamPolicyRequest, opts ...gax.CallOption) (*iampb.Policy, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	res, err := g.regClient.GetIamPolicy(ctx, req, opts...)
+	g.breaker.Record(err)
+	return res, err
+}
+
+func (g *guardedClient) GetNamespace(ctx context.Context, req *pb.GetNamespaceRequest, opts ...gax.CallOption) (*pb.Namespace, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	res, err := g.regClient.GetNamespace(ctx, req, opts...)
+	g.breaker.Record(err)
+	return res, err
+}
+
+func (g *guardedClient) GetService(ctx context.Context, req *pb.GetServiceRequest, opts ...gax.CallOption) (*pb.Service, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	res, err := g.regClient.GetService(ctx, req, opts...)
+	g.breaker.Record(err)
+	return res, err
+}
+
+func (g *guardedClient) SetIamPolicy(ctx context.Context, req *iampb.SetIamPolicyRequest, opts ...gax.CallOption) (*iampb.Policy, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	res, err := g.regClient.SetIamPolicy(ctx, req, opts...)
+	g.breaker.Record(err)
+	return res, err
+}
+
+func (g *guardedClient) TestIamPermissions(ctx context.Context, req *iampb.TestIamPermissionsRequest, opts ...gax.CallOption) (*iampb.TestIamPermissionsResponse, error) {
+	if err := g.before(ctx, g.limiter.AcquireRead); err != nil {
+		return nil, err
+	}
+
+	res, err := g.regClient.TestIamPermissions(ctx, req, opts...)
+	g.breaker.Record(err)
+	return res, err
+}
+
+func (g *guardedClient) UpdateEndpoint(ctx context.Context, req *pb.UpdateEndpointRequest, opts ...gax.CallOption) (*pb.Endpoint, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	res, err := g.regClient.UpdateEndpoint(ctx, req, opts...)
+	g.breaker.Record(err)
+	return res, err
+}
+
+func (g *guardedClient) UpdateNamespace(ctx context.Context, req *pb.UpdateNamespaceRequest, opts ...gax.CallOption) (*pb.Namespace, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	res, err := g.regClient.UpdateNamespace(ctx, req, opts...)
+	g.breaker.Record(err)
+	return res, err
+}
+
+func (g *guardedClient) UpdateService(ctx context.Context, req *pb.UpdateServiceRequest, opts ...gax.CallOption) (*pb.Service, error) {
+	if err := g.before(ctx, g.limiter.AcquireWrite); err != nil {
+		return nil, err
+	}
+
+	res, err := g.regClient.UpdateService(ctx, req, opts...)
+	g.breaker.Record(err)
+	return res, err
+}
@@ -20,6 +20,7 @@ package servicedirectory
 import (
 	"fmt"
 	"path"
+	"sort"
 	"strings"
 
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
@@ -58,26 +59,64 @@ func getRequestFilters(basePath string, lo *list.Options) string {
 		}
 
 		mf := lo.MetadataFilters
+		metadataName := ""
+		switch path.Base(basePath) {
+		case "namespaces":
+			metadataName = "labels"
+		case "services", "endpoints":
+			metadataName = "annotations"
+		}
+
 		emptyMetadata := map[string]string{}
 		metadataVals := []string{}
 		for k, v := range mf.Metadata {
-			if v == "" {
+			if v == "" || metadataName == "" {
 				emptyMetadata[k] = v
 				continue
 			}
 
-			metadataName := ""
-			switch path.Base(basePath) {
-			case "namespaces":
-				metadataName = "labels"
-			case "services", "endpoints":
-				metadataName = "annotations"
+			metadataVals = append(metadataVals, fmt.Sprintf("%s.%s=%s", metadataName, k, v))
+		}
+
+		// Sort the keys, so that the filter is always the same.
+		valuesInKeys := []string{}
+		for k := range mf.ValuesIn {
+			valuesInKeys = append(valuesInKeys, k)
+		}
+		sort.Strings(valuesInKeys)
+
+		for _, k := range valuesInKeys {
+			if metadataName == "" {
+				break
+			}
+
+			valuesFilter := []string{}
+			for _, v := range mf.ValuesIn[k] {
+				if v == "" {
+					// Service Directory can't tell empty values from
+					// missing keys, so leave them to the API.
+					valuesFilter = nil
+					break
+				}
+
+				valuesFilter = append(valuesFilter, fmt.Sprintf("%s.%s=%s", metadataName, k, v))
+			}
+
+			if len(valuesFilter) == 0 {
+				continue
 			}
 
-			if metadataName != "" {
-				metadataVals = append(metadataVals, fmt.Sprintf("%s.%s=%s", metadataName, k, v))
+			if len(valuesFilter) == 1 {
+				metadataVals = append(metadataVals, valuesFilter[0])
+			} else {
+				metadataVals = append(metadataVals, fmt.Sprintf("(%s)",
+					strings.Join(valuesFilter, " OR ")))
 			}
+
+			// Reset this, so we don't have to use filters there
+			delete(mf.ValuesIn, k)
 		}
+
 		if len(metadataVals) > 0 {
 			if len(metadataVals) == 1 {
 				reqFilter = append(reqFilter, metadataVals[0])
@@ -93,3 +132,65 @@ func getRequestFilters(basePath string, lo *list.Options) string {
 
 	return strings.Join(reqFilter, " AND ")
 }
+
+// getRequestOrderBy returns the order to request to Service Directory, if it
+// can sort objects by all the fields in the options, which are then reset so
+// that they are not applied again by the API.
+func getRequestOrderBy(basePath string, lo *list.Options) string {
+	if len(lo.OrderBy) == 0 {
+		return ""
+	}
+
+	orderBy := []string{}
+	orderedByName := false
+	for _, ob := range lo.OrderBy {
+		field := ""
+		switch path.Base(basePath) {
+		case "namespaces":
+			if ob.Field == list.OrderByName {
+				field = "name"
+			} else if key := ob.Field.MetadataKey(); key != "" {
+				field = fmt.Sprintf("labels.%s", key)
+			}
+		case "services":
+			if ob.Field == list.OrderByName {
+				field = "name"
+			}
+		case "endpoints":
+			// Addresses are not included, as Service Directory sorts them
+			// as strings, while the API sorts IPv4 ones first and then by
+			// their bytes.
+			switch ob.Field {
+			case list.OrderByName:
+				field = "name"
+			case list.OrderByPort:
+				field = "port"
+			}
+		}
+
+		if field == "" {
+			// Service Directory can't sort by this field, so leave all of
+			// them to the API.
+			return ""
+		}
+
+		if field == "name" {
+			orderedByName = true
+		}
+
+		if ob.Direction == list.Descending {
+			field += " desc"
+		}
+
+		orderBy = append(orderBy, field)
+	}
+
+	if !orderedByName {
+		orderBy = append(orderBy, "name")
+	}
+
+	// Reset this, so we don't have to sort objects there
+	lo.OrderBy = []list.OrderBy{}
+
+	return strings.Join(orderBy, ", ")
+}
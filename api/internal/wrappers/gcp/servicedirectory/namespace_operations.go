@@ -22,7 +22,11 @@ import (
 	"path"
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/revision"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
 	pb "google.golang.org/genproto/googleapis/cloud/servicedirectory/v1"
@@ -36,20 +40,48 @@ type sdNamespaceOperation struct {
 }
 
 func (n *sdNamespaceOperation) Get(ctx context.Context, opts *get.Options) (*coretypes.Namespace, error) {
+	ns, err := n.lookup(ctx, opts)
+	if err != nil {
+		if stale, ok := n.wrapper.getStaleOnError(n.pathName, err).(*coretypes.Namespace); ok {
+			return stale, nil
+		}
+
+		return nil, err
+	}
+
+	return ns, nil
+}
+
+// lookup is like Get, but it never returns a stale namespace, as its callers
+// need the current one, e.g. to check its revision before updating it.
+func (n *sdNamespaceOperation) lookup(ctx context.Context, opts *get.Options) (*coretypes.Namespace, error) {
 	if !opts.ForceRefresh {
-		if ns := n.wrapper.getFromCache(n.pathName); ns != nil {
+		if ns, found, err := n.wrapper.lookupOnCache(n.pathName); found {
+			tracing.SetCacheHit(ctx, true)
+			if err != nil {
+				return nil, err
+			}
+
 			return ns.(*coretypes.Namespace), nil
 		}
+
+		tracing.SetCacheHit(ctx, false)
 	}
 
-	ns, err := n.wrapper.client.GetNamespace(ctx, &pb.GetNamespaceRequest{
-		Name: n.pathName,
+	ns, err := n.wrapper.shared(ctx, n.pathName, metrics.NamespaceObject, opts.ForceRefresh, func(ctx context.Context) (interface{}, error) {
+		return n.wrapper.client.GetNamespace(ctx, &pb.GetNamespaceRequest{
+			Name: n.pathName,
+		})
 	})
 	if err != nil {
+		if srerr.IsNotFound(err) {
+			n.wrapper.putNotFoundOnCache(n.pathName, err)
+		}
+
 		return nil, err
 	}
 
-	namespace := toCoreNamespace(ns)
+	namespace := toCoreNamespace(ns.(*pb.Namespace))
 	n.wrapper.putOnCache(n.pathName, namespace)
 
 	return namespace, nil
@@ -74,7 +106,20 @@ func (n *sdNamespaceOperation) Create(ctx context.Context, metadata map[string]s
 	return namespace, nil
 }
 
-func (n *sdNamespaceOperation) Update(ctx context.Context, metadata map[string]string) (*coretypes.Namespace, error) {
+func (n *sdNamespaceOperation) Update(ctx context.Context, metadata map[string]string, expectedRevision string) (*coretypes.Namespace, error) {
+	if expectedRevision != "" {
+		// Service Directory does not support conditional updates, so the
+		// best we can do is checking it again right before updating it.
+		curr, err := n.lookup(ctx, &get.Options{ForceRefresh: true})
+		if err != nil {
+			return nil, err
+		}
+
+		if curr.Revision != expectedRevision {
+			return nil, srerr.Conflict
+		}
+	}
+
 	res, err := n.wrapper.client.UpdateNamespace(ctx, &pb.UpdateNamespaceRequest{
 		Namespace: &pb.Namespace{
 			Name:   n.pathName,
@@ -95,7 +140,7 @@ func (n *sdNamespaceOperation) Update(ctx context.Context, metadata map[string]s
 }
 
 func (n *sdNamespaceOperation) Delete(ctx context.Context) error {
-	n.wrapper.cache.Delete(n.pathName)
+	n.wrapper.removeFromCache(n.pathName)
 	return n.wrapper.client.DeleteNamespace(ctx, &pb.DeleteNamespaceRequest{
 		Name: n.pathName,
 	})
@@ -116,10 +161,21 @@ func (n *sdNamespaceOperation) List(opts *list.Options) ops.NamespaceLister {
 		opts.NameFilters.In = append(opts.NameFilters.In, n.name)
 	}
 
+	// Done here rather than in Next, so that the API knows whether it has
+	// to sort objects itself right after calling this.
+	orderBy := getRequestOrderBy(
+		path.Join(n.wrapper.pathName, pathNamespaces), opts)
+
+	// Invalid tokens are returned by Next, as any other error.
+	cursor, err := newPageCursor(opts)
+
 	return &ServiceDirectoryNamespaceIterator{
 		wrapper:        n.wrapper,
 		parentPathName: parentPathName,
 		options:        opts,
+		orderBy:        orderBy,
+		cursor:         cursor,
+		err:            err,
 	}
 }
 
@@ -127,6 +183,9 @@ type ServiceDirectoryNamespaceIterator struct {
 	wrapper        *GoogleServiceDirectoryWrapper
 	parentPathName string
 	options        *list.Options
+	orderBy        string
+	cursor         *pageCursor
+	err            error
 
 	// Request is the actual request that will be sent to Service Directory.
 	// Here it is exported so that it could mocked and tested.
@@ -139,7 +198,9 @@ type ServiceDirectoryNamespaceIterator struct {
 }
 
 func (ni *ServiceDirectoryNamespaceIterator) Next(ctx context.Context) (*coretypes.Namespace, ops.NamespaceOperation, error) {
-	client := ni.wrapper.client
+	if ni.err != nil {
+		return nil, nil, ni.err
+	}
 
 	if ni.Request == nil {
 		req := &pb.ListNamespacesRequest{
@@ -153,11 +214,13 @@ func (ni *ServiceDirectoryNamespaceIterator) Next(ctx context.Context) (*coretyp
 		if reqFilters != "" {
 			req.Filter = reqFilters
 		}
+		req.OrderBy = ni.orderBy
+		req.PageToken = ni.cursor.pageToken
 		ni.Request = req
 	}
 
 	if ni.Iterator == nil {
-		ni.Iterator = client.ListNamespaces(ctx, ni.Request)
+		ni.Iterator = ni.wrapper.listNamespaces(ctx, ni.Request, ni.cursor)
 	}
 
 	var (
@@ -165,10 +228,21 @@ func (ni *ServiceDirectoryNamespaceIterator) Next(ctx context.Context) (*coretyp
 		pathName string
 	)
 	for ns == nil {
+		fetching, err := ni.wrapper.beforePage(ctx, ni.Iterator)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		next, err := ni.Iterator.Next()
+		ni.wrapper.afterPage(fetching, err)
 		if err != nil {
 			return nil, nil, err
 		}
+
+		if ni.cursor.next() {
+			continue
+		}
+
 		nsToFilter := toCoreNamespace(next)
 
 		if passed, _ := ni.options.Filter(nsToFilter); passed {
@@ -181,6 +255,14 @@ func (ni *ServiceDirectoryNamespaceIterator) Next(ctx context.Context) (*coretyp
 	return ns, ni.wrapper.Namespace(path.Base(ns.Name)), nil
 }
 
+func (ni *ServiceDirectoryNamespaceIterator) NextPageToken() string {
+	if ni.cursor == nil {
+		return ""
+	}
+
+	return ni.cursor.token()
+}
+
 func (n *sdNamespaceOperation) Service(name string) ops.ServiceOperation {
 	return &sdServiceOperation{
 		wrapper:  n.wrapper,
@@ -199,6 +281,7 @@ func toCoreNamespace(ns *pb.Namespace) *coretypes.Namespace {
 	return &coretypes.Namespace{
 		Name:           path.Base(ns.Name),
 		Metadata:       metadata,
+		Revision:       revision.FromContent(ns.Name, metadata),
 		OriginalObject: ns,
 	}
 }
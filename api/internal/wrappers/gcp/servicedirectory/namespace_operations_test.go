@@ -21,10 +21,13 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"strings"
 	"time"
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/pagetoken"
+	"github.com/CloudNativeSDWAN/serego/api/internal/revision"
 	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/gcp/servicedirectory"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
@@ -32,6 +35,8 @@ import (
 	"github.com/googleapis/gax-go/v2"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"google.golang.org/api/iterator"
 	pb "google.golang.org/genproto/googleapis/cloud/servicedirectory/v1"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
@@ -50,6 +55,7 @@ var _ = Describe("Namespace Operations", func() {
 		expectedNs = &coretypes.Namespace{
 			Name:     nsName,
 			Metadata: metadata,
+			Revision: revision.FromContent(expectedNsPath, metadata),
 			OriginalObject: &pb.Namespace{
 				Name:   expectedNsPath,
 				Labels: metadata,
@@ -167,6 +173,33 @@ var _ = Describe("Namespace Operations", func() {
 				Expect(err).NotTo(HaveOccurred())
 				Expect(gotNs).To(Equal(expectedNs))
 			})
+
+			It("only records gets performed on Service Directory", func() {
+				reg := prometheus.NewRegistry()
+				w, _ = servicedirectory.NewServiceDirectoryWrapper(f, &wrapper.Options{
+					ProjectID:           project,
+					Region:              region,
+					CacheExpirationTime: time.Minute,
+					MetricsRegisterer:   reg,
+				})
+				f._getNamespace = func(c context.Context, gnr *pb.GetNamespaceRequest, co ...gax.CallOption) (*pb.Namespace, error) {
+					return &pb.Namespace{
+						Name:   expectedNsPath,
+						Labels: metadata,
+					}, nil
+				}
+
+				for i := 0; i < 3; i++ {
+					_, err := w.Namespace(nsName).Get(context.TODO(), &get.Options{})
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				Expect(testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP serego_operations_total Number of operations performed on the service registry.
+# TYPE serego_operations_total counter
+serego_operations_total{backend="servicedirectory",object="namespace",operation="get",result="success"} 1
+`), "serego_operations_total")).To(Succeed())
+			})
 		})
 
 		Context("without cache", func() {
@@ -221,7 +254,7 @@ var _ = Describe("Namespace Operations", func() {
 					}, nil
 				}
 
-				updNs, err := w.Namespace(nsName).Update(context.TODO(), metadata)
+				updNs, err := w.Namespace(nsName).Update(context.TODO(), metadata, "")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(updNs).To(Equal(expectedNs))
 
@@ -229,7 +262,7 @@ var _ = Describe("Namespace Operations", func() {
 					Fail("should get namespace from cache not service directory")
 					return nil, nil
 				}
-				updNs, err = w.Namespace(nsName).Update(context.TODO(), metadata)
+				updNs, err = w.Namespace(nsName).Update(context.TODO(), metadata, "")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(updNs).To(Equal(expectedNs))
 			})
@@ -256,7 +289,7 @@ var _ = Describe("Namespace Operations", func() {
 					ProjectID: project,
 					Region:    region,
 				})
-				w.Namespace(nsName).Update(context.TODO(), metadata)
+				w.Namespace(nsName).Update(context.TODO(), metadata, "")
 				w.Namespace(nsName).Get(context.TODO(), &get.Options{})
 				Expect(called).To(BeTrue())
 			})
@@ -269,10 +302,38 @@ var _ = Describe("Namespace Operations", func() {
 					return nil, expErr
 				}
 
-				res, err := w.Namespace(nsName).Update(context.TODO(), map[string]string{})
+				res, err := w.Namespace(nsName).Update(context.TODO(), map[string]string{}, "")
 				Expect(res).To(BeNil())
 				Expect(err).To(MatchError(expErr))
 			})
+
+			It("does not check the revision against stale namespaces", func() {
+				w, _ = servicedirectory.NewServiceDirectoryWrapper(f, &wrapper.Options{
+					ProjectID:           project,
+					Region:              region,
+					CacheExpirationTime: time.Minute,
+					StaleGracePeriod:    time.Hour,
+				})
+				f._getNamespace = func(ctx context.Context, gnr *pb.GetNamespaceRequest, co ...gax.CallOption) (*pb.Namespace, error) {
+					return &pb.Namespace{
+						Name:   expectedNsPath,
+						Labels: metadata,
+					}, nil
+				}
+				_, err := w.Namespace(nsName).Get(context.TODO(), &get.Options{})
+				Expect(err).NotTo(HaveOccurred())
+
+				f._getNamespace = func(ctx context.Context, gnr *pb.GetNamespaceRequest, co ...gax.CallOption) (*pb.Namespace, error) {
+					return nil, srerr.CircuitOpen
+				}
+				f._updateNamespace = func(c context.Context, unr *pb.UpdateNamespaceRequest, co ...gax.CallOption) (*pb.Namespace, error) {
+					Fail("should not update the namespace")
+					return nil, nil
+				}
+				res, err := w.Namespace(nsName).Update(context.TODO(), map[string]string{}, expectedNs.Revision)
+				Expect(res).To(BeNil())
+				Expect(err).To(MatchError(srerr.CircuitOpen))
+			})
 		})
 	})
 
@@ -432,6 +493,114 @@ var _ = Describe("Namespace Operations", func() {
 			})
 		})
 
+		Context("with set-based metadata filters", func() {
+			It("includes values in the request and leaves the others", func() {
+				opts := &list.Options{
+					MetadataFilters: &list.MetadataFilters{
+						Metadata: map[string]string{},
+						ValuesIn: map[string][]string{
+							"env":  {"prod", "staging"},
+							"tier": {"backend"},
+							"zone": {"", "eu"},
+						},
+						ValuesNotIn: map[string][]string{
+							"team": {"sales"},
+						},
+					},
+				}
+				it := w.Namespace("").List(opts).(*servicedirectory.ServiceDirectoryNamespaceIterator)
+				it.Iterator = &fakeNamespaceIterator{
+					_next: func() (*pb.Namespace, error) {
+						return nil, srerr.IteratorDone
+					},
+				}
+
+				it.Next(context.Background())
+				Expect(it.Request.Filter).To(Equal("((labels.env=prod OR labels.env=staging) AND labels.tier=backend)"))
+				Expect(opts.MetadataFilters.ValuesIn).To(Equal(map[string][]string{
+					"zone": {"", "eu"},
+				}))
+				Expect(opts.MetadataFilters.ValuesNotIn).To(Equal(map[string][]string{
+					"team": {"sales"},
+				}))
+			})
+		})
+
+		Context("with a page token", func() {
+			It("resumes listing from the token", func() {
+				token := pagetoken.Encode(pagetoken.Token{Page: "page-token", Skip: 1})
+				it := w.Namespace("").List(&list.Options{
+					PageToken: token,
+				}).(*servicedirectory.ServiceDirectoryNamespaceIterator)
+				i := 0
+				it.Iterator = &fakeNamespaceIterator{
+					_next: func() (*pb.Namespace, error) {
+						i++
+						return &pb.Namespace{
+							Name: path.Join(parent, "namespaces", fmt.Sprintf("ns-%d", i)),
+						}, nil
+					},
+				}
+				Expect(it.NextPageToken()).To(Equal(token))
+
+				ns, _, err := it.Next(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ns.Name).To(Equal("ns-2"))
+				Expect(it.Request).To(Equal(&pb.ListNamespacesRequest{
+					Parent:    parent,
+					PageToken: "page-token",
+				}))
+			})
+
+			It("returns an error if the token is not valid", func() {
+				it := w.Namespace("").List(&list.Options{
+					PageToken: "not-valid",
+				})
+				ns, nsop, err := it.Next(context.Background())
+				Expect(err).To(MatchError(srerr.InvalidPageToken))
+				Expect(ns).To(BeNil())
+				Expect(nsop).To(BeNil())
+			})
+		})
+
+		Context("with order by", func() {
+			It("includes it in the request if supported", func() {
+				opts := &list.Options{
+					OrderBy: []list.OrderBy{
+						{Field: list.OrderByMetadataKey("zone"), Direction: list.Descending},
+					},
+				}
+				it := w.Namespace("").
+					List(opts).(*servicedirectory.ServiceDirectoryNamespaceIterator)
+				it.Iterator = fakeIt
+				Expect(opts.OrderBy).To(BeEmpty())
+
+				it.Next(context.Background())
+				Expect(it.Request).To(Equal(&pb.ListNamespacesRequest{
+					Parent:  parent,
+					OrderBy: "labels.zone desc, name",
+				}))
+			})
+
+			It("leaves it to the API if not supported", func() {
+				opts := &list.Options{
+					OrderBy: []list.OrderBy{
+						{Field: list.OrderByName, Direction: list.Ascending},
+						{Field: list.OrderByPort, Direction: list.Ascending},
+					},
+				}
+				it := w.Namespace("").
+					List(opts).(*servicedirectory.ServiceDirectoryNamespaceIterator)
+				it.Iterator = fakeIt
+				Expect(opts.OrderBy).To(HaveLen(2))
+
+				it.Next(context.Background())
+				Expect(it.Request).To(Equal(&pb.ListNamespacesRequest{
+					Parent: parent,
+				}))
+			})
+		})
+
 		Context("with results", func() {
 			It("returns a correct data", func() {
 				it := w.Namespace("").
@@ -478,6 +647,7 @@ var _ = Describe("Namespace Operations", func() {
 						"key-1": "val-1",
 						"key-2": "val-2",
 					},
+					Revision: revision.FromContent(path.Join(parent, "namespaces", "should-pass"), metadata),
 					OriginalObject: &pb.Namespace{
 						Name: path.Join(parent, "namespaces", "should-pass"),
 						Labels: map[string]string{
@@ -499,6 +669,7 @@ var _ = Describe("Namespace Operations", func() {
 						"key-1": "val-1",
 						"key-2": "val-2",
 					},
+					Revision: revision.FromContent(path.Join(parent, "namespaces", "should-pass"), metadata),
 					OriginalObject: &pb.Namespace{
 						Name: path.Join(parent, "namespaces", "should-pass"),
 						Labels: map[string]string{
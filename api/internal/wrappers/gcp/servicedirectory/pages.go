@@ -0,0 +1,210 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package servicedirectory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sd "cloud.google.com/go/servicedirectory/apiv1"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
+	"github.com/CloudNativeSDWAN/serego/api/internal/pagetoken"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
+	"github.com/CloudNativeSDWAN/serego/api/options/list"
+	pb "google.golang.org/genproto/googleapis/cloud/servicedirectory/v1"
+)
+
+// Service Directory iterators fetch their pages on their own, so the
+// functions below replace the function they use to do so in order to share
+// the calls with all the concurrent iterators that are fetching the same page
+// of the same list.
+
+type namespacesPage struct {
+	namespaces    []*pb.Namespace
+	nextPageToken string
+}
+
+type servicesPage struct {
+	services      []*pb.Service
+	nextPageToken string
+}
+
+type endpointsPage struct {
+	endpoints     []*pb.Endpoint
+	nextPageToken string
+}
+
+func (g *GoogleServiceDirectoryWrapper) listNamespaces(ctx context.Context, req *pb.ListNamespacesRequest, cursor *pageCursor) *sd.NamespaceIterator {
+	it := g.client.ListNamespaces(ctx, req)
+	if it == nil {
+		return it
+	}
+
+	fetch := it.InternalFetch
+	it.InternalFetch = func(pageSize int, pageToken string) ([]*pb.Namespace, string, error) {
+		ctx, span := tracing.StartListPage(ctx, g.tracer, metrics.ServiceDirectory, metrics.NamespaceObject)
+		key := pageKey(req.Parent, req.Filter, req.OrderBy, pageSize, pageToken)
+		page, err := g.calls.Do(ctx, key, func(context.Context) (interface{}, error) {
+			start := time.Now()
+			namespaces, nextPageToken, err := fetch(pageSize, pageToken)
+			g.metrics.ObserveOperation(metrics.NamespaceObject, metrics.ListPage, start, err)
+			return &namespacesPage{namespaces, nextPageToken}, err
+		})
+		tracing.End(span, err)
+		if err != nil {
+			return nil, "", err
+		}
+
+		cursor.fetched(pageToken, page.(*namespacesPage).nextPageToken, len(page.(*namespacesPage).namespaces))
+		return page.(*namespacesPage).namespaces, page.(*namespacesPage).nextPageToken, nil
+	}
+
+	return it
+}
+
+func (g *GoogleServiceDirectoryWrapper) listServices(ctx context.Context, req *pb.ListServicesRequest, cursor *pageCursor) *sd.ServiceIterator {
+	it := g.client.ListServices(ctx, req)
+	if it == nil {
+		return it
+	}
+
+	fetch := it.InternalFetch
+	it.InternalFetch = func(pageSize int, pageToken string) ([]*pb.Service, string, error) {
+		ctx, span := tracing.StartListPage(ctx, g.tracer, metrics.ServiceDirectory, metrics.ServiceObject)
+		key := pageKey(req.Parent, req.Filter, req.OrderBy, pageSize, pageToken)
+		page, err := g.calls.Do(ctx, key, func(context.Context) (interface{}, error) {
+			start := time.Now()
+			services, nextPageToken, err := fetch(pageSize, pageToken)
+			g.metrics.ObserveOperation(metrics.ServiceObject, metrics.ListPage, start, err)
+			return &servicesPage{services, nextPageToken}, err
+		})
+		tracing.End(span, err)
+		if err != nil {
+			return nil, "", err
+		}
+
+		cursor.fetched(pageToken, page.(*servicesPage).nextPageToken, len(page.(*servicesPage).services))
+		return page.(*servicesPage).services, page.(*servicesPage).nextPageToken, nil
+	}
+
+	return it
+}
+
+func (g *GoogleServiceDirectoryWrapper) listEndpoints(ctx context.Context, req *pb.ListEndpointsRequest, cursor *pageCursor) *sd.EndpointIterator {
+	it := g.client.ListEndpoints(ctx, req)
+	if it == nil {
+		return it
+	}
+
+	fetch := it.InternalFetch
+	it.InternalFetch = func(pageSize int, pageToken string) ([]*pb.Endpoint, string, error) {
+		ctx, span := tracing.StartListPage(ctx, g.tracer, metrics.ServiceDirectory, metrics.EndpointObject)
+		key := pageKey(req.Parent, req.Filter, req.OrderBy, pageSize, pageToken)
+		page, err := g.calls.Do(ctx, key, func(context.Context) (interface{}, error) {
+			start := time.Now()
+			endpoints, nextPageToken, err := fetch(pageSize, pageToken)
+			g.metrics.ObserveOperation(metrics.EndpointObject, metrics.ListPage, start, err)
+			return &endpointsPage{endpoints, nextPageToken}, err
+		})
+		tracing.End(span, err)
+		if err != nil {
+			return nil, "", err
+		}
+
+		cursor.fetched(pageToken, page.(*endpointsPage).nextPageToken, len(page.(*endpointsPage).endpoints))
+		return page.(*endpointsPage).endpoints, page.(*endpointsPage).nextPageToken, nil
+	}
+
+	return it
+}
+
+func pageKey(parent, filter, orderBy string, pageSize int, pageToken string) string {
+	return fmt.Sprintf("%s?filter=%s&orderBy=%s&pageSize=%d&pageToken=%s",
+		parent, filter, orderBy, pageSize, pageToken)
+}
+
+// pageCursor keeps track of the page of results that an iterator is reading,
+// so that it can return a token to resume listing right after the last
+// element it returned.
+type pageCursor struct {
+	// startToken is the token that listing was resumed from, if any.
+	startToken string
+	// pageToken is the token of the page being read, or of the first page
+	// to fetch if none was fetched yet.
+	pageToken     string
+	nextPageToken string
+	size          int
+	read          int
+	// skip is the number of elements to skip from the first page, in case
+	// listing is resumed from a token.
+	skip       int
+	hasFetched bool
+}
+
+func newPageCursor(opts *list.Options) (*pageCursor, error) {
+	token, err := pagetoken.Decode(opts.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pageCursor{
+		startToken: opts.PageToken,
+		pageToken:  token.Page,
+		skip:       token.Skip,
+	}, nil
+}
+
+// fetched must be called after a page of results has been fetched.
+func (c *pageCursor) fetched(pageToken, nextPageToken string, size int) {
+	if c == nil {
+		return
+	}
+
+	if c.hasFetched {
+		// Only elements of the first page may have to be skipped.
+		c.skip = 0
+	}
+
+	c.pageToken, c.nextPageToken = pageToken, nextPageToken
+	c.size, c.read, c.hasFetched = size, 0, true
+}
+
+// next must be called after an element has been read from the iterator, and
+// it returns true if the element must be skipped as it was already returned
+// before the token was created.
+func (c *pageCursor) next() bool {
+	c.read++
+	if c.skip > 0 {
+		c.skip--
+		return true
+	}
+
+	return false
+}
+
+func (c *pageCursor) token() string {
+	switch {
+	case !c.hasFetched:
+		return c.startToken
+	case c.read >= c.size:
+		return pagetoken.Encode(pagetoken.Token{Page: c.nextPageToken})
+	default:
+		return pagetoken.Encode(pagetoken.Token{Page: c.pageToken, Skip: c.read})
+	}
+}
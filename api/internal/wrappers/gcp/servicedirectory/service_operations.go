@@ -22,7 +22,11 @@ import (
 	"path"
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/revision"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
 	pb "google.golang.org/genproto/googleapis/cloud/servicedirectory/v1"
@@ -37,20 +41,48 @@ type sdServiceOperation struct {
 }
 
 func (s *sdServiceOperation) Get(ctx context.Context, opts *get.Options) (*coretypes.Service, error) {
+	serv, err := s.lookup(ctx, opts)
+	if err != nil {
+		if stale, ok := s.wrapper.getStaleOnError(s.pathName, err).(*coretypes.Service); ok {
+			return stale, nil
+		}
+
+		return nil, err
+	}
+
+	return serv, nil
+}
+
+// lookup is like Get, but it never returns a stale service, as its callers
+// need the current one, e.g. to check its revision before updating it.
+func (s *sdServiceOperation) lookup(ctx context.Context, opts *get.Options) (*coretypes.Service, error) {
 	if !opts.ForceRefresh {
-		if ns := s.wrapper.getFromCache(s.pathName); ns != nil {
+		if ns, found, err := s.wrapper.lookupOnCache(s.pathName); found {
+			tracing.SetCacheHit(ctx, true)
+			if err != nil {
+				return nil, err
+			}
+
 			return ns.(*coretypes.Service), nil
 		}
+
+		tracing.SetCacheHit(ctx, false)
 	}
 
-	serv, err := s.wrapper.client.GetService(ctx, &pb.GetServiceRequest{
-		Name: s.pathName,
+	serv, err := s.wrapper.shared(ctx, s.pathName, metrics.ServiceObject, opts.ForceRefresh, func(ctx context.Context) (interface{}, error) {
+		return s.wrapper.client.GetService(ctx, &pb.GetServiceRequest{
+			Name: s.pathName,
+		})
 	})
 	if err != nil {
+		if srerr.IsNotFound(err) {
+			s.wrapper.putNotFoundOnCache(s.pathName, err)
+		}
+
 		return nil, err
 	}
 
-	service := toCoreService(serv)
+	service := toCoreService(serv.(*pb.Service))
 	s.wrapper.putOnCache(s.pathName, service)
 
 	return service, nil
@@ -75,7 +107,20 @@ func (s *sdServiceOperation) Create(ctx context.Context, metadata map[string]str
 	return service, nil
 }
 
-func (s *sdServiceOperation) Update(ctx context.Context, metadata map[string]string) (*coretypes.Service, error) {
+func (s *sdServiceOperation) Update(ctx context.Context, metadata map[string]string, expectedRevision string) (*coretypes.Service, error) {
+	if expectedRevision != "" {
+		// Service Directory does not support conditional updates, so the
+		// best we can do is checking it again right before updating it.
+		curr, err := s.lookup(ctx, &get.Options{ForceRefresh: true})
+		if err != nil {
+			return nil, err
+		}
+
+		if curr.Revision != expectedRevision {
+			return nil, srerr.Conflict
+		}
+	}
+
 	res, err := s.wrapper.client.UpdateService(ctx, &pb.UpdateServiceRequest{
 		Service: &pb.Service{
 			Name:        s.pathName,
@@ -96,7 +141,7 @@ func (s *sdServiceOperation) Update(ctx context.Context, metadata map[string]str
 }
 
 func (s *sdServiceOperation) Delete(ctx context.Context) error {
-	s.wrapper.cache.Delete(s.pathName)
+	s.wrapper.removeFromCache(s.pathName)
 
 	return s.wrapper.client.DeleteService(ctx, &pb.DeleteServiceRequest{
 		Name: s.pathName,
@@ -112,10 +157,21 @@ func (s *sdServiceOperation) List(opts *list.Options) ops.ServiceLister {
 		opts.NameFilters.In = append(opts.NameFilters.In, s.name)
 	}
 
+	// Done here rather than in Next, so that the API knows whether it has
+	// to sort objects itself right after calling this.
+	orderBy := getRequestOrderBy(
+		path.Join(s.parentOp.pathName, pathServices), opts)
+
+	// Invalid tokens are returned by Next, as any other error.
+	cursor, err := newPageCursor(opts)
+
 	return &ServiceDirectoryServiceIterator{
 		wrapper:  s.wrapper,
 		parentOp: s.parentOp,
 		options:  opts,
+		orderBy:  orderBy,
+		cursor:   cursor,
+		err:      err,
 	}
 }
 
@@ -123,6 +179,9 @@ type ServiceDirectoryServiceIterator struct {
 	wrapper  *GoogleServiceDirectoryWrapper
 	parentOp *sdNamespaceOperation
 	options  *list.Options
+	orderBy  string
+	cursor   *pageCursor
+	err      error
 
 	// Request is the actual request that will be sent to Service Directory.
 	// Here it is exported so that it could mocked and tested.
@@ -135,7 +194,9 @@ type ServiceDirectoryServiceIterator struct {
 }
 
 func (s *ServiceDirectoryServiceIterator) Next(ctx context.Context) (*coretypes.Service, ops.ServiceOperation, error) {
-	client := s.wrapper.client
+	if s.err != nil {
+		return nil, nil, s.err
+	}
 
 	if s.Request == nil {
 		req := &pb.ListServicesRequest{
@@ -149,11 +210,13 @@ func (s *ServiceDirectoryServiceIterator) Next(ctx context.Context) (*coretypes.
 		if reqFilters != "" {
 			req.Filter = reqFilters
 		}
+		req.OrderBy = s.orderBy
+		req.PageToken = s.cursor.pageToken
 		s.Request = req
 	}
 
 	if s.Iterator == nil {
-		s.Iterator = client.ListServices(ctx, s.Request)
+		s.Iterator = s.wrapper.listServices(ctx, s.Request, s.cursor)
 	}
 
 	var (
@@ -162,10 +225,21 @@ func (s *ServiceDirectoryServiceIterator) Next(ctx context.Context) (*coretypes.
 	)
 
 	for serv == nil {
+		fetching, err := s.wrapper.beforePage(ctx, s.Iterator)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		next, err := s.Iterator.Next()
+		s.wrapper.afterPage(fetching, err)
 		if err != nil {
 			return nil, nil, err
 		}
+
+		if s.cursor.next() {
+			continue
+		}
+
 		servToFilter := toCoreService(next)
 
 		if passed, _ := s.options.Filter(servToFilter); passed {
@@ -178,6 +252,14 @@ func (s *ServiceDirectoryServiceIterator) Next(ctx context.Context) (*coretypes.
 	return serv, s.parentOp.Service(path.Base(serv.Name)), nil
 }
 
+func (s *ServiceDirectoryServiceIterator) NextPageToken() string {
+	if s.cursor == nil {
+		return ""
+	}
+
+	return s.cursor.token()
+}
+
 func (s *sdServiceOperation) Endpoint(name string) ops.EndpointOperation {
 	return &sdEndpointOperation{
 		wrapper:  s.wrapper,
@@ -199,6 +281,7 @@ func toCoreService(serv *pb.Service) *coretypes.Service {
 			return path.Base(path.Dir(path.Dir(serv.Name)))
 		}(),
 		Metadata:       metadata,
+		Revision:       revision.FromContent(serv.Name, metadata),
 		OriginalObject: serv,
 	}
 }
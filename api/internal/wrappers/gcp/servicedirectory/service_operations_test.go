@@ -32,6 +32,7 @@ import (
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/revision"
 	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/gcp/servicedirectory"
 	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
@@ -55,6 +56,7 @@ var _ = Describe("ServiceOperations", func() {
 			Name:      servName,
 			Namespace: nsName,
 			Metadata:  metadata,
+			Revision:  revision.FromContent(servPathName, metadata),
 			OriginalObject: &pb.Service{
 				Name:        servPathName,
 				Annotations: metadata,
@@ -232,7 +234,7 @@ var _ = Describe("ServiceOperations", func() {
 					}, nil
 				}
 
-				updServ, err := w.Namespace(nsName).Service(servName).Update(context.TODO(), metadata)
+				updServ, err := w.Namespace(nsName).Service(servName).Update(context.TODO(), metadata, "")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(updServ).To(Equal(expectedServ))
 
@@ -260,7 +262,7 @@ var _ = Describe("ServiceOperations", func() {
 					ProjectID: project,
 					Region:    region,
 				})
-				updServ, err := w.Namespace(nsName).Service(servName).Update(context.TODO(), metadata)
+				updServ, err := w.Namespace(nsName).Service(servName).Update(context.TODO(), metadata, "")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(updServ).To(Equal(expectedServ))
 
@@ -284,7 +286,7 @@ var _ = Describe("ServiceOperations", func() {
 					return nil, expErr
 				}
 
-				res, err := w.Namespace(nsName).Service(servName).Update(context.TODO(), metadata)
+				res, err := w.Namespace(nsName).Service(servName).Update(context.TODO(), metadata, "")
 				Expect(res).To(BeNil())
 				Expect(err).To(MatchError(expErr))
 			})
@@ -426,6 +428,43 @@ var _ = Describe("ServiceOperations", func() {
 			})
 		})
 
+		Context("with order by", func() {
+			It("includes it in the request if supported", func() {
+				opts := &list.Options{
+					OrderBy: []list.OrderBy{
+						{Field: list.OrderByName, Direction: list.Descending},
+					},
+				}
+				it := w.Namespace(nsName).Service("").
+					List(opts).(*servicedirectory.ServiceDirectoryServiceIterator)
+				it.Iterator = fakeIt
+				Expect(opts.OrderBy).To(BeEmpty())
+
+				it.Next(context.Background())
+				Expect(it.Request).To(Equal(&pb.ListServicesRequest{
+					Parent:  parent,
+					OrderBy: "name desc",
+				}))
+			})
+
+			It("leaves it to the API if not supported", func() {
+				opts := &list.Options{
+					OrderBy: []list.OrderBy{
+						{Field: list.OrderByMetadataKey("zone"), Direction: list.Ascending},
+					},
+				}
+				it := w.Namespace(nsName).Service("").
+					List(opts).(*servicedirectory.ServiceDirectoryServiceIterator)
+				it.Iterator = fakeIt
+				Expect(opts.OrderBy).To(HaveLen(1))
+
+				it.Next(context.Background())
+				Expect(it.Request).To(Equal(&pb.ListServicesRequest{
+					Parent: parent,
+				}))
+			})
+		})
+
 		Context("with results", func() {
 			It("returns a correct data", func() {
 				it := w.Namespace(nsName).Service("").
@@ -473,6 +512,7 @@ var _ = Describe("ServiceOperations", func() {
 						"key-1": "val-1",
 						"key-2": "val-2",
 					},
+					Revision: revision.FromContent(path.Join(parent, "services", "should-pass"), metadata),
 					OriginalObject: &pb.Service{
 						Name: path.Join(parent, "services", "should-pass"),
 						Annotations: map[string]string{
@@ -497,6 +537,7 @@ var _ = Describe("ServiceOperations", func() {
 						"key-1": "val-1",
 						"key-2": "val-2",
 					},
+					Revision: revision.FromContent(path.Join(parent, "services", "should-pass"), metadata),
 					OriginalObject: &pb.Service{
 						Name: path.Join(parent, "services", "should-pass"),
 						Annotations: map[string]string{
@@ -18,13 +18,24 @@
 package servicedirectory
 
 import (
+	"context"
+	"fmt"
 	"path"
 	"reflect"
+	"time"
 
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/breaker"
+	"github.com/CloudNativeSDWAN/serego/api/internal/cache"
+	"github.com/CloudNativeSDWAN/serego/api/internal/logging"
+	"github.com/CloudNativeSDWAN/serego/api/internal/metrics"
 	ops "github.com/CloudNativeSDWAN/serego/api/internal/operations"
+	"github.com/CloudNativeSDWAN/serego/api/internal/ratelimit"
+	"github.com/CloudNativeSDWAN/serego/api/internal/tracing"
 	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
-	"github.com/patrickmn/go-cache"
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/api/iterator"
 )
 
 const (
@@ -42,6 +53,13 @@ type GoogleServiceDirectoryWrapper struct {
 	client   regClient
 	pathName string
 	cache    *cache.Cache
+	limiter  *ratelimit.Limiter
+	breaker  *breaker.Breaker
+	metrics  *metrics.Metrics
+	tracer   trace.Tracer
+	logger   logr.Logger
+	// calls coalesces concurrent reads of the same objects and pages.
+	calls cache.Group
 }
 
 func NewServiceDirectoryWrapper(client regClient, wopts *wrapper.Options) (*GoogleServiceDirectoryWrapper, error) {
@@ -55,36 +73,106 @@ func NewServiceDirectoryWrapper(client regClient, wopts *wrapper.Options) (*Goog
 		return nil, srerr.NoLocationSet
 	}
 
-	return &GoogleServiceDirectoryWrapper{
+	m, err := metrics.New(wopts, metrics.ServiceDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("could not register metrics: %w", err)
+	}
+
+	limiter, brk := ratelimit.New(wopts), breaker.New(wopts)
+	if limiter != nil || brk != nil {
+		client = &guardedClient{regClient: client, limiter: limiter, breaker: brk}
+	}
+
+	g := &GoogleServiceDirectoryWrapper{
 		client:   client,
+		limiter:  limiter,
+		breaker:  brk,
+		metrics:  m,
+		tracer:   tracing.New(wopts),
+		logger:   logging.New(wopts).WithValues("backend", metrics.ServiceDirectory),
 		pathName: path.Join(pathProjects, wopts.ProjectID, pathLocations, wopts.Region),
 		cache: func() *cache.Cache {
 			if wopts.CacheExpirationTime == 0 {
 				return nil
 			}
 
-			return cache.New(wopts.CacheExpirationTime, wrapper.DefaultCacheCleanUpTime)
+			return cache.New(wopts, wopts.CacheExpirationTime)
 		}(),
-	}, nil
+	}
+	g.cache.SetMetrics(m)
+	g.cache.SetLogger(g.logger)
+
+	return g, nil
 }
 
 func (g *GoogleServiceDirectoryWrapper) putOnCache(pathName string, object interface{}) {
-	if g.cache != nil {
-		g.cache.SetDefault(pathName, object)
-	}
+	g.cache.Set(pathName, object)
+}
+
+func (g *GoogleServiceDirectoryWrapper) removeFromCache(pathName string) {
+	g.cache.Delete(pathName)
 }
 
 func (g *GoogleServiceDirectoryWrapper) getFromCache(pathName string) interface{} {
-	if g.cache == nil {
-		return nil
+	return g.cache.Get(pathName)
+}
+
+func (g *GoogleServiceDirectoryWrapper) putNotFoundOnCache(pathName string, err error) {
+	g.cache.SetNotFound(pathName, err)
+}
+
+func (g *GoogleServiceDirectoryWrapper) lookupOnCache(pathName string) (interface{}, bool, error) {
+	return g.cache.Lookup(pathName)
+}
+
+func (g *GoogleServiceDirectoryWrapper) getStaleOnError(pathName string, err error) interface{} {
+	return g.cache.GetStaleOnError(pathName, err)
+}
+
+// shared performs fetch to get an object of the provided kind, sharing the
+// call with all the concurrent ones with the same key, unless forceRefresh is
+// true, as in that case the object may have been modified after those
+// started. Only calls actually performed are recorded on metrics, not the
+// ones served from cache.
+func (g *GoogleServiceDirectoryWrapper) shared(ctx context.Context, key, object string, forceRefresh bool, fetch func(context.Context) (interface{}, error)) (interface{}, error) {
+	measured := func(ctx context.Context) (interface{}, error) {
+		start := time.Now()
+		obj, err := fetch(ctx)
+		g.metrics.ObserveOperation(object, string(wrapper.GetOperation), start, err)
+		return obj, err
 	}
 
-	object, found := g.cache.Get(pathName)
-	if !found {
-		return nil
+	if forceRefresh {
+		return measured(ctx)
+	}
+
+	return g.calls.Do(ctx, key, measured)
+}
+
+// beforePage waits for the rate limiter and checks the circuit breaker if
+// the iterator is going to fetch the next page of results from Service
+// Directory, in which case it returns true and afterPage must be called with
+// the outcome.
+func (g *GoogleServiceDirectoryWrapper) beforePage(ctx context.Context, it interface{ PageInfo() *iterator.PageInfo }) (bool, error) {
+	if (g.limiter == nil && g.breaker == nil) || it.PageInfo().Remaining() > 0 {
+		return false, nil
 	}
 
-	return object
+	if err := g.limiter.AcquireRead(ctx); err != nil {
+		return false, err
+	}
+
+	return true, g.breaker.Allow()
+}
+
+func (g *GoogleServiceDirectoryWrapper) afterPage(fetched bool, err error) {
+	if fetched {
+		if err == iterator.Done {
+			err = nil
+		}
+
+		g.breaker.Record(err)
+	}
 }
 
 func (g *GoogleServiceDirectoryWrapper) Namespace(name string) ops.NamespaceOperation {
@@ -18,13 +18,21 @@
 package servicedirectory_test
 
 import (
+	"context"
+	"time"
+
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
 	"github.com/CloudNativeSDWAN/serego/api/internal/wrappers/gcp/servicedirectory"
+	"github.com/CloudNativeSDWAN/serego/api/options/get"
 	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	sd "cloud.google.com/go/servicedirectory/apiv1"
+	"github.com/googleapis/gax-go/v2"
+	pb "google.golang.org/genproto/googleapis/cloud/servicedirectory/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var _ = Describe("Wrapper", func() {
@@ -60,4 +68,62 @@ var _ = Describe("Wrapper", func() {
 			})
 		})
 	})
+	Describe("Using rate limits", func() {
+		It("limits calls to Service Directory", func() {
+			calls := 0
+			f := &fakeRegistrationClient{
+				_getNamespace: func(_ context.Context, req *pb.GetNamespaceRequest, _ ...gax.CallOption) (*pb.Namespace, error) {
+					calls++
+					return &pb.Namespace{Name: req.Name}, nil
+				},
+			}
+			w, _ := servicedirectory.NewServiceDirectoryWrapper(f, &wrapper.Options{
+				ProjectID:         "project-id",
+				Region:            "us-west-2",
+				WriteRateLimit:    &wrapper.RateLimit{RequestsPerSecond: 1000, Burst: 1},
+				ReadRateLimit:     &wrapper.RateLimit{RequestsPerSecond: 0.001, Burst: 1},
+				RateLimitFailFast: true,
+			})
+
+			_, err := w.Namespace("ns").Get(context.Background(), &get.Options{})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = w.Namespace("ns").Get(context.Background(), &get.Options{})
+			Expect(err).To(MatchError(srerr.RateLimitExceeded))
+			Expect(calls).To(Equal(1))
+		})
+	})
+	Describe("Serving stale objects", func() {
+		It("returns expired objects when Service Directory is unavailable", func() {
+			var getErr error
+			f := &fakeRegistrationClient{
+				_getNamespace: func(_ context.Context, req *pb.GetNamespaceRequest, _ ...gax.CallOption) (*pb.Namespace, error) {
+					if getErr != nil {
+						return nil, getErr
+					}
+
+					return &pb.Namespace{Name: req.Name}, nil
+				},
+			}
+			w, _ := servicedirectory.NewServiceDirectoryWrapper(f, &wrapper.Options{
+				ProjectID:           "project-id",
+				Region:              "us-west-2",
+				CacheExpirationTime: time.Millisecond,
+				StaleGracePeriod:    time.Hour,
+			})
+
+			ns, err := w.Namespace("ns").Get(context.Background(), &get.Options{})
+			Expect(err).NotTo(HaveOccurred())
+			time.Sleep(2 * time.Millisecond)
+
+			getErr = status.Error(codes.Unavailable, "unavailable")
+			staleNs, err := w.Namespace("ns").Get(context.Background(), &get.Options{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(staleNs.Stale).To(BeTrue())
+			Expect(staleNs.Name).To(Equal(ns.Name))
+
+			_, err = w.Namespace("another").Get(context.Background(), &get.Options{})
+			Expect(err).To(MatchError(getErr))
+		})
+	})
 })
@@ -0,0 +1,63 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bulk
+
+import (
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+)
+
+const (
+	// DefaultConcurrency is the default maximum number of objects that a bulk
+	// operation will register or deregister at the same time.
+	DefaultConcurrency int = 10
+)
+
+// Options to fine tune the behavior of bulk operations.
+type Options struct {
+	// Concurrency is the maximum number of objects that are registered or
+	// deregistered at the same time.
+	Concurrency int
+}
+
+type Option func(*Options) error
+
+// WithConcurrency sets the maximum number of objects that the bulk operation
+// will register or deregister at the same time. If this option is not
+// provided, DefaultConcurrency is used.
+//
+// Keep in mind that some service registries, e.g. Cloud Map, have rate limits
+// on their APIs: a high value may result in more of your operations being
+// throttled.
+//
+// Example:
+// 	report, err := servOp.RegisterEndpoints(ctx, endpoints,
+// 		bulk.WithConcurrency(50))
+func WithConcurrency(concurrency int) Option {
+	return func(bo *Options) error {
+		if bo == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		if concurrency <= 0 {
+			return srerr.InvalidConcurrency
+		}
+
+		bo.Concurrency = concurrency
+		return nil
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bulk_test
+
+import (
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/options/bulk"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bulk Options", func() {
+	var opts *bulk.Options
+	BeforeEach(func() {
+		opts = &bulk.Options{}
+	})
+
+	It("sets the correct concurrency", func() {
+		err := bulk.WithConcurrency(5)(nil)
+		Expect(err).To(Equal(srerr.NoOptionsProvided))
+		err = bulk.WithConcurrency(0)(opts)
+		Expect(err).To(Equal(srerr.InvalidConcurrency))
+		err = bulk.WithConcurrency(-1)(opts)
+		Expect(err).To(Equal(srerr.InvalidConcurrency))
+		err = bulk.WithConcurrency(5)(opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opts).To(Equal(&bulk.Options{
+			Concurrency: 5,
+		}))
+	})
+})
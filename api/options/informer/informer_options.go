@@ -0,0 +1,91 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package informer
+
+import (
+	"time"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+)
+
+const (
+	// DefaultResyncPeriod is the default frequency that an informer will use
+	// to list all objects again from the service registry.
+	DefaultResyncPeriod time.Duration = time.Minute
+)
+
+// Options to fine tune the behavior of an informer.
+type Options struct {
+	// Namespaces is the list of the names of the namespaces that will be
+	// replicated. If empty, all namespaces are replicated.
+	Namespaces []string
+	// ResyncPeriod is the frequency that the informer will use to list all
+	// objects again from the service registry.
+	ResyncPeriod time.Duration
+}
+
+type Option func(*Options) error
+
+// WithNamespaces makes the informer replicate only the namespaces with the
+// provided names, along with their services and endpoints, instead of all of
+// them.
+//
+// Namespaces that do not exist are just ignored until they are created.
+//
+// Example:
+// 	inf, err := cache.NewInformer(sr, informer.WithNamespaces("sales", "hr"))
+func WithNamespaces(names ...string) Option {
+	return func(io *Options) error {
+		if io == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		if len(names) == 0 {
+			return srerr.NoNamespacesProvided
+		}
+
+		for _, name := range names {
+			if name == "" {
+				return srerr.EmptyNamespaceName
+			}
+		}
+
+		io.Namespaces = names
+		return nil
+	}
+}
+
+// WithResyncPeriod sets the frequency that the informer will use to list all
+// objects again from the service registry and notify the changes.
+//
+// Example:
+// 	inf, err := cache.NewInformer(sr, informer.WithResyncPeriod(30*time.Second))
+func WithResyncPeriod(period time.Duration) Option {
+	return func(io *Options) error {
+		if io == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		if period <= 0 {
+			return srerr.InvalidResyncPeriod
+		}
+
+		io.ResyncPeriod = period
+		return nil
+	}
+}
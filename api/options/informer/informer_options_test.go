@@ -0,0 +1,62 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package informer_test
+
+import (
+	"time"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/options/informer"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Informer Options", func() {
+	var opts *informer.Options
+	BeforeEach(func() {
+		opts = &informer.Options{}
+	})
+
+	It("sets the correct namespaces", func() {
+		err := informer.WithNamespaces("ns")(nil)
+		Expect(err).To(Equal(srerr.NoOptionsProvided))
+		err = informer.WithNamespaces()(opts)
+		Expect(err).To(Equal(srerr.NoNamespacesProvided))
+		err = informer.WithNamespaces("ns", "")(opts)
+		Expect(err).To(Equal(srerr.EmptyNamespaceName))
+		err = informer.WithNamespaces("ns-1", "ns-2")(opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opts).To(Equal(&informer.Options{
+			Namespaces: []string{"ns-1", "ns-2"},
+		}))
+	})
+
+	It("sets the correct resync period", func() {
+		err := informer.WithResyncPeriod(time.Second)(nil)
+		Expect(err).To(Equal(srerr.NoOptionsProvided))
+		err = informer.WithResyncPeriod(0)(opts)
+		Expect(err).To(Equal(srerr.InvalidResyncPeriod))
+		err = informer.WithResyncPeriod(-time.Second)(opts)
+		Expect(err).To(Equal(srerr.InvalidResyncPeriod))
+		err = informer.WithResyncPeriod(time.Second)(opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opts).To(Equal(&informer.Options{
+			ResyncPeriod: time.Second,
+		}))
+	})
+})
@@ -0,0 +1,435 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package list
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+)
+
+const (
+	opEqual        = "=="
+	opNotEqual     = "!="
+	opLess         = "<"
+	opLessEqual    = "<="
+	opGreater      = ">"
+	opGreaterEqual = ">="
+	opMatch        = "~"
+	opNotMatch     = "!~"
+	opIn           = "in"
+	opNotIn        = "not in"
+)
+
+// Expression is a filter expression parsed by WithExpression.
+type Expression struct {
+	source string
+	root   exprNode
+}
+
+// String returns the expression as it was provided.
+func (e *Expression) String() string {
+	if e == nil {
+		return ""
+	}
+
+	return e.source
+}
+
+// exprObject contains the fields of the object that expressions can use.
+type exprObject struct {
+	name       string
+	metadata   map[string]string
+	isEndpoint bool
+	address    net.IP
+	port       int32
+}
+
+type exprNode interface {
+	eval(obj *exprObject) bool
+}
+
+type andNode struct {
+	left, right exprNode
+}
+
+func (n *andNode) eval(obj *exprObject) bool {
+	return n.left.eval(obj) && n.right.eval(obj)
+}
+
+type orNode struct {
+	left, right exprNode
+}
+
+func (n *orNode) eval(obj *exprObject) bool {
+	return n.left.eval(obj) || n.right.eval(obj)
+}
+
+type notNode struct {
+	node exprNode
+}
+
+func (n *notNode) eval(obj *exprObject) bool {
+	return !n.node.eval(obj)
+}
+
+// isNegative returns true for operators that are satisfied by objects that
+// don't have the field, e.g. services have no port and so port != 80 is true
+// for all of them.
+func isNegative(op string) bool {
+	return op == opNotEqual || op == opNotMatch || op == opNotIn
+}
+
+func compared(op string, cmp int) bool {
+	switch op {
+	case opEqual:
+		return cmp == 0
+	case opNotEqual:
+		return cmp != 0
+	case opLess:
+		return cmp < 0
+	case opLessEqual:
+		return cmp <= 0
+	case opGreater:
+		return cmp > 0
+	case opGreaterEqual:
+		return cmp >= 0
+	}
+
+	return false
+}
+
+type stringComparison struct {
+	// key is the metadata key to compare, or empty for the name.
+	key    string
+	op     string
+	values []string
+	regex  *regexp.Regexp
+	// number is the first value if it is an unquoted number, e.g. in
+	// metadata.weight > 0.5, and isNumber tells if it is.
+	number   float64
+	isNumber bool
+}
+
+func newStringComparison(field, key, op string, values []token) (exprNode, error) {
+	comparison := &stringComparison{key: key, op: op}
+	for i, value := range values {
+		if value.kind == tokenWord {
+			// Numbers can be written without quotes for metadata, e.g.
+			// metadata.replicas > 3.
+			number, ok := parseNumber(value.text)
+			if !ok || field == "name" {
+				return nil, fmt.Errorf("%w: %s must be compared with quoted strings, found %s",
+					srerr.InvalidExpressionType, field, value)
+			}
+
+			if i == 0 {
+				comparison.number, comparison.isNumber = number, true
+			}
+		}
+
+		comparison.values = append(comparison.values, value.text)
+	}
+
+	if op == opMatch || op == opNotMatch {
+		regex, err := regexp.Compile(comparison.values[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", srerr.InvalidExpressionType, err)
+		}
+
+		comparison.regex = regex
+	}
+
+	return comparison, nil
+}
+
+func (c *stringComparison) eval(obj *exprObject) bool {
+	value := obj.name
+	if c.key != "" {
+		val, exists := obj.metadata[c.key]
+		if !exists {
+			return isNegative(c.op)
+		}
+
+		value = val
+	}
+
+	switch c.op {
+	case opMatch:
+		return c.regex.MatchString(value)
+	case opNotMatch:
+		return !c.regex.MatchString(value)
+	case opIn:
+		return nameInFilter(value, c.values...)
+	case opNotIn:
+		return !nameInFilter(value, c.values...)
+	}
+
+	// Unquoted numbers are compared as numbers with values that are numbers
+	// as well, e.g. 0.25 is less than 0.5 and 3.0 is equal to 3.
+	if c.isNumber {
+		if number, ok := parseNumber(value); ok {
+			return compared(c.op, compareNumbers(number, c.number))
+		}
+	}
+
+	switch c.op {
+	case opEqual:
+		return value == c.values[0]
+	case opNotEqual:
+		return value != c.values[0]
+	}
+
+	return compared(c.op, compareVersions(value, c.values[0]))
+}
+
+// parseNumber parses a decimal number, e.g. -1, 0.5 or 1e3, returning false
+// if text is not one, including infinities and NaN.
+func parseNumber(text string) (float64, bool) {
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil || math.IsInf(number, 0) || math.IsNaN(number) {
+		return 0, false
+	}
+
+	return number, true
+}
+
+func compareNumbers(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// compareVersions compares two strings as versions if both are made of
+// numbers separated by dots, e.g. 1.10 is greater than 1.4, or as strings
+// otherwise.
+func compareVersions(a, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	aNums, aOk := parseVersionParts(aParts)
+	bNums, bOk := parseVersionParts(bParts)
+	if !aOk || !bOk {
+		return strings.Compare(a, b)
+	}
+
+	for i := 0; i < len(aNums) || i < len(bNums); i++ {
+		var aNum, bNum uint64
+		if i < len(aNums) {
+			aNum = aNums[i]
+		}
+		if i < len(bNums) {
+			bNum = bNums[i]
+		}
+
+		switch {
+		case aNum < bNum:
+			return -1
+		case aNum > bNum:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func parseVersionParts(parts []string) ([]uint64, bool) {
+	nums := make([]uint64, len(parts))
+	for i, part := range parts {
+		num, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+
+		nums[i] = num
+	}
+
+	return nums, true
+}
+
+type portComparison struct {
+	op     string
+	values []int32
+}
+
+func newPortComparison(op string, values []token) (exprNode, error) {
+	if op == opMatch || op == opNotMatch {
+		return nil, fmt.Errorf("%w: operator %s cannot be used with port",
+			srerr.InvalidExpressionType, op)
+	}
+
+	comparison := &portComparison{op: op}
+	for _, value := range values {
+		port, err := strconv.ParseInt(value.text, 10, 32)
+		if value.kind != tokenWord || err != nil || port < 0 || port > int64(maxPortNumber) {
+			return nil, fmt.Errorf("%w: port must be compared with port numbers, found %s",
+				srerr.InvalidExpressionType, value)
+		}
+
+		comparison.values = append(comparison.values, int32(port))
+	}
+
+	return comparison, nil
+}
+
+func (c *portComparison) eval(obj *exprObject) bool {
+	if !obj.isEndpoint {
+		return isNegative(c.op)
+	}
+
+	switch c.op {
+	case opIn:
+		return portIsIn(obj.port, c.values...)
+	case opNotIn:
+		return !portIsIn(obj.port, c.values...)
+	}
+
+	cmp := 0
+	switch {
+	case obj.port < c.values[0]:
+		cmp = -1
+	case obj.port > c.values[0]:
+		cmp = 1
+	}
+
+	return compared(c.op, cmp)
+}
+
+type addressComparison struct {
+	op       string
+	networks []*net.IPNet
+}
+
+func newAddressComparison(op string, values []token) (exprNode, error) {
+	switch op {
+	case opEqual, opNotEqual, opIn, opNotIn:
+	default:
+		return nil, fmt.Errorf("%w: operator %s cannot be used with address",
+			srerr.InvalidExpressionType, op)
+	}
+
+	comparison := &addressComparison{op: op}
+	for _, value := range values {
+		if ip := net.ParseIP(value.text); ip != nil {
+			bits := 8 * len(ip)
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+
+			comparison.networks = append(comparison.networks, &net.IPNet{
+				IP:   ip,
+				Mask: net.CIDRMask(bits, bits),
+			})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(value.text)
+		if err != nil || op == opEqual || op == opNotEqual {
+			return nil, fmt.Errorf("%w: address must be compared with IP addresses or used with in and networks, found %s",
+				srerr.InvalidExpressionType, value)
+		}
+
+		comparison.networks = append(comparison.networks, network)
+	}
+
+	return comparison, nil
+}
+
+func (c *addressComparison) eval(obj *exprObject) bool {
+	if obj.address == nil {
+		return isNegative(c.op)
+	}
+
+	contained := false
+	for _, network := range c.networks {
+		if network.Contains(obj.address) {
+			contained = true
+			break
+		}
+	}
+
+	return contained != isNegative(c.op)
+}
+
+// WithExpression instructs List to only get objects that satisfy the
+// provided expression, which can combine conditions on the name, the
+// metadata, the address and the port of objects, e.g.
+// 	name ~ "^api-" && port >= 8000 && metadata.version >= "1.4" && address in 10.0.0.0/8
+//
+// Fields are compared with the following operators:
+// 	name, metadata.<key>: == != < <= > >= ~ !~ in (...) not in (...)
+// 	port:                 == != < <= > >= in (...) not in (...)
+// 	address:              == != in (...) not in (...)
+// where ~ and !~ match a regular expression, strings must be quoted and
+// in accepts both IP addresses and networks for the address. Metadata keys
+// with special characters can be written as metadata["key"], and strings
+// made of numbers separated by dots are compared as versions, e.g. "1.10"
+// is greater than "1.4". Numbers can be written without quotes for metadata
+// and are compared as numbers with values that are numbers as well, e.g.
+// metadata.weight > 0.5 is false for "0.25" and true for "1e3".
+//
+// Conditions can be combined with &&, || and !, grouping them with
+// parenthesis. Conditions on fields that an object doesn't have, e.g. the
+// port of a service or a missing metadata key, are only satisfied with
+// negative operators: != !~ and not in.
+//
+// Expressions are checked when the option is applied, returning an error in
+// case of invalid syntax or when a field is compared with a value of the wrong
+// type, e.g. port == "80". Each call to this function adds a new expression
+// that objects must satisfy together with the ones provided by precedent
+// calls.
+//
+// Example:
+// 	sr.Namespace("sales").Service("payroll").Endpoint(core.Any).List(
+// 		list.WithExpression(`port >= 8000 && metadata.version >= "1.4"`),
+// 	)
+func WithExpression(expr string) Option {
+	return func(lo *Options) error {
+		if lo == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		if strings.TrimSpace(expr) == "" {
+			return srerr.EmptyExpression
+		}
+
+		root, err := parseExpression(expr)
+		if err != nil {
+			return err
+		}
+
+		if lo.Expression != nil {
+			lo.Expression = &Expression{
+				source: fmt.Sprintf("(%s) && (%s)", lo.Expression.source, expr),
+				root:   &andNode{left: lo.Expression.root, right: root},
+			}
+			return nil
+		}
+
+		lo.Expression = &Expression{source: expr, root: root}
+		return nil
+	}
+}
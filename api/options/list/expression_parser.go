@@ -0,0 +1,374 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package list
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenLeftParen
+	tokenRightParen
+	tokenLeftBracket
+	tokenRightBracket
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+
+	return strconv.Quote(t.text)
+}
+
+func isWordChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+		(c >= '0' && c <= '9') || strings.IndexByte("_-./:", c) >= 0
+}
+
+// tokenize splits the expression in tokens. Words include dots, slashes and
+// colons, so that metadata keys, addresses and networks can be written
+// without quotes, e.g. metadata.version or 10.0.0.0/8.
+func tokenize(expr string) ([]token, error) {
+	tokens := []token{}
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '"':
+			quoted, err := strconv.QuotedPrefix(expr[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%w: unterminated string at position %d", srerr.InvalidExpression, i)
+			}
+
+			// QuotedPrefix already made sure that this is valid.
+			text, _ := strconv.Unquote(quoted)
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: i})
+			i += len(quoted)
+			continue
+		case isWordChar(c):
+			start := i
+			for i < len(expr) && isWordChar(expr[i]) {
+				i++
+			}
+
+			tokens = append(tokens, token{kind: tokenWord, text: expr[start:i], pos: start})
+			continue
+		}
+
+		two := ""
+		if i+1 < len(expr) {
+			two = expr[i : i+2]
+		}
+
+		var tok token
+		switch {
+		case two == "&&":
+			tok = token{kind: tokenAnd, text: two}
+		case two == "||":
+			tok = token{kind: tokenOr, text: two}
+		case two == "==" || two == "!=" || two == "<=" || two == ">=" || two == "!~":
+			tok = token{kind: tokenOperator, text: two}
+		case c == '<' || c == '>' || c == '~':
+			tok = token{kind: tokenOperator, text: string(c)}
+		case c == '!':
+			tok = token{kind: tokenNot, text: string(c)}
+		case c == '(':
+			tok = token{kind: tokenLeftParen, text: string(c)}
+		case c == ')':
+			tok = token{kind: tokenRightParen, text: string(c)}
+		case c == '[':
+			tok = token{kind: tokenLeftBracket, text: string(c)}
+		case c == ']':
+			tok = token{kind: tokenRightBracket, text: string(c)}
+		case c == ',':
+			tok = token{kind: tokenComma, text: string(c)}
+		default:
+			return nil, fmt.Errorf("%w: unexpected character %q at position %d", srerr.InvalidExpression, c, i)
+		}
+
+		tok.pos = i
+		tokens = append(tokens, tok)
+		i += len(tok.text)
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(expr)}), nil
+}
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+
+	return tok
+}
+
+func (p *exprParser) expect(kind tokenKind, what string) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		return tok, unexpectedToken(tok, what)
+	}
+
+	return tok, nil
+}
+
+func unexpectedToken(tok token, expected string) error {
+	return fmt.Errorf("%w: expected %s at position %d, found %s",
+		srerr.InvalidExpression, expected, tok.pos, tok)
+}
+
+// parseExpression parses the whole expression, checking that fields are
+// compared with values of the appropriate type.
+func parseExpression(expr string) (exprNode, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.next(); tok.kind != tokenEOF {
+		return nil, unexpectedToken(tok, "&& or ||")
+	}
+
+	return node, nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &orNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &andNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	switch p.peek().kind {
+	case tokenNot:
+		p.next()
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &notNode{node: node}, nil
+	case tokenLeftParen:
+		p.next()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := p.expect(tokenRightParen, ")"); err != nil {
+			return nil, err
+		}
+
+		return node, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	fieldTok, err := p.expect(tokenWord, "a field")
+	if err != nil {
+		return nil, err
+	}
+
+	field, key := fieldTok.text, ""
+	switch {
+	case field == "name", field == "address", field == "port":
+	case field == "metadata" && p.peek().kind == tokenLeftBracket:
+		p.next()
+		keyTok, err := p.expect(tokenString, "a quoted metadata key")
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := p.expect(tokenRightBracket, "]"); err != nil {
+			return nil, err
+		}
+
+		key = keyTok.text
+	case strings.HasPrefix(field, "metadata."):
+		key = strings.TrimPrefix(field, "metadata.")
+	default:
+		return nil, fmt.Errorf("%w: unknown field %s at position %d",
+			srerr.InvalidExpression, fieldTok, fieldTok.pos)
+	}
+
+	if strings.HasPrefix(field, "metadata") {
+		if key == "" {
+			return nil, fmt.Errorf("%w: empty metadata key at position %d",
+				srerr.InvalidExpression, fieldTok.pos)
+		}
+
+		field = "metadata"
+	}
+
+	op, err := p.parseOperator()
+	if err != nil {
+		return nil, err
+	}
+
+	values := []token{}
+	if op == opIn || op == opNotIn {
+		values, err = p.parseValues()
+	} else {
+		var value token
+		value, err = p.parseValue()
+		values = append(values, value)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch field {
+	case "port":
+		return newPortComparison(op, values)
+	case "address":
+		return newAddressComparison(op, values)
+	}
+
+	return newStringComparison(field, key, op, values)
+}
+
+func (p *exprParser) parseOperator() (string, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokenOperator:
+		return tok.text, nil
+	case tok.kind == tokenWord && tok.text == opIn:
+		return opIn, nil
+	case tok.kind == tokenWord && tok.text == "not":
+		if next := p.next(); next.kind != tokenWord || next.text != opIn {
+			return "", unexpectedToken(next, "in")
+		}
+
+		return opNotIn, nil
+	}
+
+	return "", unexpectedToken(tok, "an operator")
+}
+
+// parseValues parses the values on the right of in and not in, which are
+// either a single value or a list of values between parenthesis.
+func (p *exprParser) parseValues() ([]token, error) {
+	if p.peek().kind != tokenLeftParen {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		return []token{value}, nil
+	}
+
+	p.next()
+	values := []token{}
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		tok := p.next()
+		if tok.kind == tokenRightParen {
+			return values, nil
+		}
+
+		if tok.kind != tokenComma {
+			return nil, unexpectedToken(tok, ", or )")
+		}
+	}
+}
+
+func (p *exprParser) parseValue() (token, error) {
+	tok := p.next()
+	if tok.kind != tokenWord && tok.kind != tokenString {
+		return tok, unexpectedToken(tok, "a value")
+	}
+
+	return tok, nil
+}
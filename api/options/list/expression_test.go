@@ -0,0 +1,194 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package list_test
+
+import (
+	"fmt"
+
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/options/list"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithExpression", func() {
+	It("returns an error if options are nil", func() {
+		Expect(list.WithExpression("port == 80")(nil)).To(Equal(srerr.NoOptionsProvided))
+	})
+
+	It("returns an error if the expression is empty", func() {
+		Expect(list.WithExpression("  ")(&list.Options{})).To(Equal(srerr.EmptyExpression))
+	})
+
+	It("returns an error if the syntax is not valid", func() {
+		for _, expr := range []string{
+			`name == "api`,
+			`name = "api"`,
+			`name == "api" &&`,
+			`(port == 80`,
+			`port == 80)`,
+			`port 80`,
+			`zone == "eu"`,
+			`metadata. == "eu"`,
+			`metadata[zone] == "eu"`,
+			`port in (80, 443`,
+			`port not (80)`,
+			`name == "api" # comment`,
+		} {
+			err := list.WithExpression(expr)(&list.Options{})
+			Expect(err).To(MatchError(srerr.InvalidExpression), expr)
+		}
+	})
+
+	It("returns an error if the types are not valid", func() {
+		for _, expr := range []string{
+			`name == api`,
+			`name ~ "api-("`,
+			`metadata.version == v1`,
+			`metadata.weight > inf`,
+			`metadata.weight != NaN`,
+			`port == "80"`,
+			`port == 70000`,
+			`port ~ "80"`,
+			`address == 10.0.0.0/8`,
+			`address in (10.0.0.0/8, "not-an-address")`,
+			`address >= 10.0.0.1`,
+		} {
+			err := list.WithExpression(expr)(&list.Options{})
+			Expect(err).To(MatchError(srerr.InvalidExpressionType), expr)
+		}
+	})
+
+	It("combines multiple expressions", func() {
+		opts := &list.Options{}
+		Expect(list.WithExpression(`port >= 8000`)(opts)).To(Succeed())
+		Expect(list.WithExpression(`name ~ "^api-"`)(opts)).To(Succeed())
+		Expect(opts.Expression.String()).To(Equal(`(port >= 8000) && (name ~ "^api-")`))
+
+		passed, err := opts.Filter(&coretypes.Endpoint{Name: "api-1", Port: 8080})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(passed).To(BeTrue())
+
+		passed, err = opts.Filter(&coretypes.Endpoint{Name: "web-1", Port: 8080})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(passed).To(BeFalse())
+	})
+})
+
+var _ = Describe("Filter with expressions", func() {
+	filter := func(expr string, object interface{}) bool {
+		opts := &list.Options{}
+		ExpectWithOffset(1, list.WithExpression(expr)(opts)).To(Succeed())
+
+		passed, err := opts.Filter(object)
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		return passed
+	}
+
+	It("filters endpoints correctly", func() {
+		endp := &coretypes.Endpoint{
+			Name:    "api-1",
+			Address: "10.10.10.10",
+			Port:    8080,
+			Metadata: map[string]string{
+				"version":   "1.10.2",
+				"zone":      "eu-west",
+				"team/name": "sales",
+			},
+		}
+
+		for i, exp := range []struct {
+			expr   string
+			passed bool
+		}{
+			{`name ~ "^api-" && port >= 8000 && metadata.version >= "1.4" && address in 10.0.0.0/8`, true},
+			{`name == "api-1"`, true},
+			{`name != "api-1"`, false},
+			{`name in ("api-1", "api-2")`, true},
+			{`name not in ("api-1", "api-2")`, false},
+			{`name !~ "^web-"`, true},
+			{`name > "api-0" && name < "api-2"`, true},
+			{`port == 8080`, true},
+			{`port < 8080 || port > 8080`, false},
+			{`port in (80, 443)`, false},
+			{`port not in (80, 443)`, true},
+			{`address == 10.10.10.10`, true},
+			{`address != "10.10.10.10"`, false},
+			{`address in (192.168.0.0/16, 10.10.10.10)`, true},
+			{`address not in 10.10.0.0/16`, false},
+			{`address in fd00::/8`, false},
+			{`metadata.version < "1.9"`, false},
+			{`metadata.version == "1.10.2"`, true},
+			{`metadata.version > 1.10`, true},
+			{`metadata["team/name"] == "sales"`, true},
+			{`metadata.zone ~ "^eu-"`, true},
+			{`metadata.zone in ("us-east", "us-west")`, false},
+			{`metadata.missing == "value"`, false},
+			{`metadata.missing != "value"`, true},
+			{`metadata.missing not in ("a", "b")`, true},
+			{`!(port == 8080)`, false},
+			{`port == 80 || (name == "api-1" && !(metadata.zone == "us-east"))`, true},
+			{`port == 80 || name == "api-1" && metadata.zone == "us-east"`, false},
+		} {
+			Expect(filter(exp.expr, endp)).To(Equal(exp.passed), fmt.Sprintf("case %d: %s", i, exp.expr))
+		}
+	})
+
+	It("compares unquoted numbers as numbers", func() {
+		ns := &coretypes.Namespace{Name: "sales", Metadata: map[string]string{
+			"weight":   "0.25",
+			"priority": "-1",
+			"limit":    "2000",
+			"replicas": "3.0",
+			"version":  "1.10.2",
+		}}
+
+		for i, exp := range []struct {
+			expr   string
+			passed bool
+		}{
+			{`metadata.weight > 0.5`, false},
+			{`metadata.weight < 0.5`, true},
+			{`metadata.weight >= .25`, true},
+			{`metadata.priority > -2`, true},
+			{`metadata.priority < -0.5`, true},
+			{`metadata.priority >= 0`, false},
+			{`metadata.limit > 1e3`, true},
+			{`metadata.limit <= 2e3`, true},
+			{`metadata.replicas == 3`, true},
+			{`metadata.replicas != 3`, false},
+			{`metadata.replicas == "3"`, false},
+			{`metadata.version > 1.4`, true},
+		} {
+			Expect(filter(exp.expr, ns)).To(Equal(exp.passed), fmt.Sprintf("case %d: %s", i, exp.expr))
+		}
+	})
+
+	It("treats endpoint fields as missing on other objects", func() {
+		serv := &coretypes.Service{Name: "payroll"}
+		Expect(filter(`port == 80`, serv)).To(BeFalse())
+		Expect(filter(`port != 80`, serv)).To(BeTrue())
+		Expect(filter(`address in 10.0.0.0/8`, serv)).To(BeFalse())
+		Expect(filter(`address not in 10.0.0.0/8`, serv)).To(BeTrue())
+		Expect(filter(`name == "payroll"`, serv)).To(BeTrue())
+
+		ns := &coretypes.Namespace{Name: "sales", Metadata: map[string]string{"replicas": "3"}}
+		Expect(filter(`metadata.replicas >= 3 && name ~ "^sa"`, ns)).To(BeTrue())
+	})
+})
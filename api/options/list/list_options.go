@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"regexp"
+	"strings"
 	"syscall"
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
@@ -41,7 +43,7 @@ const (
 // name.
 type NameFilters struct {
 	// In is a list of names of objects that you want to get.
-	// It cannot be used together with the Prefix filter.
+	// It cannot be used together with any other name filter.
 	//
 	// Example:
 	// 	[]string{"sales", "production", "hr"}
@@ -51,6 +53,27 @@ type NameFilters struct {
 	//
 	// Examples: "prod-" or "building_",
 	Prefix string
+	// Suffix that the object names must have.
+	// It cannot be used together with the In filter.
+	//
+	// Examples: "-prod" or "_v2",
+	Suffix string
+	// Regex is a regular expression that the object names must match, with
+	// the syntax accepted by the regexp package. Use ^ and $ to match the
+	// whole name rather than part of it.
+	// It cannot be used together with the In filter.
+	//
+	// Example: "^payroll-v[0-9]+$"
+	Regex string
+	// regex is Regex compiled by WithNameRegex, so that it is not compiled
+	// again for each object.
+	regex *regexp.Regexp
+	// NotIn is a list of names of objects that you don't want to get.
+	// It cannot be used together with the In filter.
+	//
+	// Example:
+	// 	[]string{"test", "staging"}
+	NotIn []string
 }
 
 // MetadataFilters contains filters to use for filtering an object based on its
@@ -62,11 +85,37 @@ type MetadataFilters struct {
 	// It cannot be used together with the Metadata filter.
 	NoMetadata bool
 	// Metadata that the object must have.
-	// It cannot be used together with the NoMetadata filter.
+	// It cannot be used together with the NoMetadata filter, and values of
+	// keys included in the ValuesIn filter as well must be among the ones
+	// provided there.
 	//
 	// Example:
 	// 	map[string]string{"env": "prod", "maintainer": "team-24@company.com"}
 	Metadata map[string]string
+	// AbsentKeys are metadata keys that the object must not have.
+	// It cannot be used together with the NoMetadata filter, and keys cannot
+	// be included in the Metadata or ValuesIn filters as well.
+	//
+	// Example:
+	// 	[]string{"deprecated", "canary"}
+	AbsentKeys []string
+	// ValuesIn contains metadata keys that the object must have, with any of
+	// the values provided for each key.
+	// It cannot be used together with the NoMetadata filter, keys cannot be
+	// included in the AbsentKeys filter as well and, if they are included in
+	// the Metadata filter with a value, it must be among the ones provided.
+	//
+	// Example:
+	// 	map[string][]string{"env": {"prod", "staging"}}
+	ValuesIn map[string][]string
+	// ValuesNotIn contains metadata keys whose value must not be any of the
+	// ones provided for each key. Objects that don't have the key pass this
+	// filter.
+	// It cannot be used together with the NoMetadata filter.
+	//
+	// Example:
+	// 	map[string][]string{"env": {"test", "dev"}}
+	ValuesNotIn map[string][]string
 }
 
 // AddressFilters contains filters to use for filtering an *endpoint* based on its
@@ -88,6 +137,11 @@ type AddressFilters struct {
 	// - IPv4AddressFamily
 	// - IPv6AddressFamily
 	AddressFamily AddressFamily
+	// ExcludedCIDRs instructs List to only get endpoints whose address is
+	// not included in any of the given subnets.
+	//
+	// Example: []string{"10.10.10.0/24", "2002::1234:abcd:ffff:c0a8:101/64"}
+	ExcludedCIDRs []string
 }
 
 // PortFilters contains filters to use for filtering an *endpoint* based on its
@@ -102,10 +156,24 @@ type PortFilters struct {
 	// Range is exactly like the In filter except that you use this when
 	// wanting to including many ports in a range. The range delimiters are
 	// included.
-	// Cannot be used together with the In filter.
+	// Cannot be used together with the In filter, and ranges cannot be
+	// entirely excluded by the NotIn and ExcludedRanges filters.
 	//
 	// Example: []int32{{80, 85}, {8080, 8090}}
 	Range [][2]int32
+	// NotIn is a list of ports that the endpoint must not have in order to
+	// be returned.
+	// It cannot contain ports included in the In filter.
+	//
+	// Example: []int32{22, 23}
+	NotIn []int32
+	// ExcludedRanges is exactly like the NotIn filter except that you use
+	// this when wanting to exclude many ports in a range. The range
+	// delimiters are excluded as well.
+	// It cannot contain ports included in the In filter.
+	//
+	// Example: []int32{{0, 1023}}
+	ExcludedRanges [][2]int32
 }
 
 // AddressFamily is the protocol the address belongs to.
@@ -126,6 +194,9 @@ const (
 type Options struct {
 	// Results defines the number of results to pull per page.
 	Results int32
+	// PageToken is the token to resume listing from, as returned by the
+	// NextPageToken function of iterators.
+	PageToken string
 	// NameFilters provides filters for the name of the objects.
 	*NameFilters
 	// MetadataFilters provides filters for the metadata of the objects.
@@ -134,6 +205,11 @@ type Options struct {
 	*AddressFilters
 	// PortFilters provides filters for the ports of the endpoint.
 	*PortFilters
+	// Expression is an expression that objects must satisfy, which can
+	// combine conditions on all of their fields.
+	Expression *Expression
+	// OrderBy contains the fields to sort objects by, in order of priority.
+	OrderBy []OrderBy
 }
 
 // Filter returns true if the object provided as argument passes all the
@@ -160,6 +236,19 @@ func (o *Options) Filter(object interface{}) (bool, error) {
 		return false, srerr.InvalidObjectToFilter
 	}
 
+	if o.Expression != nil {
+		exprObj := &exprObject{name: name, metadata: metadata}
+		if endp, ok := object.(*coretypes.Endpoint); ok {
+			exprObj.isEndpoint = true
+			exprObj.address = net.ParseIP(endp.Address)
+			exprObj.port = endp.Port
+		}
+
+		if !o.Expression.root.eval(exprObj) {
+			return false, nil
+		}
+	}
+
 	if o.NameFilters != nil {
 		if len(o.NameFilters.In) > 0 && !nameInFilter(name, o.NameFilters.In...) {
 			return false, nil
@@ -168,6 +257,31 @@ func (o *Options) Filter(object interface{}) (bool, error) {
 		if o.NameFilters.Prefix != "" && !namePrefixFilter(name, o.NameFilters.Prefix) {
 			return false, nil
 		}
+
+		if o.NameFilters.Suffix != "" && !strings.HasSuffix(name, o.NameFilters.Suffix) {
+			return false, nil
+		}
+
+		if o.NameFilters.Regex != "" {
+			regex := o.NameFilters.regex
+			if regex == nil || regex.String() != o.NameFilters.Regex {
+				// Regex was set without WithNameRegex.
+				compiled, err := regexp.Compile(o.NameFilters.Regex)
+				if err != nil {
+					return false, srerr.InvalidNameRegexFilter
+				}
+
+				regex = compiled
+			}
+
+			if !regex.MatchString(name) {
+				return false, nil
+			}
+		}
+
+		if len(o.NameFilters.NotIn) > 0 && nameInFilter(name, o.NameFilters.NotIn...) {
+			return false, nil
+		}
 	}
 
 	if o.MetadataFilters != nil {
@@ -178,6 +292,18 @@ func (o *Options) Filter(object interface{}) (bool, error) {
 		if len(o.MetadataFilters.Metadata) > 0 && !metadataFilter(metadata, o.MetadataFilters.Metadata) {
 			return false, nil
 		}
+
+		if len(o.MetadataFilters.AbsentKeys) > 0 && !metadataKeysAbsent(metadata, o.MetadataFilters.AbsentKeys) {
+			return false, nil
+		}
+
+		if len(o.MetadataFilters.ValuesIn) > 0 && !metadataValuesIn(metadata, o.MetadataFilters.ValuesIn) {
+			return false, nil
+		}
+
+		if len(o.MetadataFilters.ValuesNotIn) > 0 && !metadataValuesNotIn(metadata, o.MetadataFilters.ValuesNotIn) {
+			return false, nil
+		}
 	}
 
 	if endp, ok := object.(*coretypes.Endpoint); ok {
@@ -193,6 +319,12 @@ func (o *Options) Filter(object interface{}) (bool, error) {
 			if o.AddressFilters.AddressFamily == syscall.AF_INET6 && !isIPv6(endp.Address) {
 				return false, nil
 			}
+
+			for _, cidr := range o.AddressFilters.ExcludedCIDRs {
+				if isInsideCIDR(cidr, endp.Address) {
+					return false, nil
+				}
+			}
 		}
 
 		if o.PortFilters != nil {
@@ -203,6 +335,14 @@ func (o *Options) Filter(object interface{}) (bool, error) {
 			if len(o.PortFilters.Range) > 0 && !portIsInRange(endp.Port, o.PortFilters.Range) {
 				return false, nil
 			}
+
+			if len(o.PortFilters.NotIn) > 0 && portIsIn(endp.Port, o.PortFilters.NotIn...) {
+				return false, nil
+			}
+
+			if len(o.PortFilters.ExcludedRanges) > 0 && portIsInRange(endp.Port, o.PortFilters.ExcludedRanges) {
+				return false, nil
+			}
 		}
 	}
 
@@ -242,7 +382,8 @@ func WithNamePrefix(prefix string) Option {
 }
 
 // WithNameIn instructs List to only retreive the given endpoints, ignore
-// all others. It cannot be used together with WithNamePrefix.
+// all others. It cannot be used together with any other name option, e.g.
+// WithNamePrefix or WithNameNotIn.
 //
 // Each call to this function appends its values to the ones provided by any
 // precedent call, skipping duplicate names.
@@ -263,7 +404,8 @@ func WithNameIn(names ...string) Option {
 			lo.NameFilters = &NameFilters{}
 		}
 
-		if lo.NameFilters.Prefix != "" {
+		if lo.NameFilters.Prefix != "" || lo.NameFilters.Suffix != "" ||
+			lo.NameFilters.Regex != "" || len(lo.NameFilters.NotIn) > 0 {
 			return srerr.IncompatibleNameFilters
 		}
 
@@ -275,6 +417,105 @@ func WithNameIn(names ...string) Option {
 	}
 }
 
+// WithNameSuffix instructs List to only get objects that have a certain
+// suffix, ignore all others. It cannot be used together with WithNameIn.
+//
+// Each call to this function replaces values provided by any precedent call.
+//
+// Example:
+// 	sr.Namespace(core.Any).List(list.WithNameSuffix("-prod"))
+func WithNameSuffix(suffix string) Option {
+	return func(lo *Options) error {
+		if lo == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		if suffix == "" {
+			return srerr.InvalidNameSuffixFilter
+		}
+
+		if lo.NameFilters == nil {
+			lo.NameFilters = &NameFilters{}
+		}
+
+		if len(lo.NameFilters.In) > 0 {
+			return srerr.IncompatibleNameFilters
+		}
+
+		lo.NameFilters.Suffix = suffix
+		return nil
+	}
+}
+
+// WithNameRegex instructs List to only get objects whose name matches the
+// provided regular expression, ignore all others. The expression must have
+// the syntax accepted by the regexp package and it matches any part of the
+// name, unless you use ^ and $. It cannot be used together with WithNameIn.
+//
+// Each call to this function replaces values provided by any precedent call.
+//
+// Example:
+// 	sr.Namespace(core.Any).List(list.WithNameRegex("^payroll-v[0-9]+$"))
+func WithNameRegex(expr string) Option {
+	return func(lo *Options) error {
+		if lo == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		if expr == "" {
+			return srerr.InvalidNameRegexFilter
+		}
+
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("%w: %s", srerr.InvalidNameRegexFilter, err)
+		}
+
+		if lo.NameFilters == nil {
+			lo.NameFilters = &NameFilters{}
+		}
+
+		if len(lo.NameFilters.In) > 0 {
+			return srerr.IncompatibleNameFilters
+		}
+
+		lo.NameFilters.Regex = expr
+		lo.NameFilters.regex = regex
+		return nil
+	}
+}
+
+// WithNameNotIn instructs List to ignore the objects with the given names,
+// which cannot be empty. It cannot be used together with WithNameIn.
+//
+// Each call to this function appends its values to the ones provided by any
+// precedent call.
+//
+// Example:
+// 	sr.Namespace(core.Any).List(list.WithNameNotIn("test", "staging"))
+func WithNameNotIn(names ...string) Option {
+	return func(lo *Options) error {
+		if lo == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		if len(names) == 0 || nameInFilter("", names...) {
+			return srerr.EmptyNameNotInFilter
+		}
+
+		if lo.NameFilters == nil {
+			lo.NameFilters = &NameFilters{}
+		}
+
+		if len(lo.NameFilters.In) > 0 {
+			return srerr.IncompatibleNameFilters
+		}
+
+		lo.NameFilters.NotIn = append(lo.NameFilters.NotIn, names...)
+		return nil
+	}
+}
+
 // WithMetadataKeys instructs List to only retreive endpoints whose metadata
 // include the given keys - even if they have no values, ignore all others.
 // It cannot be used together with WithNoMetadata.
@@ -334,7 +575,9 @@ func WithKV(key, value string) Option {
 // WithMetadata instructs List to only retreive endpoints with the provided
 // metadata key-value pairs. Add a key with an empty value if you don't care
 // about its value. All other endpoints will be ignored.
-// It cannot be used together with WithNoMetadata.
+// It cannot be used together with WithNoMetadata, and values cannot be
+// different from the ones provided for the same key to WithMetadataValueIn
+// nor among the ones provided to WithMetadataValueNotIn.
 //
 // Each call to this function appends its values to the ones provided by any
 // precedent call. In case of duplicate pairs, only the last ones will be
@@ -367,7 +610,19 @@ func WithMetadata(kv map[string]string) Option {
 				return srerr.EmptyMetadataKey
 			}
 
-			// TODO: check for long values, as well.
+			if nameInFilter(k, lo.MetadataFilters.AbsentKeys...) {
+				return srerr.IncompatibleMetadataFilters
+			}
+
+			if values, exists := lo.MetadataFilters.ValuesIn[k]; exists &&
+				kv[k] != "" && !nameInFilter(kv[k], values...) {
+				return srerr.IncompatibleMetadataFilters
+			}
+
+			if metadataValuesExcluded(kv[k], lo.MetadataFilters.ValuesIn[k],
+				lo.MetadataFilters.ValuesNotIn[k]) {
+				return srerr.IncompatibleMetadataFilters
+			}
 		}
 
 		// Then push the kvs
@@ -398,7 +653,10 @@ func WithNoMetadata() Option {
 			}
 		}
 
-		if len(lo.MetadataFilters.Metadata) > 0 {
+		if len(lo.MetadataFilters.Metadata) > 0 ||
+			len(lo.MetadataFilters.AbsentKeys) > 0 ||
+			len(lo.MetadataFilters.ValuesIn) > 0 ||
+			len(lo.MetadataFilters.ValuesNotIn) > 0 {
 			return srerr.IncompatibleMetadataFilters
 		}
 
@@ -407,6 +665,165 @@ func WithNoMetadata() Option {
 	}
 }
 
+// WithMetadataKeysAbsent instructs List to only retrieve objects whose
+// metadata don't include any of the given keys, ignore all others.
+// It cannot be used together with WithNoMetadata, and keys cannot be
+// required by other metadata options, e.g. WithMetadataKeys.
+//
+// Each call to this function appends its values to the ones provided by any
+// precedent call.
+//
+// Example:
+// 	sr.Namespace(core.Any).List(list.WithMetadataKeysAbsent("deprecated"))
+func WithMetadataKeysAbsent(keys ...string) Option {
+	return func(lo *Options) error {
+		if lo == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		if len(keys) == 0 {
+			return srerr.EmptyMetadataKeysFilter
+		}
+
+		if lo.MetadataFilters == nil {
+			lo.MetadataFilters = &MetadataFilters{
+				Metadata: map[string]string{},
+			}
+		}
+
+		if lo.MetadataFilters.NoMetadata {
+			return srerr.IncompatibleMetadataFilters
+		}
+
+		for _, key := range keys {
+			if key == "" {
+				return srerr.EmptyMetadataKey
+			}
+
+			if _, exists := lo.MetadataFilters.Metadata[key]; exists {
+				return srerr.IncompatibleMetadataFilters
+			}
+
+			if _, exists := lo.MetadataFilters.ValuesIn[key]; exists {
+				return srerr.IncompatibleMetadataFilters
+			}
+		}
+
+		lo.MetadataFilters.AbsentKeys = append(lo.MetadataFilters.AbsentKeys, keys...)
+		return nil
+	}
+}
+
+// WithMetadataValueIn instructs List to only retrieve objects whose metadata
+// include the given key with any of the given values, ignore all others.
+// It cannot be used together with WithNoMetadata nor with
+// WithMetadataKeysAbsent for the same key, and if the key must have a value
+// because of WithMetadataKeyValue, it must be among the given ones. Values
+// cannot be all excluded by WithMetadataValueNotIn either.
+//
+// Each call to this function appends its values to the ones provided by any
+// precedent call for the same key.
+//
+// Example:
+// 	sr.Namespace(core.Any).List(list.WithMetadataValueIn("env", "prod", "staging"))
+func WithMetadataValueIn(key string, values ...string) Option {
+	return func(lo *Options) error {
+		if lo == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		if key == "" {
+			return srerr.EmptyMetadataKey
+		}
+
+		if len(values) == 0 {
+			return srerr.EmptyMetadataValuesFilter
+		}
+
+		if lo.MetadataFilters == nil {
+			lo.MetadataFilters = &MetadataFilters{
+				Metadata: map[string]string{},
+			}
+		}
+
+		if lo.MetadataFilters.NoMetadata ||
+			nameInFilter(key, lo.MetadataFilters.AbsentKeys...) {
+			return srerr.IncompatibleMetadataFilters
+		}
+
+		if value := lo.MetadataFilters.Metadata[key]; value != "" &&
+			!nameInFilter(value, values...) &&
+			!nameInFilter(value, lo.MetadataFilters.ValuesIn[key]...) {
+			return srerr.IncompatibleMetadataFilters
+		}
+
+		valuesIn := append(append([]string{}, lo.MetadataFilters.ValuesIn[key]...), values...)
+		if metadataValuesExcluded(lo.MetadataFilters.Metadata[key], valuesIn,
+			lo.MetadataFilters.ValuesNotIn[key]) {
+			return srerr.IncompatibleMetadataFilters
+		}
+
+		if lo.MetadataFilters.ValuesIn == nil {
+			lo.MetadataFilters.ValuesIn = map[string][]string{}
+		}
+
+		lo.MetadataFilters.ValuesIn[key] = append(lo.MetadataFilters.ValuesIn[key], values...)
+		return nil
+	}
+}
+
+// WithMetadataValueNotIn instructs List to ignore objects whose metadata
+// include the given key with any of the given values. Objects that don't have
+// the key are not ignored: use WithMetadataKeys as well if you want them to
+// be.
+// It cannot be used together with WithNoMetadata, nor can it exclude all
+// the values that the key is required to have by WithMetadataKeyValue or
+// WithMetadataValueIn.
+//
+// Each call to this function appends its values to the ones provided by any
+// precedent call for the same key.
+//
+// Example:
+// 	sr.Namespace(core.Any).List(list.WithMetadataValueNotIn("env", "test", "dev"))
+func WithMetadataValueNotIn(key string, values ...string) Option {
+	return func(lo *Options) error {
+		if lo == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		if key == "" {
+			return srerr.EmptyMetadataKey
+		}
+
+		if len(values) == 0 {
+			return srerr.EmptyMetadataValuesFilter
+		}
+
+		if lo.MetadataFilters == nil {
+			lo.MetadataFilters = &MetadataFilters{
+				Metadata: map[string]string{},
+			}
+		}
+
+		if lo.MetadataFilters.NoMetadata {
+			return srerr.IncompatibleMetadataFilters
+		}
+
+		valuesNotIn := append(append([]string{}, lo.MetadataFilters.ValuesNotIn[key]...), values...)
+		if metadataValuesExcluded(lo.MetadataFilters.Metadata[key],
+			lo.MetadataFilters.ValuesIn[key], valuesNotIn) {
+			return srerr.IncompatibleMetadataFilters
+		}
+
+		if lo.MetadataFilters.ValuesNotIn == nil {
+			lo.MetadataFilters.ValuesNotIn = map[string][]string{}
+		}
+
+		lo.MetadataFilters.ValuesNotIn[key] = append(lo.MetadataFilters.ValuesNotIn[key], values...)
+		return nil
+	}
+}
+
 // WithResultsNumber provides a custom value for the number of objects to get
 // per page.
 //
@@ -525,6 +942,42 @@ func WithIPv6Only() Option {
 	}
 }
 
+// WithCIDRExcluded instructs List to ignore endpoints with an address inside
+// any of the given networks.
+//
+// Each call to this function appends its values to the ones provided
+// previously.
+//
+// This option is ignored if used on namespaces or services.
+//
+// Example:
+// 	sd.Namespace("hr").Service("payroll").Endpoints(core.Any).
+// 		List(list.WithCIDRExcluded("10.10.10.0/24", "10.10.20.0/24"))
+func WithCIDRExcluded(cidrs ...string) Option {
+	return func(lo *Options) error {
+		if lo == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		if len(cidrs) == 0 {
+			return srerr.NoCIDRProvided
+		}
+
+		for _, cidr := range cidrs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return srerr.InvalidCIDRProvided
+			}
+		}
+
+		if lo.AddressFilters == nil {
+			lo.AddressFilters = &AddressFilters{}
+		}
+
+		lo.AddressFilters.ExcludedCIDRs = append(lo.AddressFilters.ExcludedCIDRs, cidrs...)
+		return nil
+	}
+}
+
 // WithPortIn instructs List to only get endpoints with a port included in the
 // provided list, ignoring all other endpoints.
 //
@@ -561,6 +1014,11 @@ func WithPortIn(ports ...int32) Option {
 				return fmt.Errorf(`invalid port (%d) provided: %w`, ins, srerr.InvalidPort)
 			}
 
+			if portIsIn(ins, lo.PortFilters.NotIn...) ||
+				portIsInRange(ins, lo.PortFilters.ExcludedRanges) {
+				return fmt.Errorf("port %d is also excluded: %w", ins, srerr.IncompatiblePortFilters)
+			}
+
 			found := false
 			for _, p := range lo.PortFilters.In {
 				if p == ins {
@@ -579,7 +1037,9 @@ func WithPortIn(ports ...int32) Option {
 }
 
 // WithPortRange instructs List to only get endpoints within a certain range,
-// ignoring all other endpoints. The range delimiters are *included*.
+// ignoring all other endpoints. The range delimiters are *included*. The
+// range cannot be entirely excluded with WithPortNotIn and
+// WithPortRangeExcluded.
 //
 // Each call to this function appends its values to the ones provided
 // previously.
@@ -613,6 +1073,10 @@ func WithPortRange(start, end int32) Option {
 			}
 		}
 
+		if portRangeIsExcluded([2]int32{start, end}, lo.PortFilters.NotIn, lo.PortFilters.ExcludedRanges) {
+			return fmt.Errorf("ports %d-%d are all excluded: %w", start, end, srerr.IncompatiblePortFilters)
+		}
+
 		// We just add the range without checking if this is overlapping, nor do
 		// we check if ports in the `In` filter already included in one of these
 		// ranges. We do this for simplicity.
@@ -621,3 +1085,107 @@ func WithPortRange(start, end int32) Option {
 		return nil
 	}
 }
+
+// WithPortNotIn instructs List to ignore endpoints with a port included in the
+// provided list. Ports cannot be included in WithPortIn as well, and cannot
+// exclude all ports of a range provided to WithPortRange.
+//
+// Each call to this function appends its values to the ones provided
+// previously.
+//
+// This option is ignored if used on namespaces or services.
+//
+// Example:
+// 	sd.Namespace("hr").Service("payroll").Endpoints(core.Any).
+// 		List(list.WithPortNotIn(22, 23))
+func WithPortNotIn(ports ...int32) Option {
+	return func(lo *Options) error {
+		if lo == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		if len(ports) == 0 {
+			return srerr.NoPortsProvided
+		}
+
+		if lo.PortFilters == nil {
+			lo.PortFilters = &PortFilters{}
+		}
+
+		toAdd := []int32{}
+		for _, port := range ports {
+			if port < 0 || port > math.MaxUint16 {
+				return fmt.Errorf(`invalid port (%d) provided: %w`, port, srerr.InvalidPort)
+			}
+
+			if portIsIn(port, lo.PortFilters.In...) {
+				return fmt.Errorf("port %d is also included: %w", port, srerr.IncompatiblePortFilters)
+			}
+
+			if !portIsIn(port, lo.PortFilters.NotIn...) && !portIsIn(port, toAdd...) {
+				toAdd = append(toAdd, port)
+			}
+		}
+
+		notIn := append(append([]int32{}, lo.PortFilters.NotIn...), toAdd...)
+		for _, r := range lo.PortFilters.Range {
+			if portRangeIsExcluded(r, notIn, lo.PortFilters.ExcludedRanges) {
+				return fmt.Errorf("ports %d-%d are all excluded: %w", r[0], r[1], srerr.IncompatiblePortFilters)
+			}
+		}
+
+		lo.PortFilters.NotIn = notIn
+		return nil
+	}
+}
+
+// WithPortRangeExcluded instructs List to ignore endpoints with a port
+// within a certain range. The range delimiters are *excluded* as well. The
+// range cannot include ports provided to WithPortIn, and cannot exclude all
+// ports of a range provided to WithPortRange.
+//
+// Each call to this function appends its values to the ones provided
+// previously.
+//
+// This option is ignored if used on namespaces or services.
+//
+// Example:
+// 	sd.Namespace("hr").Service("payroll").Endpoints(core.Any).
+// 		List(list.WithPortRangeExcluded(0, 1023))
+func WithPortRangeExcluded(start, end int32) Option {
+	return func(lo *Options) error {
+		if lo == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		if start > end || start < 0 || end > math.MaxUint16 {
+			return fmt.Errorf("invalid range (%d-%d) provided: %w", start, end, srerr.InvalidPortRange)
+		}
+
+		if lo.PortFilters == nil {
+			lo.PortFilters = &PortFilters{}
+		}
+
+		for _, r := range lo.PortFilters.ExcludedRanges {
+			if r[0] == start && r[1] == end {
+				return nil
+			}
+		}
+
+		excluded := append(append([][2]int32{}, lo.PortFilters.ExcludedRanges...), [2]int32{start, end})
+		for _, port := range lo.PortFilters.In {
+			if port >= start && port <= end {
+				return fmt.Errorf("port %d is also included: %w", port, srerr.IncompatiblePortFilters)
+			}
+		}
+
+		for _, r := range lo.PortFilters.Range {
+			if portRangeIsExcluded(r, lo.PortFilters.NotIn, excluded) {
+				return fmt.Errorf("ports %d-%d are all excluded: %w", r[0], r[1], srerr.IncompatiblePortFilters)
+			}
+		}
+
+		lo.PortFilters.ExcludedRanges = excluded
+		return nil
+	}
+}
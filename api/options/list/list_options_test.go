@@ -18,11 +18,13 @@
 package list_test
 
 import (
+	"fmt"
 	"math"
 	"syscall"
 
 	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/pagetoken"
 	"github.com/CloudNativeSDWAN/serego/api/options/list"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -86,6 +88,58 @@ var _ = Describe("List Options", func() {
 		})
 	})
 
+	Context("negative and pattern name filters", func() {
+		It("applies name suffix, regex and not in filters", func() {
+			err := list.WithNameSuffix("-prod")(nil)
+			Expect(err).To(Equal(srerr.NoOptionsProvided))
+
+			err = list.WithNameSuffix("")(opts)
+			Expect(err).To(Equal(srerr.InvalidNameSuffixFilter))
+
+			err = list.WithNameRegex("")(opts)
+			Expect(err).To(Equal(srerr.InvalidNameRegexFilter))
+
+			err = list.WithNameRegex("payroll-(")(opts)
+			Expect(err).To(MatchError(srerr.InvalidNameRegexFilter))
+
+			err = list.WithNameNotIn()(opts)
+			Expect(err).To(Equal(srerr.EmptyNameNotInFilter))
+
+			err = list.WithNameNotIn("payroll-test", "")(opts)
+			Expect(err).To(Equal(srerr.EmptyNameNotInFilter))
+
+			Expect(list.WithNameSuffix("-prod")(opts)).To(Succeed())
+			Expect(list.WithNameRegex("^payroll")(opts)).To(Succeed())
+			Expect(list.WithNameNotIn("payroll-test")(opts)).To(Succeed())
+			Expect(list.WithNameNotIn("payroll-dev")(opts)).To(Succeed())
+			expOpts := &list.Options{
+				NameFilters: &list.NameFilters{
+					Suffix: "-prod",
+					NotIn:  []string{"payroll-test", "payroll-dev"},
+				},
+			}
+			// The expression is compiled only once, by the option.
+			Expect(list.WithNameRegex("^payroll")(expOpts)).To(Succeed())
+			Expect(expOpts.NameFilters.Regex).To(Equal("^payroll"))
+			Expect(opts).To(Equal(expOpts))
+
+			By("checking incompatible filters")
+			Expect(list.WithNameIn("payroll")(opts)).To(Equal(srerr.IncompatibleNameFilters))
+			for _, opt := range []list.Option{
+				list.WithNameSuffix("-prod"),
+				list.WithNameRegex("^payroll"),
+				list.WithNameNotIn("payroll-test"),
+			} {
+				err = opt(&list.Options{
+					NameFilters: &list.NameFilters{
+						In: []string{"payroll"},
+					},
+				})
+				Expect(err).To(Equal(srerr.IncompatibleNameFilters))
+			}
+		})
+	})
+
 	Context("metadata filters", func() {
 		It("applies metadata filters", func() {
 			By("using keys", func() {
@@ -155,6 +209,209 @@ var _ = Describe("List Options", func() {
 			})
 		})
 	})
+	Context("negative and set-based metadata filters", func() {
+		It("applies them", func() {
+			err := list.WithMetadataKeysAbsent("key")(nil)
+			Expect(err).To(Equal(srerr.NoOptionsProvided))
+
+			err = list.WithMetadataKeysAbsent()(opts)
+			Expect(err).To(Equal(srerr.EmptyMetadataKeysFilter))
+
+			err = list.WithMetadataKeysAbsent("")(opts)
+			Expect(err).To(Equal(srerr.EmptyMetadataKey))
+
+			for _, opt := range []list.Option{
+				list.WithMetadataValueIn("", "val"),
+				list.WithMetadataValueNotIn("", "val"),
+			} {
+				Expect(opt(opts)).To(Equal(srerr.EmptyMetadataKey))
+			}
+
+			for _, opt := range []list.Option{
+				list.WithMetadataValueIn("key"),
+				list.WithMetadataValueNotIn("key"),
+			} {
+				Expect(opt(opts)).To(Equal(srerr.EmptyMetadataValuesFilter))
+			}
+
+			opts = &list.Options{}
+			Expect(list.WithMetadataKeysAbsent("deprecated")(opts)).To(Succeed())
+			Expect(list.WithMetadataValueIn("env", "prod")(opts)).To(Succeed())
+			Expect(list.WithMetadataValueIn("env", "staging")(opts)).To(Succeed())
+			Expect(list.WithMetadataValueNotIn("zone", "eu")(opts)).To(Succeed())
+			Expect(opts).To(Equal(&list.Options{
+				MetadataFilters: &list.MetadataFilters{
+					Metadata:    map[string]string{},
+					AbsentKeys:  []string{"deprecated"},
+					ValuesIn:    map[string][]string{"env": {"prod", "staging"}},
+					ValuesNotIn: map[string][]string{"zone": {"eu"}},
+				},
+			}))
+
+			By("checking incompatible filters")
+			Expect(list.WithNoMetadata()(opts)).To(Equal(srerr.IncompatibleMetadataFilters))
+			Expect(list.WithMetadataKeys("deprecated")(opts)).To(Equal(srerr.IncompatibleMetadataFilters))
+			Expect(list.WithMetadataKeysAbsent("env")(opts)).To(Equal(srerr.IncompatibleMetadataFilters))
+			Expect(list.WithMetadataValueIn("deprecated", "yes")(opts)).To(Equal(srerr.IncompatibleMetadataFilters))
+
+			pinned := &list.Options{}
+			Expect(list.WithMetadataKeyValue("env", "prod")(pinned)).To(Succeed())
+			Expect(list.WithMetadataValueIn("env", "staging", "dev")(pinned)).To(Equal(srerr.IncompatibleMetadataFilters))
+			Expect(list.WithMetadataValueIn("env", "staging", "prod")(pinned)).To(Succeed())
+			Expect(list.WithMetadataValueIn("env", "dev")(pinned)).To(Succeed())
+			Expect(list.WithMetadataKeyValue("env", "test")(pinned)).To(Equal(srerr.IncompatibleMetadataFilters))
+			Expect(list.WithMetadataKeyValue("env", "dev")(pinned)).To(Succeed())
+			Expect(pinned.MetadataFilters.Metadata).To(Equal(map[string]string{"env": "dev"}))
+			Expect(list.WithMetadataValueNotIn("env", "dev")(pinned)).To(Equal(srerr.IncompatibleMetadataFilters))
+			Expect(list.WithMetadataValueNotIn("env", "prod")(pinned)).To(Succeed())
+			Expect(list.WithMetadataKeyValue("env", "prod")(pinned)).To(Equal(srerr.IncompatibleMetadataFilters))
+
+			excluded := &list.Options{}
+			Expect(list.WithMetadataValueIn("env", "prod", "staging")(excluded)).To(Succeed())
+			Expect(list.WithMetadataValueNotIn("env", "prod")(excluded)).To(Succeed())
+			Expect(list.WithMetadataValueNotIn("env", "staging")(excluded)).To(Equal(srerr.IncompatibleMetadataFilters))
+			Expect(list.WithMetadataValueIn("zone", "eu")(excluded)).To(Succeed())
+			Expect(list.WithMetadataValueNotIn("zone", "eu", "us")(excluded)).To(Equal(srerr.IncompatibleMetadataFilters))
+
+			notIn := &list.Options{}
+			Expect(list.WithMetadataValueNotIn("env", "prod")(notIn)).To(Succeed())
+			Expect(list.WithMetadataValueIn("env", "prod")(notIn)).To(Equal(srerr.IncompatibleMetadataFilters))
+			Expect(list.WithMetadataKeyValue("env", "prod")(notIn)).To(Equal(srerr.IncompatibleMetadataFilters))
+			Expect(list.WithMetadataValueIn("env", "prod", "dev")(notIn)).To(Succeed())
+
+			noMetadata := &list.Options{}
+			Expect(list.WithNoMetadata()(noMetadata)).To(Succeed())
+			for _, opt := range []list.Option{
+				list.WithMetadataKeysAbsent("deprecated"),
+				list.WithMetadataValueIn("env", "prod"),
+				list.WithMetadataValueNotIn("env", "test"),
+			} {
+				Expect(opt(noMetadata)).To(Equal(srerr.IncompatibleMetadataFilters))
+			}
+		})
+	})
+
+	Context("selector", func() {
+		It("translates it to metadata filters", func() {
+			err := list.WithSelector("env=prod")(nil)
+			Expect(err).To(Equal(srerr.NoOptionsProvided))
+
+			err = list.WithSelector("")(opts)
+			Expect(err).To(Equal(srerr.EmptySelector))
+
+			err = list.WithSelector("env in (prod")(opts)
+			Expect(err).To(MatchError(srerr.InvalidSelector))
+
+			err = list.WithSelector("replicas>3")(opts)
+			Expect(err).To(MatchError(srerr.UnsupportedSelectorOperator))
+
+			opts = &list.Options{}
+			err = list.WithSelector("env in (prod,staging),tier!=frontend,zone notin (eu,us),team=sales,owner==,contact,!deprecated")(opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opts).To(Equal(&list.Options{
+				MetadataFilters: &list.MetadataFilters{
+					Metadata: map[string]string{
+						"team":    "sales",
+						"contact": "",
+					},
+					AbsentKeys: []string{"deprecated"},
+					ValuesIn: map[string][]string{
+						"env":   {"prod", "staging"},
+						"owner": {""},
+					},
+					ValuesNotIn: map[string][]string{
+						"tier": {"frontend"},
+						"zone": {"eu", "us"},
+					},
+				},
+			}))
+
+			By("checking incompatible filters")
+			Expect(list.WithSelector("deprecated")(opts)).To(Equal(srerr.IncompatibleMetadataFilters))
+			Expect(list.WithSelector("!env")(opts)).To(Equal(srerr.IncompatibleMetadataFilters))
+			Expect(list.WithSelector("team!=sales")(opts)).To(Equal(srerr.IncompatibleMetadataFilters))
+			Expect(list.WithSelector("env notin (prod,staging)")(opts)).To(Equal(srerr.IncompatibleMetadataFilters))
+			Expect(list.WithSelector("env=prod,env!=prod")(&list.Options{})).To(Equal(srerr.IncompatibleMetadataFilters))
+
+			noMetadata := &list.Options{}
+			Expect(list.WithNoMetadata()(noMetadata)).To(Succeed())
+			Expect(list.WithSelector("env=prod")(noMetadata)).To(Equal(srerr.IncompatibleMetadataFilters))
+		})
+	})
+
+	It("applies excluded CIDRs", func() {
+		err := list.WithCIDRExcluded("10.10.10.0/24")(nil)
+		Expect(err).To(Equal(srerr.NoOptionsProvided))
+
+		err = list.WithCIDRExcluded()(opts)
+		Expect(err).To(Equal(srerr.NoCIDRProvided))
+
+		err = list.WithCIDRExcluded("10.10.10.0/24", "10.10.10.10")(opts)
+		Expect(err).To(Equal(srerr.InvalidCIDRProvided))
+
+		Expect(list.WithCIDR("10.10.0.0/16")(opts)).To(Succeed())
+		Expect(list.WithCIDRExcluded("10.10.10.0/24")(opts)).To(Succeed())
+		Expect(list.WithCIDRExcluded("10.10.20.0/24")(opts)).To(Succeed())
+		Expect(opts).To(Equal(&list.Options{
+			AddressFilters: &list.AddressFilters{
+				CIDR:          "10.10.0.0/16",
+				ExcludedCIDRs: []string{"10.10.10.0/24", "10.10.20.0/24"},
+			},
+		}))
+	})
+
+	It("applies port exclusions", func() {
+		err := list.WithPortNotIn(22)(nil)
+		Expect(err).To(Equal(srerr.NoOptionsProvided))
+
+		err = list.WithPortNotIn()(opts)
+		Expect(err).To(Equal(srerr.NoPortsProvided))
+
+		err = list.WithPortNotIn(-1)(opts)
+		Expect(err).To(MatchError(srerr.InvalidPort))
+
+		err = list.WithPortRangeExcluded(1023, 0)(opts)
+		Expect(err).To(MatchError(srerr.InvalidPortRange))
+
+		Expect(list.WithPortNotIn(22, 23, 22)(opts)).To(Succeed())
+		Expect(list.WithPortNotIn(23, 25)(opts)).To(Succeed())
+		Expect(list.WithPortRangeExcluded(0, 1023)(opts)).To(Succeed())
+		Expect(list.WithPortRangeExcluded(0, 1023)(opts)).To(Succeed())
+		Expect(opts).To(Equal(&list.Options{
+			PortFilters: &list.PortFilters{
+				NotIn:          []int32{22, 23, 25},
+				ExcludedRanges: [][2]int32{{0, 1023}},
+			},
+		}))
+
+		By("checking incompatible filters")
+		Expect(list.WithPortIn(22)(opts)).To(MatchError(srerr.IncompatiblePortFilters))
+		Expect(list.WithPortIn(8080)(opts)).To(Succeed())
+		Expect(list.WithPortNotIn(8080)(opts)).To(MatchError(srerr.IncompatiblePortFilters))
+	})
+
+	It("rejects exclusions of all included ports", func() {
+		included := &list.Options{}
+		Expect(list.WithPortIn(80, 8080)(included)).To(Succeed())
+		Expect(list.WithPortRangeExcluded(0, 1023)(included)).To(MatchError(srerr.IncompatiblePortFilters))
+		Expect(list.WithPortRangeExcluded(1024, 8079)(included)).To(Succeed())
+		Expect(list.WithPortIn(1024)(included)).To(MatchError(srerr.IncompatiblePortFilters))
+
+		inRange := &list.Options{}
+		Expect(list.WithPortRange(8000, 8002)(inRange)).To(Succeed())
+		Expect(list.WithPortNotIn(8000)(inRange)).To(Succeed())
+		Expect(list.WithPortRangeExcluded(8001, 8001)(inRange)).To(Succeed())
+		Expect(list.WithPortNotIn(8002)(inRange)).To(MatchError(srerr.IncompatiblePortFilters))
+		Expect(list.WithPortRangeExcluded(8002, 9000)(inRange)).To(MatchError(srerr.IncompatiblePortFilters))
+		Expect(list.WithPortRange(8000, 8001)(inRange)).To(MatchError(srerr.IncompatiblePortFilters))
+		Expect(list.WithPortRange(8000, 8003)(inRange)).To(Succeed())
+		Expect(inRange.PortFilters).To(Equal(&list.PortFilters{
+			Range:          [][2]int32{{8000, 8002}, {8000, 8003}},
+			NotIn:          []int32{8000},
+			ExcludedRanges: [][2]int32{{8001, 8001}},
+		}))
+	})
+
 	It("applies the correct results number", func() {
 		var results int32 = 22
 		err := list.WithResultsNumber(-1)(nil)
@@ -169,6 +426,47 @@ var _ = Describe("List Options", func() {
 			Results: results,
 		}))
 	})
+	It("applies the page token", func() {
+		err := list.WithPageToken("")(nil)
+		Expect(err).To(Equal(srerr.NoOptionsProvided))
+
+		err = list.WithPageToken("not-valid")(opts)
+		Expect(err).To(Equal(srerr.InvalidPageToken))
+
+		token := pagetoken.Encode(pagetoken.Token{Page: "page", Skip: 2})
+		err = list.WithPageToken(token)(opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opts).To(Equal(&list.Options{
+			PageToken: token,
+		}))
+	})
+
+	It("applies order by", func() {
+		err := list.WithOrderBy(list.OrderByName, list.Ascending)(nil)
+		Expect(err).To(Equal(srerr.NoOptionsProvided))
+
+		err = list.WithOrderBy("whatever", list.Ascending)(opts)
+		Expect(err).To(Equal(srerr.InvalidOrderBy))
+
+		err = list.WithOrderBy(list.OrderByMetadataKey(""), list.Ascending)(opts)
+		Expect(err).To(Equal(srerr.InvalidOrderBy))
+
+		err = list.WithOrderBy(list.OrderByPort, list.Direction(5))(opts)
+		Expect(err).To(Equal(srerr.InvalidOrderBy))
+
+		err = list.WithOrderBy(list.OrderByPort, list.Ascending)(opts)
+		Expect(err).NotTo(HaveOccurred())
+		err = list.WithOrderBy(list.OrderByMetadataKey("zone"), list.Descending)(opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opts).To(Equal(&list.Options{
+			OrderBy: []list.OrderBy{
+				{Field: list.OrderByPort, Direction: list.Ascending},
+				{Field: list.OrderByMetadataKey("zone"), Direction: list.Descending},
+			},
+		}))
+		Expect(opts.OrderBy[1].Field.MetadataKey()).To(Equal("zone"))
+	})
+
 	Context("address filters", func() {
 		It("applies the correct CIDR", func() {
 			err := list.WithCIDR("")(nil)
@@ -367,6 +665,124 @@ var _ = Describe("Filter", func() {
 		})
 	})
 
+	Describe("negative and pattern name filters", func() {
+		It("filters resource correctly", func() {
+			opts = &list.Options{
+				NameFilters: &list.NameFilters{
+					Suffix: "-prod",
+					Regex:  "^payroll-v[0-9]+",
+					NotIn:  []string{"payroll-v1-prod"},
+				},
+			}
+
+			for name, expPassed := range map[string]bool{
+				"payroll-v2-prod":    true,
+				"payroll-v1-prod":    false,
+				"payroll-v2-staging": false,
+				"hr-v2-prod":         false,
+			} {
+				passed, err := opts.Filter(&coretypes.Service{Name: name})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(passed).To(Equal(expPassed), name)
+			}
+
+			By("checking invalid expressions")
+			opts.NameFilters.Regex = "payroll-("
+			passed, err := opts.Filter(&coretypes.Service{Name: "payroll-v2-prod"})
+			Expect(passed).To(BeFalse())
+			Expect(err).To(Equal(srerr.InvalidNameRegexFilter))
+		})
+	})
+
+	Describe("negative and set-based metadata filters", func() {
+		It("filters resource correctly", func() {
+			opts = &list.Options{
+				MetadataFilters: &list.MetadataFilters{
+					AbsentKeys:  []string{"deprecated"},
+					ValuesIn:    map[string][]string{"env": {"prod", "staging"}},
+					ValuesNotIn: map[string][]string{"zone": {"eu"}},
+				},
+			}
+
+			for i, exp := range []struct {
+				metadata map[string]string
+				passed   bool
+			}{
+				{map[string]string{"env": "prod"}, true},
+				{map[string]string{"env": "staging", "zone": "us"}, true},
+				{map[string]string{"env": "prod", "zone": "eu"}, false},
+				{map[string]string{"env": "prod", "deprecated": ""}, false},
+				{map[string]string{"env": "test"}, false},
+				{map[string]string{"zone": "us"}, false},
+			} {
+				passed, err := opts.Filter(&coretypes.Namespace{Metadata: exp.metadata})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(passed).To(Equal(exp.passed), fmt.Sprintf("case %d", i))
+			}
+		})
+	})
+
+	Describe("selector", func() {
+		It("filters resource correctly", func() {
+			opts = &list.Options{}
+			Expect(list.WithSelector("env in (prod,staging),zone!=eu,owner=,!deprecated")(opts)).To(Succeed())
+
+			for i, exp := range []struct {
+				metadata map[string]string
+				passed   bool
+			}{
+				{map[string]string{"env": "prod", "owner": ""}, true},
+				{map[string]string{"env": "staging", "zone": "us", "owner": ""}, true},
+				{map[string]string{"env": "prod"}, false},
+				{map[string]string{"env": "prod", "owner": "alice"}, false},
+				{map[string]string{"env": "prod", "zone": "eu", "owner": ""}, false},
+				{map[string]string{"env": "prod", "deprecated": "", "owner": ""}, false},
+				{map[string]string{"env": "test", "owner": ""}, false},
+			} {
+				passed, err := opts.Filter(&coretypes.Namespace{Metadata: exp.metadata})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(passed).To(Equal(exp.passed), fmt.Sprintf("case %d", i))
+			}
+		})
+	})
+
+	Describe("address and port exclusions", func() {
+		It("filters resource correctly", func() {
+			opts = &list.Options{
+				AddressFilters: &list.AddressFilters{
+					ExcludedCIDRs: []string{"10.10.10.0/24", "2001:db8::/32"},
+				},
+				PortFilters: &list.PortFilters{
+					NotIn:          []int32{8443},
+					ExcludedRanges: [][2]int32{{0, 1023}},
+				},
+			}
+
+			for i, exp := range []struct {
+				address string
+				port    int32
+				passed  bool
+			}{
+				{"10.10.20.10", 8080, true},
+				{"10.10.10.10", 8080, false},
+				{"2001:db8::1", 8080, false},
+				{"2001:db9::1", 8080, true},
+				{"10.10.20.10", 8443, false},
+				{"10.10.20.10", 1023, false},
+				{"10.10.20.10", 1024, true},
+			} {
+				passed, err := opts.Filter(&coretypes.Endpoint{Address: exp.address, Port: exp.port})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(passed).To(Equal(exp.passed), fmt.Sprintf("case %d", i))
+			}
+
+			By("ignoring them on other objects")
+			passed, err := opts.Filter(&coretypes.Service{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(passed).To(BeTrue())
+		})
+	})
+
 	Describe("Testing NoMetadata filter", func() {
 		Context("with metadata", func() {
 			It("should return false", func() {
@@ -642,3 +1058,64 @@ var _ = Describe("Filter", func() {
 		})
 	})
 })
+
+var _ = Describe("Less", func() {
+	endpoint := func(name, address string, port int32, zone string) *coretypes.Endpoint {
+		return &coretypes.Endpoint{
+			Name:     name,
+			Address:  address,
+			Port:     port,
+			Metadata: map[string]string{"zone": zone},
+		}
+	}
+
+	Context("with no order by", func() {
+		It("sorts by name", func() {
+			opts := &list.Options{}
+			Expect(opts.Less(&coretypes.Namespace{Name: "a"}, &coretypes.Namespace{Name: "b"})).To(BeTrue())
+			Expect(opts.Less(&coretypes.Namespace{Name: "b"}, &coretypes.Namespace{Name: "a"})).To(BeFalse())
+		})
+	})
+
+	Context("with multiple fields", func() {
+		It("sorts by the next field when values are the same", func() {
+			opts := &list.Options{
+				OrderBy: []list.OrderBy{
+					{Field: list.OrderByPort, Direction: list.Ascending},
+					{Field: list.OrderByMetadataKey("zone"), Direction: list.Descending},
+				},
+			}
+
+			Expect(opts.Less(endpoint("b", "", 80, ""), endpoint("a", "", 8080, ""))).To(BeTrue())
+			Expect(opts.Less(endpoint("a", "", 80, "eu"), endpoint("b", "", 80, "us"))).To(BeFalse())
+			Expect(opts.Less(endpoint("b", "", 80, "us"), endpoint("a", "", 80, "eu"))).To(BeTrue())
+			Expect(opts.Less(endpoint("a", "", 80, "us"), endpoint("b", "", 80, "us"))).To(BeTrue())
+		})
+	})
+
+	Context("with addresses", func() {
+		It("compares their bytes", func() {
+			opts := &list.Options{
+				OrderBy: []list.OrderBy{
+					{Field: list.OrderByAddress, Direction: list.Ascending},
+				},
+			}
+
+			Expect(opts.Less(endpoint("a", "10.0.0.9", 80, ""), endpoint("b", "10.0.0.10", 80, ""))).To(BeTrue())
+			Expect(opts.Less(endpoint("a", "2001:db8::1", 80, ""), endpoint("b", "10.0.0.10", 80, ""))).To(BeFalse())
+			Expect(opts.Less(endpoint("a", "not-valid", 80, ""), endpoint("b", "2001:db8::1", 80, ""))).To(BeFalse())
+		})
+	})
+
+	Context("with endpoint fields on services", func() {
+		It("sorts by name", func() {
+			opts := &list.Options{
+				OrderBy: []list.OrderBy{
+					{Field: list.OrderByPort, Direction: list.Descending},
+				},
+			}
+
+			Expect(opts.Less(&coretypes.Service{Name: "a"}, &coretypes.Service{Name: "b"})).To(BeTrue())
+		})
+	})
+})
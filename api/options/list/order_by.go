@@ -0,0 +1,201 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package list
+
+import (
+	"bytes"
+	"net"
+	"strings"
+
+	coretypes "github.com/CloudNativeSDWAN/serego/api/core/types"
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+)
+
+// OrderField is the field to sort objects by.
+type OrderField string
+
+const (
+	// OrderByName sorts objects by their name.
+	OrderByName OrderField = "name"
+	// OrderByAddress sorts endpoints by their address, with IPv4 addresses
+	// first. It has no effect on namespaces and services.
+	OrderByAddress OrderField = "address"
+	// OrderByPort sorts endpoints by their port. It has no effect on
+	// namespaces and services.
+	OrderByPort OrderField = "port"
+
+	metadataOrderPrefix string = "metadata."
+)
+
+// OrderByMetadataKey returns the field to sort objects by the value of the
+// provided metadata key. Objects that don't have the key are sorted as if
+// their value was empty.
+func OrderByMetadataKey(key string) OrderField {
+	return OrderField(metadataOrderPrefix + key)
+}
+
+// MetadataKey returns the metadata key of the field, if it is one created
+// with OrderByMetadataKey, or an empty string otherwise.
+func (f OrderField) MetadataKey() string {
+	if !strings.HasPrefix(string(f), metadataOrderPrefix) {
+		return ""
+	}
+
+	return strings.TrimPrefix(string(f), metadataOrderPrefix)
+}
+
+// Direction is the direction to sort objects in.
+type Direction int
+
+const (
+	// Ascending sorts objects from the lowest to the highest value.
+	Ascending Direction = iota
+	// Descending sorts objects from the highest to the lowest value.
+	Descending
+)
+
+// OrderBy is a field to sort objects by, along with its direction.
+type OrderBy struct {
+	Field     OrderField
+	Direction Direction
+}
+
+// WithOrderBy instructs List to return objects sorted by the provided field
+// and direction, rather than in the order the service registry returns them,
+// which differs among service registries.
+//
+// Each call to this function adds a field, which is used to sort objects
+// that have the same value on the fields provided by any precedent call.
+// Objects that have the same value on all fields are sorted by name.
+//
+// Objects are sorted by the service registry if it supports it, e.g. Service
+// Directory, and otherwise by the API, which retrieves all of them before
+// returning the first one.
+//
+// Example:
+// 	sr.Namespace("prod").Service("payroll").Endpoint(core.Any).List(
+// 		list.WithOrderBy(list.OrderByPort, list.Ascending),
+// 		list.WithOrderBy(list.OrderByMetadataKey("zone"), list.Descending))
+func WithOrderBy(field OrderField, direction Direction) Option {
+	return func(lo *Options) error {
+		if lo == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		switch field {
+		case OrderByName, OrderByAddress, OrderByPort:
+		default:
+			if field.MetadataKey() == "" {
+				return srerr.InvalidOrderBy
+			}
+		}
+
+		if direction != Ascending && direction != Descending {
+			return srerr.InvalidOrderBy
+		}
+
+		lo.OrderBy = append(lo.OrderBy, OrderBy{Field: field, Direction: direction})
+		return nil
+	}
+}
+
+// Less returns true if the first object must be returned before the second
+// one according to the OrderBy options. Both objects must be of the same
+// type, among those provided in the api/core/types package: Namespace,
+// Service or Endpoint.
+func (o *Options) Less(first, second interface{}) bool {
+	for _, orderBy := range o.OrderBy {
+		cmp := compareField(orderBy.Field, first, second)
+		if orderBy.Direction == Descending {
+			cmp = -cmp
+		}
+
+		if cmp != 0 {
+			return cmp < 0
+		}
+	}
+
+	return compareField(OrderByName, first, second) < 0
+}
+
+// compareField returns a negative number if the field of the first object
+// is lower than the second's, a positive one if it is higher and zero if
+// they are the same.
+func compareField(field OrderField, first, second interface{}) int {
+	firstName, firstMetadata, firstEndp := fieldsOf(first)
+	secondName, secondMetadata, secondEndp := fieldsOf(second)
+
+	switch field {
+	case OrderByName:
+		return strings.Compare(firstName, secondName)
+	case OrderByAddress:
+		if firstEndp == nil || secondEndp == nil {
+			return 0
+		}
+
+		return compareAddresses(firstEndp.Address, secondEndp.Address)
+	case OrderByPort:
+		if firstEndp == nil || secondEndp == nil {
+			return 0
+		}
+
+		return int(firstEndp.Port) - int(secondEndp.Port)
+	default:
+		key := field.MetadataKey()
+		return strings.Compare(firstMetadata[key], secondMetadata[key])
+	}
+}
+
+func fieldsOf(object interface{}) (string, map[string]string, *coretypes.Endpoint) {
+	switch obj := object.(type) {
+	case *coretypes.Namespace:
+		return obj.Name, obj.Metadata, nil
+	case *coretypes.Service:
+		return obj.Name, obj.Metadata, nil
+	case *coretypes.Endpoint:
+		return obj.Name, obj.Metadata, obj
+	default:
+		return "", nil, nil
+	}
+}
+
+// compareAddresses compares IP addresses by their bytes, so that e.g.
+// 10.0.0.9 comes before 10.0.0.10, with IPv4 addresses first. Addresses
+// that are not valid are compared as strings, after all valid ones.
+func compareAddresses(first, second string) int {
+	firstIP, secondIP := net.ParseIP(first), net.ParseIP(second)
+	switch {
+	case firstIP == nil && secondIP == nil:
+		return strings.Compare(first, second)
+	case firstIP == nil:
+		return 1
+	case secondIP == nil:
+		return -1
+	}
+
+	firstIPv4, secondIPv4 := firstIP.To4() != nil, secondIP.To4() != nil
+	if firstIPv4 != secondIPv4 {
+		if firstIPv4 {
+			return -1
+		}
+
+		return 1
+	}
+
+	return bytes.Compare(firstIP.To16(), secondIP.To16())
+}
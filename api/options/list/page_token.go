@@ -0,0 +1,52 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package list
+
+import (
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/internal/pagetoken"
+)
+
+// WithPageToken instructs List to resume listing objects from the position
+// of the provided token, as returned by the NextPageToken function of the
+// iterator of a previous List call. An empty token lists objects from the
+// first one.
+//
+// The token is opaque and safe to be used in URLs, but it is only valid for
+// the same service registry and the same List call that returned it, i.e.
+// with the same parent object and options, and it may stop being valid after
+// some time.
+//
+// Example:
+// 	it := sd.Namespace("hr").Service(core.Any).List(
+// 		list.WithResultsNumber(20),
+// 		list.WithPageToken(token))
+func WithPageToken(token string) Option {
+	return func(lo *Options) error {
+		if lo == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		if _, err := pagetoken.Decode(token); err != nil {
+			return err
+		}
+
+		lo.PageToken = token
+		return nil
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package list
+
+import (
+	"fmt"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// WithSelector instructs List to only get objects whose metadata match the
+// provided selector, written with the syntax of Kubernetes label selectors,
+// e.g. "env in (prod,staging),tier!=frontend,!deprecated".
+//
+// The selector is translated to the other metadata options, and so it cannot
+// be used together with WithNoMetadata nor with options that contradict it:
+// 	"env=prod"              -> WithMetadataKeyValue("env", "prod")
+// 	"env in (prod,staging)" -> WithMetadataValueIn("env", "prod", "staging")
+// 	"env!=prod"             -> WithMetadataValueNotIn("env", "prod")
+// 	"env notin (prod,dev)"  -> WithMetadataValueNotIn("env", "prod", "dev")
+// 	"env"                   -> WithMetadataKeys("env")
+// 	"!env"                  -> WithMetadataKeysAbsent("env")
+// Numeric comparisons, i.e. "gt" and "lt", are not supported.
+//
+// Filters are applied by the service registry where possible, e.g. equality
+// and set-based requirements on Service Directory, and by the API otherwise.
+//
+// Example:
+// 	sr.Namespace(core.Any).List(list.WithSelector("env in (prod,staging),!deprecated"))
+func WithSelector(selector string) Option {
+	return func(lo *Options) error {
+		if lo == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		if selector == "" {
+			return srerr.EmptySelector
+		}
+
+		requirements, err := labels.ParseToRequirements(selector)
+		if err != nil {
+			return fmt.Errorf("%w: %s", srerr.InvalidSelector, err)
+		}
+
+		for _, req := range requirements {
+			var (
+				key    = req.Key()
+				values = req.Values().List()
+				opt    Option
+			)
+
+			switch req.Operator() {
+			case selection.Equals, selection.DoubleEquals:
+				if values[0] == "" {
+					// An empty value in the Metadata filter means that any
+					// value is fine.
+					opt = WithMetadataValueIn(key, values[0])
+				} else {
+					opt = WithMetadataKeyValue(key, values[0])
+				}
+			case selection.In:
+				opt = WithMetadataValueIn(key, values...)
+			case selection.NotEquals, selection.NotIn:
+				opt = WithMetadataValueNotIn(key, values...)
+			case selection.Exists:
+				opt = WithMetadataKeys(key)
+			case selection.DoesNotExist:
+				opt = WithMetadataKeysAbsent(key)
+			default:
+				return fmt.Errorf("%w: %s", srerr.UnsupportedSelectorOperator, req.Operator())
+			}
+
+			if err := opt(lo); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
@@ -51,6 +51,59 @@ func metadataFilter(metadata, needle map[string]string) bool {
 	return true
 }
 
+func metadataKeysAbsent(metadata map[string]string, keys []string) bool {
+	for _, k := range keys {
+		if _, exists := metadata[k]; exists {
+			return false
+		}
+	}
+
+	return true
+}
+
+func metadataValuesIn(metadata map[string]string, needle map[string][]string) bool {
+	for k, values := range needle {
+		val, exists := metadata[k]
+		if !exists || !nameInFilter(val, values...) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func metadataValuesNotIn(metadata map[string]string, needle map[string][]string) bool {
+	for k, values := range needle {
+		val, exists := metadata[k]
+		if exists && nameInFilter(val, values...) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// metadataValuesExcluded returns true if all the values that a metadata key
+// can have, i.e. value if it is not empty or the ones in in otherwise, are
+// also in notIn.
+func metadataValuesExcluded(value string, in, notIn []string) bool {
+	if value != "" {
+		return nameInFilter(value, notIn...)
+	}
+
+	if len(in) == 0 {
+		return false
+	}
+
+	for _, val := range in {
+		if !nameInFilter(val, notIn...) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func isInsideCIDR(cidr, addr string) bool {
 	// We do not check error because it is done before
 	_, ipnet, _ := net.ParseCIDR(cidr)
@@ -96,3 +149,15 @@ func portIsInRange(port int32, ranges [][2]int32) bool {
 
 	return false
 }
+
+// portRangeIsExcluded returns true if all ports in the range are either in
+// notIn or in any of the excluded ranges.
+func portRangeIsExcluded(r [2]int32, notIn []int32, excluded [][2]int32) bool {
+	for port := r[0]; port <= r[1]; port++ {
+		if !portIsIn(port, notIn...) && !portIsInRange(port, excluded) {
+			return false
+		}
+	}
+
+	return true
+}
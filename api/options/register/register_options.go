@@ -71,6 +71,10 @@ type Options struct {
 	// when the endpoint operation is defined with no name, otherwise it is
 	// ignored.
 	GenerateName bool
+	// ExpectedRevision is the revision that the object must have on the
+	// service registry for the Register function to update it. If empty, the
+	// object is updated regardless of its current revision.
+	ExpectedRevision string
 }
 
 type Option func(*Options) error
@@ -119,6 +123,10 @@ func WithCreateMode() Option {
 // you provide here, then you will have to use WithReplaceMetadata along with
 // this.
 //
+// Limits on the number and length of keys and values depend on the service
+// registry, so they are checked by Register before calling it: read
+// core.Capabilities to learn more.
+//
 // Example:
 // 	sd.Namespace("hr").Service("payroll").Register(
 //		register.WithKV("commit", "adf6h45bc"),
@@ -138,8 +146,6 @@ func WithMetadata(metadata map[string]string) Option {
 			if k == "" {
 				return srerr.EmptyMetadataKey
 			}
-
-			// TODO: check for long values, as well.
 		}
 
 		// Then push the kvs
@@ -273,3 +279,31 @@ func WithGenerateName() Option {
 		return nil
 	}
 }
+
+// WithExpectedRevision instructs Register to only update the object if its
+// revision on the service registry is still the one provided, e.g. the one
+// you got from the Revision field of a previous Get.
+//
+// If the object was modified by someone else in the meantime, or if it does
+// not exist anymore, Register will return errors.Conflict without updating
+// anything.
+//
+// For example:
+// 	serv, _ := servOp.Get(ctx)
+// 	err := servOp.Register(ctx,
+// 		register.WithKV("version", "1.2.3"),
+// 		register.WithExpectedRevision(serv.Revision))
+func WithExpectedRevision(revision string) Option {
+	return func(ro *Options) error {
+		if ro == nil {
+			return srerr.NoOptionsProvided
+		}
+
+		if revision == "" {
+			return srerr.EmptyRevision
+		}
+
+		ro.ExpectedRevision = revision
+		return nil
+	}
+}
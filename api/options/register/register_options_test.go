@@ -163,4 +163,15 @@ var _ = Describe("Register Options", func() {
 			GenerateName: true,
 		}))
 	})
+	It("sets the expected revision correctly", func() {
+		err := register.WithExpectedRevision("42")(nil)
+		Expect(err).To(Equal(srerr.NoOptionsProvided))
+		err = register.WithExpectedRevision("")(opts)
+		Expect(err).To(Equal(srerr.EmptyRevision))
+		err = register.WithExpectedRevision("42")(opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opts).To(Equal(&register.Options{
+			ExpectedRevision: "42",
+		}))
+	})
 })
@@ -0,0 +1,153 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package wrapper
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+)
+
+// AuditAction is the kind of operation recorded on an audit entry.
+type AuditAction string
+
+const (
+	RegisterAction   AuditAction = "register"
+	DeregisterAction AuditAction = "deregister"
+)
+
+// AuditEntry describes a Register or Deregister operation, either successful
+// or failed.
+type AuditEntry struct {
+	// Time when the operation finished.
+	Time time.Time `json:"time"`
+	// Principal who performed the operation, as set on its context with
+	// core.WithPrincipal.
+	Principal string      `json:"principal,omitempty"`
+	Action    AuditAction `json:"action"`
+	Object    Object      `json:"object"`
+	// Path of the object, e.g. namespaces/sales/services/support.
+	Path string `json:"path"`
+	// Mode is either create or update for Register operations that went as
+	// far as retrieving the object, and empty otherwise.
+	Mode string `json:"mode,omitempty"`
+	// Before is the *types.Namespace, *types.Service or *types.Endpoint as
+	// it was before the operation, if it existed. It is only set for
+	// Register operations and Deregister operations performed in a
+	// transaction, as those are the ones that retrieve the object first.
+	Before interface{} `json:"before,omitempty"`
+	// After is the *types.Namespace, *types.Service or *types.Endpoint as
+	// Register operations want it to be. It is never set for Deregister
+	// operations.
+	After interface{} `json:"after,omitempty"`
+	// Error returned by the operation, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// AuditSink records audit entries.
+//
+// You can provide your own implementation with WithAuditSink, as long as it
+// is safe for concurrent use. Entries must not be modified.
+type AuditSink interface {
+	// Record records the provided entry.
+	Record(entry *AuditEntry) error
+}
+
+// JSONLinesAuditSink is an AuditSink that appends entries to a file, one JSON
+// object per line. The file is kept open until Close is called.
+type JSONLinesAuditSink struct {
+	lock sync.Mutex
+	file *os.File
+}
+
+// NewJSONLinesAuditSink opens the file with the provided path for appending,
+// creating it if it does not exist, and returns a JSONLinesAuditSink that
+// writes entries on it.
+//
+// Example:
+// 	sink, err := wrapper.NewJSONLinesAuditSink("/var/log/myapp/audit.jsonl")
+// 	if err != nil {
+// 		return err
+// 	}
+// 	defer sink.Close()
+func NewJSONLinesAuditSink(path string) (*JSONLinesAuditSink, error) {
+	if path == "" {
+		return nil, srerr.EmptyAuditLogPath
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONLinesAuditSink{file: file}, nil
+}
+
+// Record writes the entry on the file as a single line.
+func (j *JSONLinesAuditSink) Record(entry *AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	// A single write, so that lines are not mixed with other processes
+	// appending to the same file.
+	_, err = j.file.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the file.
+func (j *JSONLinesAuditSink) Close() error {
+	return j.file.Close()
+}
+
+// MemoryAuditSink is an AuditSink that keeps all entries in memory, e.g. for
+// tests.
+type MemoryAuditSink struct {
+	lock    sync.Mutex
+	entries []AuditEntry
+}
+
+// NewMemoryAuditSink returns an empty MemoryAuditSink.
+func NewMemoryAuditSink() *MemoryAuditSink {
+	return &MemoryAuditSink{entries: []AuditEntry{}}
+}
+
+// Record keeps a copy of the entry.
+func (m *MemoryAuditSink) Record(entry *AuditEntry) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.entries = append(m.entries, *entry)
+	return nil
+}
+
+// Entries returns all entries recorded so far, in the same order as they
+// were recorded.
+func (m *MemoryAuditSink) Entries() []AuditEntry {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return append([]AuditEntry{}, m.entries...)
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package wrapper_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+)
+
+var _ = Describe("Audit sinks", func() {
+	entry := &wrapper.AuditEntry{
+		Principal: "alice",
+		Action:    wrapper.RegisterAction,
+		Object:    wrapper.NamespaceObject,
+		Path:      "namespaces/sales",
+		Mode:      "create",
+	}
+
+	It("appends entries as JSON lines", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "audit.jsonl")
+
+		_, err := wrapper.NewJSONLinesAuditSink("")
+		Expect(err).To(MatchError(srerr.EmptyAuditLogPath))
+
+		for i := 0; i < 2; i++ {
+			// Reopen it to make sure entries are appended.
+			sink, err := wrapper.NewJSONLinesAuditSink(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sink.Record(entry)).To(Succeed())
+			Expect(sink.Close()).To(Succeed())
+		}
+
+		file, err := os.Open(path)
+		Expect(err).NotTo(HaveOccurred())
+		defer file.Close()
+
+		lines := 0
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			lines++
+			read := map[string]interface{}{}
+			Expect(json.Unmarshal(scanner.Bytes(), &read)).To(Succeed())
+			Expect(read).To(HaveKeyWithValue("principal", "alice"))
+			Expect(read).To(HaveKeyWithValue("action", "register"))
+			Expect(read).To(HaveKeyWithValue("path", "namespaces/sales"))
+			Expect(read).NotTo(HaveKey("before"))
+		}
+		Expect(lines).To(Equal(2))
+	})
+
+	It("keeps entries in memory", func() {
+		sink := wrapper.NewMemoryAuditSink()
+		Expect(sink.Entries()).To(BeEmpty())
+
+		Expect(sink.Record(entry)).To(Succeed())
+		entries := sink.Entries()
+		Expect(entries).To(Equal([]wrapper.AuditEntry{*entry}))
+
+		entries[0].Principal = "bob"
+		Expect(sink.Entries()[0].Principal).To(Equal("alice"))
+	})
+})
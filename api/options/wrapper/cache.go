@@ -0,0 +1,222 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package wrapper
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+)
+
+const (
+	// DefaultCacheMaxEntries is the maximum number of objects stored by the
+	// cache used when none is provided with WithCache.
+	DefaultCacheMaxEntries int = 10000
+)
+
+// Cache stores objects retrieved from the service registry, so that they
+// don't have to be retrieved again for some time.
+//
+// You can provide your own implementation with WithCache, as long as it is
+// safe for concurrent use. Note that keys are not only names of objects and
+// that values are pointers shared with the API, so they must not be copied
+// or modified.
+type Cache interface {
+	// Get returns the value stored with the provided key and true, or false
+	// if it is not there or it is expired.
+	Get(key string) (interface{}, bool)
+	// Set stores the value with the provided key for the provided time.
+	Set(key string, value interface{}, ttl time.Duration)
+	// Delete removes the value stored with the provided key, if any.
+	Delete(key string)
+}
+
+// CacheStatsRecorder is implemented by caches that keep statistics about
+// lookups, like LRUCache.
+//
+// Values are stored for longer than they are valid, e.g. to return them in
+// case the service registry can't be reached, so only the API knows whether
+// a lookup found a valid value: it records the result of each lookup through
+// RecordLookup, and caches implementing this should not count calls to Get.
+type CacheStatsRecorder interface {
+	// RecordLookup records a lookup that found a valid value, if hit is
+	// true, or that did not find any otherwise.
+	RecordLookup(hit bool)
+}
+
+// CachePeeker is implemented by caches that can return a value without
+// affecting which values are removed first, like LRUCache. The API uses it
+// when it looks up values for internal purposes, e.g. to check if an object
+// modified on the service registry is the one on cache.
+type CachePeeker interface {
+	// Peek is like Get, but it does not count as a use of the value.
+	Peek(key string) (interface{}, bool)
+}
+
+// CacheStats contains statistics about the usage of a cache.
+type CacheStats struct {
+	// Hits is the number of lookups that found a valid value.
+	Hits uint64
+	// Misses is the number of lookups that did not find a value or found an
+	// expired one.
+	Misses uint64
+	// Evictions is the number of values removed to make room for new ones.
+	Evictions uint64
+	// Entries is the number of values currently stored, including expired
+	// ones that were not removed yet.
+	Entries int
+}
+
+// LRUCache is a Cache that stores up to a maximum number of values, removing
+// the least recently used ones when it is full.
+type LRUCache struct {
+	lock       sync.Mutex
+	maxEntries int
+	order      *list.List
+	items      map[string]*list.Element
+	stats      CacheStats
+}
+
+type lruEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// NewLRUCache returns an LRUCache that can store up to maxEntries values.
+//
+// For example:
+// 	lru, err := wrapper.NewLRUCache(500)
+// 	if err != nil {
+// 		return err
+// 	}
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient, wrapper.WithCache(lru))
+// 	// ...
+// 	fmt.Println("cache hits:", lru.Stats().Hits)
+func NewLRUCache(maxEntries int) (*LRUCache, error) {
+	if maxEntries < 1 {
+		return nil, srerr.InvalidCacheSize
+	}
+
+	return &LRUCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      map[string]*list.Element{},
+	}, nil
+}
+
+// Get returns the value stored with the provided key and true, or false
+// if it is not there or it is expired.
+func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, found := c.items[key]
+	if !found {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Peek returns the value stored with the provided key and true, or false if
+// it is not there or it is expired, without marking it as recently used.
+func (c *LRUCache) Peek(key string) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, found := c.items[key]
+	if !found {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+// Set stores the value with the provided key for the provided time,
+// removing the least recently used value if the cache is full.
+func (c *LRUCache) Set(key string, value interface{}, ttl time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry := &lruEntry{key: key, value: value, expires: time.Now().Add(ttl)}
+	if elem, found := c.items[key]; found {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	if c.order.Len() >= c.maxEntries {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+
+	c.items[key] = c.order.PushFront(entry)
+}
+
+// Delete removes the value stored with the provided key, if any.
+func (c *LRUCache) Delete(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elem, found := c.items[key]; found {
+		c.remove(elem)
+	}
+}
+
+// RecordLookup records the result of a lookup in the statistics.
+func (c *LRUCache) RecordLookup(hit bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if hit {
+		c.stats.Hits++
+	} else {
+		c.stats.Misses++
+	}
+}
+
+// Stats returns the statistics about the usage of the cache. Hits and misses
+// are the ones recorded with RecordLookup by the API.
+func (c *LRUCache) Stats() CacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	return stats
+}
+
+func (c *LRUCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*lruEntry).key)
+}
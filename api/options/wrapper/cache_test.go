@@ -0,0 +1,101 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package wrapper_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+)
+
+var _ = Describe("LRU Cache", func() {
+	It("returns an error if the size is not valid", func() {
+		lru, err := wrapper.NewLRUCache(0)
+		Expect(lru).To(BeNil())
+		Expect(err).To(MatchError(srerr.InvalidCacheSize))
+	})
+
+	get := func(lru *wrapper.LRUCache, key string) interface{} {
+		value, _ := lru.Get(key)
+		return value
+	}
+
+	It("removes the least recently used values when full", func() {
+		lru, err := wrapper.NewLRUCache(2)
+		Expect(err).NotTo(HaveOccurred())
+
+		lru.Set("one", 1, time.Hour)
+		lru.Set("two", 2, time.Hour)
+		Expect(get(lru, "one")).To(Equal(1))
+
+		lru.Set("three", 3, time.Hour)
+		_, found := lru.Get("two")
+		Expect(found).To(BeFalse())
+		Expect(get(lru, "three")).To(Equal(3))
+
+		lru.Set("one", 11, time.Hour)
+		Expect(get(lru, "one")).To(Equal(11))
+		Expect(lru.Stats()).To(Equal(wrapper.CacheStats{
+			Evictions: 1,
+			Entries:   2,
+		}))
+	})
+
+	It("peeks values without marking them as recently used", func() {
+		lru, _ := wrapper.NewLRUCache(2)
+		lru.Set("one", 1, time.Hour)
+		lru.Set("two", 2, time.Hour)
+		lru.Set("expired", 3, -time.Second)
+
+		_, found := lru.Peek("expired")
+		Expect(found).To(BeFalse())
+		value, found := lru.Peek("two")
+		Expect(found).To(BeTrue())
+		Expect(value).To(Equal(2))
+
+		lru.Set("three", 3, time.Hour)
+		_, found = lru.Peek("two")
+		Expect(found).To(BeFalse())
+	})
+
+	It("does not return expired or deleted values", func() {
+		lru, _ := wrapper.NewLRUCache(10)
+		lru.Set("expired", 1, -time.Second)
+		lru.Set("deleted", 2, time.Hour)
+		lru.Delete("deleted")
+		lru.Delete("not-there")
+
+		_, found := lru.Get("expired")
+		Expect(found).To(BeFalse())
+		_, found = lru.Get("deleted")
+		Expect(found).To(BeFalse())
+		Expect(lru.Stats()).To(Equal(wrapper.CacheStats{}))
+	})
+
+	It("counts the lookups recorded by the API", func() {
+		lru, _ := wrapper.NewLRUCache(10)
+		lru.RecordLookup(true)
+		lru.RecordLookup(false)
+		lru.RecordLookup(false)
+		Expect(lru.Stats()).To(Equal(wrapper.CacheStats{Hits: 1, Misses: 2}))
+	})
+})
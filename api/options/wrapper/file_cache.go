@@ -0,0 +1,136 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package wrapper
+
+import (
+	"encoding/binary"
+	"time"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var fileCacheBucket = []byte("objects")
+
+// PersistentCache saves objects retrieved from the service registry, so that
+// they can be returned even after the application restarts in case the
+// service registry can't be reached.
+//
+// You can provide your own implementation with WithPersistentCache, as long
+// as it is safe for concurrent use.
+type PersistentCache interface {
+	// Load returns the data saved with the provided key and true, or false
+	// if it is not there or it is too old.
+	Load(key string) ([]byte, bool)
+	// Save saves the data with the provided key, replacing any previous one.
+	Save(key string, data []byte) error
+	// Delete removes the data saved with the provided key, if any.
+	Delete(key string) error
+}
+
+// FileCache is a PersistentCache that saves data on a file, which is kept
+// open until Close is called.
+type FileCache struct {
+	db     *bolt.DB
+	maxAge time.Duration
+}
+
+// NewFileCache opens the file with the provided path, creating it if it does
+// not exist, and returns a FileCache that saves data on it. Data saved more
+// than maxAge ago is never loaded.
+//
+// The file can't be opened by more than one FileCache at the same time, even
+// from different processes.
+//
+// Example:
+// 	fc, err := wrapper.NewFileCache("/var/lib/myapp/serego.db", 24*time.Hour)
+// 	if err != nil {
+// 		return err
+// 	}
+// 	defer fc.Close()
+func NewFileCache(path string, maxAge time.Duration) (*FileCache, error) {
+	if path == "" {
+		return nil, srerr.EmptyCachePath
+	}
+
+	if maxAge <= 0 {
+		return nil, srerr.InvalidCacheMaxAge
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(fileCacheBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &FileCache{db: db, maxAge: maxAge}, nil
+}
+
+// Load returns the data saved with the provided key and true, or false if it
+// is not there or it was saved more than the maximum age ago.
+func (f *FileCache) Load(key string) ([]byte, bool) {
+	var data []byte
+	f.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(fileCacheBucket).Get([]byte(key))
+		if len(value) < 8 {
+			return nil
+		}
+
+		savedAt := time.Unix(0, int64(binary.BigEndian.Uint64(value[:8])))
+		if time.Since(savedAt) > f.maxAge {
+			return nil
+		}
+
+		// Values are only valid during the transaction.
+		data = append([]byte{}, value[8:]...)
+		return nil
+	})
+
+	return data, data != nil
+}
+
+// Save saves the data with the provided key along with the current time.
+func (f *FileCache) Save(key string, data []byte) error {
+	value := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint64(value[:8], uint64(time.Now().UnixNano()))
+	copy(value[8:], data)
+
+	// Batch groups concurrent calls in a single write to the file.
+	return f.db.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(fileCacheBucket).Put([]byte(key), value)
+	})
+}
+
+// Delete removes the data saved with the provided key.
+func (f *FileCache) Delete(key string) error {
+	return f.db.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(fileCacheBucket).Delete([]byte(key))
+	})
+}
+
+// Close closes the file.
+func (f *FileCache) Close() error {
+	return f.db.Close()
+}
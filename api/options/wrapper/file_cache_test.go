@@ -0,0 +1,76 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package wrapper_test
+
+import (
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
+)
+
+var _ = Describe("File Cache", func() {
+	var path string
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "cache.db")
+	})
+
+	It("returns an error if the arguments are not valid", func() {
+		_, err := wrapper.NewFileCache("", time.Hour)
+		Expect(err).To(MatchError(srerr.EmptyCachePath))
+		_, err = wrapper.NewFileCache(path, 0)
+		Expect(err).To(MatchError(srerr.InvalidCacheMaxAge))
+		_, err = wrapper.NewFileCache(filepath.Join(path, "not-a-dir", "cache.db"), time.Hour)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("loads saved data after it is reopened", func() {
+		fc, err := wrapper.NewFileCache(path, time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fc.Save("one", []byte("1"))).To(Succeed())
+		Expect(fc.Save("two", []byte("2"))).To(Succeed())
+		Expect(fc.Delete("two")).To(Succeed())
+		Expect(fc.Close()).To(Succeed())
+
+		fc, err = wrapper.NewFileCache(path, time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		defer fc.Close()
+
+		data, found := fc.Load("one")
+		Expect(found).To(BeTrue())
+		Expect(data).To(Equal([]byte("1")))
+		_, found = fc.Load("two")
+		Expect(found).To(BeFalse())
+	})
+
+	It("does not load data older than the max age", func() {
+		fc, _ := wrapper.NewFileCache(path, 10*time.Millisecond)
+		defer fc.Close()
+
+		Expect(fc.Save("one", []byte("1"))).To(Succeed())
+		Eventually(func() bool {
+			_, found := fc.Load("one")
+			return found
+		}).WithTimeout(time.Second).Should(BeFalse())
+	})
+})
@@ -0,0 +1,99 @@
+// Copyright (c) 2022 Cisco Systems, Inc. and its affiliates
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package wrapper
+
+import "context"
+
+// Operation is the kind of call being performed on the service registry.
+type Operation string
+
+const (
+	GetOperation    Operation = "get"
+	CreateOperation Operation = "create"
+	UpdateOperation Operation = "update"
+	DeleteOperation Operation = "delete"
+	// ListOperation is the operation of each call to the Next function of a
+	// lister.
+	ListOperation   Operation = "list"
+	CommitOperation Operation = "commit"
+)
+
+// Object is the type of object the call is performed on.
+type Object string
+
+const (
+	NamespaceObject   Object = "namespace"
+	ServiceObject     Object = "service"
+	EndpointObject    Object = "endpoint"
+	TransactionObject Object = "transaction"
+)
+
+// Call contains information about a call to the service registry.
+//
+// For List calls, only the names of the parents of the objects being listed
+// are set, e.g. Namespace is empty when listing namespaces.
+type Call struct {
+	Operation Operation
+	Object    Object
+	Namespace string
+	Service   string
+	Endpoint  string
+	// Path of the object, e.g. namespaces/sales/services/support, or of the
+	// objects being listed for List calls, e.g. namespaces/sales/services.
+	Path string
+	// Options is the *get.Options of Get calls and the *list.Options of List
+	// calls, and nil for all other calls.
+	Options interface{}
+	// Metadata, Address, Port and ExpectedRevision of the object being
+	// created or updated: Address and Port are only set for endpoints, and
+	// ExpectedRevision only for updates.
+	Metadata         map[string]string
+	Address          string
+	Port             int32
+	ExpectedRevision string
+	// Result is the *types.Namespace, *types.Service or *types.Endpoint
+	// returned by the call, and is only set after it succeeded: Delete and
+	// Commit calls never have a result.
+	Result interface{}
+}
+
+// IsRead returns true if the call does not modify the service registry.
+func (c *Call) IsRead() bool {
+	return c.Operation == GetOperation || c.Operation == ListOperation
+}
+
+// Handler performs the call to the service registry, or to the next
+// interceptor in the chain.
+type Handler func(ctx context.Context) error
+
+// Interceptor is called in place of the actual call to the service registry,
+// which it performs by calling next, optionally doing something before or
+// after it or even not calling it at all, e.g. returning an error instead.
+//
+// Interceptors can be provided with WithInterceptors, and must be safe for
+// concurrent use.
+//
+// Example:
+// 	func denyDeletes(ctx context.Context, call *wrapper.Call, next wrapper.Handler) error {
+// 		if call.Operation == wrapper.DeleteOperation && call.Namespace == "production" {
+// 			return fmt.Errorf("cannot delete %s", call.Path)
+// 		}
+//
+// 		return next(ctx)
+// 	}
+type Interceptor func(ctx context.Context, call *Call, next Handler) error
@@ -21,13 +21,74 @@ import (
 	"time"
 
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	DefaultCacheExpirationTime time.Duration = 5 * time.Minute
-	DefaultCacheCleanUpTime    time.Duration = 10 * time.Minute
+	DefaultCacheExpirationTime         time.Duration = 5 * time.Minute
+	DefaultCacheCleanUpTime            time.Duration = 10 * time.Minute
+	DefaultNegativeCacheExpirationTime time.Duration = 5 * time.Second
 )
 
+// DefaultRetryPolicy is a retry policy suitable for most use cases, which you
+// can use as a starting point for your own.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// RetryPolicy defines how operations that failed with a transient error
+// should be retried. Look at errors.IsTransient to know which errors are
+// considered transient.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times an operation is performed,
+	// including the first one.
+	MaxAttempts int
+	// InitialBackoff is the time to wait before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum time to wait between two attempts.
+	// Leave this empty to not put any limit to it.
+	MaxBackoff time.Duration
+	// Multiplier is the factor by which the backoff is multiplied after each
+	// retry. Leave this empty to always wait InitialBackoff.
+	Multiplier float64
+	// Jitter is the fraction of the backoff, between 0 and 1, that is
+	// randomly subtracted from it, to prevent many clients from retrying at
+	// the same time.
+	Jitter float64
+	// AttemptTimeout is the deadline for each single attempt. Attempts that
+	// exceed it are retried, unless the context of the operation is done as
+	// well. Leave this empty to only use the context of the operation.
+	AttemptTimeout time.Duration
+}
+
+// RateLimit defines the maximum rate of calls to the service registry.
+type RateLimit struct {
+	// RequestsPerSecond is the number of calls allowed each second.
+	RequestsPerSecond float64
+	// Burst is the maximum number of calls that can be performed at once,
+	// i.e. faster than RequestsPerSecond, after a period of inactivity.
+	Burst int
+}
+
+// CircuitBreaker defines when to stop performing calls to a service registry
+// that is unhealthy.
+type CircuitBreaker struct {
+	// FailureThreshold is the number of consecutive calls that must fail
+	// with a transient error for the circuit to open, i.e. to stop
+	// performing calls.
+	FailureThreshold int
+	// OpenTimeout is the time after which a single call is allowed again
+	// to check if the service registry is healthy: if it succeeds the
+	// circuit is closed, otherwise it stays open for another OpenTimeout.
+	OpenTimeout time.Duration
+}
+
 // Options to fine tune the behavior of the Service Registry API.
 type Options struct {
 	// CacheExpirationTime defines the time after which an element will be
@@ -36,6 +97,38 @@ type Options struct {
 	// CacheCleanUpTime defines the frequency with the cache will delete
 	// expired elements.
 	CacheCleanUpTime time.Duration
+	// Cache where to store objects. Leave this nil to use an LRUCache with
+	// DefaultCacheMaxEntries.
+	Cache Cache
+	// PersistentCache where to save objects, to be returned in case the
+	// service registry can't be reached even after a restart. Leave this nil
+	// to keep objects in memory only.
+	PersistentCache PersistentCache
+	// CacheInvalidation removes objects from cache as soon as they are
+	// modified by anyone on the service registry.
+	//
+	// This is only supported by etcd, and ignored by all other service
+	// registries.
+	CacheInvalidation bool
+	// MetricsRegisterer where to register metrics about calls to the service
+	// registry and cache. Leave this nil to not record any metric.
+	MetricsRegisterer prometheus.Registerer
+	// TracerProvider used to create spans for operations and calls to the
+	// service registry. Leave this nil to not record any span.
+	TracerProvider trace.TracerProvider
+	// Logger where to log what the API does. Leave this empty to not log
+	// anything.
+	Logger logr.Logger
+	// Interceptors that are called, in order, around each call to the
+	// service registry.
+	Interceptors []Interceptor
+	// AuditSink where to record all Register and Deregister operations.
+	// Leave this nil to not record them.
+	AuditSink AuditSink
+	// NegativeCacheExpirationTime defines the time after which the cache
+	// will forget that an object was not found. Leave this empty to never
+	// remember it.
+	NegativeCacheExpirationTime time.Duration
 	// Region where to register all resources in the service registry.
 	//
 	// This is *required* for Google Service Directory, and ignored by all
@@ -46,12 +139,35 @@ type Options struct {
 	// This is *required* for Google Service Directory, and ignored by all
 	// other service registries.
 	ProjectID string
+	// RetryPolicy for operations that fail with a transient error. Leave this
+	// nil to never retry them.
+	RetryPolicy *RetryPolicy
+	// RateLimit for all calls to the service registry. Leave this nil to
+	// not limit them.
+	RateLimit *RateLimit
+	// ReadRateLimit for calls that read from the service registry, which
+	// must satisfy RateLimit as well, if set.
+	ReadRateLimit *RateLimit
+	// WriteRateLimit for calls that modify the service registry, which
+	// must satisfy RateLimit as well, if set.
+	WriteRateLimit *RateLimit
+	// RateLimitFailFast makes calls that exceed the rate limits fail
+	// immediately instead of waiting until they are allowed.
+	RateLimitFailFast bool
+	// StaleGracePeriod is the time expired objects are kept on cache, to be
+	// returned in case the service registry can't be reached. Leave this
+	// empty to remove them as soon as they expire.
+	StaleGracePeriod time.Duration
+	// CircuitBreaker for calls to the service registry. Leave this nil to
+	// always perform calls.
+	CircuitBreaker *CircuitBreaker
 }
 
 type Option func(*Options) error
 
 // WithNoCache instructs the API to never use cache, but rather always
-// perform calls to the service registry.
+// perform calls to the service registry. This disables negative caching as
+// well.
 //
 // Note that this may cause latencies and slow performance in some situations.
 //
@@ -60,6 +176,7 @@ type Option func(*Options) error
 func WithNoCache() Option {
 	return func(wo *Options) error {
 		wo.CacheExpirationTime = 0
+		wo.NegativeCacheExpirationTime = 0
 		return nil
 	}
 }
@@ -87,6 +204,253 @@ func WithCacheExpirationTime(expTime time.Duration) Option {
 	}
 }
 
+// WithNegativeCacheExpirationTime instructs the API to remember that an
+// object was not found for the provided time instead of the default one,
+// which is 5 seconds. During this time, getting it again returns the same
+// error without performing any call to the service registry, unless it is
+// created in the meantime through the API.
+//
+// Keep this time short if other systems/applications are creating objects
+// in the service registry as well.
+//
+// For example:
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient, wrapper.WithNegativeCacheExpirationTime(time.Second))
+func WithNegativeCacheExpirationTime(expTime time.Duration) Option {
+	return func(wo *Options) error {
+		if expTime <= 0 {
+			return srerr.InvalidCacheExpirationTime
+		}
+
+		wo.NegativeCacheExpirationTime = expTime
+		return nil
+	}
+}
+
+// WithNoNegativeCache instructs the API to never remember that objects were
+// not found, but rather always perform calls to the service registry to get
+// them.
+//
+// For example:
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient, wrapper.WithNoNegativeCache())
+func WithNoNegativeCache() Option {
+	return func(wo *Options) error {
+		wo.NegativeCacheExpirationTime = 0
+		return nil
+	}
+}
+
+// WithCache instructs the API to store objects on the provided cache instead
+// of the default one, which is an LRUCache that can store up to
+// DefaultCacheMaxEntries objects.
+//
+// Objects are still kept for the time set with WithCacheExpirationTime, and
+// this has no effect if WithNoCache is provided as well.
+//
+// For example, to bound the cache to 1000 objects:
+// 	lru, err := wrapper.NewLRUCache(1000)
+// 	if err != nil {
+// 		return err
+// 	}
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient, wrapper.WithCache(lru))
+func WithCache(cache Cache) Option {
+	return func(o *Options) error {
+		if cache == nil {
+			return srerr.NoCacheProvided
+		}
+
+		o.Cache = cache
+		return nil
+	}
+}
+
+// WithPersistentCache instructs the API to save all namespaces, services and
+// endpoints it retrieves on the provided persistent cache, e.g. a FileCache,
+// in addition to the in-memory one. Objects are saved in background, not to
+// slow down calls, so the ones retrieved right before the application exits
+// may not be saved.
+//
+// When getting an object fails with a transient error and it is not found on
+// the in-memory cache, e.g. because the application just started and the
+// service registry is not reachable, the object saved on the persistent
+// cache is returned instead, with its Stale field set to true. Note that the
+// OriginalObject field of such objects is always nil.
+//
+// Do not share the same persistent cache among different service
+// registries, and note that this has no effect if cache is disabled.
+//
+// For example:
+// 	fc, err := wrapper.NewFileCache("/var/lib/myapp/serego.db", 24*time.Hour)
+// 	if err != nil {
+// 		return err
+// 	}
+// 	defer fc.Close()
+//
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient, wrapper.WithPersistentCache(fc))
+func WithPersistentCache(cache PersistentCache) Option {
+	return func(o *Options) error {
+		if cache == nil {
+			return srerr.NoCacheProvided
+		}
+
+		o.PersistentCache = cache
+		return nil
+	}
+}
+
+// WithCacheInvalidation instructs the API to watch the service registry in
+// background and remove objects from cache as soon as they are modified or
+// deleted, even by other processes or applications, instead of waiting for
+// them to expire.
+//
+// This is only supported by etcd, where the watch is stopped when the client
+// is closed, and it is ignored by all other service registries. It has no
+// effect if cache is disabled.
+//
+// For example:
+// 	sr, err := core.NewServiceRegistryFromEtcd(cli, wrapper.WithCacheInvalidation())
+func WithCacheInvalidation() Option {
+	return func(o *Options) error {
+		o.CacheInvalidation = true
+		return nil
+	}
+}
+
+// WithMetrics instructs the API to record Prometheus metrics and register
+// them on the provided registerer, e.g. prometheus.DefaultRegisterer. The
+// following metrics are recorded, all with a "backend" label with the type
+// of the service registry:
+//
+// 	- serego_operations_total and serego_operation_duration_seconds, with
+// 	  the number and duration of operations performed on the service
+// 	  registry, by object, operation and result. Gets served from cache are
+// 	  not counted, while lists are counted by page.
+// 	- serego_cache_lookups_total, with the number of objects looked up on
+// 	  cache, by result: hit, negative_hit or miss.
+// 	- serego_cloudmap_operation_polls_total, with the number of times the
+// 	  status of a Cloud Map operation was polled.
+//
+// You can provide the same registerer to more than one service registry.
+//
+// For example:
+// 	reg := prometheus.NewRegistry()
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient, wrapper.WithMetrics(reg))
+func WithMetrics(registerer prometheus.Registerer) Option {
+	return func(o *Options) error {
+		if registerer == nil {
+			return srerr.NoRegistererProvided
+		}
+
+		o.MetricsRegisterer = registerer
+		return nil
+	}
+}
+
+// WithTracerProvider instructs the API to create OpenTelemetry spans with
+// the provided tracer provider, e.g. otel.GetTracerProvider(), for each
+// operation and for each call performed to the service registry.
+//
+// Spans have attributes with the type of service registry and the names of
+// the objects, as well as the register mode for register operations and
+// whether the object was found on cache for get calls.
+//
+// For example:
+// 	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(myExporter))
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient, wrapper.WithTracerProvider(tp))
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *Options) error {
+		if provider == nil {
+			return srerr.NoTracerProviderProvided
+		}
+
+		o.TracerProvider = provider
+		return nil
+	}
+}
+
+// WithLogger instructs the API to log what it does with the provided logger,
+// e.g. one created with funcr, zapr or any other logr implementation.
+//
+// Calls to the service registry, decisions taken on cache, objects left
+// without parent that are deleted automatically and polls of Cloud Map
+// operations are logged with verbosity 1, while events that are not expected
+// to happen often, e.g. when objects are returned from cache because the
+// service registry can't be reached, are logged with verbosity 0.
+//
+// For example:
+// 	logger := funcr.New(func(prefix, args string) {
+// 		fmt.Println(prefix, args)
+// 	}, funcr.Options{Verbosity: 1})
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient, wrapper.WithLogger(logger))
+func WithLogger(logger logr.Logger) Option {
+	return func(o *Options) error {
+		if logger.GetSink() == nil {
+			return srerr.NoLoggerProvided
+		}
+
+		o.Logger = logger
+		return nil
+	}
+}
+
+// WithInterceptors instructs the API to call the provided interceptors
+// around each call to the service registry, in the same order as they are
+// provided: the first one wraps all the others. Calling this option more
+// than once appends the new interceptors to the ones provided before.
+//
+// Interceptors are called before the API retries calls, so they see each
+// call only once, regardless of how many attempts were needed.
+//
+// For example:
+// 	auditor := func(ctx context.Context, call *wrapper.Call, next wrapper.Handler) error {
+// 		err := next(ctx)
+// 		log.Println(call.Operation, call.Path, err)
+// 		return err
+// 	}
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient, wrapper.WithInterceptors(auditor))
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(o *Options) error {
+		if len(interceptors) == 0 {
+			return srerr.NoInterceptorProvided
+		}
+
+		for _, i := range interceptors {
+			if i == nil {
+				return srerr.NoInterceptorProvided
+			}
+		}
+
+		o.Interceptors = append(o.Interceptors, interceptors...)
+		return nil
+	}
+}
+
+// WithAuditSink instructs the API to record all Register and Deregister
+// operations on the provided sink, e.g. a JSONLinesAuditSink, including the
+// ones performed in bulk or in a transaction and the ones that failed.
+//
+// Each entry contains the principal who performed the operation, which you
+// can set on its context with core.WithPrincipal, the path of the object and,
+// for Register operations, how it was before and how it is supposed to be
+// after the operation.
+//
+// For example:
+// 	sink, err := wrapper.NewJSONLinesAuditSink("/var/log/myapp/audit.jsonl")
+// 	if err != nil {
+// 		return err
+// 	}
+// 	defer sink.Close()
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient, wrapper.WithAuditSink(sink))
+func WithAuditSink(sink AuditSink) Option {
+	return func(o *Options) error {
+		if sink == nil {
+			return srerr.NoAuditSinkProvided
+		}
+
+		o.AuditSink = sink
+		return nil
+	}
+}
+
 // WithRegion defines the region where to register all resources in the
 // service registry.
 //
@@ -123,3 +487,172 @@ func WithProjectID(ID string) Option {
 		return nil
 	}
 }
+
+// WithRetryPolicy instructs the API to retry operations on the service
+// registry when they fail with a transient error, e.g. a network error or
+// the service registry throttling requests, according to the provided policy.
+//
+// Operations are retried with exponential backoff, and each attempt can have
+// its own deadline through the AttemptTimeout field. Note that iterators are
+// never retried.
+//
+// For example:
+// 	policy := wrapper.DefaultRetryPolicy
+// 	policy.AttemptTimeout = 2 * time.Second
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient, wrapper.WithRetryPolicy(policy))
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *Options) error {
+		switch {
+		case policy.MaxAttempts < 1,
+			policy.InitialBackoff < 0,
+			policy.MaxBackoff < 0,
+			policy.MaxBackoff > 0 && policy.MaxBackoff < policy.InitialBackoff,
+			policy.Multiplier != 0 && policy.Multiplier < 1,
+			policy.Jitter < 0 || policy.Jitter > 1,
+			policy.AttemptTimeout < 0:
+			return srerr.InvalidRetryPolicy
+		}
+
+		o.RetryPolicy = &policy
+		return nil
+	}
+}
+
+// WithRateLimit limits the rate of calls performed to the service registry,
+// e.g. to avoid exhausting the API quotas of your account.
+//
+// Calls that exceed the rate wait until they are allowed or until their
+// context is done, unless WithRateLimitFailFast is provided as well.
+// Note that a single operation may need more than one call, e.g. a Get on
+// Cloud Map needs one call to get the object and another one for its tags.
+//
+// For example, to allow 10 calls per second with bursts of 20:
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient, wrapper.WithRateLimit(10, 20))
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(o *Options) error {
+		rateLimit, err := newRateLimit(requestsPerSecond, burst)
+		if err != nil {
+			return err
+		}
+
+		o.RateLimit = rateLimit
+		return nil
+	}
+}
+
+// WithReadRateLimit limits the rate of calls that read from the service
+// registry, i.e. get and list calls. This can be used together with
+// WithRateLimit, in which case calls must satisfy both limits.
+//
+// For example:
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient,
+// 		wrapper.WithReadRateLimit(20, 40),
+// 		wrapper.WithWriteRateLimit(5, 5),
+// 	)
+func WithReadRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(o *Options) error {
+		rateLimit, err := newRateLimit(requestsPerSecond, burst)
+		if err != nil {
+			return err
+		}
+
+		o.ReadRateLimit = rateLimit
+		return nil
+	}
+}
+
+// WithWriteRateLimit limits the rate of calls that modify the service
+// registry, i.e. create, update and delete calls. This can be used together
+// with WithRateLimit, in which case calls must satisfy both limits.
+//
+// For example:
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient,
+// 		wrapper.WithReadRateLimit(20, 40),
+// 		wrapper.WithWriteRateLimit(5, 5),
+// 	)
+func WithWriteRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(o *Options) error {
+		rateLimit, err := newRateLimit(requestsPerSecond, burst)
+		if err != nil {
+			return err
+		}
+
+		o.WriteRateLimit = rateLimit
+		return nil
+	}
+}
+
+// WithRateLimitFailFast makes calls that exceed the rate limits fail
+// immediately with errors.RateLimitExceeded, instead of waiting until they
+// are allowed.
+//
+// For example:
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient,
+// 		wrapper.WithRateLimit(10, 20),
+// 		wrapper.WithRateLimitFailFast(),
+// 	)
+func WithRateLimitFailFast() Option {
+	return func(o *Options) error {
+		o.RateLimitFailFast = true
+		return nil
+	}
+}
+
+func newRateLimit(requestsPerSecond float64, burst int) (*RateLimit, error) {
+	if requestsPerSecond <= 0 || burst < 1 {
+		return nil, srerr.InvalidRateLimit
+	}
+
+	return &RateLimit{RequestsPerSecond: requestsPerSecond, Burst: burst}, nil
+}
+
+// WithStaleWhileError instructs the API to keep objects on cache for the
+// provided grace period after they expire, and to return them if getting
+// them from the service registry fails with a transient error, e.g. because
+// it is unavailable. Objects returned this way have their Stale field set to
+// true.
+//
+// Look at errors.IsTransient to know which errors are considered transient,
+// and note that this has no effect if cache is disabled.
+//
+// For example:
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient, wrapper.WithStaleWhileError(time.Hour))
+func WithStaleWhileError(gracePeriod time.Duration) Option {
+	return func(o *Options) error {
+		if gracePeriod <= 0 {
+			return srerr.InvalidStaleGracePeriod
+		}
+
+		o.StaleGracePeriod = gracePeriod
+		return nil
+	}
+}
+
+// WithCircuitBreaker instructs the API to stop performing calls to the
+// service registry after failureThreshold consecutive calls failed with a
+// transient error, e.g. because it is unavailable. While the circuit is open
+// calls fail immediately with errors.CircuitOpen, until openTimeout passes
+// and a single call is performed to check if the service registry is
+// healthy again.
+//
+// This works well with WithStaleWhileError, so that you can still get
+// objects from cache while the service registry is unhealthy.
+//
+// For example:
+// 	sd, err := core.NewServiceRegistryFromMyProvider(myClient,
+// 		wrapper.WithCircuitBreaker(5, 30*time.Second),
+// 		wrapper.WithStaleWhileError(time.Hour),
+// 	)
+func WithCircuitBreaker(failureThreshold int, openTimeout time.Duration) Option {
+	return func(o *Options) error {
+		if failureThreshold < 1 || openTimeout <= 0 {
+			return srerr.InvalidCircuitBreaker
+		}
+
+		o.CircuitBreaker = &CircuitBreaker{
+			FailureThreshold: failureThreshold,
+			OpenTimeout:      openTimeout,
+		}
+		return nil
+	}
+}
@@ -18,10 +18,16 @@
 package wrapper_test
 
 import (
+	"context"
+	"path/filepath"
 	"time"
 
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 
 	srerr "github.com/CloudNativeSDWAN/serego/api/errors"
 	"github.com/CloudNativeSDWAN/serego/api/options/wrapper"
@@ -53,6 +59,122 @@ var _ = Describe("Wrapper Options", func() {
 		}))
 	})
 
+	It("sets correct negative cache option time", func() {
+		err := wrapper.WithNegativeCacheExpirationTime(time.Second)(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options).To(Equal(&wrapper.Options{
+			NegativeCacheExpirationTime: time.Second,
+		}))
+
+		err = wrapper.WithNegativeCacheExpirationTime(0)(options)
+		Expect(err).To(Equal(srerr.InvalidCacheExpirationTime))
+
+		err = wrapper.WithNoNegativeCache()(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options).To(Equal(&wrapper.Options{}))
+
+		options.NegativeCacheExpirationTime = time.Second
+		err = wrapper.WithNoCache()(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options).To(Equal(&wrapper.Options{}))
+	})
+
+	It("sets correct cache", func() {
+		lru, _ := wrapper.NewLRUCache(10)
+		err := wrapper.WithCache(lru)(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options).To(Equal(&wrapper.Options{Cache: lru}))
+
+		err = wrapper.WithCache(nil)(&wrapper.Options{})
+		Expect(err).To(MatchError(srerr.NoCacheProvided))
+	})
+
+	It("sets correct persistent cache", func() {
+		fc, err := wrapper.NewFileCache(filepath.Join(GinkgoT().TempDir(), "cache.db"), time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		defer fc.Close()
+
+		err = wrapper.WithPersistentCache(fc)(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options).To(Equal(&wrapper.Options{PersistentCache: fc}))
+
+		err = wrapper.WithPersistentCache(nil)(&wrapper.Options{})
+		Expect(err).To(MatchError(srerr.NoCacheProvided))
+	})
+
+	It("sets correct cache invalidation option", func() {
+		err := wrapper.WithCacheInvalidation()(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options).To(Equal(&wrapper.Options{CacheInvalidation: true}))
+	})
+
+	It("sets correct metrics option", func() {
+		registry := prometheus.NewRegistry()
+		err := wrapper.WithMetrics(registry)(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options.MetricsRegisterer).To(Equal(registry))
+
+		err = wrapper.WithMetrics(nil)(&wrapper.Options{})
+		Expect(err).To(Equal(srerr.NoRegistererProvided))
+	})
+
+	It("sets correct tracer provider option", func() {
+		provider := trace.NewNoopTracerProvider()
+		err := wrapper.WithTracerProvider(provider)(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options).To(Equal(&wrapper.Options{TracerProvider: provider}))
+
+		err = wrapper.WithTracerProvider(nil)(&wrapper.Options{})
+		Expect(err).To(Equal(srerr.NoTracerProviderProvided))
+	})
+
+	It("sets correct logger option", func() {
+		logger := funcr.New(func(_, _ string) {}, funcr.Options{})
+		err := wrapper.WithLogger(logger)(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options.Logger.GetSink()).NotTo(BeNil())
+
+		err = wrapper.WithLogger(logr.Logger{})(&wrapper.Options{})
+		Expect(err).To(Equal(srerr.NoLoggerProvided))
+	})
+
+	It("sets correct interceptors option", func() {
+		called := []string{}
+		named := func(name string) wrapper.Interceptor {
+			return func(ctx context.Context, _ *wrapper.Call, next wrapper.Handler) error {
+				called = append(called, name)
+				return next(ctx)
+			}
+		}
+
+		err := wrapper.WithInterceptors(named("first"), named("second"))(options)
+		Expect(err).NotTo(HaveOccurred())
+		err = wrapper.WithInterceptors(named("third"))(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options.Interceptors).To(HaveLen(3))
+		for _, i := range options.Interceptors {
+			Expect(i(context.Background(), &wrapper.Call{}, func(context.Context) error {
+				return nil
+			})).To(Succeed())
+		}
+		Expect(called).To(Equal([]string{"first", "second", "third"}))
+
+		err = wrapper.WithInterceptors()(&wrapper.Options{})
+		Expect(err).To(Equal(srerr.NoInterceptorProvided))
+		err = wrapper.WithInterceptors(named("first"), nil)(&wrapper.Options{})
+		Expect(err).To(Equal(srerr.NoInterceptorProvided))
+	})
+
+	It("sets correct audit sink option", func() {
+		sink := wrapper.NewMemoryAuditSink()
+		err := wrapper.WithAuditSink(sink)(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options).To(Equal(&wrapper.Options{AuditSink: sink}))
+
+		err = wrapper.WithAuditSink(nil)(&wrapper.Options{})
+		Expect(err).To(Equal(srerr.NoAuditSinkProvided))
+	})
+
 	It("sets correct region option", func() {
 		err := wrapper.WithRegion("my-region")(options)
 		Expect(err).NotTo(HaveOccurred())
@@ -68,4 +190,79 @@ var _ = Describe("Wrapper Options", func() {
 			ProjectID: "my-project",
 		}))
 	})
+	It("sets correct retry policy option", func() {
+		err := wrapper.WithRetryPolicy(wrapper.DefaultRetryPolicy)(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options).To(Equal(&wrapper.Options{
+			RetryPolicy: &wrapper.RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: 100 * time.Millisecond,
+				MaxBackoff:     5 * time.Second,
+				Multiplier:     2,
+				Jitter:         0.2,
+			},
+		}))
+
+		err = wrapper.WithRetryPolicy(wrapper.RetryPolicy{MaxAttempts: 5})(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options.RetryPolicy).To(Equal(&wrapper.RetryPolicy{MaxAttempts: 5}))
+
+		for _, policy := range []wrapper.RetryPolicy{
+			{MaxAttempts: 0},
+			{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: time.Millisecond},
+			{MaxAttempts: 3, Multiplier: 0.5},
+			{MaxAttempts: 3, Jitter: 1.5},
+			{MaxAttempts: 3, AttemptTimeout: -1},
+		} {
+			err = wrapper.WithRetryPolicy(policy)(options)
+			Expect(err).To(Equal(srerr.InvalidRetryPolicy))
+		}
+	})
+	It("sets correct rate limit options", func() {
+		err := wrapper.WithRateLimit(10, 20)(options)
+		Expect(err).NotTo(HaveOccurred())
+		err = wrapper.WithReadRateLimit(5, 1)(options)
+		Expect(err).NotTo(HaveOccurred())
+		err = wrapper.WithWriteRateLimit(0.5, 1)(options)
+		Expect(err).NotTo(HaveOccurred())
+		err = wrapper.WithRateLimitFailFast()(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options).To(Equal(&wrapper.Options{
+			RateLimit:         &wrapper.RateLimit{RequestsPerSecond: 10, Burst: 20},
+			ReadRateLimit:     &wrapper.RateLimit{RequestsPerSecond: 5, Burst: 1},
+			WriteRateLimit:    &wrapper.RateLimit{RequestsPerSecond: 0.5, Burst: 1},
+			RateLimitFailFast: true,
+		}))
+
+		err = wrapper.WithRateLimit(0, 1)(options)
+		Expect(err).To(Equal(srerr.InvalidRateLimit))
+
+		err = wrapper.WithReadRateLimit(10, 0)(options)
+		Expect(err).To(Equal(srerr.InvalidRateLimit))
+
+		err = wrapper.WithWriteRateLimit(-1, 10)(options)
+		Expect(err).To(Equal(srerr.InvalidRateLimit))
+	})
+	It("sets correct stale and circuit breaker options", func() {
+		err := wrapper.WithStaleWhileError(time.Hour)(options)
+		Expect(err).NotTo(HaveOccurred())
+		err = wrapper.WithCircuitBreaker(5, time.Minute)(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(options).To(Equal(&wrapper.Options{
+			StaleGracePeriod: time.Hour,
+			CircuitBreaker: &wrapper.CircuitBreaker{
+				FailureThreshold: 5,
+				OpenTimeout:      time.Minute,
+			},
+		}))
+
+		err = wrapper.WithStaleWhileError(0)(options)
+		Expect(err).To(Equal(srerr.InvalidStaleGracePeriod))
+
+		err = wrapper.WithCircuitBreaker(0, time.Minute)(options)
+		Expect(err).To(Equal(srerr.InvalidCircuitBreaker))
+
+		err = wrapper.WithCircuitBreaker(5, 0)(options)
+		Expect(err).To(Equal(srerr.InvalidCircuitBreaker))
+	})
 })